@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	skv "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func (hs *HTTPServer) AdminRotateDataEncryptionKeys(c *contextmodel.ReqContext) response.Response {
@@ -18,11 +19,21 @@ func (hs *HTTPServer) AdminRotateDataEncryptionKeys(c *contextmodel.ReqContext)
 }
 
 func (hs *HTTPServer) AdminReEncryptEncryptionKeys(c *contextmodel.ReqContext) response.Response {
-	if err := hs.SecretsService.ReEncryptDataKeys(c.Req.Context()); err != nil {
+	status, err := hs.secretsMigrator.ReEncryptDataKeys(c.Req.Context())
+	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to re-encrypt data keys", err)
 	}
 
-	return response.Respond(http.StatusOK, "Data encryption keys re-encrypted successfully")
+	message := "Data encryption keys re-encrypted successfully"
+	if len(status.Remaining) > 0 {
+		message = "Some data encryption keys are still encrypted with a previous encryption provider"
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message":   message,
+		"provider":  status.Provider,
+		"remaining": status.Remaining,
+	})
 }
 
 func (hs *HTTPServer) AdminReEncryptSecrets(c *contextmodel.ReqContext) response.Response {
@@ -51,6 +62,15 @@ func (hs *HTTPServer) AdminRollbackSecrets(c *contextmodel.ReqContext) response.
 	return response.Respond(http.StatusOK, "Secrets rolled back successfully")
 }
 
+func (hs *HTTPServer) AdminGetRotationStatus(c *contextmodel.ReqContext) response.Response {
+	status, err := hs.secretsMigrator.RotationStatus(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the data keys rotation status", err)
+	}
+
+	return response.JSON(http.StatusOK, status)
+}
+
 // To migrate to the plugin, it must be installed and configured
 // so as not to lose access to migrated secrets
 func (hs *HTTPServer) AdminMigrateSecretsToPlugin(c *contextmodel.ReqContext) response.Response {
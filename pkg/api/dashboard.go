@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/org"
 	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
 	publicdashboardModels "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/star"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -633,8 +634,7 @@ func (hs *HTTPServer) postDashboard(c *contextmodel.ReqContext, cmd dashboards.S
 		return response.Error(http.StatusInternalServerError, "Error while connecting library panels", err)
 	}
 
-	c.TimeRequest(metrics.MApiDashboardSave)
-	return response.JSON(http.StatusOK, util.DynMap{
+	result := util.DynMap{
 		"status":    "success",
 		"slug":      dashboard.Slug,
 		"version":   dashboard.Version,
@@ -642,7 +642,20 @@ func (hs *HTTPServer) postDashboard(c *contextmodel.ReqContext, cmd dashboards.S
 		"uid":       dashboard.UID,
 		"url":       dashboard.GetURL(),
 		"folderUid": dashboard.FolderUID,
-	})
+	}
+
+	// commit the changes of dashboards provisioned from a git repository with a write-back branch, the dashboard is
+	// already saved so a failure is reported in the response
+	if provisioningData != nil && allowUiUpdate {
+		author := git.Signature{Name: c.SignedInUser.GetDisplayName(), Email: c.SignedInUser.GetEmail()}
+		if err := hs.ProvisioningService.WriteBackDashboard(ctx, provisioningData, dashboard.Data, cmd.Message, author); err != nil {
+			hs.log.Error("Failed to write back provisioned dashboard", "uid", dashboard.UID, "error", err)
+			result["writeBackError"] = "Dashboard saved but not committed to its git repository, check the server logs"
+		}
+	}
+
+	c.TimeRequest(metrics.MApiDashboardSave)
+	return response.JSON(http.StatusOK, result)
 }
 
 // swagger:route GET /dashboards/home dashboards getHomeDashboard
@@ -955,6 +968,86 @@ func (hs *HTTPServer) GetDashboardVersion(c *contextmodel.ReqContext) response.R
 	return response.JSON(http.StatusOK, dashVersionMeta)
 }
 
+// swagger:route GET /dashboards/uid/{uid}/versions/{DashboardVersionID}/diff dashboard_versions getDashboardVersionDiffByUID
+//
+// Get the changes of a dashboard version.
+//
+// Compares the dashboard version with a base version, by default the version it was created from. Panels are matched by ID and variables by name, and the changes are returned as a JSON patch and as a human-readable summary.
+//
+// Responses:
+// 200: dashboardVersionDiffResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDashboardVersionDiff(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "api.GetDashboardVersionDiff")
+	defer span.End()
+	c.Req = c.Req.WithContext(ctx)
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.SignedInUser.GetOrgID(), 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian, err := guardian.NewByDashboard(c.Req.Context(), dash, c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	getVersion := func(version int) (*dashver.DashboardVersionDTO, response.Response) {
+		res, err := hs.dashboardVersionService.Get(c.Req.Context(), &dashver.GetDashboardVersionQuery{
+			OrgID:        c.SignedInUser.GetOrgID(),
+			DashboardID:  dash.ID,
+			DashboardUID: dash.UID,
+			Version:      version,
+		})
+		if err != nil {
+			if errors.Is(err, dashver.ErrDashboardVersionNotFound) {
+				return nil, response.Error(http.StatusNotFound, fmt.Sprintf("Dashboard version %d not found", version), err)
+			}
+			return nil, response.Error(http.StatusInternalServerError, "Failed to get dashboard version", err)
+		}
+		return res, nil
+	}
+
+	version, err := strconv.Atoi(web.Params(c.Req)[":id"])
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+	newVersion, rsp := getVersion(version)
+	if rsp != nil {
+		return rsp
+	}
+
+	base := newVersion.ParentVersion
+	if c.Query("base") != "" {
+		if base, err = strconv.Atoi(c.Query("base")); err != nil {
+			return response.Error(http.StatusBadRequest, "base version is invalid", err)
+		}
+	}
+	// the first version has no parent and is compared with an empty dashboard
+	var baseData *simplejson.Json
+	if base != 0 {
+		baseVersion, rsp := getVersion(base)
+		if rsp != nil {
+			return rsp
+		}
+		baseData = baseVersion.Data
+	}
+
+	result, err := dashdiffs.CalculateSemanticDiff(baseData, newVersion.Data)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 // swagger:route POST /dashboards/calculate-diff dashboards calculateDashboardDiff
 //
 // Perform diff on two dashboards.
@@ -1130,13 +1223,25 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *contextmodel.ReqContext) respon
 	}
 
 	saveCmd := dashboards.SaveDashboardCommand{}
-	saveCmd.RestoredFrom = version.Version
 	saveCmd.OrgID = c.SignedInUser.GetOrgID()
 	saveCmd.UserID = userID
-	saveCmd.Dashboard = version.Data
+	if len(apiCmd.Panels) > 0 || len(apiCmd.Variables) > 0 {
+		// only the selected panels and variables are restored into the current version
+		saveCmd.Dashboard, err = dashdiffs.RestoreElements(dash.Data, version.Data, apiCmd.Panels, apiCmd.Variables)
+		if err != nil {
+			if errors.Is(err, dashdiffs.ErrPanelNotFound) || errors.Is(err, dashdiffs.ErrVariableNotFound) {
+				return response.Error(http.StatusBadRequest, err.Error(), err)
+			}
+			return response.Error(http.StatusInternalServerError, "Failed to restore dashboard elements", err)
+		}
+		saveCmd.Message = restoredElementsMessage(apiCmd, version.Version)
+	} else {
+		saveCmd.RestoredFrom = version.Version
+		saveCmd.Dashboard = version.Data
+		saveCmd.Message = fmt.Sprintf("Restored from version %d", version.Version)
+	}
 	saveCmd.Dashboard.Set("version", dash.Version)
 	saveCmd.Dashboard.Set("uid", dash.UID)
-	saveCmd.Message = fmt.Sprintf("Restored from version %d", version.Version)
 	// nolint:staticcheck
 	saveCmd.FolderID = dash.FolderID
 	metrics.MFolderIDsAPICount.WithLabelValues(metrics.RestoreDashboardVersion).Inc()
@@ -1145,6 +1250,21 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *contextmodel.ReqContext) respon
 	return hs.postDashboard(c, saveCmd)
 }
 
+func restoredElementsMessage(cmd dtos.RestoreDashboardVersionCommand, version int) string {
+	var elements []string
+	if len(cmd.Panels) > 0 {
+		ids := make([]string, 0, len(cmd.Panels))
+		for _, id := range cmd.Panels {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		elements = append(elements, "panels "+strings.Join(ids, ", "))
+	}
+	if len(cmd.Variables) > 0 {
+		elements = append(elements, "variables "+strings.Join(cmd.Variables, ", "))
+	}
+	return fmt.Sprintf("Restored %s from version %d", strings.Join(elements, " and "), version)
+}
+
 // swagger:route GET /dashboards/tags dashboards getDashboardTags
 //
 // Get all dashboards tags of an organisation.
@@ -1242,6 +1362,19 @@ type GetDashboardVersionByUIDParams struct {
 	UID string `json:"uid"`
 }
 
+// swagger:parameters getDashboardVersionDiffByUID
+type GetDashboardVersionDiffByUIDParams struct {
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// The version to compare with. Defaults to the parent version of the dashboard version.
+	// in:query
+	// required:false
+	Base int64 `json:"base"`
+}
+
 // swagger:parameters getDashboardVersions getDashboardVersionsByUID
 type GetDashboardVersionsParams struct {
 	// Maximum number of results to return
@@ -1367,6 +1500,11 @@ type PostDashboardResponse struct {
 		// FolderUID The unique identifier (uid) of the folder the dashboard belongs to.
 		// required: false
 		FolderUID string `json:"folderUid"`
+
+		// WriteBackError is set when the dashboard is provisioned from a git repository and the changes could not be
+		// committed to its write-back branch.
+		// required: false
+		WriteBackError string `json:"writeBackError,omitempty"`
 	} `json:"body"`
 }
 
@@ -1412,6 +1550,12 @@ type DashboardVersionResponse struct {
 	Body *dashver.DashboardVersionMeta `json:"body"`
 }
 
+// swagger:response dashboardVersionDiffResponse
+type DashboardVersionDiffResponse struct {
+	// in: body
+	Body *dashdiffs.SemanticResult `json:"body"`
+}
+
 // swagger:parameters restoreDeletedDashboardByUID
 type RestoreDeletedDashboardByUID struct {
 	// in:path
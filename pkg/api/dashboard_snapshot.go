@@ -256,6 +256,59 @@ func (hs *HTTPServer) DeleteDashboardSnapshot(c *contextmodel.ReqContext) respon
 	})
 }
 
+// swagger:route POST /snapshots/{key}/recapture snapshots recaptureDashboardSnapshot
+//
+// Re-capture Snapshot by Key.
+//
+// Re-runs the queries of the dashboard the snapshot was taken from for the time range of the snapshot and stores
+// the results as a new version of the snapshot. Panels whose queries fail keep their previous data.
+//
+// Responses:
+// 200: recaptureDashboardSnapshotResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: conflictError
+// 500: internalServerError
+func (hs *HTTPServer) RecaptureDashboardSnapshot(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.SnapshotEnabled {
+		c.JsonApiErr(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+		return nil
+	}
+
+	key := web.Params(c.Req)[":key"]
+	if len(key) == 0 {
+		return response.Error(http.StatusNotFound, "Snapshot not found", nil)
+	}
+
+	queryResult, err := hs.dashboardsnapshotsService.GetDashboardSnapshot(c.Req.Context(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: key})
+	if err != nil {
+		return response.Err(err)
+	}
+
+	if queryResult.OrgID != c.SignedInUser.GetOrgID() {
+		return response.Error(http.StatusUnauthorized, "OrgID mismatch", nil)
+	}
+
+	// Re-capturing runs the queries of the dashboard, so the user must be able to edit it.
+	dashboardUID := queryResult.Dashboard.Get("uid").MustString()
+	evaluator := ac.EvalPermission(dashboards.ActionDashboardsWrite, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboardUID))
+	if canEdit, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, evaluator); err != nil || !canEdit {
+		return response.Error(http.StatusForbidden, "Access denied to this snapshot", err)
+	}
+
+	result, err := hs.dashboardsnapshotsService.RecaptureDashboardSnapshot(c.Req.Context(), &dashboardsnapshots.RecaptureDashboardSnapshotCommand{
+		Key:          key,
+		SignedInUser: c.SignedInUser,
+	})
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
 // swagger:route GET /dashboard/snapshots snapshots searchDashboardSnapshots
 //
 // List snapshots.
@@ -298,6 +351,8 @@ func (hs *HTTPServer) SearchDashboardSnapshots(c *contextmodel.ReqContext) respo
 			UserID:      snapshot.UserID,
 			External:    snapshot.External,
 			ExternalURL: snapshot.ExternalURL,
+			Size:        snapshot.Size,
+			Version:     snapshot.Version,
 			Expires:     snapshot.Expires,
 			Created:     snapshot.Created,
 			Updated:     snapshot.Updated,
@@ -337,6 +392,12 @@ type DeleteDashboardSnapshotParams struct {
 	Key string `json:"key"`
 }
 
+// swagger:parameters recaptureDashboardSnapshot
+type RecaptureDashboardSnapshotParams struct {
+	// in:path
+	Key string `json:"key"`
+}
+
 // swagger:parameters deleteDashboardSnapshotByDeleteKey
 type DeleteSnapshotByDeleteKeyParams struct {
 	// in:path
@@ -364,6 +425,12 @@ type SearchDashboardSnapshotsResponse struct {
 	Body []*dashboardsnapshots.DashboardSnapshotDTO `json:"body"`
 }
 
+// swagger:response recaptureDashboardSnapshotResponse
+type RecaptureDashboardSnapshotResponse struct {
+	// in:body
+	Body dashboardsnapshots.RecaptureDashboardSnapshotResult `json:"body"`
+}
+
 // swagger:response getDashboardSnapshotResponse
 type GetDashboardSnapshotResponse DashboardResponse
 
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
@@ -131,7 +132,7 @@ func newTestLive(t *testing.T, store db.DB) *live.GrafanaLive {
 		nil,
 		&usagestats.UsageStatsMock{T: t},
 		nil,
-		features, acimpl.ProvideAccessControl(features, zanzana.NewNoopClient()), &dashboards.FakeDashboardService{}, annotationstest.NewFakeAnnotationsRepo(), nil)
+		features, acimpl.ProvideAccessControl(features, zanzana.NewNoopClient()), &dashboards.FakeDashboardService{}, annotationstest.NewFakeAnnotationsRepo(), nil, nil)
 	require.NoError(t, err)
 	return gLive
 }
@@ -639,6 +640,61 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			}, mockSQLStore)
 	})
 
+	t.Run("Given panels and variables being restored should restore only these elements", func(t *testing.T) {
+		fakeDash := dashboards.NewDashboard("Dash")
+		fakeDash.ID = 2
+		fakeDash.Data = simplejson.MustJson([]byte(`{"title": "Dash", "version": 3, "panels": [{"id": 1, "title": "New CPU"}, {"id": 2, "title": "Memory"}], "templating": {"list": [{"name": "env", "query": "prod"}]}}`))
+		fakeDash.Version = 3
+
+		var saved *dashboards.SaveDashboardDTO
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).Return(fakeDash, nil)
+		dashboardService.On("SaveDashboard", mock.Anything, mock.AnythingOfType("*dashboards.SaveDashboardDTO"), mock.AnythingOfType("bool")).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*dashboards.SaveDashboardDTO)
+		}).Return(&dashboards.Dashboard{ID: 2, UID: "uid", Title: "Dash", Slug: "dash", Version: 4}, nil)
+
+		fakeDashboardVersionService := dashvertest.NewDashboardVersionServiceFake()
+		fakeDashboardVersionService.ExpectedDashboardVersions = []*dashver.DashboardVersionDTO{
+			{
+				DashboardID: 2,
+				Version:     1,
+				Data:        simplejson.MustJson([]byte(`{"title": "Old dash", "version": 1, "panels": [{"id": 1, "title": "CPU"}], "templating": {"list": [{"name": "env", "query": "dev"}]}}`)),
+			},
+		}
+
+		cmd := dtos.RestoreDashboardVersionCommand{
+			Version:   1,
+			Panels:    []int64{1},
+			Variables: []string{"env"},
+		}
+		restoreDashboardVersionScenario(t, "When calling POST on", "/api/dashboards/id/2/restore",
+			"/api/dashboards/id/:dashboardId/restore", dashboardService, fakeDashboardVersionService, cmd, func(sc *scenarioContext) {
+				callRestoreDashboardVersion(sc)
+				assert.Equal(t, http.StatusOK, sc.resp.Code)
+
+				require.NotNil(t, saved)
+				assert.Equal(t, "Restored panels 1 and variables env from version 1", saved.Message)
+				data := saved.Dashboard.Data
+				assert.Equal(t, "Dash", data.Get("title").MustString())
+				assert.Equal(t, "CPU", data.Get("panels").GetIndex(0).Get("title").MustString())
+				assert.Equal(t, "Memory", data.Get("panels").GetIndex(1).Get("title").MustString())
+				assert.Equal(t, "dev", data.GetPath("templating", "list").GetIndex(0).Get("query").MustString())
+			}, dbtest.NewFakeDB())
+
+		cmd.Panels = []int64{2}
+		fakeDashboardVersionService = dashvertest.NewDashboardVersionServiceFake()
+		fakeDashboardVersionService.ExpectedDashboardVersion = &dashver.DashboardVersionDTO{
+			DashboardID: 2,
+			Version:     1,
+			Data:        simplejson.MustJson([]byte(`{"title": "Old dash", "panels": [{"id": 1, "title": "CPU"}]}`)),
+		}
+		restoreDashboardVersionScenario(t, "When calling POST with a panel missing from the version on", "/api/dashboards/id/2/restore",
+			"/api/dashboards/id/:dashboardId/restore", dashboardService, fakeDashboardVersionService, cmd, func(sc *scenarioContext) {
+				callRestoreDashboardVersion(sc)
+				assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+			}, dbtest.NewFakeDB())
+	})
+
 	t.Run("Given provisioned dashboard", func(t *testing.T) {
 		mockSQLStore := dbtest.NewFakeDB()
 		dashboardStore := dashboards.NewFakeDashboardStore(t)
@@ -808,6 +864,52 @@ func TestDashboardVersionsAPIEndpoint(t *testing.T) {
 				assert.Equal(t, anonString, v.CreatedBy)
 			}
 		}, mockSQLStore)
+
+	loggedInUserScenarioWithRole(t, "When calling GET on", "GET", "/api/dashboards/uid/dash/versions/2/diff",
+		"/api/dashboards/uid/:uid/versions/:id/diff", org.RoleEditor, func(sc *scenarioContext) {
+			setUp()
+			fakeDashboardVersionService.ExpectedDashboardVersions = []*dashver.DashboardVersionDTO{
+				{
+					Version:       2,
+					ParentVersion: 1,
+					Data:          simplejson.MustJson([]byte(`{"title": "Dash", "version": 2, "panels": [{"id": 1, "title": "CPU usage"}]}`)),
+				},
+				{
+					Version: 1,
+					Data:    simplejson.MustJson([]byte(`{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "CPU"}]}`)),
+				},
+			}
+			getHS(&usertest.FakeUserService{}).callGetDashboardVersionDiff(sc)
+
+			assert.Equal(t, http.StatusOK, sc.resp.Code)
+			var diff dashdiffs.SemanticResult
+			err := json.NewDecoder(sc.resp.Body).Decode(&diff)
+			require.NoError(t, err)
+			assert.Equal(t, `Panel 1 "CPU usage" modified: title changed from "CPU" to "CPU usage"`, diff.Summary)
+			assert.Equal(t, []dashdiffs.PatchOperation{
+				{Op: dashdiffs.PatchReplace, Path: "/panels/0/title", Value: "CPU usage"},
+				{Op: dashdiffs.PatchReplace, Path: "/version", Value: float64(2)},
+			}, diff.Patch)
+		}, mockSQLStore)
+
+	loggedInUserScenarioWithRole(t, "When calling GET on the first version", "GET", "/api/dashboards/uid/dash/versions/1/diff",
+		"/api/dashboards/uid/:uid/versions/:id/diff", org.RoleEditor, func(sc *scenarioContext) {
+			setUp()
+			fakeDashboardVersionService.ExpectedDashboardVersions = []*dashver.DashboardVersionDTO{
+				{
+					Version: 1,
+					Data:    simplejson.MustJson([]byte(`{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "CPU"}]}`)),
+				},
+			}
+			getHS(&usertest.FakeUserService{}).callGetDashboardVersionDiff(sc)
+
+			assert.Equal(t, http.StatusOK, sc.resp.Code)
+			var diff dashdiffs.SemanticResult
+			err := json.NewDecoder(sc.resp.Body).Decode(&diff)
+			require.NoError(t, err)
+			assert.Equal(t, "Dashboard field \"title\" added\nPanel 1 \"CPU\" added", diff.Summary)
+			assert.Len(t, diff.Patch, 3)
+		}, mockSQLStore)
 }
 
 func getDashboardShouldReturn200WithConfig(t *testing.T, sc *scenarioContext, provisioningService provisioning.ProvisioningService, dashboardStore dashboards.Store, dashboardService dashboards.DashboardService, folderStore folder.FolderStore) dtos.DashboardFullWithMeta {
@@ -887,6 +989,11 @@ func (hs *HTTPServer) callGetDashboardVersions(sc *scenarioContext) {
 	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
 }
 
+func (hs *HTTPServer) callGetDashboardVersionDiff(sc *scenarioContext) {
+	sc.handlerFunc = hs.GetDashboardVersionDiff
+	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+}
+
 func callPostDashboard(sc *scenarioContext) {
 	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
 }
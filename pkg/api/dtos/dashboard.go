@@ -60,4 +60,8 @@ type CalculateDiffTarget struct {
 
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
+	// IDs of the panels to restore. When panels or variables are set, only these elements are restored into the current version of the dashboard.
+	Panels []int64 `json:"panels"`
+	// Names of the template variables to restore.
+	Variables []string `json:"variables"`
 }
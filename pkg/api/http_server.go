@@ -926,6 +926,16 @@ func (hs *HTTPServer) configureTLS() error {
 		CipherSuites: tlsCiphers,
 	}
 
+	// Client certificates are optional, so that the clients without one can use the other authentication methods.
+	if hs.Cfg.AuthMTLS.Enabled {
+		clientCAs, err := loadClientCAs(hs.Cfg.AuthMTLS.CACertFile)
+		if err != nil {
+			return err
+		}
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsCfg.ClientCAs = clientCAs
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 
 	if hs.Cfg.Protocol == setting.HTTP2Scheme {
@@ -939,6 +949,25 @@ func (hs *HTTPServer) configureTLS() error {
 	return nil
 }
 
+// loadClientCAs reads the PEM encoded certificates of the authorities issuing the client certificates.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, errors.New("ca_cert is required in [auth.mtls] to verify the client certificates")
+	}
+
+	// #nosec G304 - the path comes from the configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA certificates: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no client CA certificate found in %s", path)
+	}
+	return pool, nil
+}
+
 func (hs *HTTPServer) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 	hs.tlsCerts.certLock.RLock()
 	defer hs.tlsCerts.certLock.RUnlock()
@@ -0,0 +1,133 @@
+package alertingimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// ruleFile is a Prometheus, Mimir or Cortex rule file.
+type ruleFile struct {
+	Groups []definitions.PrometheusRuleGroup `yaml:"groups"`
+}
+
+// ImportPrometheusRules imports the rule groups of the Prometheus rule files given as arguments into a folder of
+// a running Grafana instance. Each file is imported in a separate request, and the command fails if a group of any
+// file could not be imported.
+func ImportPrometheusRules(c utils.CommandLine) error {
+	files := c.Args().Slice()
+	if len(files) == 0 {
+		return errors.New("missing path to a Prometheus rule file")
+	}
+	for _, name := range []string{"folder-uid", "datasource-uid"} {
+		if c.String(name) == "" {
+			return fmt.Errorf("missing required flag --%s", name)
+		}
+	}
+
+	endpoint, err := url.JoinPath(c.String("url"), "api/v1/provisioning/folder", url.PathEscape(c.String("folder-uid")), "import/prometheus")
+	if err != nil {
+		return fmt.Errorf("invalid Grafana URL: %w", err)
+	}
+	if c.Bool("dry-run") {
+		endpoint += "?dry_run=true"
+	}
+
+	var failed []string
+	for _, file := range files {
+		result, err := importFile(c, endpoint, file)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", file, err)
+		}
+		for _, g := range result.Groups {
+			for _, w := range g.Warnings {
+				logger.Warnf("%s: group '%s': %s\n", file, g.Name, w)
+			}
+			switch {
+			case g.Error != "":
+				logger.Errorf("%s: group '%s' was not imported: %s\n", file, g.Name, g.Error)
+				failed = append(failed, fmt.Sprintf("%s: %s", file, g.Name))
+			case result.DryRun:
+				logger.Infof("%s: group '%s' can be imported\n", file, g.Name)
+			default:
+				logger.Infof("%s: group '%s' was imported\n", file, g.Name)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d rule groups could not be imported: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func importFile(c utils.CommandLine, endpoint, file string) (definitions.PrometheusRulesImportResult, error) {
+	var result definitions.PrometheusRulesImportResult
+
+	// #nosec G304 - the path of the rule file is provided by the user running the command
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return result, err
+	}
+	var rules ruleFile
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return result, fmt.Errorf("failed to parse the rule file: %w", err)
+	}
+	if len(rules.Groups) == 0 {
+		return result, errors.New("the rule file has no rule groups")
+	}
+
+	body, err := json.Marshal(definitions.PrometheusRulesImport{
+		DatasourceUID: c.String("datasource-uid"),
+		Groups:        rules.Groups,
+	})
+	if err != nil {
+		return result, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := c.String("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.Bool("disable-provenance") {
+		req.Header.Set("X-Disable-Provenance", "true")
+	}
+
+	resp, err := services.HttpClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warnf("Failed to close response body: %v\n", err)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("request failed with status %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return result, fmt.Errorf("failed to parse the response: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package alertingimport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+const ruleFileContent = `groups:
+- name: node
+  interval: 30s
+  rules:
+  - alert: InstanceDown
+    expr: up == 0
+    for: 5m
+    keep_firing_for: 10m
+    labels:
+      severity: critical
+    annotations:
+      summary: Instance {{ $labels.instance }} is down
+  - record: job:up:sum
+    expr: sum by (job) (up)
+`
+
+func newCommandLine(t *testing.T, url string, dryRun bool, files ...string) *utils.MockCommandLine {
+	t.Helper()
+	args := &utils.MockArgs{}
+	args.On("Slice").Return(files)
+
+	c := &utils.MockCommandLine{}
+	c.On("Args").Return(args)
+	c.On("String", "url").Return(url)
+	c.On("String", "token").Return("test-token")
+	c.On("String", "folder-uid").Return("folder-uid")
+	c.On("String", "datasource-uid").Return("prometheus-uid")
+	c.On("Bool", "dry-run").Return(dryRun)
+	c.On("Bool", mock.Anything).Return(false)
+	return c
+}
+
+func writeRuleFile(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(ruleFileContent), 0o600))
+	return file
+}
+
+func TestImportPrometheusRules(t *testing.T) {
+	t.Run("should post the rule groups of the file", func(t *testing.T) {
+		var request *http.Request
+		var body definitions.PrometheusRulesImport
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request = r
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.NoError(t, json.NewEncoder(w).Encode(definitions.PrometheusRulesImportResult{
+				DryRun: true,
+				Groups: []definitions.PrometheusRuleGroupImportResult{{Name: "node"}},
+			}))
+		}))
+		defer server.Close()
+
+		err := ImportPrometheusRules(newCommandLine(t, server.URL, true, writeRuleFile(t)))
+		require.NoError(t, err)
+
+		require.Equal(t, http.MethodPost, request.Method)
+		require.Equal(t, "/api/v1/provisioning/folder/folder-uid/import/prometheus", request.URL.Path)
+		require.Equal(t, "true", request.URL.Query().Get("dry_run"))
+		require.Equal(t, "Bearer test-token", request.Header.Get("Authorization"))
+		require.Empty(t, request.Header.Get("X-Disable-Provenance"))
+
+		require.Equal(t, "prometheus-uid", body.DatasourceUID)
+		require.Len(t, body.Groups, 1)
+		require.Equal(t, "node", body.Groups[0].Name)
+		require.Equal(t, model.Duration(30*time.Second), body.Groups[0].Interval)
+		require.Len(t, body.Groups[0].Rules, 2)
+		require.Equal(t, model.Duration(5*time.Minute), *body.Groups[0].Rules[0].For)
+		require.Equal(t, model.Duration(10*time.Minute), *body.Groups[0].Rules[0].KeepFiringFor)
+		require.Equal(t, "job:up:sum", body.Groups[0].Rules[1].Record)
+	})
+
+	t.Run("should fail if a rule group is not imported", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewEncoder(w).Encode(definitions.PrometheusRulesImportResult{
+				Groups: []definitions.PrometheusRuleGroupImportResult{{Name: "node", Error: "invalid rule"}},
+			}))
+		}))
+		defer server.Close()
+
+		err := ImportPrometheusRules(newCommandLine(t, server.URL, false, writeRuleFile(t)))
+		require.ErrorContains(t, err, "1 rule groups could not be imported")
+	})
+
+	t.Run("should fail if the request fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		err := ImportPrometheusRules(newCommandLine(t, server.URL, false, writeRuleFile(t)))
+		require.ErrorContains(t, err, "request failed with status 403")
+	})
+
+	t.Run("should fail without rule files", func(t *testing.T) {
+		err := ImportPrometheusRules(newCommandLine(t, "http://localhost:3000", false))
+		require.Error(t, err)
+	})
+}
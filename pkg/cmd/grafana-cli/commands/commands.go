@@ -6,6 +6,7 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/alertingimport"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/datamigrations"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/secretsmigrations"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
@@ -226,6 +227,53 @@ grafana-cli user-manager conflicts ingest-file <filepath>
 	},
 }
 
+var alertingCommands = []*cli.Command{
+	{
+		Name:   "import-prometheus-rules",
+		Usage:  "import-prometheus-rules <rule file> [<rule file>...]",
+		Action: runPluginCommand(alertingimport.ImportPrometheusRules),
+		CustomHelpTemplate: `
+This command imports the rule groups of Prometheus, Mimir or Cortex rule files into a folder of a running Grafana
+instance. The rules are converted to Grafana-managed rules that query the Prometheus data source, and replace the
+rule groups of the folder with the same names. The constructs that cannot be converted are reported as warnings.
+
+# validates the conversion of the rule files without importing them
+grafana cli alerting import-prometheus-rules --token <token> --folder-uid <folder> --datasource-uid <data source> --dry-run rules/*.yaml
+
+# imports the rule files
+grafana cli alerting import-prometheus-rules --token <token> --folder-uid <folder> --datasource-uid <data source> rules/*.yaml
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "url",
+				Usage: "The URL of the Grafana instance",
+				Value: "http://localhost:3000",
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				Usage:   "The service account token used to authenticate to Grafana",
+				EnvVars: []string{"GRAFANA_TOKEN"},
+			},
+			&cli.StringFlag{
+				Name:  "folder-uid",
+				Usage: "The UID of the folder the rule groups are imported into",
+			},
+			&cli.StringFlag{
+				Name:  "datasource-uid",
+				Usage: "The UID of the Prometheus data source queried by the rules",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Convert and validate the rule groups without importing them",
+			},
+			&cli.BoolFlag{
+				Name:  "disable-provenance",
+				Usage: "Import the rules without provenance, so that they can be edited in the UI",
+			},
+		},
+	},
+}
+
 var Commands = []*cli.Command{
 	{
 		Name:        "plugins",
@@ -237,4 +285,9 @@ var Commands = []*cli.Command{
 		Usage:       "Grafana admin commands",
 		Subcommands: adminCommands,
 	},
+	{
+		Name:        "alerting",
+		Usage:       "Grafana Alerting commands",
+		Subcommands: alertingCommands,
+	},
 }
@@ -8,7 +8,8 @@ import (
 )
 
 func ReEncryptDEKS(_ utils.CommandLine, runner server.Runner) error {
-	return runner.SecretsService.ReEncryptDataKeys(context.Background())
+	_, err := runner.SecretsMigrator.ReEncryptDataKeys(context.Background())
+	return err
 }
 
 func ReEncryptSecrets(_ utils.CommandLine, runner server.Runner) error {
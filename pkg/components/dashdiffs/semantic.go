@@ -0,0 +1,792 @@
+package dashdiffs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	// ErrPanelNotFound occurs when a panel to restore is not in the version.
+	ErrPanelNotFound = errors.New("dashdiff: panel not found")
+	// ErrVariableNotFound occurs when a variable to restore is not in the version.
+	ErrVariableNotFound = errors.New("dashdiff: variable not found")
+)
+
+// ChangeKind is the kind of dashboard element a change applies to.
+type ChangeKind string
+
+const (
+	ChangeKindDashboard ChangeKind = "dashboard"
+	ChangeKindPanel     ChangeKind = "panel"
+	ChangeKindVariable  ChangeKind = "variable"
+)
+
+// ChangeAction is what happened to a dashboard element between two versions.
+type ChangeAction string
+
+const (
+	ChangeAdded    ChangeAction = "added"
+	ChangeRemoved  ChangeAction = "removed"
+	ChangeModified ChangeAction = "modified"
+	ChangeMoved    ChangeAction = "moved"
+)
+
+// Patch operations generated by CalculateSemanticDiff.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+)
+
+// SemanticResult is the dashboard-aware diff of two dashboard versions.
+// swagger:model DashboardVersionDiff
+type SemanticResult struct {
+	// Patch is the JSON patch (RFC 6902) which transforms the base version into the new version.
+	Patch []PatchOperation `json:"patch"`
+	// Changes are the changes of the dashboard, its panels and its variables.
+	Changes []Change `json:"changes"`
+	// Summary is the human-readable description of the changes, one change per line.
+	Summary string `json:"summary"`
+}
+
+// PatchOperation is an operation of a JSON patch.
+// swagger:model DashboardPatchOperation
+type PatchOperation struct {
+	// Enum: add,remove,replace,move
+	Op string `json:"op"`
+	// From is the source of a move operation.
+	From  string `json:"from,omitempty"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of add and replace operations, even when it is null.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case PatchMove:
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			From string `json:"from"`
+			Path string `json:"path"`
+		}{o.Op, o.From, o.Path})
+	case PatchRemove:
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// Change is a change of the dashboard, of a panel or of a variable.
+// swagger:model DashboardVersionChange
+type Change struct {
+	Kind   ChangeKind   `json:"kind"`
+	Action ChangeAction `json:"action"`
+	// PanelID is the ID of the panel, if the change applies to a panel.
+	PanelID int64 `json:"panelId,omitempty"`
+	// Name is the name of the variable or of the dashboard field, or the title of the panel.
+	Name string `json:"name,omitempty"`
+	// Path is the JSON pointer of the element in the new version, or in the base version if it was removed.
+	Path string `json:"path"`
+	// Details are the changes of a modified panel or variable.
+	Details []string `json:"details,omitempty"`
+}
+
+func (c Change) String() string {
+	var b strings.Builder
+	switch c.Kind {
+	case ChangeKindPanel:
+		b.WriteString("Panel")
+		if c.PanelID != 0 {
+			fmt.Fprintf(&b, " %d", c.PanelID)
+		}
+		if c.Name != "" {
+			fmt.Fprintf(&b, " %q", c.Name)
+		}
+	case ChangeKindVariable:
+		fmt.Fprintf(&b, "Variable %q", c.Name)
+	default:
+		fmt.Fprintf(&b, "Dashboard field %q", c.Name)
+	}
+	fmt.Fprintf(&b, " %s", c.Action)
+	if len(c.Details) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(c.Details, ", "))
+	}
+	return b.String()
+}
+
+// CalculateSemanticDiff computes the diff of two versions of a dashboard. Panels are matched by ID, so that a moved panel
+// is reported and patched as moved instead of being removed and added again, and variables are matched by name. A nil
+// base version is an empty dashboard.
+func CalculateSemanticDiff(baseData, newData *simplejson.Json) (*SemanticResult, error) {
+	base, err := decodeDashboard(baseData)
+	if err != nil {
+		return nil, err
+	}
+	target, err := decodeDashboard(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SemanticResult{Patch: []PatchOperation{}, Changes: []Change{}}
+	diffValues("", base, target, &result.Patch)
+	result.Changes = append(result.Changes, dashboardChanges(base, target)...)
+	result.Changes = append(result.Changes, panelChanges(flattenPanels(base), flattenPanels(target))...)
+	result.Changes = append(result.Changes, variableChanges(listVariables(base), listVariables(target))...)
+
+	if len(result.Changes) == 0 {
+		result.Summary = "No changes"
+		return result, nil
+	}
+	lines := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		lines = append(lines, c.String())
+	}
+	result.Summary = strings.Join(lines, "\n")
+	return result, nil
+}
+
+// RestoreElements returns the current version of the dashboard with the panels and the variables restored from an older
+// version. A restored panel keeps its current position, and a panel which was removed since is added back to its row if
+// the row still exists, or at the bottom of the dashboard otherwise.
+func RestoreElements(currentData, versionData *simplejson.Json, panelIDs []int64, variables []string) (*simplejson.Json, error) {
+	current, err := decodeDashboard(currentData)
+	if err != nil {
+		return nil, err
+	}
+	version, err := decodeDashboard(versionData)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range panelIDs {
+		if err := restorePanel(current, version, id); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range variables {
+		if err := restoreVariable(current, version, name); err != nil {
+			return nil, err
+		}
+	}
+	return simplejson.NewFromAny(current), nil
+}
+
+// decodeDashboard decodes the dashboard with numbers kept as json.Number, so that restored elements are saved unchanged.
+func decodeDashboard(data *simplejson.Json) (map[string]any, error) {
+	if data == nil {
+		return map[string]any{}, nil
+	}
+	encoded, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var dashboard map[string]any
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	if err := dec.Decode(&dashboard); err != nil {
+		return nil, err
+	}
+	if dashboard == nil {
+		dashboard = map[string]any{}
+	}
+	return dashboard, nil
+}
+
+// diffValues appends the operations which transform a into b to the patch.
+func diffValues(path string, a, b any, patch *[]PatchOperation) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(av) {
+			p := path + "/" + escapePointer(k)
+			if v, ok := bv[k]; ok {
+				diffValues(p, av[k], v, patch)
+			} else {
+				*patch = append(*patch, PatchOperation{Op: PatchRemove, Path: p})
+			}
+		}
+		for _, k := range sortedKeys(bv) {
+			if _, ok := av[k]; !ok {
+				*patch = append(*patch, PatchOperation{Op: PatchAdd, Path: path + "/" + escapePointer(k), Value: bv[k]})
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		if panelListPath.MatchString(path) && diffPanels(path, av, bv, patch) {
+			return
+		}
+		n := min(len(av), len(bv))
+		for i := 0; i < n; i++ {
+			diffValues(path+"/"+strconv.Itoa(i), av[i], bv[i], patch)
+		}
+		for i := n; i < len(bv); i++ {
+			*patch = append(*patch, PatchOperation{Op: PatchAdd, Path: path + "/" + strconv.Itoa(i), Value: bv[i]})
+		}
+		// remove the elements from the end, so that the indexes of the next operations are still valid
+		for i := len(av) - 1; i >= n; i-- {
+			*patch = append(*patch, PatchOperation{Op: PatchRemove, Path: path + "/" + strconv.Itoa(i)})
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*patch = append(*patch, PatchOperation{Op: PatchReplace, Path: path, Value: b})
+	}
+}
+
+// panelListPath matches the panels of the dashboard and the panels of its collapsed rows.
+var panelListPath = regexp.MustCompile(`^/panels(/\d+/panels)?$`)
+
+// diffPanels appends the operations which transform the panels a into the panels b to the patch. The panels are matched
+// by key, so that a moved panel is moved and then compared with its previous version. It returns false without changing
+// the patch if the keys of the panels are not unique.
+func diffPanels(path string, a, b []any, patch *[]PatchOperation) bool {
+	aKeys, ok := panelKeys(a)
+	if !ok {
+		return false
+	}
+	bKeys, ok := panelKeys(b)
+	if !ok {
+		return false
+	}
+	inB := make(map[string]bool, len(bKeys))
+	for _, k := range bKeys {
+		inB[k] = true
+	}
+
+	// remove the panels from the end, so that the indexes of the next operations are still valid
+	current := make([]string, 0, len(a))
+	values := make(map[string]any, len(a))
+	for i := len(a) - 1; i >= 0; i-- {
+		if !inB[aKeys[i]] {
+			*patch = append(*patch, PatchOperation{Op: PatchRemove, Path: path + "/" + strconv.Itoa(i)})
+		}
+	}
+	for i, k := range aKeys {
+		if inB[k] {
+			current = append(current, k)
+			values[k] = a[i]
+		}
+	}
+
+	// the panels before i are in their final order, so a panel to move is always after i
+	for i, k := range bKeys {
+		p := path + "/" + strconv.Itoa(i)
+		j := slices.Index(current[i:], k)
+		if j < 0 {
+			*patch = append(*patch, PatchOperation{Op: PatchAdd, Path: p, Value: b[i]})
+			current = slices.Insert(current, i, k)
+			continue
+		}
+		if j > 0 {
+			*patch = append(*patch, PatchOperation{Op: PatchMove, From: path + "/" + strconv.Itoa(i+j), Path: p})
+			current = slices.Insert(slices.Delete(current, i+j, i+j+1), i, k)
+		}
+		diffValues(p, values[k], b[i], patch)
+	}
+	return true
+}
+
+// panelKeys returns the keys of the panels, or false if they are not unique.
+func panelKeys(panels []any) ([]string, bool) {
+	keys := make([]string, len(panels))
+	seen := make(map[string]bool, len(panels))
+	for i, p := range panels {
+		panel, ok := p.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		keys[i] = panelKey(panel, "index:"+strconv.Itoa(i))
+		if seen[keys[i]] {
+			return nil, false
+		}
+		seen[keys[i]] = true
+	}
+	return keys, true
+}
+
+// panelKey identifies a panel by its ID, or by its position for panels without ID, or returns fallback for panels with
+// neither.
+func panelKey(panel map[string]any, fallback string) string {
+	if id, ok := toInt64(panel["id"]); ok {
+		return "id:" + strconv.FormatInt(id, 10)
+	}
+	gridPos, _ := panel["gridPos"].(map[string]any)
+	x, okX := toInt64(gridPos["x"])
+	y, okY := toInt64(gridPos["y"])
+	if okX && okY {
+		return fmt.Sprintf("gridPos:%d,%d", x, y)
+	}
+	return fallback
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ignoredDashboardFields are updated on every save and are not changes of the dashboard.
+var ignoredDashboardFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"panels":     true,
+	"templating": true,
+}
+
+func dashboardChanges(base, target map[string]any) []Change {
+	var changes []Change
+	keys := map[string]any{}
+	for k := range base {
+		keys[k] = nil
+	}
+	for k := range target {
+		keys[k] = nil
+	}
+	for _, k := range sortedKeys(keys) {
+		if ignoredDashboardFields[k] {
+			continue
+		}
+		change := Change{Kind: ChangeKindDashboard, Name: k, Path: "/" + escapePointer(k)}
+		a, inBase := base[k]
+		b, inTarget := target[k]
+		switch {
+		case !inBase:
+			change.Action = ChangeAdded
+		case !inTarget:
+			change.Action = ChangeRemoved
+		case !reflect.DeepEqual(a, b):
+			change.Action = ChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// panelRef is a panel of the dashboard, either at the top level or in a collapsed row.
+type panelRef struct {
+	key   string
+	id    int64
+	path  string
+	row   string
+	value map[string]any
+}
+
+func (p panelRef) title() string {
+	title, _ := p.value["title"].(string)
+	return title
+}
+
+func flattenPanels(dashboard map[string]any) []panelRef {
+	var result []panelRef
+	panels, _ := dashboard["panels"].([]any)
+	for i, p := range panels {
+		panel, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		ref := newPanelRef(panel, fmt.Sprintf("/panels/%d", i), "")
+		result = append(result, ref)
+		children, _ := panel["panels"].([]any)
+		for j, c := range children {
+			if child, ok := c.(map[string]any); ok {
+				result = append(result, newPanelRef(child, fmt.Sprintf("%s/panels/%d", ref.path, j), ref.key))
+			}
+		}
+	}
+	return result
+}
+
+func newPanelRef(panel map[string]any, path, row string) panelRef {
+	// panels without ID can only be matched by their position
+	ref := panelRef{key: panelKey(panel, "path:"+path), path: path, row: row, value: panel}
+	ref.id, _ = toInt64(panel["id"])
+	return ref
+}
+
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := n.Float64(); err == nil {
+			return int64(f), true
+		}
+	case float64:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+func panelChanges(base, target []panelRef) []Change {
+	basePanels := make(map[string]panelRef, len(base))
+	for _, p := range base {
+		basePanels[p.key] = p
+	}
+	targetKeys := make(map[string]bool, len(target))
+
+	var changes []Change
+	for _, p := range target {
+		targetKeys[p.key] = true
+		change := Change{Kind: ChangeKindPanel, PanelID: p.id, Name: p.title(), Path: p.path}
+		old, ok := basePanels[p.key]
+		if !ok {
+			change.Action = ChangeAdded
+			changes = append(changes, change)
+			continue
+		}
+
+		moved := old.row != p.row || positionChanged(old.value, p.value)
+		change.Details = panelDetails(old.value, p.value)
+		switch {
+		case len(change.Details) > 0:
+			change.Action = ChangeModified
+			if moved {
+				change.Details = append(change.Details, "moved")
+			}
+		case moved:
+			change.Action = ChangeMoved
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for _, p := range base {
+		if !targetKeys[p.key] {
+			changes = append(changes, Change{Kind: ChangeKindPanel, Action: ChangeRemoved, PanelID: p.id, Name: p.title(), Path: p.path})
+		}
+	}
+	return changes
+}
+
+func positionChanged(a, b map[string]any) bool {
+	ap, _ := a["gridPos"].(map[string]any)
+	bp, _ := b["gridPos"].(map[string]any)
+	return !reflect.DeepEqual(ap["x"], bp["x"]) || !reflect.DeepEqual(ap["y"], bp["y"])
+}
+
+func panelDetails(a, b map[string]any) []string {
+	var details []string
+	ap, _ := a["gridPos"].(map[string]any)
+	bp, _ := b["gridPos"].(map[string]any)
+	if !reflect.DeepEqual(ap["w"], bp["w"]) || !reflect.DeepEqual(ap["h"], bp["h"]) {
+		details = append(details, "resized")
+	}
+
+	keys := map[string]any{}
+	for k := range a {
+		keys[k] = nil
+	}
+	for k := range b {
+		keys[k] = nil
+	}
+	for _, k := range sortedKeys(keys) {
+		av, bv := a[k], b[k]
+		if reflect.DeepEqual(av, bv) {
+			continue
+		}
+		switch k {
+		case "id", "gridPos", "panels":
+			// the position is compared separately and the panels of a row are compared as panels
+		case "title", "type":
+			details = append(details, fmt.Sprintf("%s changed from %q to %q", k, stringValue(av), stringValue(bv)))
+		case "datasource":
+			details = append(details, fmt.Sprintf("data source changed from %q to %q", datasourceRef(av), datasourceRef(bv)))
+		case "libraryPanel":
+			details = append(details, libraryPanelDetail(av, bv))
+		case "targets":
+			details = append(details, queryDetails(av, bv)...)
+		default:
+			details = append(details, k+" changed")
+		}
+	}
+	return details
+}
+
+func stringValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// datasourceRef returns the UID of a data source reference, or the name of the data source for legacy references.
+func datasourceRef(v any) string {
+	if ref, ok := v.(map[string]any); ok {
+		if uid := stringValue(ref["uid"]); uid != "" {
+			return uid
+		}
+		return stringValue(ref["type"])
+	}
+	return stringValue(v)
+}
+
+func libraryPanelDetail(a, b any) string {
+	uid := func(v any) string {
+		if ref, ok := v.(map[string]any); ok {
+			return stringValue(ref["uid"])
+		}
+		return ""
+	}
+	switch {
+	case a == nil:
+		return fmt.Sprintf("library panel %q linked", uid(b))
+	case b == nil:
+		return fmt.Sprintf("library panel %q unlinked", uid(a))
+	default:
+		return fmt.Sprintf("library panel changed from %q to %q", uid(a), uid(b))
+	}
+}
+
+// queryDetails compares the queries of a panel by their reference ID.
+func queryDetails(a, b any) []string {
+	queries := func(v any) ([]string, map[string]any) {
+		list, _ := v.([]any)
+		var refIDs []string
+		byRefID := make(map[string]any, len(list))
+		for i, q := range list {
+			query, _ := q.(map[string]any)
+			refID := stringValue(query["refId"])
+			if refID == "" {
+				refID = strconv.Itoa(i)
+			}
+			refIDs = append(refIDs, refID)
+			byRefID[refID] = q
+		}
+		return refIDs, byRefID
+	}
+	baseRefIDs, base := queries(a)
+	targetRefIDs, target := queries(b)
+
+	var details []string
+	for _, refID := range targetRefIDs {
+		old, ok := base[refID]
+		switch {
+		case !ok:
+			details = append(details, fmt.Sprintf("query %s added", refID))
+		case !reflect.DeepEqual(old, target[refID]):
+			details = append(details, fmt.Sprintf("query %s modified", refID))
+		}
+	}
+	for _, refID := range baseRefIDs {
+		if _, ok := target[refID]; !ok {
+			details = append(details, fmt.Sprintf("query %s removed", refID))
+		}
+	}
+	if len(details) == 0 {
+		details = append(details, "queries reordered")
+	}
+	return details
+}
+
+// variableRef is a template variable of the dashboard.
+type variableRef struct {
+	name  string
+	path  string
+	value map[string]any
+}
+
+func listVariables(dashboard map[string]any) []variableRef {
+	templating, _ := dashboard["templating"].(map[string]any)
+	list, _ := templating["list"].([]any)
+	var result []variableRef
+	for i, v := range list {
+		variable, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		result = append(result, variableRef{
+			name:  stringValue(variable["name"]),
+			path:  fmt.Sprintf("/templating/list/%d", i),
+			value: variable,
+		})
+	}
+	return result
+}
+
+func variableChanges(base, target []variableRef) []Change {
+	baseVariables := make(map[string]variableRef, len(base))
+	for _, v := range base {
+		baseVariables[v.name] = v
+	}
+	targetNames := make(map[string]bool, len(target))
+
+	var changes []Change
+	for _, v := range target {
+		targetNames[v.name] = true
+		change := Change{Kind: ChangeKindVariable, Name: v.name, Path: v.path}
+		old, ok := baseVariables[v.name]
+		if !ok {
+			change.Action = ChangeAdded
+			changes = append(changes, change)
+			continue
+		}
+
+		keys := map[string]any{}
+		for k := range old.value {
+			keys[k] = nil
+		}
+		for k := range v.value {
+			keys[k] = nil
+		}
+		for _, k := range sortedKeys(keys) {
+			if !reflect.DeepEqual(old.value[k], v.value[k]) {
+				change.Details = append(change.Details, k+" changed")
+			}
+		}
+		if len(change.Details) == 0 {
+			continue
+		}
+		change.Action = ChangeModified
+		changes = append(changes, change)
+	}
+
+	for _, v := range base {
+		if !targetNames[v.name] {
+			changes = append(changes, Change{Kind: ChangeKindVariable, Action: ChangeRemoved, Name: v.name, Path: v.path})
+		}
+	}
+	return changes
+}
+
+func findPanel(panels []panelRef, id int64) (panelRef, bool) {
+	for _, p := range panels {
+		if p.key == "id:"+strconv.FormatInt(id, 10) {
+			return p, true
+		}
+	}
+	return panelRef{}, false
+}
+
+func restorePanel(current, version map[string]any, id int64) error {
+	old, ok := findPanel(flattenPanels(version), id)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrPanelNotFound, id)
+	}
+	restored := old.value
+
+	currentPanels := flattenPanels(current)
+	if p, ok := findPanel(currentPanels, id); ok {
+		// the panel keeps its position, and a row keeps its current panels
+		if gridPos, ok := p.value["gridPos"]; ok {
+			restored["gridPos"] = gridPos
+		}
+		if children, ok := p.value["panels"]; ok {
+			restored["panels"] = children
+		} else {
+			delete(restored, "panels")
+		}
+		for k := range p.value {
+			delete(p.value, k)
+		}
+		for k, v := range restored {
+			p.value[k] = v
+		}
+		return nil
+	}
+
+	if _, ok := restored["panels"]; ok {
+		// the panels of a removed row are restored separately
+		restored["panels"] = []any{}
+	}
+	if old.row != "" {
+		for _, p := range currentPanels {
+			if p.key != old.row {
+				continue
+			}
+			if children, ok := p.value["panels"].([]any); ok {
+				p.value["panels"] = append(children, restored)
+				return nil
+			}
+		}
+	}
+
+	panels, _ := current["panels"].([]any)
+	if gridPos, ok := restored["gridPos"].(map[string]any); ok {
+		gridPos["y"] = json.Number(strconv.FormatInt(dashboardBottom(panels), 10))
+	}
+	current["panels"] = append(panels, restored)
+	return nil
+}
+
+// dashboardBottom returns the first free row of the top level of the dashboard.
+func dashboardBottom(panels []any) int64 {
+	var bottom int64
+	for _, p := range panels {
+		panel, _ := p.(map[string]any)
+		gridPos, _ := panel["gridPos"].(map[string]any)
+		y, _ := toInt64(gridPos["y"])
+		h, _ := toInt64(gridPos["h"])
+		bottom = max(bottom, y+h)
+	}
+	return bottom
+}
+
+func restoreVariable(current, version map[string]any, name string) error {
+	var restored map[string]any
+	for _, v := range listVariables(version) {
+		if v.name == name {
+			restored = v.value
+			break
+		}
+	}
+	if restored == nil {
+		return fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+
+	templating, ok := current["templating"].(map[string]any)
+	if !ok {
+		templating = map[string]any{}
+		current["templating"] = templating
+	}
+	list, _ := templating["list"].([]any)
+	for i, v := range list {
+		if variable, ok := v.(map[string]any); ok && stringValue(variable["name"]) == name {
+			list[i] = restored
+			return nil
+		}
+	}
+	templating["list"] = append(list, restored)
+	return nil
+}
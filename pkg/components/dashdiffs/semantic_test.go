@@ -0,0 +1,291 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const baseDashboard = `{
+	"id": 1,
+	"uid": "dash",
+	"title": "Services",
+	"version": 3,
+	"panels": [
+		{"id": 1, "type": "timeseries", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+			"datasource": {"type": "prometheus", "uid": "prom"},
+			"targets": [{"refId": "A", "expr": "rate(cpu[5m])"}, {"refId": "B", "expr": "cpu_limit"}]},
+		{"id": 2, "type": "stat", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+		{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1},
+			"panels": [
+				{"id": 4, "type": "table", "title": "Pods", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8}},
+				{"id": 5, "type": "logs", "title": "Logs", "gridPos": {"x": 0, "y": 17, "w": 24, "h": 8}}
+			]},
+		{"id": 6, "type": "text", "title": "Notes", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 4}}
+	],
+	"templating": {"list": [
+		{"name": "env", "type": "custom", "query": "dev,prod"},
+		{"name": "job", "type": "query", "query": "label_values(job)"}
+	]}
+}`
+
+const newDashboard = `{
+	"id": 1,
+	"uid": "dash",
+	"title": "Services overview",
+	"version": 4,
+	"tags": ["services"],
+	"panels": [
+		{"id": 2, "type": "stat", "title": "Memory", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+		{"id": 1, "type": "timeseries", "title": "CPU usage", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+			"datasource": {"type": "prometheus", "uid": "mimir"},
+			"targets": [{"refId": "A", "expr": "rate(cpu[1m])"}, {"refId": "C", "expr": "cpu_request"}]},
+		{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1},
+			"panels": [
+				{"id": 4, "type": "table", "title": "Pods", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8},
+					"libraryPanel": {"uid": "pods", "name": "Pods"}}
+			]},
+		{"id": 7, "type": "gauge", "title": "Disk", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8}}
+	],
+	"templating": {"list": [
+		{"name": "env", "type": "custom", "query": "dev,staging,prod"},
+		{"name": "cluster", "type": "query", "query": "label_values(cluster)"}
+	]}
+}`
+
+func TestCalculateSemanticDiff(t *testing.T) {
+	base := simplejson.MustJson([]byte(baseDashboard))
+	target := simplejson.MustJson([]byte(newDashboard))
+
+	t.Run("should report the changes of the dashboard, panels and variables", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(base, target)
+		require.NoError(t, err)
+
+		require.Equal(t, []Change{
+			{Kind: ChangeKindDashboard, Action: ChangeAdded, Name: "tags", Path: "/tags"},
+			{Kind: ChangeKindDashboard, Action: ChangeModified, Name: "title", Path: "/title"},
+			{Kind: ChangeKindPanel, Action: ChangeMoved, PanelID: 2, Name: "Memory", Path: "/panels/0"},
+			{Kind: ChangeKindPanel, Action: ChangeModified, PanelID: 1, Name: "CPU usage", Path: "/panels/1", Details: []string{
+				`data source changed from "prom" to "mimir"`,
+				"query A modified",
+				"query C added",
+				"query B removed",
+				`title changed from "CPU" to "CPU usage"`,
+				"moved",
+			}},
+			{Kind: ChangeKindPanel, Action: ChangeModified, PanelID: 4, Name: "Pods", Path: "/panels/2/panels/0", Details: []string{
+				`library panel "pods" linked`,
+			}},
+			{Kind: ChangeKindPanel, Action: ChangeAdded, PanelID: 7, Name: "Disk", Path: "/panels/3"},
+			{Kind: ChangeKindPanel, Action: ChangeRemoved, PanelID: 5, Name: "Logs", Path: "/panels/2/panels/1"},
+			{Kind: ChangeKindPanel, Action: ChangeRemoved, PanelID: 6, Name: "Notes", Path: "/panels/3"},
+			{Kind: ChangeKindVariable, Action: ChangeModified, Name: "env", Path: "/templating/list/0", Details: []string{"query changed"}},
+			{Kind: ChangeKindVariable, Action: ChangeAdded, Name: "cluster", Path: "/templating/list/1"},
+			{Kind: ChangeKindVariable, Action: ChangeRemoved, Name: "job", Path: "/templating/list/1"},
+		}, result.Changes)
+
+		require.True(t, strings.HasPrefix(result.Summary, "Dashboard field \"tags\" added\nDashboard field \"title\" modified\nPanel 2 \"Memory\" moved\n"))
+		require.Contains(t, result.Summary, "Panel 4 \"Pods\" modified: library panel \"pods\" linked\n")
+		require.Contains(t, result.Summary, "Variable \"job\" removed")
+	})
+
+	t.Run("should return a JSON patch which transforms the base version into the new version", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(base, target)
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(result.Patch)
+		require.NoError(t, err)
+		var patch []map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &patch))
+
+		var doc, expected any
+		require.NoError(t, json.Unmarshal([]byte(baseDashboard), &doc))
+		require.NoError(t, json.Unmarshal([]byte(newDashboard), &expected))
+		for _, op := range patch {
+			doc = applyPatchOperation(t, doc, op)
+		}
+		require.Equal(t, expected, doc)
+
+		// the swapped panels are moved instead of being replaced
+		require.Contains(t, patch, map[string]any{"op": PatchMove, "from": "/panels/1", "path": "/panels/0"})
+		require.Contains(t, patch, map[string]any{"op": PatchReplace, "path": "/panels/1/title", "value": "CPU usage"})
+	})
+
+	t.Run("should compare the first version with an empty dashboard", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(nil, simplejson.MustJson([]byte(`{"title": "Services", "panels": [{"id": 1}]}`)))
+		require.NoError(t, err)
+		require.Equal(t, []PatchOperation{
+			{Op: PatchAdd, Path: "/panels", Value: []any{map[string]any{"id": json.Number("1")}}},
+			{Op: PatchAdd, Path: "/title", Value: "Services"},
+		}, result.Patch)
+		require.Equal(t, "Dashboard field \"title\" added\nPanel 1 added", result.Summary)
+	})
+
+	t.Run("should match the panels without ID by position", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(
+			simplejson.MustJson([]byte(`{"panels": [{"title": "A", "gridPos": {"x": 0, "y": 0}}, {"title": "B", "gridPos": {"x": 0, "y": 8}}]}`)),
+			simplejson.MustJson([]byte(`{"panels": [{"title": "B v2", "gridPos": {"x": 0, "y": 8}}]}`)),
+		)
+		require.NoError(t, err)
+		require.Equal(t, []PatchOperation{
+			{Op: PatchRemove, Path: "/panels/0"},
+			{Op: PatchReplace, Path: "/panels/0/title", Value: "B v2"},
+		}, result.Patch)
+	})
+
+	t.Run("should report no changes for identical versions", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(base, simplejson.MustJson([]byte(baseDashboard)))
+		require.NoError(t, err)
+		require.Empty(t, result.Patch)
+		require.Empty(t, result.Changes)
+		require.Equal(t, "No changes", result.Summary)
+	})
+
+	t.Run("should escape the keys of the JSON pointers", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(
+			simplejson.MustJson([]byte(`{"a/b": 1}`)),
+			simplejson.MustJson([]byte(`{"a/b": 2, "c~d": null}`)),
+		)
+		require.NoError(t, err)
+		encoded, err := json.Marshal(result.Patch)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"op": "replace", "path": "/a~1b", "value": 2}, {"op": "add", "path": "/c~0d", "value": null}]`, string(encoded))
+	})
+}
+
+func TestRestoreElements(t *testing.T) {
+	current := simplejson.MustJson([]byte(newDashboard))
+	version := simplejson.MustJson([]byte(baseDashboard))
+
+	t.Run("should restore a panel at its current position", func(t *testing.T) {
+		result, err := RestoreElements(current, version, []int64{1}, nil)
+		require.NoError(t, err)
+
+		panel := result.Get("panels").GetIndex(1)
+		require.Equal(t, "CPU", panel.Get("title").MustString())
+		require.Equal(t, "prom", panel.GetPath("datasource", "uid").MustString())
+		require.Equal(t, int64(12), panel.GetPath("gridPos", "x").MustInt64())
+		// the rest of the dashboard is unchanged
+		require.Equal(t, "Services overview", result.Get("title").MustString())
+		require.Len(t, result.Get("panels").MustArray(), 4)
+	})
+
+	t.Run("should restore a removed panel to its row", func(t *testing.T) {
+		result, err := RestoreElements(current, version, []int64{5}, nil)
+		require.NoError(t, err)
+
+		rowPanels := result.Get("panels").GetIndex(2).Get("panels")
+		require.Len(t, rowPanels.MustArray(), 2)
+		require.Equal(t, "Logs", rowPanels.GetIndex(1).Get("title").MustString())
+	})
+
+	t.Run("should restore a removed panel at the bottom of the dashboard", func(t *testing.T) {
+		result, err := RestoreElements(current, version, []int64{6}, nil)
+		require.NoError(t, err)
+
+		panels := result.Get("panels").MustArray()
+		require.Len(t, panels, 5)
+		panel := result.Get("panels").GetIndex(4)
+		require.Equal(t, "Notes", panel.Get("title").MustString())
+		require.Equal(t, int64(17), panel.GetPath("gridPos", "y").MustInt64())
+	})
+
+	t.Run("should restore variables", func(t *testing.T) {
+		result, err := RestoreElements(current, version, nil, []string{"env", "job"})
+		require.NoError(t, err)
+
+		variables := result.GetPath("templating", "list")
+		require.Len(t, variables.MustArray(), 3)
+		require.Equal(t, "dev,prod", variables.GetIndex(0).Get("query").MustString())
+		require.Equal(t, "cluster", variables.GetIndex(1).Get("name").MustString())
+		require.Equal(t, "job", variables.GetIndex(2).Get("name").MustString())
+	})
+
+	t.Run("should not change the current version", func(t *testing.T) {
+		_, err := RestoreElements(current, version, []int64{1, 5}, []string{"env"})
+		require.NoError(t, err)
+		require.Equal(t, "CPU usage", current.Get("panels").GetIndex(1).Get("title").MustString())
+		require.Equal(t, "dev,staging,prod", current.GetPath("templating", "list").GetIndex(0).Get("query").MustString())
+	})
+
+	t.Run("should fail if the element is not in the version", func(t *testing.T) {
+		_, err := RestoreElements(current, version, []int64{7}, nil)
+		require.ErrorIs(t, err, ErrPanelNotFound)
+
+		_, err = RestoreElements(current, version, nil, []string{"cluster"})
+		require.ErrorIs(t, err, ErrVariableNotFound)
+	})
+}
+
+// applyPatchOperation applies an add, remove, replace or move operation of a JSON patch to a decoded JSON document.
+func applyPatchOperation(t *testing.T, doc any, op map[string]any) any {
+	t.Helper()
+	if op["op"] == PatchMove {
+		from := op["from"].(string)
+		var value any = doc
+		for _, token := range pointerTokens(from) {
+			switch n := value.(type) {
+			case map[string]any:
+				value = n[token]
+			case []any:
+				i, err := strconv.Atoi(token)
+				require.NoError(t, err)
+				value = n[i]
+			}
+		}
+		doc = applyPatchOperation(t, doc, map[string]any{"op": PatchRemove, "path": from})
+		return applyPatchOperation(t, doc, map[string]any{"op": PatchAdd, "path": op["path"], "value": value})
+	}
+
+	path := op["path"].(string)
+	if path == "" {
+		return op["value"]
+	}
+	tokens := pointerTokens(path)
+
+	var apply func(node any, tokens []string) any
+	apply = func(node any, tokens []string) any {
+		last := len(tokens) == 1
+		switch n := node.(type) {
+		case map[string]any:
+			if !last {
+				n[tokens[0]] = apply(n[tokens[0]], tokens[1:])
+			} else if op["op"] == PatchRemove {
+				delete(n, tokens[0])
+			} else {
+				n[tokens[0]] = op["value"]
+			}
+			return n
+		case []any:
+			i, err := strconv.Atoi(tokens[0])
+			require.NoError(t, err)
+			switch {
+			case !last:
+				n[i] = apply(n[i], tokens[1:])
+			case op["op"] == PatchRemove:
+				n = append(n[:i], n[i+1:]...)
+			case op["op"] == PatchAdd:
+				n = append(n[:i], append([]any{op["value"]}, n[i:]...)...)
+			default:
+				n[i] = op["value"]
+			}
+			return n
+		}
+		t.Fatalf("invalid path %s", path)
+		return nil
+	}
+	return apply(doc, tokens)
+}
+
+func pointerTokens(path string) []string {
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
@@ -83,20 +83,22 @@ func (gm *MathCommand) Type() string {
 // ReduceCommand is an expression command for reduction of a timeseries such as a min, mean, or max.
 type ReduceCommand struct {
 	Reducer      mathexp.ReducerID
+	Options      mathexp.ReduceOptions
 	VarToReduce  string
 	refID        string
 	seriesMapper mathexp.ReduceMapper
 }
 
 // NewReduceCommand creates a new ReduceCMD.
-func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
-	_, err := mathexp.GetReduceFunc(reducer)
+func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce string, mapper mathexp.ReduceMapper, opts mathexp.ReduceOptions) (*ReduceCommand, error) {
+	_, err := mathexp.GetSeriesReduceFunc(reducer, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ReduceCommand{
 		Reducer:      reducer,
+		Options:      opts,
 		VarToReduce:  varToReduce,
 		refID:        refID,
 		seriesMapper: mapper,
@@ -125,6 +127,19 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 	}
 	redFunc := mathexp.ReducerID(strings.ToLower(redString))
 
+	var opts mathexp.ReduceOptions
+	if redFunc == mathexp.ReducerPercentile {
+		rawPercentile, ok := rn.Query["percentile"]
+		if !ok {
+			return nil, errors.New("percentile must be specified when reducer is 'percentile'")
+		}
+		percentile, ok := rawPercentile.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected percentile to be a number, got %T", rawPercentile)
+		}
+		opts.Percentile = percentile
+	}
+
 	var mapper mathexp.ReduceMapper = nil
 	settings, ok := rn.Query["settings"]
 	if ok {
@@ -154,7 +169,7 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 			return nil, fmt.Errorf("field settings must be an object, got %T for refId %v", s, rn.RefID)
 		}
 	}
-	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper)
+	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper, opts)
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -175,7 +190,7 @@ func (gr *ReduceCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.
 	for i, val := range vars[gr.VarToReduce].Values {
 		switch v := val.(type) {
 		case mathexp.Series:
-			num, err := v.Reduce(gr.refID, gr.Reducer, gr.seriesMapper)
+			num, err := v.ReduceWithOptions(gr.refID, gr.Reducer, gr.Options, gr.seriesMapper)
 			if err != nil {
 				return newRes, err
 			}
@@ -343,6 +358,8 @@ const (
 	TypeThreshold
 	// TypeSQL is the CMDType for running SQL expressions
 	TypeSQL
+	// TypeForecast is the CMDType for forecasting and scoring anomalies in-process
+	TypeForecast
 )
 
 func (gt CommandType) String() string {
@@ -359,6 +376,8 @@ func (gt CommandType) String() string {
 		return "threshold"
 	case TypeSQL:
 		return "sql"
+	case TypeForecast:
+		return "forecast"
 	default:
 		return "unknown"
 	}
@@ -379,6 +398,8 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeThreshold, nil
 	case "sql":
 		return TypeSQL, nil
+	case "forecast":
+		return TypeForecast, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const (
+	defaultForecastAlpha       = 0.5
+	defaultForecastBeta        = 0.1
+	defaultForecastGamma       = 0.3
+	defaultForecastSensitivity = 3
+)
+
+// ForecastCommand is an expression command that forecasts each time series in-process and scores
+// how far each point is from the forecast, without calling the Machine Learning API.
+type ForecastCommand struct {
+	ReferenceVar string
+	RefID        string
+	Options      mathexp.ForecastOptions
+}
+
+// NewForecastCommand creates a new ForecastCommand.
+func NewForecastCommand(refID, referenceVar string, opts mathexp.ForecastOptions) (*ForecastCommand, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &ForecastCommand{
+		ReferenceVar: referenceVar,
+		RefID:        refID,
+		Options:      opts,
+	}, nil
+}
+
+// newForecastCommandFromQuery creates a ForecastCommand from a ForecastQuery, filling in the defaults. The step is
+// the interval of the query, zero if unknown.
+func newForecastCommandFromQuery(refID string, q *ForecastQuery, step time.Duration) (*ForecastCommand, error) {
+	referenceVar, err := getReferenceVar(q.Expression, refID)
+	if err != nil {
+		return nil, err
+	}
+	opts := mathexp.ForecastOptions{
+		Algorithm:   q.Algorithm,
+		Alpha:       defaultForecastAlpha,
+		Beta:        defaultForecastBeta,
+		Gamma:       defaultForecastGamma,
+		Sensitivity: defaultForecastSensitivity,
+		Step:        step,
+	}
+	if opts.Algorithm == "" {
+		opts.Algorithm = mathexp.ForecastHoltWinters
+	}
+	if q.Season != "" {
+		if opts.Season, err = gtime.ParseDuration(q.Season); err != nil {
+			return nil, fmt.Errorf(`failed to parse forecast "season" duration field %q: %w`, q.Season, err)
+		}
+	}
+	if q.Horizon != "" {
+		if opts.Horizon, err = gtime.ParseDuration(q.Horizon); err != nil {
+			return nil, fmt.Errorf(`failed to parse forecast "horizon" duration field %q: %w`, q.Horizon, err)
+		}
+	}
+	if q.Alpha != nil {
+		opts.Alpha = *q.Alpha
+	}
+	if q.Beta != nil {
+		opts.Beta = *q.Beta
+	}
+	if q.Gamma != nil {
+		opts.Gamma = *q.Gamma
+	}
+	if q.Sensitivity != nil {
+		opts.Sensitivity = *q.Sensitivity
+	}
+	return NewForecastCommand(refID, referenceVar, opts)
+}
+
+// UnmarshalForecastCommand creates a ForecastCommand from Grafana's frontend query.
+func UnmarshalForecastCommand(rn *rawNode) (*ForecastCommand, error) {
+	q := ForecastQuery{}
+	if err := json.Unmarshal(rn.QueryRaw, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse the forecast command: %w", err)
+	}
+	var step time.Duration
+	if rawIntervalMS, ok := rn.Query["intervalMs"]; ok {
+		intervalMS, ok := rawIntervalMS.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected intervalMs to be an float64, got type %T for refId %v", rawIntervalMS, rn.RefID)
+		}
+		step = time.Duration(intervalMS) * time.Millisecond
+	}
+	return newForecastCommandFromQuery(rn.RefID, &q, step)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (fc *ForecastCommand) NeedsVars() []string {
+	return []string{fc.ReferenceVar}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (fc *ForecastCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.Vars, tracer tracing.Tracer) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteForecast")
+	defer span.End()
+
+	newRes := mathexp.Results{}
+	for _, val := range vars[fc.ReferenceVar].Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			forecast, err := v.Forecast(fc.RefID, fc.Options)
+			if err != nil {
+				return newRes, err
+			}
+			for _, s := range forecast {
+				newRes.Values = append(newRes.Values, s)
+			}
+		case mathexp.NoData:
+			newRes.Values = append(newRes.Values, v.New())
+		default:
+			return newRes, fmt.Errorf("can only forecast type series, got type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+func (fc *ForecastCommand) Type() string {
+	return TypeForecast.String()
+}
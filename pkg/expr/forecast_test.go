@@ -0,0 +1,121 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestUnmarshalForecastCommand(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		cmd, err := UnmarshalForecastCommand(&rawNode{
+			RefID:    "B",
+			QueryRaw: []byte(`{ "type": "forecast", "expression": "$A", "season": "1d" }`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "A", cmd.ReferenceVar)
+		require.Equal(t, []string{"A"}, cmd.NeedsVars())
+		require.Equal(t, mathexp.ForecastOptions{
+			Algorithm:   mathexp.ForecastHoltWinters,
+			Season:      24 * time.Hour,
+			Alpha:       defaultForecastAlpha,
+			Beta:        defaultForecastBeta,
+			Gamma:       defaultForecastGamma,
+			Sensitivity: defaultForecastSensitivity,
+		}, cmd.Options)
+	})
+
+	t.Run("should read all options", func(t *testing.T) {
+		cmd, err := UnmarshalForecastCommand(&rawNode{
+			RefID: "B",
+			QueryRaw: []byte(`{
+				"type": "forecast",
+				"expression": "A",
+				"algorithm": "seasonal_decomposition",
+				"season": "1w",
+				"horizon": "1h",
+				"alpha": 0.2,
+				"beta": 0.3,
+				"gamma": 0.4,
+				"sensitivity": 5
+			}`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, mathexp.ForecastOptions{
+			Algorithm:   mathexp.ForecastSeasonalDecomposition,
+			Season:      7 * 24 * time.Hour,
+			Horizon:     time.Hour,
+			Alpha:       0.2,
+			Beta:        0.3,
+			Gamma:       0.4,
+			Sensitivity: 5,
+		}, cmd.Options)
+	})
+
+	t.Run("should return an error if the horizon is too many intervals", func(t *testing.T) {
+		rn := &rawNode{
+			RefID:    "B",
+			Query:    map[string]any{"intervalMs": float64(1000)},
+			QueryRaw: []byte(`{ "type": "forecast", "expression": "$A", "horizon": "1d" }`),
+		}
+		_, err := UnmarshalForecastCommand(rn)
+		require.ErrorContains(t, err, "horizon 24h0m0s is more than 10000 points of 1s")
+
+		rn.Query["intervalMs"] = float64(60000)
+		cmd, err := UnmarshalForecastCommand(rn)
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, cmd.Options.Step)
+	})
+
+	for name, query := range map[string]string{
+		"missing expression": `{ "type": "forecast" }`,
+		"unknown algorithm":  `{ "type": "forecast", "expression": "$A", "algorithm": "prophet" }`,
+		"invalid season":     `{ "type": "forecast", "expression": "$A", "season": "daily" }`,
+		"alpha out of range": `{ "type": "forecast", "expression": "$A", "alpha": 1.5 }`,
+		"zero sensitivity":   `{ "type": "forecast", "expression": "$A", "sensitivity": 0 }`,
+	} {
+		t.Run("should return an error for "+name, func(t *testing.T) {
+			_, err := UnmarshalForecastCommand(&rawNode{RefID: "B", QueryRaw: []byte(query)})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestForecastCommandExecute(t *testing.T) {
+	cmd, err := NewForecastCommand("B", "A", mathexp.ForecastOptions{
+		Algorithm:   mathexp.ForecastSeasonalDecomposition,
+		Sensitivity: 3,
+	})
+	require.NoError(t, err)
+
+	series := mathexp.NewSeries("A", data.Labels{"host": "a"}, 5)
+	for i := 0; i < 5; i++ {
+		series.SetPoint(i, time.Unix(int64(i*60), 0), util.Pointer(float64(i)))
+	}
+
+	t.Run("should return the forecast series of each input series", func(t *testing.T) {
+		res, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{series, mathexp.NewNoData()}},
+		}, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.Len(t, res.Values, 5)
+		for i, kind := range []string{mathexp.ForecastPrediction, mathexp.ForecastUpper, mathexp.ForecastLower, mathexp.ForecastScore} {
+			require.Equal(t, data.Labels{"host": "a", mathexp.ForecastLabel: kind}, res.Values[i].GetLabels())
+		}
+		require.Equal(t, mathexp.NewNoData(), res.Values[4])
+	})
+
+	t.Run("should return an error for numbers", func(t *testing.T) {
+		_, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{mathexp.NewNumber("A", nil)}},
+		}, tracing.InitializeTracerForTest())
+		require.Error(t, err)
+	})
+}
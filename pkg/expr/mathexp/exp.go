@@ -5,6 +5,7 @@ import (
 	"math"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -208,34 +209,10 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 		unions = append(unions, u)
 	}
 
-	aVar := biNode.Args[0].String()
-	bVar := biNode.Args[1].String()
-
 	aMatched := make([]bool, len(aResults.Values))
 	bMatched := make([]bool, len(bResults.Values))
 	collectDrops := func() {
-		check := func(v string, matchArray []bool, r *Results) {
-			for i, b := range matchArray {
-				if b {
-					continue
-				}
-				if e.Drops == nil {
-					e.Drops = make(map[string]map[string][]data.Labels)
-				}
-				if e.Drops[biNode.String()] == nil {
-					e.Drops[biNode.String()] = make(map[string][]data.Labels)
-				}
-
-				if r.Values[i].Type() == parse.TypeNoData {
-					continue
-				}
-
-				e.DropCount++
-				e.Drops[biNode.String()][v] = append(e.Drops[biNode.String()][v], r.Values[i].GetLabels())
-			}
-		}
-		check(aVar, aMatched, &aResults)
-		check(bVar, bMatched, &bResults)
+		e.collectDrops(biNode, aResults, bResults, aMatched, bMatched)
 	}
 
 	aValueLen := len(aResults.Values)
@@ -306,6 +283,151 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 	return unions
 }
 
+// collectDrops records the values of a binary operation that did not match any value on the other side.
+func (e *State) collectDrops(biNode *parse.BinaryNode, aResults, bResults Results, aMatched, bMatched []bool) {
+	check := func(v string, matchArray []bool, r *Results) {
+		for i, b := range matchArray {
+			if b {
+				continue
+			}
+			if e.Drops == nil {
+				e.Drops = make(map[string]map[string][]data.Labels)
+			}
+			if e.Drops[biNode.String()] == nil {
+				e.Drops[biNode.String()] = make(map[string][]data.Labels)
+			}
+
+			if r.Values[i].Type() == parse.TypeNoData {
+				continue
+			}
+
+			e.DropCount++
+			e.Drops[biNode.String()][v] = append(e.Drops[biNode.String()][v], r.Values[i].GetLabels())
+		}
+	}
+	check(biNode.Args[0].String(), aMatched, &aResults)
+	check(biNode.Args[1].String(), bMatched, &bResults)
+}
+
+// matchedUnion creates Union objects for a binary operation with vector matching, such as
+// $A / on(instance) $B or $A / ignoring(pod) group_left $B. Values are matched when their labels are
+// equal on the labels listed in on(...), or on all labels but those listed in ignoring(...).
+// Unlike union, each value may only match one value on the other side, unless that side is
+// the "many" side of group_left or group_right.
+func (e *State) matchedUnion(aResults, bResults Results, biNode *parse.BinaryNode) ([]*Union, error) {
+	unions := []*Union{}
+	m := biNode.Matching
+
+	aValueLen := len(aResults.Values)
+	bValueLen := len(bResults.Values)
+	if aValueLen == 0 || bValueLen == 0 {
+		return unions, nil
+	}
+
+	aMatched := make([]bool, aValueLen)
+	bMatched := make([]bool, bValueLen)
+
+	if aValueLen == 1 || bValueLen == 1 {
+		aNoData := aResults.Values[0].Type() == parse.TypeNoData
+		bNoData := bResults.Values[0].Type() == parse.TypeNoData
+		if aNoData || bNoData {
+			unions = append(unions, &Union{
+				A: aResults.Values[0],
+				B: bResults.Values[0],
+			})
+			e.collectDrops(biNode, aResults, bResults, aMatched, bMatched)
+			return unions, nil
+		}
+	}
+
+	signature := func(labels data.Labels) string {
+		sig := data.Labels{}
+		for k, v := range labels {
+			if slices.Contains(m.Labels, k) == m.On {
+				sig[k] = v
+			}
+		}
+		return sig.String()
+	}
+	group := func(r Results) (map[string][]int, []string) {
+		bySig := make(map[string][]int)
+		sigs := make([]string, len(r.Values))
+		for i, v := range r.Values {
+			if v.Type() == parse.TypeNoData {
+				continue
+			}
+			sigs[i] = signature(v.GetLabels())
+			bySig[sigs[i]] = append(bySig[sigs[i]], i)
+		}
+		return bySig, sigs
+	}
+	aBySig, aSigs := group(aResults)
+	bBySig, _ := group(bResults)
+
+	for iA, a := range aResults.Values {
+		if a.Type() == parse.TypeNoData {
+			continue
+		}
+		sig := aSigs[iA]
+		bIdx := bBySig[sig]
+		if len(bIdx) == 0 {
+			continue
+		}
+		if len(aBySig[sig]) > 1 && m.Card != parse.CardManyToOne {
+			return nil, fmt.Errorf("found duplicate series for the match group {%s} on the left hand-side of the operation %s: many-to-many matching not allowed, use group_left if the left hand-side is the many side", sig, biNode)
+		}
+		if len(bIdx) > 1 && m.Card != parse.CardOneToMany {
+			return nil, fmt.Errorf("found duplicate series for the match group {%s} on the right hand-side of the operation %s: many-to-many matching not allowed, use group_right if the right hand-side is the many side", sig, biNode)
+		}
+		for _, iB := range bIdx {
+			b := bResults.Values[iB]
+			unions = append(unions, &Union{
+				Labels: matchedLabels(m, a.GetLabels(), b.GetLabels()),
+				A:      a,
+				B:      b,
+			})
+			aMatched[iA] = true
+			bMatched[iB] = true
+		}
+	}
+
+	e.collectDrops(biNode, aResults, bResults, aMatched, bMatched)
+	return unions, nil
+}
+
+// matchedLabels returns the labels of the result of matching values with labels a and b.
+// A one-to-one match keeps the labels of a listed in on(...), or all but those listed in ignoring(...).
+// Many-to-one and one-to-many matches keep the labels of the "many" side, and set the included
+// labels to their value on the "one" side.
+func matchedLabels(m *parse.VectorMatching, a, b data.Labels) data.Labels {
+	var labels data.Labels
+	switch m.Card {
+	case parse.CardManyToOne:
+		labels = a.Copy()
+	case parse.CardOneToMany:
+		labels, a, b = b.Copy(), b, a
+	default:
+		labels = data.Labels{}
+		for k, v := range a {
+			if slices.Contains(m.Labels, k) == m.On {
+				labels[k] = v
+			}
+		}
+		return labels
+	}
+	if labels == nil {
+		labels = data.Labels{}
+	}
+	for _, k := range m.Include {
+		if v, ok := b[k]; ok {
+			labels[k] = v
+		} else {
+			delete(labels, k)
+		}
+	}
+	return labels
+}
+
 func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	res := Results{Values: Values{}}
 	ar, err := e.walk(node.Args[0])
@@ -316,7 +438,15 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	if err != nil {
 		return res, err
 	}
-	unions := e.union(ar, br, node)
+	var unions []*Union
+	if node.Matching != nil {
+		unions, err = e.matchedUnion(ar, br, node)
+		if err != nil {
+			return res, err
+		}
+	} else {
+		unions = e.union(ar, br, node)
+	}
 	for _, uni := range unions {
 		var value Value
 		switch at := uni.A.(type) {
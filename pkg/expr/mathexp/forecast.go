@@ -0,0 +1,399 @@
+package mathexp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// The forecast algorithm
+// +enum
+type ForecastAlgorithm string
+
+const (
+	// Additive Holt-Winters (triple exponential smoothing)
+	ForecastHoltWinters ForecastAlgorithm = "holt_winters"
+	// Linear trend plus the mean seasonal pattern
+	ForecastSeasonalDecomposition ForecastAlgorithm = "seasonal_decomposition"
+)
+
+// ForecastLabel is the label added to each series returned by Forecast to tell them apart.
+const ForecastLabel = "forecast"
+
+// The values of ForecastLabel.
+const (
+	ForecastPrediction = "prediction"
+	ForecastUpper      = "upper"
+	ForecastLower      = "lower"
+	ForecastScore      = "score"
+)
+
+// MaxForecastPoints is the maximum number of points forecast past the last point of a series.
+const MaxForecastPoints = 10000
+
+// madScale turns the median absolute deviation into an estimate of the
+// standard deviation for normally distributed values.
+const madScale = 1.4826
+
+// ForecastOptions configures Series.Forecast.
+type ForecastOptions struct {
+	Algorithm ForecastAlgorithm
+	// Season is the length of the seasonal pattern, zero for no seasonality.
+	Season time.Duration
+	// Horizon is how far past the last point to forecast.
+	Horizon time.Duration
+	// Step is the expected interval between the points of the series, zero if unknown. The horizon can not be
+	// more than MaxForecastPoints steps.
+	Step time.Duration
+	// Smoothing factors for the level, trend and season of Holt-Winters, between 0 and 1.
+	Alpha, Beta, Gamma float64
+	// Sensitivity is the number of scaled median absolute deviations between
+	// the prediction and the upper and lower bands.
+	Sensitivity float64
+}
+
+// Validate returns an error if the options can not be used to forecast.
+func (o ForecastOptions) Validate() error {
+	switch o.Algorithm {
+	case ForecastHoltWinters:
+		for name, v := range map[string]float64{"alpha": o.Alpha, "beta": o.Beta, "gamma": o.Gamma} {
+			if !(v > 0 && v < 1) {
+				return fmt.Errorf("%s must be between 0 and 1, got %v", name, v)
+			}
+		}
+	case ForecastSeasonalDecomposition:
+	default:
+		return fmt.Errorf("unsupported forecast algorithm '%s'", o.Algorithm)
+	}
+	if o.Season < 0 {
+		return fmt.Errorf("season can not be negative, got %v", o.Season)
+	}
+	if o.Horizon < 0 {
+		return fmt.Errorf("horizon can not be negative, got %v", o.Horizon)
+	}
+	if o.Step > 0 && o.Horizon/o.Step > MaxForecastPoints {
+		return fmt.Errorf("horizon %v is more than %d points of %v", o.Horizon, MaxForecastPoints, o.Step)
+	}
+	if !(o.Sensitivity > 0) {
+		return fmt.Errorf("sensitivity must be greater than 0, got %v", o.Sensitivity)
+	}
+	return nil
+}
+
+// Forecast fits the series with the algorithm of the options and returns four series, told apart
+// by the ForecastLabel label: the prediction, the upper and lower bands around it, and the anomaly
+// score of each point. The score is the distance between the point and the prediction in scaled
+// median absolute deviations, so a point is outside the bands when its score is above the sensitivity.
+// The prediction and the bands go on past the last point for the horizon of the options.
+func (s Series) Forecast(refID string, opts ForecastOptions) ([]Series, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	sorted := sortedSeries(s)
+	n := sorted.Len()
+	times := make([]time.Time, n)
+	values := make([]float64, n)
+	for i := 0; i < n; i++ {
+		t, f := sorted.GetPoint(i)
+		times[i] = t
+		values[i] = math.NaN()
+		if f != nil {
+			values[i] = *f
+		}
+	}
+
+	step := medianStep(times)
+	horizon, period := 0, 0
+	if step > 0 {
+		if opts.Horizon/step > MaxForecastPoints {
+			return nil, fmt.Errorf("horizon %v is more than %d points of %v", opts.Horizon, MaxForecastPoints, step)
+		}
+		horizon = int(opts.Horizon / step)
+		period = int(math.Round(float64(opts.Season) / float64(step)))
+	}
+	var notice *data.Notice
+	if period > 0 && n < 2*period {
+		notice = &data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "series has fewer than two seasons of points, forecast without seasonality",
+		}
+		period = 0
+	}
+	if period < 2 {
+		period = 0
+	}
+
+	var predicted []float64
+	switch opts.Algorithm {
+	case ForecastHoltWinters:
+		predicted = holtWinters(values, period, opts.Alpha, opts.Beta, opts.Gamma, horizon)
+	case ForecastSeasonalDecomposition:
+		predicted = seasonalDecomposition(values, period, horizon)
+	}
+
+	residuals := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		if r := values[i] - predicted[i]; !math.IsNaN(r) {
+			residuals = append(residuals, r)
+		}
+	}
+	deviation := madScale * medianAbsoluteDeviation(residuals)
+	band := opts.Sensitivity * deviation
+
+	newSeries := func(kind string, size int) Series {
+		labels := data.Labels{}
+		for k, v := range s.GetLabels() {
+			labels[k] = v
+		}
+		labels[ForecastLabel] = kind
+		return NewSeries(refID, labels, size)
+	}
+	prediction := newSeries(ForecastPrediction, n+horizon)
+	upper := newSeries(ForecastUpper, n+horizon)
+	lower := newSeries(ForecastLower, n+horizon)
+	score := newSeries(ForecastScore, n)
+	for i := 0; i < n+horizon; i++ {
+		var t time.Time
+		if i < n {
+			t = times[i]
+		} else {
+			t = times[n-1].Add(time.Duration(i-n+1) * step)
+		}
+		p := predicted[i]
+		if math.IsNaN(p) {
+			prediction.SetPoint(i, t, nil)
+			upper.SetPoint(i, t, nil)
+			lower.SetPoint(i, t, nil)
+		} else {
+			u, l := p+band, p-band
+			prediction.SetPoint(i, t, &p)
+			upper.SetPoint(i, t, &u)
+			lower.SetPoint(i, t, &l)
+		}
+		if i < n {
+			score.SetPoint(i, t, anomalyScore(values[i]-p, deviation))
+		}
+	}
+
+	result := []Series{prediction, upper, lower, score}
+	if notice != nil {
+		for _, r := range result {
+			r.AddNotice(*notice)
+		}
+	}
+	return result, nil
+}
+
+// anomalyScore returns the residual in scaled median absolute deviations.
+// It returns nil when the residual is not a number.
+func anomalyScore(residual, deviation float64) *float64 {
+	if math.IsNaN(residual) {
+		return nil
+	}
+	var score float64
+	switch {
+	case deviation > 0:
+		score = math.Abs(residual) / deviation
+	case residual != 0:
+		score = math.Inf(1)
+	}
+	return &score
+}
+
+// holtWinters returns the one step ahead predictions of additive Holt-Winters for each value
+// followed by the predictions for the horizon. The first season is used to initialize the model
+// and is predicted with the initial level and seasonal pattern. A period of zero fits the
+// level and trend only. Missing values are replaced by their prediction.
+func holtWinters(values []float64, period int, alpha, beta, gamma float64, horizon int) []float64 {
+	n := len(values)
+	predicted := make([]float64, n+horizon)
+	if n == 0 {
+		for i := range predicted {
+			predicted[i] = math.NaN()
+		}
+		return predicted
+	}
+
+	var level, trend float64
+	seasonal := make([]float64, max(period, 1))
+	start := 1
+	if period > 0 {
+		first, second := nanMean(values[:period]), nanMean(values[period:2*period])
+		level = first
+		trend = (second - first) / float64(period)
+		if math.IsNaN(trend) {
+			trend = 0
+		}
+		for i := 0; i < period; i++ {
+			if !math.IsNaN(values[i]) {
+				seasonal[i] = values[i] - level
+			}
+			predicted[i] = level + seasonal[i]
+		}
+		start = period
+	} else {
+		level = nanMean(values[:1])
+		predicted[0] = level
+	}
+	if math.IsNaN(level) {
+		level = nanMean(values)
+	}
+
+	season := func(i int) int {
+		if period == 0 {
+			return 0
+		}
+		return i % period
+	}
+	for i := start; i < n; i++ {
+		s := seasonal[season(i)]
+		predicted[i] = level + trend + s
+		y := values[i]
+		if math.IsNaN(y) {
+			y = predicted[i]
+		}
+		newLevel := alpha*(y-s) + (1-alpha)*(level+trend)
+		trend = beta*(newLevel-level) + (1-beta)*trend
+		level = newLevel
+		if period > 0 {
+			seasonal[season(i)] = gamma*(y-level) + (1-gamma)*s
+		}
+	}
+	for h := 1; h <= horizon; h++ {
+		predicted[n-1+h] = level + float64(h)*trend + seasonal[season(n-1+h)]
+	}
+	return predicted
+}
+
+// seasonalDecomposition splits the values into a seasonal pattern and a linear trend, and returns
+// their sum for each value followed by the predictions for the horizon. The seasonal pattern is the
+// mean difference between the values and their centered moving average over a season at each position
+// of the season, and the trend is fitted with least squares to the values without the seasonal pattern.
+// A period of zero fits the trend only.
+func seasonalDecomposition(values []float64, period int, horizon int) []float64 {
+	seasonal := make([]float64, max(period, 1))
+	if period > 0 {
+		sums := make([]float64, period)
+		counts := make([]float64, period)
+		half := period / 2
+		for i := half; i < len(values)-half; i++ {
+			avg := centeredMovingAverage(values[i-half:i+half+1], period)
+			if d := values[i] - avg; !math.IsNaN(d) {
+				sums[i%period] += d
+				counts[i%period]++
+			}
+		}
+		var mean float64
+		for i := range seasonal {
+			if counts[i] > 0 {
+				seasonal[i] = sums[i] / counts[i]
+			}
+			mean += seasonal[i]
+		}
+		mean /= float64(period)
+		for i := range seasonal {
+			seasonal[i] -= mean
+		}
+	}
+	season := func(i int) float64 {
+		if period == 0 {
+			return 0
+		}
+		return seasonal[i%period]
+	}
+
+	var sumX, sumY, sumXY, sumXX, count float64
+	for i, y := range values {
+		if math.IsNaN(y) {
+			continue
+		}
+		x := float64(i)
+		y -= season(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+		count++
+	}
+	intercept, slope := math.NaN(), 0.0
+	if count > 0 {
+		intercept = sumY / count
+		if d := count*sumXX - sumX*sumX; d != 0 {
+			slope = (count*sumXY - sumX*sumY) / d
+			intercept = (sumY - slope*sumX) / count
+		}
+	}
+
+	predicted := make([]float64, len(values)+horizon)
+	for i := range predicted {
+		predicted[i] = intercept + slope*float64(i) + season(i)
+	}
+	return predicted
+}
+
+// centeredMovingAverage returns the mean of a window of period values, or period+1 values with
+// half weight at both ends when the period is even, so that each position of the season counts once.
+func centeredMovingAverage(window []float64, period int) float64 {
+	var sum float64
+	for i, v := range window {
+		if period%2 == 0 && (i == 0 || i == len(window)-1) {
+			v /= 2
+		}
+		sum += v
+	}
+	return sum / float64(period)
+}
+
+// medianAbsoluteDeviation returns the median of the absolute deviations from the median of the values.
+func medianAbsoluteDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	m := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+	}
+	return median(deviations)
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// medianStep returns the median time between consecutive points.
+func medianStep(times []time.Time) time.Duration {
+	if len(times) < 2 {
+		return 0
+	}
+	steps := make([]float64, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		steps = append(steps, float64(times[i].Sub(times[i-1])))
+	}
+	return time.Duration(median(steps))
+}
+
+// nanMean returns the mean of the values that are not NaN, or NaN if there are none.
+func nanMean(values []float64) float64 {
+	var sum, count float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / count
+}
@@ -0,0 +1,138 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesForecast(t *testing.T) {
+	pattern := []float64{0, 10, 0, -10}
+	seasonal := func(spikeAt int) Series {
+		s := NewSeries("A", data.Labels{"host": "a"}, 40)
+		for i := 0; i < 40; i++ {
+			v := float64(i) + pattern[i%len(pattern)]
+			if i == spikeAt {
+				v += 100
+			}
+			s.SetPoint(i, time.Unix(int64(i*60), 0), &v)
+		}
+		return s
+	}
+	opts := ForecastOptions{
+		Algorithm:   ForecastSeasonalDecomposition,
+		Season:      4 * time.Minute,
+		Horizon:     2 * time.Minute,
+		Sensitivity: 3,
+	}
+
+	t.Run("should return the prediction, bands and score series", func(t *testing.T) {
+		result, err := seasonal(-1).Forecast("B", opts)
+		require.NoError(t, err)
+		require.Len(t, result, 4)
+		for i, kind := range []string{ForecastPrediction, ForecastUpper, ForecastLower, ForecastScore} {
+			require.Equal(t, data.Labels{"host": "a", ForecastLabel: kind}, result[i].GetLabels())
+		}
+		require.Equal(t, 42, result[0].Len())
+		require.Equal(t, 40, result[3].Len())
+	})
+
+	t.Run("should fit a seasonal pattern with a trend and forecast it", func(t *testing.T) {
+		result, err := seasonal(-1).Forecast("B", opts)
+		require.NoError(t, err)
+		prediction := result[0]
+		for i := 0; i < 42; i++ {
+			tm, v := prediction.GetPoint(i)
+			require.Equal(t, time.Unix(int64(i*60), 0), tm)
+			require.InDelta(t, float64(i)+pattern[i%len(pattern)], *v, 1e-9)
+		}
+	})
+
+	t.Run("should score a spike above the sensitivity", func(t *testing.T) {
+		for _, algorithm := range []ForecastAlgorithm{ForecastSeasonalDecomposition, ForecastHoltWinters} {
+			o := opts
+			o.Algorithm = algorithm
+			o.Alpha, o.Beta, o.Gamma = 0.5, 0.1, 0.3
+			result, err := seasonal(30).Forecast("B", o)
+			require.NoError(t, err)
+			score := result[3]
+			require.Greater(t, *score.GetValue(30), o.Sensitivity, algorithm)
+
+			upper := result[1]
+			require.Greater(t, *seasonal(30).GetValue(30), *upper.GetValue(30), algorithm)
+		}
+	})
+
+	t.Run("should forecast a constant series without seasonality", func(t *testing.T) {
+		s := NewSeries("A", nil, 10)
+		for i := 0; i < 10; i++ {
+			s.SetPoint(i, time.Unix(int64(i*10), 0), float64Pointer(5))
+		}
+		result, err := s.Forecast("B", ForecastOptions{
+			Algorithm:   ForecastHoltWinters,
+			Horizon:     20 * time.Second,
+			Alpha:       0.5,
+			Beta:        0.1,
+			Gamma:       0.1,
+			Sensitivity: 3,
+		})
+		require.NoError(t, err)
+		prediction, score := result[0], result[3]
+		require.Equal(t, 12, prediction.Len())
+		for i := 0; i < prediction.Len(); i++ {
+			require.InDelta(t, 5, *prediction.GetValue(i), 1e-9)
+		}
+		for i := 0; i < score.Len(); i++ {
+			require.Equal(t, 0.0, *score.GetValue(i))
+		}
+		require.Equal(t, time.Unix(110, 0), prediction.GetTime(11))
+	})
+
+	t.Run("should add a notice when the series is shorter than two seasons", func(t *testing.T) {
+		o := opts
+		o.Season = time.Hour
+		result, err := seasonal(-1).Forecast("B", o)
+		require.NoError(t, err)
+		for _, r := range result {
+			require.NotNil(t, r.Frame.Meta)
+			require.Len(t, r.Frame.Meta.Notices, 1)
+		}
+	})
+
+	t.Run("should keep missing points as null scores", func(t *testing.T) {
+		s := seasonal(-1)
+		s.SetPoint(5, s.GetTime(5), nil)
+		result, err := s.Forecast("B", opts)
+		require.NoError(t, err)
+		require.Nil(t, result[3].GetValue(5))
+		require.False(t, math.IsNaN(*result[0].GetValue(5)))
+	})
+
+	t.Run("should validate the options", func(t *testing.T) {
+		for _, o := range []ForecastOptions{
+			{Algorithm: "prophet", Sensitivity: 3},
+			{Algorithm: ForecastHoltWinters, Alpha: 0, Beta: 0.1, Gamma: 0.1, Sensitivity: 3},
+			{Algorithm: ForecastSeasonalDecomposition, Sensitivity: 0},
+			{Algorithm: ForecastSeasonalDecomposition, Sensitivity: 3, Season: -time.Hour},
+			{Algorithm: ForecastSeasonalDecomposition, Sensitivity: 3, Horizon: 24 * time.Hour, Step: time.Second},
+		} {
+			_, err := seasonal(-1).Forecast("B", o)
+			require.Error(t, err, o)
+		}
+	})
+
+	t.Run("should not forecast more than the maximum number of points", func(t *testing.T) {
+		o := opts
+		o.Horizon = (MaxForecastPoints + 1) * time.Minute
+		_, err := seasonal(-1).Forecast("B", o)
+		require.ErrorContains(t, err, "horizon")
+
+		o.Horizon = MaxForecastPoints * time.Minute
+		result, err := seasonal(-1).Forecast("B", o)
+		require.NoError(t, err)
+		require.Equal(t, 40+MaxForecastPoints, result[0].Len())
+	})
+}
@@ -1,7 +1,12 @@
 package mathexp
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
@@ -68,6 +73,48 @@ var builtins = map[string]parse.Func{
 		VariantReturn: true,
 		F:             floor,
 	},
+	"clamp": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clamp,
+	},
+	"timeShift": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		Check:  checkDurationArg(1),
+		F:      timeShift,
+	},
+	"rate": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      rate,
+	},
+	"deriv": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      deriv,
+	},
+	"movingAvg": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		Check:  checkDurationArg(1),
+		F:      movingAvg,
+	},
+	"cumsum": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      cumsum,
+	},
+	"topk": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             topk,
+	},
+	"bottomk": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             bottomk,
+	},
 }
 
 // abs returns the absolute value for each result in NumberSet, SeriesSet, or Scalar
@@ -307,3 +354,286 @@ func floor(e *State, varSet Results) (Results, error) {
 	}
 	return newRes, nil
 }
+
+// clamp limits the value for each result in NumberSet, SeriesSet, or Scalar to be between minV and maxV.
+func clamp(e *State, varSet Results, minRes Results, maxRes Results) (Results, error) {
+	newRes := Results{}
+	minV, err := scalarArg(minRes, "min")
+	if err != nil {
+		return newRes, err
+	}
+	maxV, err := scalarArg(maxRes, "max")
+	if err != nil {
+		return newRes, err
+	}
+	if minV > maxV {
+		return newRes, fmt.Errorf("clamp: min %v is greater than max %v", minV, maxV)
+	}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, func(f float64) float64 {
+			if math.IsNaN(f) {
+				return f
+			}
+			return math.Max(minV, math.Min(maxV, f))
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// timeShift moves each point of each series in SeriesSet forward in time by the duration, so that
+// $A - timeShift($A, "1w") compares every point with the one a week before it.
+// A negative duration moves the points back in time.
+func timeShift(e *State, varSet Results, rawDuration string) (Results, error) {
+	d, err := gtime.ParseDuration(rawDuration)
+	if err != nil {
+		return Results{}, fmt.Errorf("timeShift: %w", err)
+	}
+	return perSeries(e, varSet, "timeShift", func(s Series) Series {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			newSeries.SetPoint(i, t.Add(d), f)
+		}
+		return newSeries
+	})
+}
+
+// rate returns the per-second rate of increase between each point and the one before it for
+// each series in SeriesSet. A value lower than the one before it is taken as a counter reset.
+// The first point of each series is dropped.
+func rate(e *State, varSet Results) (Results, error) {
+	return perSeries(e, varSet, "rate", func(s Series) Series {
+		return perPointPair(e, s, func(prev, cur float64, seconds float64) float64 {
+			if cur < prev {
+				return cur / seconds
+			}
+			return (cur - prev) / seconds
+		})
+	})
+}
+
+// deriv returns the per-second change between each point and the one before it for each
+// series in SeriesSet. Unlike rate, the result is negative when the value goes down.
+// The first point of each series is dropped.
+func deriv(e *State, varSet Results) (Results, error) {
+	return perSeries(e, varSet, "deriv", func(s Series) Series {
+		return perPointPair(e, s, func(prev, cur float64, seconds float64) float64 {
+			return (cur - prev) / seconds
+		})
+	})
+}
+
+// movingAvg returns, at each point of each series in SeriesSet, the mean of the points within the
+// window ending at that point. Null and NaN points are left out of the mean.
+func movingAvg(e *State, varSet Results, rawWindow string) (Results, error) {
+	window, err := gtime.ParseDuration(rawWindow)
+	if err != nil {
+		return Results{}, fmt.Errorf("movingAvg: %w", err)
+	}
+	if window <= 0 {
+		return Results{}, fmt.Errorf("movingAvg: window must be greater than zero, got %v", rawWindow)
+	}
+	return perSeries(e, varSet, "movingAvg", func(s Series) Series {
+		s = sortedSeries(s)
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		var count int
+		start := 0
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f != nil && !math.IsNaN(*f) {
+				sum += *f
+				count++
+			}
+			for ; !s.GetTime(start).After(t.Add(-window)); start++ {
+				if f := s.GetValue(start); f != nil && !math.IsNaN(*f) {
+					sum -= *f
+					count--
+				}
+			}
+			var avg *float64
+			if count > 0 {
+				v := sum / float64(count)
+				avg = &v
+			}
+			newSeries.SetPoint(i, t, avg)
+		}
+		return newSeries
+	})
+}
+
+// cumsum returns the running total of each series in SeriesSet.
+// Null and NaN points are skipped and left as they are.
+func cumsum(e *State, varSet Results) (Results, error) {
+	return perSeries(e, varSet, "cumsum", func(s Series) Series {
+		s = sortedSeries(s)
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f == nil || math.IsNaN(*f) {
+				newSeries.SetPoint(i, t, f)
+				continue
+			}
+			sum += *f
+			v := sum
+			newSeries.SetPoint(i, t, &v)
+		}
+		return newSeries
+	})
+}
+
+// topk returns the k items of a NumberSet with the highest values, or the k series of a SeriesSet
+// with the highest mean. Items with a null or NaN value are never returned.
+func topk(e *State, varSet Results, kRes Results) (Results, error) {
+	return rankK(e, varSet, kRes, "topk", true)
+}
+
+// bottomk returns the k items of a NumberSet with the lowest values, or the k series of a SeriesSet
+// with the lowest mean. Items with a null or NaN value are never returned.
+func bottomk(e *State, varSet Results, kRes Results) (Results, error) {
+	return rankK(e, varSet, kRes, "bottomk", false)
+}
+
+func rankK(e *State, varSet Results, kRes Results, name string, desc bool) (Results, error) {
+	newRes := Results{}
+	kF, err := scalarArg(kRes, "k")
+	if err != nil {
+		return newRes, fmt.Errorf("%s: %w", name, err)
+	}
+	if kF < 1 || kF != math.Trunc(kF) {
+		return newRes, fmt.Errorf("%s: k must be a positive integer, got %v", name, kF)
+	}
+	k := int(kF)
+
+	type ranked struct {
+		val  Value
+		rank float64
+	}
+	items := make([]ranked, 0, len(varSet.Values))
+	for _, res := range varSet.Values {
+		var f *float64
+		switch v := res.(type) {
+		case Number:
+			f = v.GetFloat64Value()
+		case Scalar:
+			f = v.GetFloat64Value()
+		case Series:
+			f = Avg((*Float64Field)(v.Frame.Fields[seriesTypeValIdx]))
+		case NoData:
+			newRes.Values = append(newRes.Values, v.New())
+			continue
+		default:
+			return newRes, fmt.Errorf("%s: can not rank type %v", name, res.Type())
+		}
+		if f == nil || math.IsNaN(*f) {
+			continue
+		}
+		items = append(items, ranked{val: res, rank: *f})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return items[i].rank > items[j].rank
+		}
+		return items[i].rank < items[j].rank
+	})
+	if len(items) > k {
+		items = items[:k]
+	}
+	for _, item := range items {
+		newRes.Values = append(newRes.Values, item.val)
+	}
+	return newRes, nil
+}
+
+// perSeries applies seriesF to each Series in varSet. NoData is passed through as is,
+// any other type is an error.
+func perSeries(e *State, varSet Results, name string, seriesF func(s Series) Series) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		switch v := res.(type) {
+		case Series:
+			newRes.Values = append(newRes.Values, seriesF(v))
+		case NoData:
+			newRes.Values = append(newRes.Values, v.New())
+		default:
+			return newRes, fmt.Errorf("%s: expected a time series, got %v", name, res.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// perPointPair returns a series with pairF applied to each point and the previous one that has
+// a number value, together with the seconds between them. Points with a null or NaN value result
+// in a null point, and the first point is dropped.
+func perPointPair(e *State, s Series, pairF func(prev, cur float64, seconds float64) float64) Series {
+	s = sortedSeries(s)
+	newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+	var prevT time.Time
+	var prev *float64
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		if f == nil || math.IsNaN(*f) {
+			if prev != nil {
+				newSeries.AppendPoint(t, nil)
+			}
+			continue
+		}
+		if prev != nil {
+			var v *float64
+			if seconds := t.Sub(prevT).Seconds(); seconds > 0 {
+				r := pairF(*prev, *f, seconds)
+				v = &r
+			}
+			newSeries.AppendPoint(t, v)
+		}
+		prevT, prev = t, f
+	}
+	return newSeries
+}
+
+// sortedSeries returns a copy of the series sorted from oldest to newest.
+func sortedSeries(s Series) Series {
+	newSeries := NewSeries(s.GetName(), s.GetLabels(), s.Len())
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		newSeries.SetPoint(i, t, f)
+	}
+	newSeries.SortByTime(false)
+	return newSeries
+}
+
+// scalarArg returns the value of a Scalar argument.
+func scalarArg(res Results, name string) (float64, error) {
+	if len(res.Values) != 1 {
+		return 0, fmt.Errorf("expected a single value for %s, got %v", name, len(res.Values))
+	}
+	sc, ok := res.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("expected a scalar for %s, got %v", name, res.Values[0].Type())
+	}
+	f := sc.GetFloat64Value()
+	if f == nil || math.IsNaN(*f) {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return *f, nil
+}
+
+// checkDurationArg returns a parse time check that the string argument at idx is a valid duration.
+func checkDurationArg(idx int) func(*parse.Tree, *parse.FuncNode) error {
+	return func(_ *parse.Tree, f *parse.FuncNode) error {
+		arg, ok := f.Args[idx].(*parse.StringNode)
+		if !ok {
+			return fmt.Errorf("parse: expected a duration string for argument %v of %s", idx, f.Name)
+		}
+		if _, err := gtime.ParseDuration(arg.Text); err != nil {
+			return fmt.Errorf("parse: invalid duration %q for %s: %w", arg.Text, f.Name, err)
+		}
+		return nil
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/stretchr/testify/require"
 )
@@ -140,3 +142,195 @@ func TestIsNumberFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestSeriesFuncs(t *testing.T) {
+	counter := Vars{
+		"A": resultValuesNoErr(
+			makeSeries("", data.Labels{"host": "a"},
+				tp{time.Unix(20, 0), float64Pointer(3)},
+				tp{time.Unix(0, 0), float64Pointer(10)},
+				tp{time.Unix(10, 0), float64Pointer(15)},
+				tp{time.Unix(30, 0), float64Pointer(8)},
+			),
+		),
+	}
+
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name: "timeShift moves points forward in time",
+			expr: `timeShift($A, "1d")`,
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", nil, tp{time.Unix(0, 0), float64Pointer(1)}),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil, tp{time.Unix(0, 0).Add(24 * time.Hour), float64Pointer(1)}),
+			),
+		},
+		{
+			name:     "timeShift with an invalid duration",
+			expr:     `timeShift($A, "yesterday")`,
+			newErrIs: require.Error,
+		},
+		{
+			name:      "rate handles counter resets",
+			expr:      `rate($A)`,
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(10, 0), float64Pointer(0.5)},
+					tp{time.Unix(20, 0), float64Pointer(0.3)},
+					tp{time.Unix(30, 0), float64Pointer(0.5)},
+				),
+			),
+		},
+		{
+			name:      "deriv goes negative",
+			expr:      `deriv($A)`,
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(10, 0), float64Pointer(0.5)},
+					tp{time.Unix(20, 0), float64Pointer(-1.2)},
+					tp{time.Unix(30, 0), float64Pointer(0.5)},
+				),
+			),
+		},
+		{
+			name:      "movingAvg over a time window",
+			expr:      `movingAvg($A, "20s")`,
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), float64Pointer(10)},
+					tp{time.Unix(10, 0), float64Pointer(12.5)},
+					tp{time.Unix(20, 0), float64Pointer(9)},
+					tp{time.Unix(30, 0), float64Pointer(5.5)},
+				),
+			),
+		},
+		{
+			name:      "cumsum skips null points",
+			expr:      `cumsum($A)`,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(1)},
+						tp{time.Unix(10, 0), nil},
+						tp{time.Unix(20, 0), float64Pointer(2)},
+					),
+				),
+			},
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), float64Pointer(1)},
+					tp{time.Unix(10, 0), nil},
+					tp{time.Unix(20, 0), float64Pointer(3)},
+				),
+			),
+		},
+		{
+			name:      "rate on a number is an error",
+			expr:      `rate($A)`,
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(1)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+			results:   Results{},
+		},
+		{
+			name:      "clamp on series",
+			expr:      `clamp($A, 5, 12)`,
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(20, 0), float64Pointer(5)},
+					tp{time.Unix(0, 0), float64Pointer(10)},
+					tp{time.Unix(10, 0), float64Pointer(12)},
+					tp{time.Unix(30, 0), float64Pointer(8)},
+				),
+			),
+		},
+		{
+			name:      "clamp with min greater than max",
+			expr:      `clamp($A, 12, 5)`,
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+			results:   Results{},
+		},
+		{
+			name: "topk on numbers",
+			expr: `topk($A, 2)`,
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeNumber("", data.Labels{"host": "a"}, float64Pointer(1)),
+					makeNumber("", data.Labels{"host": "b"}, float64Pointer(3)),
+					makeNumber("", data.Labels{"host": "c"}, nil),
+					makeNumber("", data.Labels{"host": "d"}, float64Pointer(2)),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"host": "b"}, float64Pointer(3)),
+				makeNumber("", data.Labels{"host": "d"}, float64Pointer(2)),
+			),
+		},
+		{
+			name: "bottomk on series ranks by mean",
+			expr: `bottomk($A, 1)`,
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", data.Labels{"host": "a"}, tp{time.Unix(0, 0), float64Pointer(1)}, tp{time.Unix(10, 0), float64Pointer(9)}),
+					makeSeries("", data.Labels{"host": "b"}, tp{time.Unix(0, 0), float64Pointer(4)}, tp{time.Unix(10, 0), float64Pointer(4)}),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "b"}, tp{time.Unix(0, 0), float64Pointer(4)}, tp{time.Unix(10, 0), float64Pointer(4)}),
+			),
+		},
+		{
+			name:      "topk with a fractional k",
+			expr:      `topk($A, 1.5)`,
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(1)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+			results:   Results{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if err == nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ package parse
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 // A Node is an element in the parse tree. The interface is trivial.
@@ -300,6 +302,8 @@ type BinaryNode struct {
 	Args     [2]Node
 	Operator item
 	OpStr    string
+	// Matching is how the items of the two arguments are matched, nil for the default label subset matching.
+	Matching *VectorMatching
 }
 
 func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
@@ -308,11 +312,17 @@ func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
 
 // String returns the string representation of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) String() string {
+	if b.Matching != nil {
+		return fmt.Sprintf("%s %s %s %s", b.Args[0], b.Operator.val, b.Matching, b.Args[1])
+	}
 	return fmt.Sprintf("%s %s %s", b.Args[0], b.Operator.val, b.Args[1])
 }
 
 // StringAST returns the string representation of abstract syntax tree of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) StringAST() string {
+	if b.Matching != nil {
+		return fmt.Sprintf("%s %s(%s, %s)", b.Operator.val, b.Matching, b.Args[0], b.Args[1])
+	}
 	return fmt.Sprintf("%s(%s, %s)", b.Operator.val, b.Args[0], b.Args[1])
 }
 
@@ -321,6 +331,67 @@ func (b *BinaryNode) Check(t *Tree) error {
 	return nil
 }
 
+// VectorMatchCardinality is how many items on each side of a binary operation can match each other.
+type VectorMatchCardinality int
+
+const (
+	// CardOneToOne matches each item with at most one item on the other side.
+	CardOneToOne VectorMatchCardinality = iota
+	// CardManyToOne matches many items on the left side with one item on the right side (group_left).
+	CardManyToOne
+	// CardOneToMany matches one item on the left side with many items on the right side (group_right).
+	CardOneToMany
+)
+
+// VectorMatching describes how the labels of the items on each side of a binary operation are
+// matched, e.g. on(instance) or ignoring(pod) group_left(team).
+type VectorMatching struct {
+	// On is true when only the Labels are compared, and false when all labels
+	// but the Labels are compared.
+	On     bool
+	Labels []string
+	Card   VectorMatchCardinality
+	// Include are the labels copied from the "one" side to the result of a
+	// many-to-one or one-to-many match.
+	Include []string
+}
+
+// String returns the string representation of the VectorMatching as it is written in an expression.
+func (m *VectorMatching) String() string {
+	b := strings.Builder{}
+	if m.On {
+		b.WriteString("on")
+	} else {
+		b.WriteString("ignoring")
+	}
+	b.WriteString(labelsString(m.Labels))
+	switch m.Card {
+	case CardManyToOne:
+		b.WriteString(" group_left")
+	case CardOneToMany:
+		b.WriteString(" group_right")
+	}
+	if m.Card != CardOneToOne && len(m.Include) > 0 {
+		b.WriteString(labelsString(m.Include))
+	}
+	return b.String()
+}
+
+// labelsString returns a label list as it is written in an expression,
+// quoting the labels that are not made of letters, digits and underscores.
+func labelsString(labels []string) string {
+	quoted := make([]string, len(labels))
+	for i, l := range labels {
+		quoted[i] = l
+		if l == "" || strings.IndexFunc(l, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		}) >= 0 || !unicode.IsLetter([]rune(l)[0]) {
+			quoted[i] = strconv.Quote(l)
+		}
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
 // Return returns the result type of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) Return() ReturnType {
 	t0 := b.Args[0].Return()
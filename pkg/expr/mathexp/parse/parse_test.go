@@ -0,0 +1,28 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFuncArguments(t *testing.T) {
+	funcs := map[string]Func{
+		"f": {Args: []ReturnType{TypeScalar, TypeScalar}, Return: TypeScalar},
+		"g": {Return: TypeScalar},
+	}
+
+	for _, expr := range []string{"f(1, 2)", "f(1,2) + g()", "f(g(), f(1, 2) * 3)"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr, funcs)
+			require.NoError(t, err)
+		})
+	}
+
+	for _, expr := range []string{"f(1,,2)", "f(1,)", "f(1 2)", "f(,1)", "f(1, 2", "g(,)"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr, funcs)
+			require.ErrorContains(t, err, "in func")
+		})
+	}
+}
@@ -22,13 +22,42 @@ const (
 	ReducerCount  ReducerID = "count"
 	ReducerLast   ReducerID = "last"
 	ReducerMedian ReducerID = "median"
+	// The first value
+	ReducerFirst ReducerID = "first"
+	// The n-th percentile, see ReduceOptions.Percentile
+	ReducerPercentile ReducerID = "percentile"
+	// Population standard deviation
+	ReducerStdDev ReducerID = "stddev"
+	// Population variance
+	ReducerVariance ReducerID = "variance"
+	// Difference between the max and min values
+	ReducerRange ReducerID = "range"
+	// Difference between the last and first values
+	ReducerDelta ReducerID = "delta"
+	// Increase of a counter over the series, accounting for resets
+	ReducerIncrease ReducerID = "increase"
+	// Per-second rate of increase of a counter, accounting for resets
+	ReducerRate ReducerID = "rate"
 )
 
 // GetSupportedReduceFuncs returns collection of supported function names
 func GetSupportedReduceFuncs() []ReducerID {
-	return []ReducerID{ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian}
+	return []ReducerID{
+		ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian,
+		ReducerFirst, ReducerPercentile, ReducerStdDev, ReducerVariance, ReducerRange, ReducerDelta,
+		ReducerIncrease, ReducerRate,
+	}
+}
+
+// ReduceOptions are the parameters of reducers that take one.
+type ReduceOptions struct {
+	// Percentile is the percentile, from 0 to 100, returned by ReducerPercentile.
+	Percentile float64
 }
 
+// SeriesReducerFunc reduces a series using its timestamps as well as its values.
+type SeriesReducerFunc = func(s Series) *float64
+
 func Sum(fv *Float64Field) *float64 {
 	var sum float64
 	for i := 0; i < fv.Len(); i++ {
@@ -126,6 +155,133 @@ func Median(fv *Float64Field) *float64 {
 	}
 }
 
+func First(fv *Float64Field) *float64 {
+	var f float64
+	if fv.Len() == 0 {
+		f = math.NaN()
+		return &f
+	}
+	return fv.GetValue(0)
+}
+
+// Percentile returns a ReducerFunc that computes the p-th percentile (0 to 100)
+// by linear interpolation between the closest ranks.
+func Percentile(p float64) ReducerFunc {
+	return func(fv *Float64Field) *float64 {
+		values, ok := sortedValues(fv)
+		if !ok || len(values) == 0 {
+			nan := math.NaN()
+			return &nan
+		}
+		rank := p / 100 * float64(len(values)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		v := values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+		return &v
+	}
+}
+
+func Variance(fv *Float64Field) *float64 {
+	if fv.Len() == 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	mean := Avg(fv)
+	if math.IsNaN(*mean) {
+		return mean
+	}
+	var sum float64
+	for i := 0; i < fv.Len(); i++ {
+		d := *fv.GetValue(i) - *mean
+		sum += d * d
+	}
+	v := sum / float64(fv.Len())
+	return &v
+}
+
+func StdDev(fv *Float64Field) *float64 {
+	v := Variance(fv)
+	f := math.Sqrt(*v)
+	return &f
+}
+
+func Range(fv *Float64Field) *float64 {
+	minV, maxV := Min(fv), Max(fv)
+	f := *maxV - *minV
+	return &f
+}
+
+func Delta(fv *Float64Field) *float64 {
+	first, last := First(fv), Last(fv)
+	if first == nil || last == nil {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *last - *first
+	return &f
+}
+
+// Increase returns how much a counter went up over the series. A value lower
+// than the one before it is taken as a counter reset, after which the counter
+// started again from zero.
+func Increase(s Series) *float64 {
+	var increase float64
+	var prev *float64
+	if s.Len() < 2 {
+		nan := math.NaN()
+		return &nan
+	}
+	for i := 0; i < s.Len(); i++ {
+		v := s.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			nan := math.NaN()
+			return &nan
+		}
+		if prev != nil {
+			if *v < *prev {
+				increase += *v
+			} else {
+				increase += *v - *prev
+			}
+		}
+		prev = v
+	}
+	return &increase
+}
+
+// Rate returns the per-second increase of a counter between the first and the
+// last point of the series, see Increase.
+func Rate(s Series) *float64 {
+	increase := Increase(s)
+	if math.IsNaN(*increase) {
+		return increase
+	}
+	seconds := s.GetTime(s.Len() - 1).Sub(s.GetTime(0)).Seconds()
+	if seconds <= 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *increase / seconds
+	return &f
+}
+
+// sortedValues returns the values in ascending order. It returns false if any
+// of the values is nil or NaN.
+func sortedValues(fv *Float64Field) ([]float64, bool) {
+	values := make([]float64, 0, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		values = append(values, *v)
+	}
+	sort.Float64s(values)
+	return values, true
+}
+
+// GetReduceFunc returns the function for reducers that only need the values of
+// a series. Use GetSeriesReduceFunc for all reducers.
 func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 	switch rFunc {
 	case ReducerSum:
@@ -142,15 +298,59 @@ func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 		return Last, nil
 	case ReducerMedian:
 		return Median, nil
+	case ReducerFirst:
+		return First, nil
+	case ReducerStdDev:
+		return StdDev, nil
+	case ReducerVariance:
+		return Variance, nil
+	case ReducerRange:
+		return Range, nil
+	case ReducerDelta:
+		return Delta, nil
+	case ReducerPercentile, ReducerIncrease, ReducerRate:
+		return nil, fmt.Errorf("reduction %v needs options or timestamps and is only supported on series", rFunc)
 	default:
 		return nil, fmt.Errorf("reduction %v not implemented", rFunc)
 	}
 }
 
+// GetSeriesReduceFunc returns the function for any supported reducer.
+func GetSeriesReduceFunc(rFunc ReducerID, opts ReduceOptions) (SeriesReducerFunc, error) {
+	switch rFunc {
+	case ReducerPercentile:
+		if opts.Percentile < 0 || opts.Percentile > 100 || math.IsNaN(opts.Percentile) {
+			return nil, fmt.Errorf("percentile must be between 0 and 100, got %v", opts.Percentile)
+		}
+		return valuesReducer(Percentile(opts.Percentile)), nil
+	case ReducerIncrease:
+		return Increase, nil
+	case ReducerRate:
+		return Rate, nil
+	}
+	f, err := GetReduceFunc(rFunc)
+	if err != nil {
+		return nil, err
+	}
+	return valuesReducer(f), nil
+}
+
+func valuesReducer(f ReducerFunc) SeriesReducerFunc {
+	return func(s Series) *float64 {
+		floatField := Float64Field(*s.Frame.Fields[seriesTypeValIdx])
+		return f(&floatField)
+	}
+}
+
 // Reduce turns the Series into a Number based on the given reduction function
 // if ReduceMapper is defined it applies it to the provided series and performs reduction of the resulting series.
 // Otherwise, the reduction operation is done against the original series.
 func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper) (Number, error) {
+	return s.ReduceWithOptions(refID, rFunc, ReduceOptions{}, mapper)
+}
+
+// ReduceWithOptions is like Reduce for reducers that take parameters, such as ReducerPercentile.
+func (s Series) ReduceWithOptions(refID string, rFunc ReducerID, opts ReduceOptions, mapper ReduceMapper) (Number, error) {
 	var l data.Labels
 	if s.GetLabels() != nil {
 		l = s.GetLabels().Copy()
@@ -161,13 +361,11 @@ func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper) (Numb
 	if mapper != nil {
 		series = mapSeries(s, mapper)
 	}
-	fVec := series.Frame.Fields[seriesTypeValIdx]
-	floatField := Float64Field(*fVec)
-	reduceFunc, err := GetReduceFunc(rFunc)
+	reduceFunc, err := GetSeriesReduceFunc(rFunc, opts)
 	if err != nil {
 		return number, fmt.Errorf("invalid expression '%s': %w", refID, err)
 	}
-	f = reduceFunc(&floatField)
+	f = reduceFunc(series)
 	if f != nil && mapper != nil {
 		f = mapper.MapOutput(f)
 	}
@@ -543,3 +543,84 @@ func sortedFloat64(f []float64) []float64 {
 	sort.Float64s(f)
 	return f
 }
+
+func TestSeriesReduceWithOptions(t *testing.T) {
+	counter := makeSeries("requests", nil,
+		tp{time.Unix(0, 0), float64Pointer(10)},
+		tp{time.Unix(10, 0), float64Pointer(15)},
+		tp{time.Unix(20, 0), float64Pointer(3)}, // counter reset
+		tp{time.Unix(30, 0), float64Pointer(8)},
+	)
+	values := makeSeries("temp", nil,
+		tp{time.Unix(0, 0), float64Pointer(2)},
+		tp{time.Unix(10, 0), float64Pointer(4)},
+		tp{time.Unix(20, 0), float64Pointer(4)},
+		tp{time.Unix(30, 0), float64Pointer(4)},
+		tp{time.Unix(40, 0), float64Pointer(5)},
+		tp{time.Unix(50, 0), float64Pointer(5)},
+		tp{time.Unix(60, 0), float64Pointer(7)},
+		tp{time.Unix(70, 0), float64Pointer(9)},
+	)
+
+	var tests = []struct {
+		name     string
+		red      ReducerID
+		opts     ReduceOptions
+		series   Series
+		mapper   ReduceMapper
+		expected float64
+	}{
+		{name: "first", red: ReducerFirst, series: values, expected: 2},
+		{name: "variance", red: ReducerVariance, series: values, expected: 4},
+		{name: "stddev", red: ReducerStdDev, series: values, expected: 2},
+		{name: "range", red: ReducerRange, series: values, expected: 7},
+		{name: "delta", red: ReducerDelta, series: values, expected: 7},
+		{name: "p0 is the min", red: ReducerPercentile, opts: ReduceOptions{Percentile: 0}, series: values, expected: 2},
+		{name: "p50 is the median", red: ReducerPercentile, opts: ReduceOptions{Percentile: 50}, series: values, expected: 4.5},
+		{name: "p95 interpolates", red: ReducerPercentile, opts: ReduceOptions{Percentile: 95}, series: values, expected: 8.3},
+		{name: "p100 is the max", red: ReducerPercentile, opts: ReduceOptions{Percentile: 100}, series: values, expected: 9},
+		{name: "increase accounts for resets", red: ReducerIncrease, series: counter, expected: 13},
+		{name: "rate is per second", red: ReducerRate, series: counter, expected: 13.0 / 30},
+		{name: "delta ignores resets", red: ReducerDelta, series: counter, expected: -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.series.ReduceWithOptions("", tt.red, tt.opts, tt.mapper)
+			require.NoError(t, err)
+			require.InDelta(t, tt.expected, *n.GetFloat64Value(), 1e-9)
+		})
+	}
+
+	t.Run("should return NaN for a non-number value unless it is dropped", func(t *testing.T) {
+		s := makeSeries("temp", nil,
+			tp{time.Unix(0, 0), float64Pointer(1)},
+			tp{time.Unix(10, 0), nil},
+			tp{time.Unix(20, 0), float64Pointer(3)},
+		)
+		for _, red := range []ReducerID{ReducerStdDev, ReducerVariance, ReducerRange, ReducerPercentile, ReducerIncrease, ReducerRate} {
+			n, err := s.ReduceWithOptions("", red, ReduceOptions{Percentile: 50}, nil)
+			require.NoError(t, err)
+			require.True(t, math.IsNaN(*n.GetFloat64Value()), red)
+
+			n, err = s.ReduceWithOptions("", red, ReduceOptions{Percentile: 50}, DropNonNumber{})
+			require.NoError(t, err)
+			require.NotNil(t, n.GetFloat64Value(), red)
+			require.False(t, math.IsNaN(*n.GetFloat64Value()), red)
+		}
+	})
+
+	t.Run("should return NaN for rate and increase with a single point", func(t *testing.T) {
+		s := makeSeries("temp", nil, tp{time.Unix(0, 0), float64Pointer(1)})
+		for _, red := range []ReducerID{ReducerIncrease, ReducerRate} {
+			n, err := s.ReduceWithOptions("", red, ReduceOptions{}, nil)
+			require.NoError(t, err)
+			require.True(t, math.IsNaN(*n.GetFloat64Value()), red)
+		}
+	})
+
+	t.Run("should error on a percentile out of range", func(t *testing.T) {
+		_, err := values.ReduceWithOptions("", ReducerPercentile, ReduceOptions{Percentile: 101}, nil)
+		require.Error(t, err)
+	})
+}
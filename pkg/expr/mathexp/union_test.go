@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -300,3 +301,116 @@ func Test_union(t *testing.T) {
 		})
 	}
 }
+
+func TestVectorMatching(t *testing.T) {
+	requests := resultValuesNoErr(
+		makeNumber("", data.Labels{"instance": "a", "pod": "1"}, float64Pointer(10)),
+		makeNumber("", data.Labels{"instance": "a", "pod": "2"}, float64Pointer(20)),
+		makeNumber("", data.Labels{"instance": "b", "pod": "3"}, float64Pointer(30)),
+	)
+	capacity := resultValuesNoErr(
+		makeNumber("", data.Labels{"instance": "a", "team": "x"}, float64Pointer(100)),
+		makeNumber("", data.Labels{"instance": "b", "team": "y"}, float64Pointer(200)),
+	)
+	errCounts := resultValuesNoErr(
+		makeNumber("", data.Labels{"instance": "a", "pod": "1", "level": "error"}, float64Pointer(1)),
+		makeNumber("", data.Labels{"instance": "c", "pod": "4", "level": "error"}, float64Pointer(2)),
+	)
+
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  assert.ErrorAssertionFunc
+		execErrIs assert.ErrorAssertionFunc
+		results   Results
+		dropCount int64
+	}{
+		{
+			name:      "ignoring matches on the other labels",
+			expr:      "$A / ignoring(level) $B",
+			vars:      Vars{"A": errCounts, "B": requests},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "a", "pod": "1"}, float64Pointer(0.1)),
+			),
+			dropCount: 3,
+		},
+		{
+			name:      "on keeps only the matching labels",
+			expr:      "$A / on(instance, pod) $B",
+			vars:      Vars{"A": errCounts, "B": requests},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "a", "pod": "1"}, float64Pointer(0.1)),
+			),
+			dropCount: 3,
+		},
+		{
+			name:      "group_left matches many to one and copies included labels",
+			expr:      "$A / on(instance) group_left(team) $B",
+			vars:      Vars{"A": requests, "B": capacity},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "a", "pod": "1", "team": "x"}, float64Pointer(0.1)),
+				makeNumber("", data.Labels{"instance": "a", "pod": "2", "team": "x"}, float64Pointer(0.2)),
+				makeNumber("", data.Labels{"instance": "b", "pod": "3", "team": "y"}, float64Pointer(0.15)),
+			),
+		},
+		{
+			name:      "group_right matches one to many",
+			expr:      "$B / on(instance) group_right $A",
+			vars:      Vars{"A": requests, "B": capacity},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "a", "pod": "1"}, float64Pointer(10)),
+				makeNumber("", data.Labels{"instance": "a", "pod": "2"}, float64Pointer(5)),
+				makeNumber("", data.Labels{"instance": "b", "pod": "3"}, float64Pointer(200.0/30)),
+			),
+		},
+		{
+			name:      "many-to-many without group is an error",
+			expr:      "$A / on(instance) $B",
+			vars:      Vars{"A": requests, "B": capacity},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.Error,
+		},
+		{
+			name:     "matching with a scalar is an error",
+			expr:     "$A / on(instance) 2",
+			newErrIs: assert.Error,
+		},
+		{
+			name:     "unterminated label list is an error",
+			expr:     "$A / on(instance $B",
+			newErrIs: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e == nil {
+				return
+			}
+			s := &State{Expr: e, Vars: tt.vars, tracer: tracing.InitializeTracerForTest()}
+			res, err := e.executeState(s)
+			tt.execErrIs(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.dropCount, s.DropCount)
+			if !assert.Len(t, res.Values, len(tt.results.Values)) {
+				return
+			}
+			for i := range res.Values {
+				assert.Equal(t, tt.results.Values[i].GetLabels(), res.Values[i].GetLabels())
+				assert.InDelta(t, *tt.results.Values[i].(Number).GetFloat64Value(), *res.Values[i].(Number).GetFloat64Value(), 1e-9)
+			}
+		})
+	}
+}
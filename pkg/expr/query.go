@@ -27,8 +27,11 @@ const (
 	// Threshold
 	QueryTypeThreshold QueryType = "threshold"
 
-	// SQL query via DuckDB
+	// SQL query run by an embedded SQLite engine
 	QueryTypeSQL QueryType = "sql"
+
+	// Forecast and score anomalies without the Machine Learning API
+	QueryTypeForecast QueryType = "forecast"
 )
 
 type MathQuery struct {
@@ -43,6 +46,9 @@ type ReduceQuery struct {
 	// The reducer
 	Reducer mathexp.ReducerID `json:"reducer"`
 
+	// The percentile to compute, only valid when the reducer is percentile
+	Percentile *float64 `json:"percentile,omitempty" jsonschema:"minimum=0,maximum=100,example=95,example=99"`
+
 	// Reducer Options
 	Settings *ReduceSettings `json:"settings,omitempty"`
 }
@@ -79,6 +85,33 @@ type SQLExpression struct {
 	Expression string `json:"expression" jsonschema:"minLength=1,example=SELECT * FROM A LIMIT 1"`
 }
 
+// QueryType = forecast
+type ForecastQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The forecast algorithm, defaults to holt_winters
+	Algorithm mathexp.ForecastAlgorithm `json:"algorithm,omitempty"`
+
+	// The length of the seasonal pattern, no seasonality when empty
+	Season string `json:"season,omitempty" jsonschema:"example=1d,example=1w"`
+
+	// How far past the last point to forecast, at most 10000 points of the series interval
+	Horizon string `json:"horizon,omitempty" jsonschema:"example=1h"`
+
+	// Level smoothing factor for holt_winters, defaults to 0.5
+	Alpha *float64 `json:"alpha,omitempty" jsonschema:"minimum=0,maximum=1"`
+
+	// Trend smoothing factor for holt_winters, defaults to 0.1
+	Beta *float64 `json:"beta,omitempty" jsonschema:"minimum=0,maximum=1"`
+
+	// Seasonal smoothing factor for holt_winters, defaults to 0.3
+	Gamma *float64 `json:"gamma,omitempty" jsonschema:"minimum=0,maximum=1"`
+
+	// Number of scaled median absolute deviations between the prediction and the bands, defaults to 3
+	Sensitivity *float64 `json:"sensitivity,omitempty" jsonschema:"minimum=0,example=3"`
+}
+
 //-------------------------------
 // Non-query commands
 //-------------------------------
@@ -3,6 +3,7 @@ package expr
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/data/utils/jsoniter"
@@ -87,10 +88,18 @@ func (h *ExpressionQueryReader) ReadQuery(
 				err = fmt.Errorf("unsupported reduce mode")
 			}
 		}
+		var opts mathexp.ReduceOptions
+		if err == nil && q.Reducer == mathexp.ReducerPercentile {
+			if q.Percentile == nil {
+				err = fmt.Errorf("percentile must be specified when reducer is '%s'", q.Reducer)
+			} else {
+				opts.Percentile = *q.Percentile
+			}
+		}
 		if err == nil {
 			eq.Properties = q
 			eq.Command, err = NewReduceCommand(common.RefID,
-				q.Reducer, referenceVar, mapper)
+				q.Reducer, referenceVar, mapper, opts)
 		}
 
 	case QueryTypeResample:
@@ -177,6 +186,14 @@ func (h *ExpressionQueryReader) ReadQuery(
 			}
 		}
 
+	case QueryTypeForecast:
+		q := &ForecastQuery{}
+		err = iter.ReadVal(q)
+		if err == nil {
+			eq.Properties = q
+			eq.Command, err = newForecastCommandFromQuery(common.RefID, q, time.Duration(common.IntervalMS)*time.Millisecond)
+		}
+
 	default:
 		err = fmt.Errorf("unknown query type (%s)", common.QueryType)
 	}
@@ -1,26 +1,597 @@
 package sql
 
 import (
+	"context"
+	gosql "database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
+const driverName = "sqlite"
+
+// DB is an in-memory SQL engine used to run SQL expressions over data frames.
+// Every call runs against a fresh, private database so that nothing is shared
+// between queries.
 type DB struct {
 }
 
+// NewInMemoryDB creates a new DB.
+func NewInMemoryDB() *DB {
+	return &DB{}
+}
+
+// TablesList returns the names of the tables referenced by rawSQL.
 func (db *DB) TablesList(rawSQL string) ([]string, error) {
-	return nil, errors.New("not implemented")
+	return TablesList(rawSQL)
 }
 
-func (db *DB) RunCommands(commands []string) (string, error) {
-	return "", errors.New("not implemented")
+// RunCommands executes the commands in order and returns the rows of the last
+// one as a JSON array of objects. The commands are not validated, so they must
+// never come from a user; use QueryFramesInto for that.
+func (db *DB) RunCommands(ctx context.Context, commands []string) (string, error) {
+	if len(commands) == 0 {
+		return "", nil
+	}
+
+	conn, closeDB, err := db.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer closeDB()
+
+	for _, cmd := range commands[:len(commands)-1] {
+		if _, err := conn.ExecContext(ctx, cmd); err != nil {
+			return "", err
+		}
+	}
+
+	frame := data.NewFrame("")
+	if err := queryInto(ctx, conn, commands[len(commands)-1], frame); err != nil {
+		return "", err
+	}
+
+	rows := make([]map[string]any, frame.Rows())
+	for i := range rows {
+		row := make(map[string]any, len(frame.Fields))
+		for _, f := range frame.Fields {
+			v, _ := f.ConcreteAt(i)
+			row[f.Name] = v
+		}
+		rows[i] = row
+	}
+	b, err := json.Marshal(rows)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
 }
 
-func (db *DB) QueryFramesInto(name string, query string, frames []*data.Frame, f *data.Frame) error {
-	return errors.New("not implemented")
+// QueryFramesInto loads every frame as a table named after its RefID, runs
+// query and writes the result into f, which is named name. Frames that share
+// a RefID are appended to the same table. The query must be a single SELECT
+// statement, see ValidateQuery, and is cancelled when ctx is done.
+func (db *DB) QueryFramesInto(ctx context.Context, name string, query string, frames []*data.Frame, f *data.Frame) error {
+	if err := ValidateQuery(query); err != nil {
+		return err
+	}
+
+	tables, err := tablesFromFrames(frames)
+	if err != nil {
+		return err
+	}
+
+	conn, closeDB, err := db.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	for _, t := range tables {
+		if err := t.load(ctx, conn); err != nil {
+			return fmt.Errorf("failed to load table %q: %w", t.name, err)
+		}
+	}
+
+	f.Name = name
+	return queryInto(ctx, conn, query, f)
 }
 
-func NewInMemoryDB() *DB {
-	return &DB{}
+// open returns a connection to a new, private in-memory database and a
+// function that closes it. ATTACH is disabled on the connection, so queries
+// can not reach any database file.
+func (db *DB) open(ctx context.Context) (*gosql.Conn, func(), error) {
+	// Times are written in a sortable format rather than time.Time.String().
+	sqlDB, err := gosql.Open(driverName, "file::memory:?mode=memory&_time_format=sqlite")
+	if err != nil {
+		return nil, nil, err
+	}
+	// The database only exists for as long as this single connection.
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, nil, errors.Join(err, sqlDB.Close())
+	}
+	closeDB := func() {
+		_ = conn.Close()
+		_ = sqlDB.Close()
+	}
+	if _, err := sqlite.Limit(conn, sqlite3.SQLITE_LIMIT_ATTACHED, 0); err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+	return conn, closeDB, nil
+}
+
+// column types used when loading frames into tables. The declared type is
+// what lets the result be converted back to the right field type.
+const (
+	columnTypeInteger   = "INTEGER"
+	columnTypeReal      = "REAL"
+	columnTypeText      = "TEXT"
+	columnTypeBoolean   = "BOOLEAN"
+	columnTypeTimestamp = "TIMESTAMP"
+)
+
+type column struct {
+	name    string
+	colType string
+}
+
+type table struct {
+	name    string
+	columns []column
+	rows    []map[string]any
+}
+
+// tablesFromFrames groups the frames by RefID. Field labels are added as
+// text columns so they can be used to filter, group and join on.
+func tablesFromFrames(frames []*data.Frame) ([]*table, error) {
+	byName := map[string]*table{}
+	var order []string
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		t, ok := byName[frame.RefID]
+		if !ok {
+			// Table names are not case sensitive.
+			for _, name := range order {
+				if strings.EqualFold(name, frame.RefID) {
+					return nil, fmt.Errorf("refIDs %q and %q only differ in case and can not both be used as tables", name, frame.RefID)
+				}
+			}
+			t = &table{name: frame.RefID}
+			byName[frame.RefID] = t
+			order = append(order, frame.RefID)
+		}
+		if err := t.addFrame(frame); err != nil {
+			return nil, fmt.Errorf("failed to load frame for refID %q: %w", frame.RefID, err)
+		}
+	}
+
+	tables := make([]*table, 0, len(order))
+	for _, name := range order {
+		tables = append(tables, byName[name])
+	}
+	return tables, nil
+}
+
+func (t *table) addColumn(name, colType string) {
+	for i, c := range t.columns {
+		if c.name != name {
+			continue
+		}
+		if c.colType != colType {
+			// Mixed numeric types widen to REAL, anything else to TEXT.
+			if isNumericColumn(c.colType) && isNumericColumn(colType) {
+				t.columns[i].colType = columnTypeReal
+			} else {
+				t.columns[i].colType = columnTypeText
+			}
+		}
+		return
+	}
+	t.columns = append(t.columns, column{name: name, colType: colType})
+}
+
+func isNumericColumn(colType string) bool {
+	return colType == columnTypeInteger || colType == columnTypeReal
+}
+
+// seriesGroup is a set of fields of a frame that share the same labels.
+type seriesGroup struct {
+	labels data.Labels
+	fields []*data.Field
+}
+
+// addFrame adds the rows of frame to the table. Fields without labels, such
+// as the time field, are shared by every row. Fields with labels are split
+// out by label set, so a wide frame holding several series becomes one set of
+// rows per series with the label values in their own columns, the same as
+// the long format.
+func (t *table) addFrame(frame *data.Frame) error {
+	var shared []*data.Field
+	var groups []*seriesGroup
+	fieldNames := map[string]bool{}
+	for _, field := range frame.Fields {
+		if len(field.Labels) == 0 {
+			if fieldNames[field.Name] {
+				return fmt.Errorf("duplicate field name %q", field.Name)
+			}
+			fieldNames[field.Name] = true
+			shared = append(shared, field)
+			continue
+		}
+		var group *seriesGroup
+		for _, g := range groups {
+			if g.labels.Equals(field.Labels) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &seriesGroup{labels: field.Labels}
+			groups = append(groups, group)
+		}
+		for _, f := range group.fields {
+			if f.Name == field.Name {
+				return fmt.Errorf("duplicate field name %q for labels %s", field.Name, field.Labels)
+			}
+		}
+		group.fields = append(group.fields, field)
+	}
+	for _, g := range groups {
+		for _, f := range g.fields {
+			if isSharedField(shared, f.Name) {
+				return fmt.Errorf("field name %q is used both with and without labels", f.Name)
+			}
+			fieldNames[f.Name] = true
+		}
+	}
+	if len(groups) == 0 {
+		groups = []*seriesGroup{{}}
+	}
+
+	for _, field := range shared {
+		t.addColumn(field.Name, columnTypeForField(field))
+	}
+	for _, g := range groups {
+		for _, field := range g.fields {
+			t.addColumn(field.Name, columnTypeForField(field))
+		}
+		keys := make([]string, 0, len(g.labels))
+		for k := range g.labels {
+			if fieldNames[k] {
+				return fmt.Errorf("label %q has the same name as a field", k)
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			t.addColumn(k, columnTypeText)
+		}
+	}
+
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		for i := 0; i < rowLen; i++ {
+			row := make(map[string]any, len(shared)+len(g.fields)+len(g.labels))
+			for k, v := range g.labels {
+				row[k] = v
+			}
+			for _, field := range shared {
+				row[field.Name] = concreteAt(field, i)
+			}
+			for _, field := range g.fields {
+				row[field.Name] = concreteAt(field, i)
+			}
+			t.rows = append(t.rows, row)
+		}
+	}
+	return nil
+}
+
+func isSharedField(shared []*data.Field, name string) bool {
+	for _, f := range shared {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func concreteAt(field *data.Field, i int) any {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return nil
+	}
+	if t, ok := v.(time.Time); ok {
+		// Stored as text, so use a single zone to keep comparisons right.
+		return t.UTC()
+	}
+	return v
+}
+
+func (t *table) load(ctx context.Context, conn *gosql.Conn) error {
+	if len(t.columns) == 0 {
+		return nil
+	}
+
+	defs := make([]string, len(t.columns))
+	names := make([]string, len(t.columns))
+	params := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		defs[i] = quoteIdentifier(c.name) + " " + c.colType
+		names[i] = quoteIdentifier(c.name)
+		params[i] = "?"
+	}
+
+	create := fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(t.name), strings.Join(defs, ", "))
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return err
+	}
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(t.name), strings.Join(names, ", "), strings.Join(params, ", "))
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	args := make([]any, len(t.columns))
+	for _, row := range t.rows {
+		for i, c := range t.columns {
+			args[i] = row[c.name]
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return errors.Join(err, stmt.Close(), tx.Rollback())
+		}
+	}
+	if err := stmt.Close(); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func columnTypeForField(field *data.Field) string {
+	ft := field.Type()
+	switch {
+	case ft.Time():
+		return columnTypeTimestamp
+	case ft == data.FieldTypeBool || ft == data.FieldTypeNullableBool:
+		return columnTypeBoolean
+	case ft == data.FieldTypeFloat32 || ft == data.FieldTypeNullableFloat32 ||
+		ft == data.FieldTypeFloat64 || ft == data.FieldTypeNullableFloat64:
+		return columnTypeReal
+	case ft.Numeric():
+		return columnTypeInteger
+	default:
+		return columnTypeText
+	}
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// queryInto runs query and appends one field per result column to f. The
+// field type is taken from the column's declared type when the column maps
+// directly to a table column, and otherwise from the values returned.
+func queryInto(ctx context.Context, conn *gosql.Conn, query string, f *data.Frame) error {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	values := make([][]any, len(colTypes))
+	dest := make([]any, len(colTypes))
+	for rows.Next() {
+		row := make([]any, len(colTypes))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range row {
+			values[i] = append(values[i], v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, ct := range colTypes {
+		field, err := fieldFromColumn(ct.Name(), strings.ToUpper(ct.DatabaseTypeName()), values[i])
+		if err != nil {
+			return fmt.Errorf("column %q: %w", ct.Name(), err)
+		}
+		f.Fields = append(f.Fields, field)
+	}
+	return nil
+}
+
+func fieldFromColumn(name, declType string, values []any) (*data.Field, error) {
+	ft := fieldTypeForDeclType(declType)
+	if ft == data.FieldTypeUnknown {
+		ft = fieldTypeForValues(values)
+	}
+
+	field := data.NewFieldFromFieldType(ft, len(values))
+	field.Name = name
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		cv, err := convertValue(ft, v)
+		if err != nil {
+			return nil, err
+		}
+		field.Set(i, cv)
+	}
+	return field, nil
+}
+
+func fieldTypeForDeclType(declType string) data.FieldType {
+	switch declType {
+	case columnTypeInteger, "INT", "BIGINT", "SMALLINT", "TINYINT":
+		return data.FieldTypeNullableInt64
+	case columnTypeReal, "FLOAT", "DOUBLE", "NUMERIC", "DECIMAL":
+		return data.FieldTypeNullableFloat64
+	case columnTypeText, "VARCHAR", "CHAR", "STRING":
+		return data.FieldTypeNullableString
+	case columnTypeBoolean, "BOOL":
+		return data.FieldTypeNullableBool
+	case columnTypeTimestamp, "DATETIME", "DATE":
+		return data.FieldTypeNullableTime
+	default:
+		return data.FieldTypeUnknown
+	}
+}
+
+// fieldTypeForValues picks the field type for an expression column from the
+// values it holds. Integer and float values mixed in one column give a float.
+func fieldTypeForValues(values []any) data.FieldType {
+	ft := data.FieldTypeUnknown
+	for _, v := range values {
+		var vt data.FieldType
+		switch v.(type) {
+		case nil:
+			continue
+		case int64:
+			vt = data.FieldTypeNullableInt64
+		case float64:
+			vt = data.FieldTypeNullableFloat64
+		case bool:
+			vt = data.FieldTypeNullableBool
+		case time.Time:
+			vt = data.FieldTypeNullableTime
+		default:
+			vt = data.FieldTypeNullableString
+		}
+		switch {
+		case ft == data.FieldTypeUnknown || ft == vt:
+			ft = vt
+		case (ft == data.FieldTypeNullableInt64 && vt == data.FieldTypeNullableFloat64) ||
+			(ft == data.FieldTypeNullableFloat64 && vt == data.FieldTypeNullableInt64):
+			ft = data.FieldTypeNullableFloat64
+		default:
+			return data.FieldTypeNullableString
+		}
+	}
+	if ft == data.FieldTypeUnknown {
+		// Only NULLs, there is nothing to go by.
+		return data.FieldTypeNullableString
+	}
+	return ft
+}
+
+func convertValue(ft data.FieldType, v any) (any, error) {
+	switch ft {
+	case data.FieldTypeNullableInt64:
+		switch t := v.(type) {
+		case int64:
+			return &t, nil
+		case float64:
+			i := int64(t)
+			return &i, nil
+		case bool:
+			var i int64
+			if t {
+				i = 1
+			}
+			return &i, nil
+		}
+	case data.FieldTypeNullableFloat64:
+		switch t := v.(type) {
+		case float64:
+			return &t, nil
+		case int64:
+			f := float64(t)
+			return &f, nil
+		}
+	case data.FieldTypeNullableBool:
+		switch t := v.(type) {
+		case bool:
+			return &t, nil
+		case int64:
+			b := t != 0
+			return &b, nil
+		}
+	case data.FieldTypeNullableTime:
+		switch t := v.(type) {
+		case time.Time:
+			return &t, nil
+		case int64:
+			// Times are stored as text, so an integer only ends up in a TIMESTAMP
+			// column when the query puts it there, e.g. by a UNION with a numeric
+			// column. It is read as epoch milliseconds.
+			tm := time.UnixMilli(t).UTC()
+			return &tm, nil
+		case string:
+			tm, err := parseTime(t)
+			if err != nil {
+				return nil, err
+			}
+			return &tm, nil
+		}
+	case data.FieldTypeNullableString:
+		var s string
+		switch t := v.(type) {
+		case string:
+			s = t
+		case []byte:
+			s = string(t)
+		case time.Time:
+			s = t.Format(time.RFC3339Nano)
+		default:
+			s = fmt.Sprintf("%v", t)
+		}
+		return &s, nil
+	}
+	return nil, fmt.Errorf("can not convert value of type %T to %s", v, ft)
+}
+
+// timeFormats are the formats the sqlite driver may use for time values.
+var timeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSuffix(s, "Z")
+	for _, layout := range timeFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse %q as a time", s)
 }
@@ -0,0 +1,195 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFramesInto(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+
+	cpu := func(host string, values ...float64) *data.Frame {
+		f := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t1, t2}),
+			data.NewField("value", data.Labels{"host": host}, values),
+		)
+		f.RefID = "A"
+		return f
+	}
+	hosts := data.NewFrame("",
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("cores", nil, []int64{2, 4}),
+		data.NewField("enabled", nil, []bool{true, false}),
+	)
+	hosts.RefID = "B"
+
+	t.Run("should load frames as tables named after their refID", func(t *testing.T) {
+		db := NewInMemoryDB()
+		frame := &data.Frame{}
+		err := db.QueryFramesInto(context.Background(), "C", `SELECT time, host, value FROM A ORDER BY host, time`, []*data.Frame{cpu("a", 1, 2), cpu("b", 3, 4)}, frame)
+		require.NoError(t, err)
+
+		require.Equal(t, "C", frame.Name)
+		require.Equal(t, 4, frame.Rows())
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+
+		ts, ok := frame.Fields[0].ConcreteAt(1)
+		require.True(t, ok)
+		require.True(t, t2.Equal(ts.(time.Time)))
+		host, _ := frame.Fields[1].ConcreteAt(2)
+		require.Equal(t, "b", host)
+		value, _ := frame.Fields[2].ConcreteAt(3)
+		require.Equal(t, 4.0, value)
+	})
+
+	t.Run("should join tables and type expression columns from their values", func(t *testing.T) {
+		db := NewInMemoryDB()
+		frame := &data.Frame{}
+		err := db.QueryFramesInto(context.Background(), "C", `
+			SELECT A.host, sum(A.value) / B.cores AS per_core, count(*) AS n, B.enabled
+			FROM A JOIN B ON A.host = B.host
+			GROUP BY A.host ORDER BY A.host`,
+			[]*data.Frame{cpu("a", 1, 2), cpu("b", 3, 4), hosts}, frame)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[2].Type())
+		require.Equal(t, data.FieldTypeNullableBool, frame.Fields[3].Type())
+
+		perCore, _ := frame.Fields[1].ConcreteAt(0)
+		require.Equal(t, 1.5, perCore)
+		n, _ := frame.Fields[2].ConcreteAt(1)
+		require.Equal(t, int64(2), n)
+		enabled, _ := frame.Fields[3].ConcreteAt(1)
+		require.Equal(t, false, enabled)
+	})
+
+	t.Run("should keep nulls", func(t *testing.T) {
+		v := 1.0
+		f := data.NewFrame("", data.NewField("value", nil, []*float64{&v, nil}))
+		f.RefID = "A"
+
+		db := NewInMemoryDB()
+		frame := &data.Frame{}
+		err := db.QueryFramesInto(context.Background(), "B", `SELECT value FROM A`, []*data.Frame{f}, frame)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		_, ok := frame.Fields[0].ConcreteAt(1)
+		require.False(t, ok)
+	})
+
+	t.Run("should split a wide frame with several series into rows per series", func(t *testing.T) {
+		wide := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t1, t2}),
+			data.NewField("value", data.Labels{"host": "a"}, []float64{1, 2}),
+			data.NewField("value", data.Labels{"host": "b"}, []float64{3, 4}),
+		)
+		wide.RefID = "A"
+
+		db := NewInMemoryDB()
+		frame := &data.Frame{}
+		err := db.QueryFramesInto(context.Background(), "B", `SELECT host, sum(value) AS total FROM A GROUP BY host ORDER BY host`, []*data.Frame{wide}, frame)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, frame.Rows())
+		a, _ := frame.Fields[1].ConcreteAt(0)
+		require.Equal(t, 3.0, a)
+		b, _ := frame.Fields[1].ConcreteAt(1)
+		require.Equal(t, 7.0, b)
+	})
+
+	t.Run("should return an error when a label has the name of a field", func(t *testing.T) {
+		f := data.NewFrame("",
+			data.NewField("host", nil, []string{"a"}),
+			data.NewField("value", data.Labels{"host": "b"}, []float64{1}),
+		)
+		f.RefID = "A"
+
+		db := NewInMemoryDB()
+		err := db.QueryFramesInto(context.Background(), "B", `SELECT * FROM A`, []*data.Frame{f}, &data.Frame{})
+		require.ErrorContains(t, err, `label "host"`)
+	})
+
+	t.Run("should return an error for refIDs that only differ in case", func(t *testing.T) {
+		a := data.NewFrame("", data.NewField("value", nil, []float64{1}))
+		a.RefID = "a"
+		b := data.NewFrame("", data.NewField("value", nil, []float64{2}))
+		b.RefID = "A"
+
+		db := NewInMemoryDB()
+		err := db.QueryFramesInto(context.Background(), "B", `SELECT * FROM a`, []*data.Frame{a, b}, &data.Frame{})
+		require.ErrorContains(t, err, "only differ in case")
+	})
+
+	t.Run("should return an error for a missing table", func(t *testing.T) {
+		db := NewInMemoryDB()
+		err := db.QueryFramesInto(context.Background(), "B", `SELECT * FROM nope`, nil, &data.Frame{})
+		require.Error(t, err)
+	})
+}
+
+func TestQueryFramesIntoRejectsUnsafeStatements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attached.db")
+	frame := data.NewFrame("", data.NewField("value", nil, []float64{1}))
+	frame.RefID = "A"
+
+	for _, query := range []string{
+		fmt.Sprintf(`ATTACH DATABASE '%s' AS g`, path),
+		fmt.Sprintf(`SELECT * FROM A; ATTACH DATABASE '%s' AS g; CREATE TABLE g.t(x); SELECT 1`, path),
+		`SELECT * FROM A; SELECT * FROM A`,
+		`PRAGMA database_list`,
+	} {
+		db := NewInMemoryDB()
+		err := db.QueryFramesInto(context.Background(), "B", query, []*data.Frame{frame}, &data.Frame{})
+		require.Error(t, err, query)
+	}
+
+	_, err := os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAttachIsDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attached.db")
+
+	db := NewInMemoryDB()
+	_, err := db.RunCommands(context.Background(), []string{
+		fmt.Sprintf(`ATTACH DATABASE '%s' AS g`, path),
+		"SELECT 1",
+	})
+	require.Error(t, err)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestQueryFramesIntoCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	db := NewInMemoryDB()
+	err := db.QueryFramesInto(ctx, "A", `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c) SELECT count(*) FROM c`, nil, &data.Frame{})
+	require.Error(t, err)
+}
+
+func TestRunCommands(t *testing.T) {
+	db := NewInMemoryDB()
+	out, err := db.RunCommands(context.Background(), []string{
+		"CREATE TABLE t (a INTEGER, b TEXT)",
+		"INSERT INTO t VALUES (1, 'x'), (2, 'y')",
+		"SELECT a, b FROM t ORDER BY a",
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":1,"b":"x"},{"a":2,"b":"y"}]`, out)
+}
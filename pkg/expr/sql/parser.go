@@ -1,67 +1,51 @@
 package sql
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/jeremywohl/flatten"
-)
-
-const (
-	TABLE_NAME    = "table_name"
-	ERROR         = ".error"
-	ERROR_MESSAGE = ".error_message"
 )
 
 var logger = log.New("sql_expr")
 
-// TablesList returns a list of tables for the sql statement
+// TablesList returns a list of tables for the sql statement. Names defined by a
+// WITH clause are not included. An error is returned if the statement is not
+// a single SELECT, see validateQuery.
 func TablesList(rawSQL string) ([]string, error) {
-	duckDB := NewInMemoryDB()
-	rawSQL = strings.Replace(rawSQL, "'", "''", -1)
-	cmd := fmt.Sprintf("SELECT json_serialize_sql('%s')", rawSQL)
-	ret, err := duckDB.RunCommands([]string{cmd})
+	tokens, err := tokenize(rawSQL)
 	if err != nil {
-		logger.Error("error serializing sql", "error", err.Error(), "sql", rawSQL, "cmd", cmd)
-		return nil, fmt.Errorf("error serializing sql: %s", err.Error())
+		logger.Error("error tokenizing sql", "error", err.Error(), "sql", rawSQL)
+		return nil, fmt.Errorf("error in sql: %s", err.Error())
 	}
 
-	ast := []map[string]any{}
-	err = json.Unmarshal([]byte(ret), &ast)
-	if err != nil {
-		logger.Error("error converting json sql to ast", "error", err.Error(), "ret", ret)
-		return nil, fmt.Errorf("error converting json to ast: %s", err.Error())
+	if err := validateQuery(tokens); err != nil {
+		logger.Warn("rejected sql", "error", err.Error(), "sql", rawSQL)
+		return nil, fmt.Errorf("error in sql: %s", err.Error())
 	}
 
-	return tablesFromAST(ast)
-}
-
-// tablesFromAST returns a list of tables from the ast
-func tablesFromAST(ast []map[string]any) ([]string, error) {
-	flat, err := flatten.Flatten(ast[0], "", flatten.DotStyle)
-	if err != nil {
-		logger.Error("error flattening ast", "error", err.Error(), "ast", ast)
-		return nil, fmt.Errorf("error flattening ast: %s", err.Error())
+	p := &tableParser{tokens: tokens}
+	if err := p.parse(); err != nil {
+		logger.Error("error in sql", "error", err.Error(), "sql", rawSQL)
+		return nil, fmt.Errorf("error in sql: %s", err.Error())
 	}
 
+	ctes := cteNames(tokens)
 	tables := []string{}
-	for k, v := range flat {
-		if strings.HasSuffix(k, ERROR) {
-			v, ok := v.(bool)
-			if ok && v {
-				logger.Error("error in sql", "error", k)
-				return nil, astError(k, flat)
-			}
+	for _, t := range p.tables {
+		if existsInList(t, tables) || existsInListFold(t, ctes) {
+			continue
 		}
-		if strings.Contains(k, TABLE_NAME) {
-			table, ok := v.(string)
-			if ok && !existsInList(table, tables) {
-				tables = append(tables, v.(string))
+		// SQLite ignores case in table names, so "a" and "A" would be the same
+		// table while being two different refIDs.
+		for _, other := range tables {
+			if strings.EqualFold(t, other) {
+				return nil, fmt.Errorf("error in sql: table names %q and %q only differ in case", other, t)
 			}
 		}
+		tables = append(tables, t)
 	}
 	sort.Strings(tables)
 
@@ -70,13 +54,380 @@ func tablesFromAST(ast []map[string]any) ([]string, error) {
 	return tables, nil
 }
 
-func astError(k string, flat map[string]any) error {
-	key := strings.Replace(k, ERROR, "", 1)
-	message, ok := flat[key+ERROR_MESSAGE]
-	if !ok {
-		message = "unknown error in sql"
+// ValidateQuery returns an error unless rawSQL is a single read-only SELECT
+// statement. Only such statements may be run by the engine, anything else
+// (ATTACH, PRAGMA, INSERT, ...) could reach outside of the in-memory database.
+func ValidateQuery(rawSQL string) error {
+	tokens, err := tokenize(rawSQL)
+	if err != nil {
+		return err
+	}
+	return validateQuery(tokens)
+}
+
+// statementWords are the words that start a statement other than SELECT.
+// None of them may appear unquoted anywhere in a query.
+var statementWords = map[string]bool{
+	"ALTER": true, "ANALYZE": true, "ATTACH": true, "BEGIN": true, "COMMIT": true,
+	"CREATE": true, "DELETE": true, "DETACH": true, "DROP": true,
+	"EXPLAIN": true, "INSERT": true, "PRAGMA": true, "REINDEX": true, "RELEASE": true,
+	"REPLACE": true, "ROLLBACK": true, "SAVEPOINT": true, "UPDATE": true, "VACUUM": true,
+}
+
+func validateQuery(tokens []token) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("empty query")
+	}
+	first := tokens[0]
+	if !first.is(tokenKeyword, "SELECT") && !first.is(tokenKeyword, "WITH") && !first.is(tokenKeyword, "VALUES") {
+		return fmt.Errorf("only SELECT statements are supported, got %q", first.value)
+	}
+	for i, tok := range tokens {
+		if tok.is(tokenPunct, ";") {
+			for _, rest := range tokens[i+1:] {
+				if !rest.is(tokenPunct, ";") {
+					return fmt.Errorf("only a single statement is supported")
+				}
+			}
+			break
+		}
+		if tok.kind != tokenIdent || !statementWords[strings.ToUpper(tok.value)] {
+			continue
+		}
+		// replace() is also a string function.
+		if strings.EqualFold(tok.value, "REPLACE") && i+1 < len(tokens) && tokens[i+1].is(tokenPunct, "(") {
+			continue
+		}
+		return fmt.Errorf("%s is not allowed in a query", strings.ToUpper(tok.value))
+	}
+	return nil
+}
+
+// cteNames returns the names of the common table expressions defined by
+// every WITH clause in tokens.
+func cteNames(tokens []token) []string {
+	var names []string
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].is(tokenKeyword, "WITH") {
+			continue
+		}
+		i++
+		if i < len(tokens) && tokens[i].is(tokenKeyword, "RECURSIVE") {
+			i++
+		}
+		for i < len(tokens) && tokens[i].isIdent() {
+			names = append(names, tokens[i].value)
+			i++
+			// Optional column list, "AS", optional "[NOT] MATERIALIZED" and the
+			// body are skipped up to the comma before the next definition.
+			if i < len(tokens) && tokens[i].is(tokenPunct, "(") {
+				i = skipParens(tokens, i)
+			}
+			for i < len(tokens) && !tokens[i].is(tokenPunct, "(") {
+				i++
+			}
+			i = skipParens(tokens, i)
+			if i < len(tokens) && tokens[i].is(tokenPunct, ",") {
+				i++
+				continue
+			}
+			break
+		}
+	}
+	return names
+}
+
+// skipParens returns the index after the parenthesis that closes the one at
+// tokens[i].
+func skipParens(tokens []token, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch {
+		case tokens[i].is(tokenPunct, "("):
+			depth++
+		case tokens[i].is(tokenPunct, ")"):
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+type tokenKind int
+
+const (
+	tokenKeyword tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	// value is the upper-cased keyword, the identifier as written (without
+	// quotes) or the punctuation character(s).
+	value string
+}
+
+func (t token) is(kind tokenKind, value string) bool {
+	return t.kind == kind && t.value == value
+}
+
+func (t token) isIdent() bool {
+	return t.kind == tokenIdent || t.kind == tokenQuotedIdent
+}
+
+// keywords are the reserved words that may follow a table reference or
+// otherwise change how the statement is read. Anything not in here is
+// treated as an identifier.
+var keywords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "CAST": true, "CROSS": true, "DESC": true, "DISTINCT": true,
+	"ELSE": true, "END": true, "EXCEPT": true, "EXISTS": true, "FETCH": true,
+	"FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "IN": true,
+	"INDEXED": true, "INNER": true, "INTERSECT": true, "IS": true, "JOIN": true, "LEFT": true,
+	"LIKE": true, "LIMIT": true, "NATURAL": true, "NOT": true, "NULL": true,
+	"OFFSET": true, "ON": true, "OR": true, "ORDER": true, "OUTER": true,
+	"OVER": true, "PARTITION": true, "QUALIFY": true, "RECURSIVE": true,
+	"RIGHT": true, "SELECT": true, "THEN": true, "UNION": true, "USING": true,
+	"VALUES": true, "WHEN": true, "WHERE": true, "WINDOW": true, "WITH": true,
+}
+
+// tokenize splits rawSQL into tokens, dropping whitespace and comments.
+func tokenize(rawSQL string) ([]token, error) {
+	var tokens []token
+	src := []rune(rawSQL)
+	for i := 0; i < len(src); {
+		r := src[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(src) && src[i+1] == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i+1 < len(src) && (src[i] != '*' || src[i+1] != '/') {
+				i++
+			}
+			if i+1 >= len(src) {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2
+		case r == '\'' || r == '"' || r == '`':
+			value, n, err := readQuoted(src[i:], r)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokenQuotedIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, value: value})
+			i += n
+		case r == '[':
+			end := i + 1
+			for end < len(src) && src[end] != ']' {
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated quoted identifier %q", string(src[i:]))
+			}
+			tokens = append(tokens, token{kind: tokenQuotedIdent, value: string(src[i+1 : end])})
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_' || src[i] == '$') {
+				i++
+			}
+			word := string(src[start:i])
+			if keywords[strings.ToUpper(word)] {
+				tokens = append(tokens, token{kind: tokenKeyword, value: strings.ToUpper(word)})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, value: word})
+			}
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(src) && (unicode.IsDigit(src[i]) || unicode.IsLetter(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(src[start:i])})
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, value: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted reads a quoted string or identifier starting at src[0], where a
+// doubled quote character is an escaped quote. It returns the unquoted value
+// and the number of runes consumed.
+func readQuoted(src []rune, quote rune) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(src); i++ {
+		if src[i] != quote {
+			sb.WriteRune(src[i])
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == quote {
+			sb.WriteRune(quote)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
 	}
-	return fmt.Errorf("error in sql: %s", message)
+	return "", 0, fmt.Errorf("unterminated quoted value %q", string(src))
+}
+
+// tableParser walks the token stream and records every name used as a table
+// reference after FROM or JOIN. It does not validate the full grammar, that is
+// left to the engine, but it does reject table lists it can not make sense of.
+type tableParser struct {
+	tokens []token
+	pos    int
+	tables []string
+
+	// parens tracks, for each open parenthesis, whether its content is a
+	// query (true) or an expression such as a function call (false), and
+	// whether it is a subquery used as a table reference.
+	parens []parenScope
+}
+
+type parenScope struct {
+	query    bool
+	tableRef bool
+}
+
+func (p *tableParser) peek(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return token{kind: tokenPunct, value: ""}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *tableParser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *tableParser) inQuery() bool {
+	return len(p.parens) == 0 || p.parens[len(p.parens)-1].query
+}
+
+func (p *tableParser) parse() error {
+	for !p.eof() {
+		tok := p.peek(0)
+		switch {
+		case tok.is(tokenPunct, "("):
+			next := p.peek(1)
+			isQuery := next.is(tokenKeyword, "SELECT") || next.is(tokenKeyword, "WITH") || next.is(tokenKeyword, "VALUES")
+			p.parens = append(p.parens, parenScope{query: isQuery})
+			p.pos++
+		case tok.is(tokenPunct, ")"):
+			if len(p.parens) == 0 {
+				return fmt.Errorf("unbalanced parenthesis")
+			}
+			scope := p.parens[len(p.parens)-1]
+			p.parens = p.parens[:len(p.parens)-1]
+			p.pos++
+			if scope.tableRef {
+				if err := p.parseAfterTableRef(); err != nil {
+					return err
+				}
+			}
+		case (tok.is(tokenKeyword, "FROM") || tok.is(tokenKeyword, "JOIN")) && p.inQuery():
+			p.pos++
+			if err := p.parseTableRef(); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	if len(p.parens) != 0 {
+		return fmt.Errorf("unbalanced parenthesis")
+	}
+	return nil
+}
+
+// parseTableRef reads a single table reference: a (possibly qualified) table
+// name, a subquery or a parenthesized join.
+func (p *tableParser) parseTableRef() error {
+	tok := p.peek(0)
+	switch {
+	case tok.is(tokenPunct, "("):
+		next := p.peek(1)
+		if next.is(tokenKeyword, "SELECT") || next.is(tokenKeyword, "WITH") || next.is(tokenKeyword, "VALUES") {
+			// The subquery itself is read by the main loop, the alias and any
+			// following comma are read once its closing parenthesis is found.
+			p.parens = append(p.parens, parenScope{query: true, tableRef: true})
+			p.pos++
+			return nil
+		}
+		// A parenthesized join, e.g. "FROM a LEFT JOIN (b JOIN c ON ...)".
+		p.parens = append(p.parens, parenScope{query: true, tableRef: true})
+		p.pos++
+		return p.parseTableRef()
+	case tok.isIdent():
+		name := tok.value
+		p.pos++
+		if p.peek(0).is(tokenPunct, ".") {
+			// Only the main database exists, a schema name could only refer
+			// to an attached one.
+			return fmt.Errorf("schema qualified table names are not supported: %q", name+"."+p.peek(1).value)
+		}
+		if p.peek(0).is(tokenPunct, "(") {
+			// A table valued function, its arguments are read by the main loop.
+			p.parens = append(p.parens, parenScope{query: false, tableRef: true})
+			p.pos++
+			return nil
+		}
+		p.tables = append(p.tables, name)
+		return p.parseAfterTableRef()
+	default:
+		return fmt.Errorf("expected table name but got %q", tok.value)
+	}
+}
+
+// parseAfterTableRef reads the optional alias of a table reference and any
+// further comma separated references.
+func (p *tableParser) parseAfterTableRef() error {
+	if p.peek(0).is(tokenKeyword, "AS") {
+		p.pos++
+		if !p.peek(0).isIdent() {
+			return fmt.Errorf("expected alias after AS but got %q", p.peek(0).value)
+		}
+		p.pos++
+	} else if p.peek(0).isIdent() {
+		p.pos++
+	}
+	switch {
+	case p.peek(0).is(tokenKeyword, "INDEXED") && p.peek(1).is(tokenKeyword, "BY") && p.peek(2).isIdent():
+		p.pos += 3
+	case p.peek(0).is(tokenKeyword, "NOT") && p.peek(1).is(tokenKeyword, "INDEXED"):
+		p.pos += 2
+	}
+	if p.peek(0).is(tokenPunct, "(") && p.peek(-1).isIdent() {
+		// Column aliases, e.g. "AS t(a, b)".
+		p.parens = append(p.parens, parenScope{})
+		p.pos++
+		return nil
+	}
+
+	tok := p.peek(0)
+	switch {
+	case tok.is(tokenPunct, ","):
+		p.pos++
+		return p.parseTableRef()
+	case tok.isIdent():
+		return fmt.Errorf("syntax error at or near %q", tok.value)
+	}
+	return nil
 }
 
 func existsInList(table string, list []string) bool {
@@ -87,3 +438,12 @@ func existsInList(table string, list []string) bool {
 	}
 	return false
 }
+
+func existsInListFold(table string, list []string) bool {
+	for _, t := range list {
+		if strings.EqualFold(t, table) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,7 +7,6 @@ import (
 )
 
 func TestParse(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -16,7 +15,6 @@ func TestParse(t *testing.T) {
 }
 
 func TestParseWithComma(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo,bar"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -26,7 +24,6 @@ func TestParseWithComma(t *testing.T) {
 }
 
 func TestParseWithCommas(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo,bar,baz"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -36,27 +33,24 @@ func TestParseWithCommas(t *testing.T) {
 	assert.Equal(t, "foo", tables[2])
 }
 
-func TestArray(t *testing.T) {
-	t.Skip()
-	sql := "SELECT array_value(1, 2, 3)"
+func TestJSONArray(t *testing.T) {
+	sql := "SELECT json_array(1, 2, 3)"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
 	assert.Equal(t, 0, len(tables))
 }
 
-func TestArray2(t *testing.T) {
-	t.Skip()
-	sql := "SELECT array_value(1, 2, 3)[2]"
+func TestJSONExtract(t *testing.T) {
+	sql := "SELECT json_extract('[3, 2, 1]', '$[1]')"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
 	assert.Equal(t, 0, len(tables))
 }
 
-func TestXxx(t *testing.T) {
-	t.Skip()
-	sql := "SELECT [3, 2, 1]::INT[3];"
+func TestCast(t *testing.T) {
+	sql := "SELECT CAST('3' AS INTEGER);"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
@@ -64,7 +58,6 @@ func TestXxx(t *testing.T) {
 }
 
 func TestParseSubquery(t *testing.T) {
-	t.Skip()
 	sql := "select * from (select * from people limit 1)"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -74,7 +67,6 @@ func TestParseSubquery(t *testing.T) {
 }
 
 func TestJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A
 	JOIN B ON A.name = B.name
 	LIMIT 10`
@@ -87,7 +79,6 @@ func TestJoin(t *testing.T) {
 }
 
 func TestRightJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A
 	RIGHT JOIN B ON A.name = B.name
 	LIMIT 10`
@@ -100,7 +91,6 @@ func TestRightJoin(t *testing.T) {
 }
 
 func TestAliasWithJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A as X
 	RIGHT JOIN B ON A.name = X.name
 	LIMIT 10`
@@ -113,7 +103,6 @@ func TestAliasWithJoin(t *testing.T) {
 }
 
 func TestAlias(t *testing.T) {
-	t.Skip()
 	sql := `select * from A as X LIMIT 10`
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -123,14 +112,12 @@ func TestAlias(t *testing.T) {
 }
 
 func TestError(t *testing.T) {
-	t.Skip()
 	sql := `select * from zzz aaa zzz`
 	_, err := TablesList((sql))
 	assert.NotNil(t, err)
 }
 
 func TestParens(t *testing.T) {
-	t.Skip()
 	sql := `SELECT  t1.Col1,
 	t2.Col1,
 	t3.Col1
@@ -149,7 +136,6 @@ func TestParens(t *testing.T) {
 }
 
 func TestWith(t *testing.T) {
-	t.Skip()
 	sql := `WITH
 
 	current_month AS (
@@ -191,14 +177,13 @@ func TestWith(t *testing.T) {
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
-	assert.Equal(t, 5, len(tables))
+	assert.Equal(t, 3, len(tables))
 	assert.Equal(t, "A", tables[0])
 	assert.Equal(t, "B", tables[1])
 	assert.Equal(t, "BEE", tables[2])
 }
 
 func TestWithQuote(t *testing.T) {
-	t.Skip()
 	sql := "select *,'junk' from foo"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -207,10 +192,83 @@ func TestWithQuote(t *testing.T) {
 }
 
 func TestWithQuote2(t *testing.T) {
-	t.Skip()
-	sql := "SELECT json_serialize_sql('SELECT 1')"
+	sql := "SELECT replace('SELECT 1 FROM foo', 'foo', 'bar')"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
 	assert.Equal(t, 0, len(tables))
 }
+
+func TestScalarSubquery(t *testing.T) {
+	sql := "SELECT count(*) FILTER (WHERE v > 1), (SELECT max(v) FROM B) FROM A"
+	tables, err := TablesList((sql))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(tables))
+	assert.Equal(t, "A", tables[0])
+	assert.Equal(t, "B", tables[1])
+}
+
+func TestBracketQuoted(t *testing.T) {
+	sql := `SELECT * FROM [my table] JOIN "B" ON 1 = 1`
+	tables, err := TablesList((sql))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(tables))
+	assert.Equal(t, "B", tables[0])
+	assert.Equal(t, "my table", tables[1])
+}
+
+func TestIndexedBy(t *testing.T) {
+	sql := "SELECT * FROM A AS X INDEXED BY idx, B NOT INDEXED WHERE X.v > 1"
+	tables, err := TablesList((sql))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(tables))
+	assert.Equal(t, "A", tables[0])
+	assert.Equal(t, "B", tables[1])
+}
+
+func TestCaseOnlyDifference(t *testing.T) {
+	sql := "SELECT * FROM a JOIN A ON a.x = A.x"
+	_, err := TablesList((sql))
+	assert.NotNil(t, err)
+}
+
+func TestAttach(t *testing.T) {
+	sql := "ATTACH DATABASE '/tmp/grafana.db' AS g"
+	_, err := TablesList((sql))
+	assert.NotNil(t, err)
+}
+
+func TestMultipleStatements(t *testing.T) {
+	sql := "SELECT 1; ATTACH DATABASE '/tmp/grafana.db' AS g; SELECT * FROM g.user"
+	_, err := TablesList((sql))
+	assert.NotNil(t, err)
+}
+
+func TestTrailingSemicolon(t *testing.T) {
+	sql := "SELECT * FROM A;"
+	tables, err := TablesList((sql))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, len(tables))
+}
+
+func TestWriteStatement(t *testing.T) {
+	for _, sql := range []string{
+		"INSERT INTO A VALUES (1)",
+		"PRAGMA table_info(A)",
+		"WITH x AS (SELECT 1) DELETE FROM A",
+		"CREATE TABLE t (a INTEGER)",
+	} {
+		_, err := TablesList((sql))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestSchemaQualified(t *testing.T) {
+	sql := "SELECT * FROM g.secret"
+	_, err := TablesList((sql))
+	assert.NotNil(t, err)
+}
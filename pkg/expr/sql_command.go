@@ -77,7 +77,7 @@ func (gr *SQLCommand) NeedsVars() []string {
 // Execute runs the command and returns the results or an error if the command
 // failed to execute.
 func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.Vars, tracer tracing.Tracer) (mathexp.Results, error) {
-	_, span := tracer.Start(ctx, "SSE.ExecuteSQL")
+	ctx, span := tracer.Start(ctx, "SSE.ExecuteSQL")
 	defer span.End()
 
 	allFrames := []*data.Frame{}
@@ -97,7 +97,7 @@ func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.V
 	var frame = &data.Frame{}
 
 	logger.Debug("Executing query", "query", gr.query, "frames", len(allFrames))
-	err := db.QueryFramesInto(gr.refID, gr.query, allFrames, frame)
+	err := db.QueryFramesInto(ctx, gr.refID, gr.query, allFrames, frame)
 	if err != nil {
 		logger.Error("Failed to query frames", "error", err.Error())
 		rsp.Error = err
@@ -111,15 +111,52 @@ func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.V
 		rsp.Values = mathexp.Values{
 			mathexp.NoData{Frame: frame},
 		}
+		return rsp, nil
 	}
 
-	rsp.Values = mathexp.Values{
-		mathexp.TableData{Frame: frame},
-	}
+	rsp.Values = sqlResultValues(frame)
 
 	return rsp, nil
 }
 
+// sqlResultValues turns the text columns of the result back into labels when
+// the result has the shape of a number set (one numeric column) or of a long
+// time series, so the output can be used by other expressions. Any other
+// result is returned as a table.
+func sqlResultValues(frame *data.Frame) mathexp.Values {
+	switch {
+	case frame.TimeSeriesSchema().Type == data.TimeSeriesTypeNot && isNumberTable(frame):
+		numbers, err := extractNumberSet(frame)
+		if err != nil {
+			break
+		}
+		vals := make(mathexp.Values, 0, len(numbers))
+		for _, n := range numbers {
+			vals = append(vals, n)
+		}
+		return vals
+	case frame.TimeSeriesSchema().Type == data.TimeSeriesTypeLong:
+		wide, err := data.LongToWide(frame, nil)
+		if err != nil {
+			// For example when the rows are not sorted by time.
+			logger.Debug("Failed to convert SQL result to wide series, returning it as a table", "error", err)
+			break
+		}
+		series, err := WideToMany(wide, nil)
+		if err != nil {
+			break
+		}
+		vals := make(mathexp.Values, 0, len(series))
+		for _, s := range series {
+			vals = append(vals, s)
+		}
+		return vals
+	}
+	return mathexp.Values{
+		mathexp.TableData{Frame: frame},
+	}
+}
+
 func (gr *SQLCommand) Type() string {
 	return TypeSQL.String()
 }
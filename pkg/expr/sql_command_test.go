@@ -3,10 +3,15 @@ package expr
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
 func TestNewCommand(t *testing.T) {
-	t.Skip()
 	cmd, err := NewSQLCommand("a", "select a from foo, bar")
 	if err != nil && strings.Contains(err.Error(), "feature is not enabled") {
 		return
@@ -25,3 +30,41 @@ func TestNewCommand(t *testing.T) {
 		return
 	}
 }
+
+func TestSQLResultValues(t *testing.T) {
+	t.Run("number table becomes a number set with labels", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("value", nil, []float64{1, 2}),
+		)
+		vals := sqlResultValues(frame)
+		require.Len(t, vals, 2)
+		require.Equal(t, parse.TypeNumberSet, vals[0].Type())
+		require.Equal(t, data.Labels{"host": "a"}, vals[0].GetLabels())
+		require.Equal(t, data.Labels{"host": "b"}, vals[1].GetLabels())
+	})
+
+	t.Run("long time series becomes a series set with labels", func(t *testing.T) {
+		t1 := time.Unix(0, 0)
+		frame := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t1, t1, t1.Add(time.Minute), t1.Add(time.Minute)}),
+			data.NewField("host", nil, []string{"a", "b", "a", "b"}),
+			data.NewField("value", nil, []float64{1, 2, 3, 4}),
+		)
+		vals := sqlResultValues(frame)
+		require.Len(t, vals, 2)
+		require.Equal(t, parse.TypeSeriesSet, vals[0].Type())
+		require.Equal(t, data.Labels{"host": "a"}, vals[0].GetLabels())
+	})
+
+	t.Run("anything else is a table", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("host", nil, []string{"a"}),
+			data.NewField("a", nil, []float64{1}),
+			data.NewField("b", nil, []float64{2}),
+		)
+		vals := sqlResultValues(frame)
+		require.Len(t, vals, 1)
+		require.Equal(t, parse.TypeTableData, vals[0].Type())
+	})
+}
@@ -24,12 +24,26 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
 )
 
 var _ builder.APIGroupBuilder = (*FolderAPIBuilder)(nil)
 
 var resourceInfo = v0alpha1.FolderResourceInfo
 
+func init() {
+	// add the folders to the unified storage search index
+	resource.RegisterIndexableResource(resource.IndexableResource{
+		Group:    resourceInfo.GroupResource().Group,
+		Resource: resourceInfo.GroupResource().Resource,
+		Kind:     resourceInfo.GroupVersionKind().Kind,
+		Fields: []resource.SpecFieldMapping{
+			{Field: "title", Type: "string"},
+			{Field: "description", Type: "string"},
+		},
+	})
+}
+
 // This is used just so wire has something unique to return
 type FolderAPIBuilder struct {
 	gv            schema.GroupVersion
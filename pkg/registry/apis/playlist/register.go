@@ -21,10 +21,25 @@ import (
 	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
 	playlistsvc "github.com/grafana/grafana/pkg/services/playlist"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
 )
 
 var _ builder.APIGroupBuilder = (*PlaylistAPIBuilder)(nil)
 
+func init() {
+	// add the playlists to the unified storage search index
+	kind := playlist.PlaylistKind()
+	resource.RegisterIndexableResource(resource.IndexableResource{
+		Group:    kind.Group(),
+		Resource: kind.GroupVersionResource().Resource,
+		Kind:     kind.Kind(),
+		Fields: []resource.SpecFieldMapping{
+			{Field: "interval", Type: "string"},
+			{Field: "title", Type: "string"},
+		},
+	})
+}
+
 // This is used just so wire has something unique to return
 type PlaylistAPIBuilder struct {
 	service    playlistsvc.Service
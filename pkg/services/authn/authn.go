@@ -28,6 +28,7 @@ const (
 	ClientSession     = "auth.client.session"
 	ClientForm        = "auth.client.form"
 	ClientProxy       = "auth.client.proxy"
+	ClientMTLS        = "auth.client.mtls"
 	ClientSAML        = "auth.client.saml"
 )
 
@@ -1,6 +1,8 @@
 package authnimpl
 
 import (
+	"fmt"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/infra/tracing"
@@ -39,7 +41,7 @@ func ProvideRegistration(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP, settingsProviderService setting.Provider,
 	tracer tracing.Tracer,
-) Registration {
+) (Registration, error) {
 	logger := log.New("authn.registration")
 
 	authnSvc.RegisterClient(clients.ProvideRender(renderService))
@@ -91,6 +93,16 @@ func ProvideRegistration(
 		authnSvc.RegisterClient(clients.ProvideJWT(jwtService, cfg))
 	}
 
+	if cfg.AuthMTLS.Enabled {
+		// an invalid [auth.mtls] section fails the startup like an invalid TLS configuration, instead of silently
+		// disabling client certificate auth
+		mtls, err := clients.ProvideMTLS(cfg)
+		if err != nil {
+			return Registration{}, fmt.Errorf("failed to configure client certificate auth: %w", err)
+		}
+		authnSvc.RegisterClient(mtls)
+	}
+
 	if cfg.ExtJWTAuth.Enabled && features.IsEnabledGlobally(featuremgmt.FlagAuthAPIAccessTokenAuth) {
 		authnSvc.RegisterClient(clients.ProvideExtendedJWT(cfg))
 	}
@@ -119,5 +131,5 @@ func ProvideRegistration(
 	authnSvc.RegisterPostAuthHook(rbacSync.SyncPermissionsHook, 120)
 	authnSvc.RegisterPostLoginHook(orgSync.SetDefaultOrgHook, 140)
 
-	return Registration{}
+	return Registration{}, nil
 }
@@ -0,0 +1,341 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var _ authn.ContextAwareClient = new(MTLS)
+
+var (
+	errMTLSInvalidCertificate = errutil.Unauthorized(
+		"mtls.invalid-certificate", errutil.WithPublicMessage("Invalid client certificate"))
+	errMTLSRevokedCertificate = errutil.Unauthorized(
+		"mtls.revoked-certificate", errutil.WithPublicMessage("Client certificate has been revoked"))
+	errMTLSRevocationCheck = errutil.Internal(
+		"mtls.revocation-check-failed", errutil.WithPublicMessage("Failed to check the revocation of the client certificate"))
+	errMTLSMissingIdentity = errutil.Unauthorized(
+		"mtls.missing-identity", errutil.WithPublicMessage("Missing login and email in client certificate"))
+	errMTLSInvalidRole = errutil.Forbidden(
+		"mtls.invalid-role", errutil.WithPublicMessage("Invalid role in client certificate"))
+	errMTLSTemplate = errutil.Internal("mtls.template-failed")
+)
+
+// mtlsTemplateFuncs are the functions available in the templates mapping the certificate fields to the user attributes.
+var mtlsTemplateFuncs = template.FuncMap{
+	"first": func(values []string) string {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	},
+	"has": func(value string, values []string) bool {
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	},
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// mtlsCertificate is the data the user attributes templates are executed with.
+type mtlsCertificate struct {
+	Subject        pkix.Name
+	Issuer         pkix.Name
+	SerialNumber   string
+	EmailAddresses []string
+	DNSNames       []string
+	URIs           []string
+}
+
+func newMTLSCertificate(cert *x509.Certificate) mtlsCertificate {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	return mtlsCertificate{
+		Subject:        cert.Subject,
+		Issuer:         cert.Issuer,
+		SerialNumber:   cert.SerialNumber.String(),
+		EmailAddresses: cert.EmailAddresses,
+		DNSNames:       cert.DNSNames,
+		URIs:           uris,
+	}
+}
+
+func ProvideMTLS(cfg *setting.Cfg) (*MTLS, error) {
+	c := &MTLS{
+		cfg: cfg,
+		log: log.New(authn.ClientMTLS),
+		now: time.Now,
+	}
+
+	var err error
+	for _, t := range []struct {
+		name  string
+		text  string
+		field **template.Template
+	}{
+		{"login_template", cfg.AuthMTLS.LoginTemplate, &c.login},
+		{"email_template", cfg.AuthMTLS.EmailTemplate, &c.email},
+		{"name_template", cfg.AuthMTLS.NameTemplate, &c.name},
+		{"role_template", cfg.AuthMTLS.RoleTemplate, &c.role},
+	} {
+		if *t.field, err = template.New(t.name).Funcs(mtlsTemplateFuncs).Parse(t.text); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", t.name, err)
+		}
+	}
+
+	if cfg.AuthMTLS.CRLFile != "" {
+		c.crl = &crlFile{path: cfg.AuthMTLS.CRLFile}
+		// fail early on an invalid file, it is reloaded once changed
+		if _, err := c.crl.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// MTLS authenticates requests with the client certificate verified by the TLS server of Grafana.
+type MTLS struct {
+	cfg   *setting.Cfg
+	log   log.Logger
+	login *template.Template
+	email *template.Template
+	name  *template.Template
+	role  *template.Template
+	crl   *crlFile
+	now   func() time.Time
+}
+
+func (c *MTLS) Name() string {
+	return authn.ClientMTLS
+}
+
+func (c *MTLS) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	state := r.HTTPRequest.TLS
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return nil, errMTLSInvalidCertificate.Errorf("no verified client certificate")
+	}
+
+	chain := state.VerifiedChains[0]
+	cert := chain[0]
+	// a trusted certificate used directly is its own issuer
+	issuer := cert
+	if len(chain) > 1 {
+		issuer = chain[1]
+	}
+
+	if err := c.checkRevocation(cert, issuer, state.OCSPResponse); err != nil {
+		c.log.FromContext(ctx).Warn("Rejected client certificate", "subject", cert.Subject.String(), "serial", cert.SerialNumber.String(), "error", err)
+		return nil, err
+	}
+
+	id := &authn.Identity{
+		AuthenticatedBy: login.MTLSAuthModule,
+		AuthID:          cert.Subject.String(),
+		OrgRoles:        map[int64]org.RoleType{},
+		ClientParams: authn.ClientParams{
+			SyncUser:        true,
+			FetchSyncedUser: true,
+			SyncPermissions: true,
+			SyncOrgRoles:    !c.cfg.AuthMTLS.SkipOrgRoleSync,
+			AllowSignUp:     c.cfg.AuthMTLS.AutoSignUp,
+		},
+	}
+
+	var role string
+	data := newMTLSCertificate(cert)
+	for _, t := range []struct {
+		tmpl  *template.Template
+		value *string
+	}{{c.login, &id.Login}, {c.email, &id.Email}, {c.name, &id.Name}, {c.role, &role}} {
+		var buf bytes.Buffer
+		if err := t.tmpl.Execute(&buf, data); err != nil {
+			return nil, errMTLSTemplate.Errorf("failed to execute %s: %w", t.tmpl.Name(), err)
+		}
+		*t.value = strings.TrimSpace(buf.String())
+	}
+
+	if id.Login == "" && id.Email == "" {
+		return nil, errMTLSMissingIdentity.Errorf("login and email templates are empty for certificate %s", cert.Subject.String())
+	}
+	if id.Login != "" {
+		id.ClientParams.LookUpParams.Login = &id.Login
+	}
+	if id.Email != "" {
+		id.ClientParams.LookUpParams.Email = &id.Email
+	}
+
+	orgRoles, isGrafanaAdmin, err := getRoles(c.cfg, func() (org.RoleType, *bool, error) {
+		if c.cfg.AuthMTLS.SkipOrgRoleSync {
+			return "", nil, nil
+		}
+
+		orgRole, grafanaAdmin := org.RoleType(role), false
+		if role == roleGrafanaAdmin {
+			orgRole, grafanaAdmin = org.RoleAdmin, true
+		}
+		if c.cfg.AuthMTLS.RoleAttributeStrict && !orgRole.IsValid() {
+			return "", nil, errMTLSInvalidRole.Errorf("invalid role in client certificate: %s", role)
+		}
+
+		if !c.cfg.AuthMTLS.AllowAssignGrafanaAdmin {
+			return orgRole, nil, nil
+		}
+
+		return orgRole, &grafanaAdmin, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id.OrgRoles = orgRoles
+	id.IsGrafanaAdmin = isGrafanaAdmin
+
+	return id, nil
+}
+
+func (c *MTLS) IsEnabled() bool {
+	return c.cfg.AuthMTLS.Enabled
+}
+
+func (c *MTLS) Test(ctx context.Context, r *authn.Request) bool {
+	return r.HTTPRequest != nil && r.HTTPRequest.TLS != nil && len(r.HTTPRequest.TLS.VerifiedChains) > 0
+}
+
+func (c *MTLS) Priority() uint {
+	return 35
+}
+
+// checkRevocation rejects the certificate if it is revoked by the OCSP response stapled by the client
+// or by the configured CRL.
+func (c *MTLS) checkRevocation(cert, issuer *x509.Certificate, staple []byte) error {
+	now := c.now()
+
+	if len(staple) > 0 {
+		resp, err := ocsp.ParseResponseForCert(staple, cert, issuer)
+		if err != nil {
+			return errMTLSInvalidCertificate.Errorf("invalid stapled OCSP response: %w", err)
+		}
+		if !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate) {
+			return errMTLSInvalidCertificate.Errorf("stapled OCSP response expired at %s", resp.NextUpdate)
+		}
+		switch resp.Status {
+		case ocsp.Good:
+		case ocsp.Revoked:
+			return errMTLSRevokedCertificate.Errorf("certificate revoked at %s", resp.RevokedAt)
+		default:
+			return errMTLSInvalidCertificate.Errorf("unknown certificate status in stapled OCSP response")
+		}
+	} else if c.cfg.AuthMTLS.RequireOCSPStaple {
+		return errMTLSInvalidCertificate.Errorf("missing stapled OCSP response")
+	}
+
+	if c.crl == nil {
+		return nil
+	}
+
+	lists, err := c.crl.load()
+	if err != nil {
+		return errMTLSRevocationCheck.Errorf("%w", err)
+	}
+
+	for _, list := range lists {
+		// the file may contain the lists of several CAs
+		if !bytes.Equal(list.RawIssuer, cert.RawIssuer) {
+			continue
+		}
+		if err := list.CheckSignatureFrom(issuer); err != nil {
+			return errMTLSRevocationCheck.Errorf("invalid CRL signature: %w", err)
+		}
+		if !list.NextUpdate.IsZero() && now.After(list.NextUpdate) {
+			return errMTLSRevocationCheck.Errorf("CRL of %s expired at %s", issuer.Subject.String(), list.NextUpdate)
+		}
+		for _, entry := range list.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return errMTLSRevokedCertificate.Errorf("certificate revoked at %s", entry.RevocationTime)
+			}
+		}
+	}
+
+	return nil
+}
+
+// crlFile holds the certificate revocation lists of a PEM or DER file, reloaded when the file is modified.
+type crlFile struct {
+	path    string
+	mtx     sync.Mutex
+	modTime time.Time
+	lists   []*x509.RevocationList
+}
+
+func (f *crlFile) load() ([]*x509.RevocationList, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL file: %w", err)
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.lists != nil && info.ModTime().Equal(f.modTime) {
+		return f.lists, nil
+	}
+
+	// #nosec G304 - the path comes from the configuration
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL file: %w", err)
+	}
+
+	var lists []*x509.RevocationList
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		list, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CRL file: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		list, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CRL file: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no CRL found in %s", f.path)
+	}
+
+	f.lists, f.modTime = lists, info.ModTime()
+	return lists, nil
+}
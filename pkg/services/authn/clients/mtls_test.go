@@ -0,0 +1,289 @@
+package clients
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, subject pkix.Name, emails ...string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:   big.NewInt(serial),
+		Subject:        subject,
+		EmailAddresses: emails,
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca.cert, key.Public(), ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func (ca *testCA) crl(t *testing.T, nextUpdate time.Time, revoked ...int64) []byte {
+	t.Helper()
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                nextUpdate.Add(-time.Hour),
+		NextUpdate:                nextUpdate,
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func (ca *testCA) ocsp(t *testing.T, cert *x509.Certificate, status int) []byte {
+	t.Helper()
+	resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+		Status:       status,
+		SerialNumber: cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+		RevokedAt:    time.Now().Add(-time.Minute),
+	}, ca.key)
+	require.NoError(t, err)
+	return resp
+}
+
+func mtlsRequest(ca *testCA, cert *x509.Certificate, staple []byte) *authn.Request {
+	return &authn.Request{HTTPRequest: &http.Request{TLS: &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{cert, ca.cert}},
+		OCSPResponse:   staple,
+	}}}
+}
+
+func mtlsConfig(modify func(s *setting.AuthMTLSSettings)) *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.AuthMTLS = setting.AuthMTLSSettings{
+		Enabled:       true,
+		LoginTemplate: "{{ .Subject.CommonName }}",
+		EmailTemplate: "{{ first .EmailAddresses }}",
+		NameTemplate:  "{{ .Subject.CommonName }}",
+		RoleTemplate:  `{{ if has "ops" .Subject.OrganizationalUnit }}Admin{{ else }}Viewer{{ end }}`,
+		AutoSignUp:    true,
+	}
+	if modify != nil {
+		modify(&cfg.AuthMTLS)
+	}
+	return cfg
+}
+
+func TestMTLS_Authenticate(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	alice := ca.issue(t, 10, pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"ops"}}, "alice@example.com")
+	bob := ca.issue(t, 11, pkix.Name{CommonName: "bob"})
+
+	t.Run("maps the certificate fields to the identity", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(nil))
+		require.NoError(t, err)
+
+		r := mtlsRequest(ca, alice, nil)
+		require.True(t, c.Test(context.Background(), r))
+
+		id, err := c.Authenticate(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, login.MTLSAuthModule, id.AuthenticatedBy)
+		assert.Equal(t, "CN=alice,OU=ops", id.AuthID)
+		assert.Equal(t, "alice", id.Login)
+		assert.Equal(t, "alice@example.com", id.Email)
+		assert.Equal(t, "alice", id.Name)
+		assert.Equal(t, map[int64]org.RoleType{1: org.RoleAdmin}, id.OrgRoles)
+		assert.Equal(t, authn.ClientParams{
+			SyncUser:        true,
+			AllowSignUp:     true,
+			FetchSyncedUser: true,
+			SyncOrgRoles:    true,
+			SyncPermissions: true,
+			LookUpParams: login.UserLookupParams{
+				Login: &id.Login,
+				Email: &id.Email,
+			},
+		}, id.ClientParams)
+
+		id, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.NoError(t, err)
+		assert.Equal(t, "bob", id.Login)
+		assert.Empty(t, id.Email)
+		assert.Nil(t, id.ClientParams.LookUpParams.Email)
+		assert.Equal(t, map[int64]org.RoleType{1: org.RoleViewer}, id.OrgRoles)
+	})
+
+	t.Run("assigns the Grafana admin role only if allowed", func(t *testing.T) {
+		modify := func(s *setting.AuthMTLSSettings) { s.RoleTemplate = "GrafanaAdmin" }
+		c, err := ProvideMTLS(mtlsConfig(modify))
+		require.NoError(t, err)
+		id, err := c.Authenticate(context.Background(), mtlsRequest(ca, alice, nil))
+		require.NoError(t, err)
+		assert.Nil(t, id.IsGrafanaAdmin)
+
+		c, err = ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) {
+			modify(s)
+			s.AllowAssignGrafanaAdmin = true
+		}))
+		require.NoError(t, err)
+		id, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, nil))
+		require.NoError(t, err)
+		require.NotNil(t, id.IsGrafanaAdmin)
+		assert.True(t, *id.IsGrafanaAdmin)
+	})
+
+	t.Run("rejects invalid roles in strict mode", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) {
+			s.RoleTemplate = "{{ first .Subject.OrganizationalUnit }}"
+			s.RoleAttributeStrict = true
+		}))
+		require.NoError(t, err)
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, nil))
+		require.ErrorIs(t, err, errMTLSInvalidRole)
+	})
+
+	t.Run("requires a login or an email", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.LoginTemplate = "{{ first .DNSNames }}" }))
+		require.NoError(t, err)
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.ErrorIs(t, err, errMTLSMissingIdentity)
+	})
+
+	t.Run("rejects invalid templates", func(t *testing.T) {
+		_, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.LoginTemplate = "{{ .Subject" }))
+		require.ErrorContains(t, err, "invalid login_template")
+	})
+
+	t.Run("ignores requests without a verified certificate", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(nil))
+		require.NoError(t, err)
+		assert.False(t, c.Test(context.Background(), &authn.Request{HTTPRequest: &http.Request{}}))
+		assert.False(t, c.Test(context.Background(), &authn.Request{HTTPRequest: &http.Request{TLS: &tls.ConnectionState{}}}))
+	})
+}
+
+func TestMTLS_Revocation(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	other := newTestCA(t, "Other CA")
+	alice := ca.issue(t, 10, pkix.Name{CommonName: "alice"})
+	bob := ca.issue(t, 11, pkix.Name{CommonName: "bob"})
+
+	writeCRL := func(t *testing.T, content ...[]byte) string {
+		path := filepath.Join(t.TempDir(), "crl.pem")
+		var data []byte
+		for _, c := range content {
+			data = append(data, c...)
+		}
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+
+	t.Run("rejects certificates revoked by the CRL", func(t *testing.T) {
+		path := writeCRL(t, other.crl(t, time.Now().Add(time.Hour), 11), ca.crl(t, time.Now().Add(time.Hour), 10))
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.CRLFile = path }))
+		require.NoError(t, err)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, nil))
+		require.ErrorIs(t, err, errMTLSRevokedCertificate)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.NoError(t, err)
+	})
+
+	t.Run("reloads the CRL once modified", func(t *testing.T) {
+		path := writeCRL(t, ca.crl(t, time.Now().Add(time.Hour)))
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.CRLFile = path }))
+		require.NoError(t, err)
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(path, ca.crl(t, time.Now().Add(time.Hour), 11), 0600))
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.ErrorIs(t, err, errMTLSRevokedCertificate)
+	})
+
+	t.Run("fails closed on an expired CRL", func(t *testing.T) {
+		path := writeCRL(t, ca.crl(t, time.Now().Add(-time.Minute)))
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.CRLFile = path }))
+		require.NoError(t, err)
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, bob, nil))
+		require.ErrorIs(t, err, errMTLSRevocationCheck)
+	})
+
+	t.Run("rejects an invalid CRL file", func(t *testing.T) {
+		_, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.CRLFile = writeCRL(t, []byte("invalid")) }))
+		require.Error(t, err)
+	})
+
+	t.Run("checks the stapled OCSP response", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(nil))
+		require.NoError(t, err)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, ca.ocsp(t, alice, ocsp.Good)))
+		require.NoError(t, err)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, ca.ocsp(t, alice, ocsp.Revoked)))
+		require.ErrorIs(t, err, errMTLSRevokedCertificate)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, other.ocsp(t, alice, ocsp.Good)))
+		require.ErrorIs(t, err, errMTLSInvalidCertificate)
+	})
+
+	t.Run("requires a stapled OCSP response if configured", func(t *testing.T) {
+		c, err := ProvideMTLS(mtlsConfig(func(s *setting.AuthMTLSSettings) { s.RequireOCSPStaple = true }))
+		require.NoError(t, err)
+
+		_, err = c.Authenticate(context.Background(), mtlsRequest(ca, alice, nil))
+		require.ErrorIs(t, err, errMTLSInvalidCertificate)
+	})
+}
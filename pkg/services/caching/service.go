@@ -2,8 +2,22 @@ package caching
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 const (
@@ -35,10 +49,6 @@ type CachedResourceDataResponse struct {
 	UpdateCacheFn CacheResourceResponseFn
 }
 
-func ProvideCachingService() *OSSCachingService {
-	return &OSSCachingService{}
-}
-
 type CachingService interface {
 	// HandleQueryRequest uses a QueryDataRequest to check the cache for any existing results for that query.
 	// If none are found, it should return false and a CachedQueryDataResponse with an UpdateCacheFn which can be used to update the results cache after the fact.
@@ -49,16 +59,315 @@ type CachingService interface {
 	HandleResourceRequest(context.Context, *backend.CallResourceRequest) (bool, CachedResourceDataResponse)
 }
 
-// Implementation of interface - does nothing
+// OSSCachingService caches query results and resource responses of data sources in the remote cache.
+// The zero value does not cache anything.
 type OSSCachingService struct {
+	settings setting.CachingSettings
+	// sendUserHeader is set if the X-Grafana-User header is sent to all the data sources.
+	sendUserHeader bool
+	cache          remotecache.CacheStorage
+	log            log.Logger
+	requests       *prometheus.CounterVec
+	now            func() time.Time
+}
+
+func ProvideCachingService(cfg *setting.Cfg, cache remotecache.CacheStorage, reg prometheus.Registerer) *OSSCachingService {
+	return &OSSCachingService{
+		settings:       cfg.Caching,
+		sendUserHeader: cfg.SendUserHeader,
+		cache:          cache,
+		log:            log.New("caching"),
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "caching",
+			Name:      "requests_total",
+			Help:      "Number of query and resource requests handled by the caching service, by cache status.",
+		}, []string{"request", "cache"}),
+		now: time.Now,
+	}
 }
 
 func (s *OSSCachingService) HandleQueryRequest(ctx context.Context, req *backend.QueryDataRequest) (bool, CachedQueryDataResponse) {
-	return false, CachedQueryDataResponse{}
+	if !s.settings.Enabled || s.cache == nil {
+		return false, CachedQueryDataResponse{}
+	}
+
+	ds := req.PluginContext.DataSourceInstanceSettings
+	ttl := s.settings.TTL
+	if ds != nil {
+		if dsTTL, ok := s.settings.DataSourceTTLs[ds.UID]; ok {
+			ttl = dsTTL
+		}
+	}
+	if ttl <= 0 {
+		s.setStatus(ctx, queryRequest, StatusDisabled)
+		return false, CachedQueryDataResponse{}
+	}
+	user, cacheable := s.userScope(ctx, req.PluginContext)
+	if !cacheable || (s.settings.SkipRelativeTimeRanges && s.relative(req.Queries)) {
+		s.setStatus(ctx, queryRequest, StatusBypass)
+		return false, CachedQueryDataResponse{}
+	}
+
+	key, err := s.queryKey(req, user)
+	if err != nil {
+		s.log.Warn("Failed to create cache key for query", "datasource", ds.UID, "error", err)
+		s.setStatus(ctx, queryRequest, StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+
+	var cached backend.QueryDataResponse
+	if ok, err := s.get(ctx, key, &cached); err != nil {
+		s.setStatus(ctx, queryRequest, StatusError)
+		return false, CachedQueryDataResponse{}
+	} else if ok {
+		s.setStatus(ctx, queryRequest, StatusHit)
+		return true, CachedQueryDataResponse{Response: &cached}
+	}
+
+	s.setStatus(ctx, queryRequest, StatusMiss)
+	return false, CachedQueryDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.QueryDataResponse) {
+			if resp == nil {
+				return
+			}
+			for _, r := range resp.Responses {
+				// Errors are often transient, they should not be served for the whole TTL.
+				if r.Error != nil {
+					return
+				}
+			}
+			s.set(ctx, key, resp, ttl)
+		},
+	}
 }
 
 func (s *OSSCachingService) HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse) {
-	return false, CachedResourceDataResponse{}
+	if !s.settings.Enabled || s.cache == nil || s.settings.ResourceTTL <= 0 {
+		return false, CachedResourceDataResponse{}
+	}
+
+	ds := req.PluginContext.DataSourceInstanceSettings
+	user, cacheable := s.userScope(ctx, req.PluginContext)
+	if !cacheable || req.Method != http.MethodGet {
+		s.setStatus(ctx, resourceRequest, StatusBypass)
+		return false, CachedResourceDataResponse{}
+	}
+
+	key, err := resourceKey(req, user)
+	if err != nil {
+		s.log.Warn("Failed to create cache key for resource request", "datasource", ds.UID, "error", err)
+		s.setStatus(ctx, resourceRequest, StatusError)
+		return false, CachedResourceDataResponse{}
+	}
+
+	var cached backend.CallResourceResponse
+	if ok, err := s.get(ctx, key, &cached); err != nil {
+		s.setStatus(ctx, resourceRequest, StatusError)
+		return false, CachedResourceDataResponse{}
+	} else if ok {
+		s.setStatus(ctx, resourceRequest, StatusHit)
+		return true, CachedResourceDataResponse{Response: &cached}
+	}
+
+	s.setStatus(ctx, resourceRequest, StatusMiss)
+	var mtx sync.Mutex
+	responses := 0
+	return false, CachedResourceDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.CallResourceResponse) {
+			mtx.Lock()
+			responses++
+			n := responses
+			mtx.Unlock()
+
+			// A streamed response cannot be replayed from a single cache entry, so a response is only cached if it is
+			// the only one the plugin sends.
+			if n == 2 {
+				if err := s.cache.Delete(ctx, key); err != nil {
+					s.log.Warn("Failed to delete resource response from cache", "error", err)
+				}
+			}
+			if n > 1 || resp == nil || resp.Status < http.StatusOK || resp.Status >= http.StatusMultipleChoices {
+				return
+			}
+			s.set(ctx, key, resp, s.settings.ResourceTTL)
+		},
+	}
+}
+
+const (
+	queryRequest    = "query"
+	resourceRequest = "resource"
+)
+
+// userScope returns the user whose requests share the cached responses of a data source, or an empty string if all
+// the users share them. The responses of data sources that forward the identity of the user depend on the user, e.g.
+// through cookies, team headers of label based access control or ID tokens. It returns false if the responses of the
+// data source are not cached.
+func (s *OSSCachingService) userScope(ctx context.Context, pCtx backend.PluginContext) (string, bool) {
+	ds := pCtx.DataSourceInstanceSettings
+	if ds == nil {
+		return "", false
+	}
+	var jsonData struct {
+		OAuthPassThru   bool     `json:"oauthPassThru"`
+		KeepCookies     []string `json:"keepCookies"`
+		TeamHTTPHeaders struct {
+			Headers map[string]json.RawMessage `json:"headers"`
+		} `json:"teamHttpHeaders"`
+		ForwardGrafanaHeaders bool `json:"forwardGrafanaHeaders"`
+	}
+	if len(ds.JSONData) > 0 {
+		if err := json.Unmarshal(ds.JSONData, &jsonData); err != nil {
+			return "", false
+		}
+	}
+	// The OAuth token of the user expires independently of the cached responses.
+	if jsonData.OAuthPassThru {
+		return "", false
+	}
+
+	perUser := s.sendUserHeader || jsonData.ForwardGrafanaHeaders || len(jsonData.KeepCookies) > 0 || len(jsonData.TeamHTTPHeaders.Headers) > 0
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.SignedInUser != nil && reqCtx.SignedInUser.GetIDToken() != "" {
+		perUser = true
+	}
+	if !perUser {
+		return "", true
+	}
+	if pCtx.User == nil || pCtx.User.Login == "" {
+		return "", false
+	}
+	return pCtx.User.Login, true
+}
+
+// relative returns true if a query's time range ends now, e.g. "Last 6 hours".
+func (s *OSSCachingService) relative(queries []backend.DataQuery) bool {
+	window := max(s.settings.TimeRangeAlignment, time.Minute)
+	since := s.now().Add(-window)
+	for _, q := range queries {
+		if q.TimeRange.To.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+type queryCacheKey struct {
+	OrgID             int64
+	DataSourceUID     string
+	DataSourceUpdated time.Time
+	User              string `json:",omitempty"`
+	Queries           []queryCacheKeyQuery
+}
+
+type queryCacheKeyQuery struct {
+	RefID         string
+	QueryType     string
+	MaxDataPoints int64
+	Interval      time.Duration
+	From          time.Time
+	To            time.Time
+	JSON          []byte
+}
+
+// queryKey returns the cache key of a query request. The time ranges of the queries are aligned, so that requests sent
+// within the same interval share a key. The data source's update time is part of the key, so that changes to its
+// settings do not return stale results. The results of a user scoped data source are only shared by the requests of
+// the user.
+func (s *OSSCachingService) queryKey(req *backend.QueryDataRequest, user string) (string, error) {
+	ds := req.PluginContext.DataSourceInstanceSettings
+	k := queryCacheKey{
+		OrgID:             req.PluginContext.OrgID,
+		DataSourceUID:     ds.UID,
+		DataSourceUpdated: ds.Updated,
+		User:              user,
+		Queries:           make([]queryCacheKeyQuery, 0, len(req.Queries)),
+	}
+	for _, q := range req.Queries {
+		from, to := q.TimeRange.From, q.TimeRange.To
+		if s.settings.TimeRangeAlignment > 0 {
+			from, to = from.Truncate(s.settings.TimeRangeAlignment), to.Truncate(s.settings.TimeRangeAlignment)
+		}
+		k.Queries = append(k.Queries, queryCacheKeyQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			Interval:      q.Interval,
+			From:          from.UTC(),
+			To:            to.UTC(),
+			JSON:          q.JSON,
+		})
+	}
+	return hashKey(queryRequest, k)
+}
+
+type resourceCacheKey struct {
+	OrgID             int64
+	DataSourceUID     string
+	DataSourceUpdated time.Time
+	User              string `json:",omitempty"`
+	Path              string
+	URL               string
+}
+
+func resourceKey(req *backend.CallResourceRequest, user string) (string, error) {
+	ds := req.PluginContext.DataSourceInstanceSettings
+	return hashKey(resourceRequest, resourceCacheKey{
+		OrgID:             req.PluginContext.OrgID,
+		DataSourceUID:     ds.UID,
+		DataSourceUpdated: ds.Updated,
+		User:              user,
+		Path:              req.Path,
+		URL:               req.URL,
+	})
+}
+
+func hashKey(request string, k any) (string, error) {
+	b, err := json.Marshal(k)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "caching:" + request + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// get reads the value of a key into v, and returns false if the key is not in the cache.
+func (s *OSSCachingService) get(ctx context.Context, key string, v any) (bool, error) {
+	b, err := s.cache.Get(ctx, key)
+	if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		s.log.Warn("Failed to read from cache", "error", err)
+		return false, err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		// The value was written by an incompatible version, it is replaced on the next update.
+		s.log.Debug("Failed to decode cached value", "key", key, "error", err)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *OSSCachingService) set(ctx context.Context, key string, v any, ttl time.Duration) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		s.log.Warn("Failed to encode value for cache", "error", err)
+		return
+	}
+	if err := s.cache.Set(ctx, key, b, ttl); err != nil {
+		s.log.Warn("Failed to write to cache", "error", err)
+	}
+}
+
+// setStatus sets the X-Cache header of the response and counts the request.
+func (s *OSSCachingService) setStatus(ctx context.Context, request, status string) {
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Context != nil {
+		reqCtx.Resp.Header().Set(XCacheHeader, status)
+	}
+	if s.requests != nil {
+		s.requests.WithLabelValues(request, status).Inc()
+	}
 }
 
 var _ CachingService = &OSSCachingService{}
@@ -0,0 +1,248 @@
+package caching
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestHandleQueryRequest(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	setup := func(t *testing.T, settings setting.CachingSettings) (*OSSCachingService, remotecache.FakeCacheStorage) {
+		t.Helper()
+		settings.Enabled = true
+		cache := remotecache.NewFakeCacheStorage()
+		s := ProvideCachingService(&setting.Cfg{Caching: settings}, cache, prometheus.NewRegistry())
+		s.now = func() time.Time { return now }
+		return s, cache
+	}
+	request := func(uid string, from, to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      1,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: uid, JSONData: []byte(`{}`)},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      []byte(`{"expr":"up"}`),
+			}},
+		}
+	}
+	response := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("up", data.NewField("value", nil, []float64{1}))}},
+	}}
+
+	t.Run("caches the response of a query", func(t *testing.T) {
+		s, _ := setup(t, setting.CachingSettings{TTL: time.Minute})
+		from := now.Add(-2 * time.Hour)
+		to := now.Add(-time.Hour)
+
+		ctx, resp := withReqContext()
+		hit, cr := s.HandleQueryRequest(ctx, request("ds", from, to))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, resp.Header().Get(XCacheHeader))
+		require.NotNil(t, cr.UpdateCacheFn)
+		cr.UpdateCacheFn(ctx, response)
+
+		ctx, resp = withReqContext()
+		hit, cr = s.HandleQueryRequest(ctx, request("ds", from, to))
+		require.True(t, hit)
+		require.Equal(t, StatusHit, resp.Header().Get(XCacheHeader))
+		require.Equal(t, 1.0, cr.Response.Responses["A"].Frames[0].Fields[0].At(0))
+
+		require.Equal(t, 1.0, testutil.ToFloat64(s.requests.WithLabelValues(queryRequest, StatusHit)))
+		require.Equal(t, 1.0, testutil.ToFloat64(s.requests.WithLabelValues(queryRequest, StatusMiss)))
+	})
+
+	t.Run("aligns the time range of queries", func(t *testing.T) {
+		s, _ := setup(t, setting.CachingSettings{TTL: time.Minute, TimeRangeAlignment: time.Minute})
+		from := now.Add(-2 * time.Hour).Truncate(time.Minute)
+		to := now.Add(-time.Hour).Truncate(time.Minute)
+
+		_, cr := s.HandleQueryRequest(context.Background(), request("ds", from, to))
+		cr.UpdateCacheFn(context.Background(), response)
+
+		hit, _ := s.HandleQueryRequest(context.Background(), request("ds", from.Add(20*time.Second), to.Add(20*time.Second)))
+		require.True(t, hit)
+		hit, _ = s.HandleQueryRequest(context.Background(), request("ds", from.Add(time.Minute), to.Add(time.Minute)))
+		require.False(t, hit)
+		hit, _ = s.HandleQueryRequest(context.Background(), request("other", from, to))
+		require.False(t, hit)
+	})
+
+	t.Run("does not cache responses with errors", func(t *testing.T) {
+		s, cache := setup(t, setting.CachingSettings{TTL: time.Minute})
+		_, cr := s.HandleQueryRequest(context.Background(), request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour)))
+		cr.UpdateCacheFn(context.Background(), &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.ErrDataResponse(backend.StatusBadRequest, "bad query"),
+		}})
+		require.Empty(t, cache.Storage)
+	})
+
+	t.Run("uses the TTL of the data source", func(t *testing.T) {
+		s, _ := setup(t, setting.CachingSettings{TTL: time.Minute, DataSourceTTLs: map[string]time.Duration{"ds": 0}})
+
+		ctx, resp := withReqContext()
+		hit, cr := s.HandleQueryRequest(ctx, request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour)))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusDisabled, resp.Header().Get(XCacheHeader))
+	})
+
+	t.Run("skips relative time ranges if configured", func(t *testing.T) {
+		s, _ := setup(t, setting.CachingSettings{TTL: time.Minute, TimeRangeAlignment: time.Minute, SkipRelativeTimeRanges: true})
+
+		ctx, resp := withReqContext()
+		_, cr := s.HandleQueryRequest(ctx, request("ds", now.Add(-time.Hour), now))
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusBypass, resp.Header().Get(XCacheHeader))
+
+		_, cr = s.HandleQueryRequest(context.Background(), request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour)))
+		require.NotNil(t, cr.UpdateCacheFn)
+	})
+
+	t.Run("skips data sources that forward the user's OAuth identity", func(t *testing.T) {
+		s, _ := setup(t, setting.CachingSettings{TTL: time.Minute})
+		req := request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour))
+		req.PluginContext.DataSourceInstanceSettings.JSONData = []byte(`{"oauthPassThru":true}`)
+
+		_, cr := s.HandleQueryRequest(context.Background(), req)
+		require.Nil(t, cr.UpdateCacheFn)
+	})
+
+	t.Run("caches the responses of data sources that forward the user's identity by user", func(t *testing.T) {
+		for name, jsonData := range map[string]string{
+			"cookies":           `{"keepCookies":["session"]}`,
+			"team headers":      `{"teamHttpHeaders":{"headers":{"1":[{"header":"X-Prom-Label-Policy","value":"1:{job=\"a\"}"}]}}}`,
+			"Grafana headers":   `{"forwardGrafanaHeaders":true}`,
+			"no team headers":   `{"teamHttpHeaders":{"headers":{}}}`,
+			"user header (cfg)": `{}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, _ := setup(t, setting.CachingSettings{TTL: time.Minute})
+				s.sendUserHeader = name == "user header (cfg)"
+				perUser := name != "no team headers"
+				req := func(login string) *backend.QueryDataRequest {
+					req := request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour))
+					req.PluginContext.DataSourceInstanceSettings.JSONData = []byte(jsonData)
+					if login != "" {
+						req.PluginContext.User = &backend.User{Login: login}
+					}
+					return req
+				}
+
+				_, cr := s.HandleQueryRequest(context.Background(), req("alice"))
+				cr.UpdateCacheFn(context.Background(), response)
+
+				hit, _ := s.HandleQueryRequest(context.Background(), req("alice"))
+				require.True(t, hit)
+				hit, _ = s.HandleQueryRequest(context.Background(), req("bob"))
+				require.Equal(t, !perUser, hit)
+				if perUser {
+					_, cr = s.HandleQueryRequest(context.Background(), req(""))
+					require.Nil(t, cr.UpdateCacheFn)
+				}
+			})
+		}
+	})
+
+	t.Run("does nothing if caching is disabled", func(t *testing.T) {
+		s := &OSSCachingService{}
+
+		ctx, resp := withReqContext()
+		hit, cr := s.HandleQueryRequest(ctx, request("ds", now.Add(-2*time.Hour), now.Add(-time.Hour)))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Empty(t, resp.Header().Get(XCacheHeader))
+	})
+}
+
+func TestHandleResourceRequest(t *testing.T) {
+	cache := remotecache.NewFakeCacheStorage()
+	s := ProvideCachingService(&setting.Cfg{Caching: setting.CachingSettings{Enabled: true, ResourceTTL: time.Minute}}, cache, prometheus.NewRegistry())
+	request := func(method, url string) *backend.CallResourceRequest {
+		return &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      1,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+			},
+			Method: method,
+			Path:   "labels",
+			URL:    url,
+		}
+	}
+
+	t.Run("caches the response of a GET request", func(t *testing.T) {
+		_, cr := s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=up"))
+		cr.UpdateCacheFn(context.Background(), &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(`["job"]`)})
+
+		ctx, resp := withReqContext()
+		hit, cr := s.HandleResourceRequest(ctx, request(http.MethodGet, "labels?match=up"))
+		require.True(t, hit)
+		require.Equal(t, StatusHit, resp.Header().Get(XCacheHeader))
+		require.Equal(t, []byte(`["job"]`), cr.Response.Body)
+
+		hit, _ = s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=down"))
+		require.False(t, hit)
+	})
+
+	t.Run("does not cache failed requests", func(t *testing.T) {
+		_, cr := s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=error"))
+		cr.UpdateCacheFn(context.Background(), &backend.CallResourceResponse{Status: http.StatusInternalServerError})
+
+		hit, _ := s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=error"))
+		require.False(t, hit)
+	})
+
+	t.Run("does not cache streamed responses", func(t *testing.T) {
+		_, cr := s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=stream"))
+		cr.UpdateCacheFn(context.Background(), &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte("a")})
+		cr.UpdateCacheFn(context.Background(), &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte("b")})
+
+		hit, _ := s.HandleResourceRequest(context.Background(), request(http.MethodGet, "labels?match=stream"))
+		require.False(t, hit)
+	})
+
+	t.Run("caches the responses of data sources that forward the user's identity by user", func(t *testing.T) {
+		req := func(login string) *backend.CallResourceRequest {
+			req := request(http.MethodGet, "labels?match=team")
+			req.PluginContext.DataSourceInstanceSettings.JSONData = []byte(`{"teamHttpHeaders":{"headers":{"1":[]}}}`)
+			req.PluginContext.User = &backend.User{Login: login}
+			return req
+		}
+		_, cr := s.HandleResourceRequest(context.Background(), req("alice"))
+		cr.UpdateCacheFn(context.Background(), &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(`["job"]`)})
+
+		hit, _ := s.HandleResourceRequest(context.Background(), req("alice"))
+		require.True(t, hit)
+		hit, _ = s.HandleResourceRequest(context.Background(), req("bob"))
+		require.False(t, hit)
+	})
+
+	t.Run("bypasses requests that are not GET requests", func(t *testing.T) {
+		ctx, resp := withReqContext()
+		_, cr := s.HandleResourceRequest(ctx, request(http.MethodPost, "labels"))
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusBypass, resp.Header().Get(XCacheHeader))
+	})
+}
+
+func withReqContext() (context.Context, web.ResponseWriter) {
+	resp := web.NewResponseWriter(http.MethodGet, httptest.NewRecorder())
+	return ctxkey.Set(context.Background(), &contextmodel.ReqContext{Context: &web.Context{Resp: resp}}), resp
+}
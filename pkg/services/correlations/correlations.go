@@ -55,6 +55,7 @@ type Service interface {
 	DeleteCorrelation(ctx context.Context, cmd DeleteCorrelationCommand) error
 	DeleteCorrelationsBySourceUID(ctx context.Context, cmd DeleteCorrelationsBySourceUIDCommand) error
 	DeleteCorrelationsByTargetUID(ctx context.Context, cmd DeleteCorrelationsByTargetUIDCommand) error
+	ResolveCorrelation(ctx context.Context, cmd ResolveCorrelationCommand) (ResolvedCorrelation, error)
 }
 
 type CorrelationsService struct {
@@ -111,6 +112,19 @@ func (s CorrelationsService) DeleteCorrelationsByTargetUID(ctx context.Context,
 	return s.deleteCorrelationsByTargetUID(ctx, cmd)
 }
 
+func (s CorrelationsService) ResolveCorrelation(ctx context.Context, cmd ResolveCorrelationCommand) (ResolvedCorrelation, error) {
+	correlation, err := s.getCorrelation(ctx, GetCorrelationQuery{
+		UID:       cmd.UID,
+		SourceUID: cmd.SourceUID,
+		OrgId:     cmd.OrgId,
+	})
+	if err != nil {
+		return ResolvedCorrelation{}, err
+	}
+
+	return correlation.Resolve(cmd.Frame, cmd.RowIndex)
+}
+
 func (s CorrelationsService) handleDatasourceDeletion(ctx context.Context, event *events.DataSourceDeleted) error {
 	return s.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
 		if err := s.deleteCorrelationsBySourceUID(ctx, DeleteCorrelationsBySourceUIDCommand{
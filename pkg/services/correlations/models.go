@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/services/quota"
 )
@@ -18,9 +21,13 @@ var (
 	ErrInvalidTransformationType     = errors.New("invalid transformation type")
 	ErrTransformationNotNested       = errors.New("transformations must be nested under config")
 	ErrTransformationRegexReqExp     = errors.New("regex transformations require expression")
+	ErrTransformationJSONPathReqExp  = errors.New("jsonpath transformations require expression")
 	ErrCorrelationsQuotaFailed       = errors.New("error getting correlations quota")
 	ErrCorrelationsQuotaReached      = errors.New("correlations quota reached")
 	ErrInvalidConfigType             = errors.New("correlation contains non default value in config.type")
+	ErrInvalidRowIndex               = errors.New("row index is out of range")
+	ErrFieldNotFound                 = errors.New("field not found in frame")
+	ErrVariableNotDefined            = errors.New("variable used in the correlation target is not defined")
 )
 
 const (
@@ -37,8 +44,16 @@ const (
 	external CorrelationType = "external"
 )
 
+// the type of transformation applied to the source field value to extract variables
+const (
+	TransformationRegex    = "regex"
+	TransformationLogfmt   = "logfmt"
+	TransformationJSONPath = "jsonpath"
+	TransformationLabel    = "label"
+)
+
 type Transformation struct {
-	//Enum: regex,logfmt
+	//Enum: regex,logfmt,jsonpath,label
 	Type       string `json:"type"`
 	Expression string `json:"expression,omitempty"`
 	Field      string `json:"field,omitempty"`
@@ -54,10 +69,24 @@ func (t CorrelationType) Validate() error {
 
 func (t Transformations) Validate() error {
 	for _, v := range t {
-		if v.Type != "regex" && v.Type != "logfmt" {
+		switch v.Type {
+		case TransformationRegex:
+			if len(v.Expression) == 0 {
+				return fmt.Errorf("%s: \"%s\"", ErrTransformationRegexReqExp, t)
+			}
+			if _, err := regexp.Compile(v.Expression); err != nil {
+				return fmt.Errorf("%s: %w", ErrTransformationRegexReqExp, err)
+			}
+		case TransformationJSONPath:
+			if len(v.Expression) == 0 {
+				return fmt.Errorf("%s: \"%s\"", ErrTransformationJSONPathReqExp, t)
+			}
+			if _, err := parseJSONPath(v.Expression); err != nil {
+				return fmt.Errorf("%s: %w", ErrTransformationJSONPathReqExp, err)
+			}
+		case TransformationLogfmt, TransformationLabel:
+		default:
 			return fmt.Errorf("%s: \"%s\"", ErrInvalidTransformationType, t)
-		} else if v.Type == "regex" && len(v.Expression) == 0 {
-			return fmt.Errorf("%s: \"%s\"", ErrTransformationRegexReqExp, t)
 		}
 	}
 	return nil
@@ -242,6 +271,12 @@ func (c UpdateCorrelationCommand) Validate() error {
 		return ErrUpdateCorrelationEmptyParams
 	}
 
+	if c.Config != nil {
+		if err := Transformations(c.Config.Transformations).Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -290,3 +325,43 @@ type DeleteCorrelationsByTargetUIDCommand struct {
 	TargetUID string
 	OrgId     int64
 }
+
+// ResolveCorrelationCommand is the command for resolving the target of a correlation for a row of a data frame
+// swagger:model
+type ResolveCorrelationCommand struct {
+	// UID of the correlation to be resolved.
+	UID       string `json:"-"`
+	SourceUID string `json:"-"`
+	OrgId     int64  `json:"-"`
+
+	// Data frame returned by the source data source
+	// required:true
+	Frame *data.Frame `json:"frame" binding:"Required"`
+	// Index of the row of the frame the correlation is resolved for
+	// example: 0
+	RowIndex int `json:"rowIndex"`
+}
+
+// ResolvedCorrelation is the target of a correlation interpolated with the values of a data frame row
+// swagger:model
+type ResolvedCorrelation struct {
+	// Unique identifier of the correlation
+	// example: 50xhMlg9k
+	UID string `json:"uid"`
+	// Label identifying the correlation
+	// example: My Label
+	Label string `json:"label"`
+	// The type of correlation
+	Type CorrelationType `json:"type"`
+	// UID of the data source the query is run against, set for query correlations
+	// example: PE1C5CBDA0504A6A3
+	TargetUID *string `json:"targetUID,omitempty"`
+	// Interpolated target data query, set for query correlations
+	// example: {"expr":"{job=\"app\"} |= \"abc\""}
+	Query map[string]any `json:"query,omitempty"`
+	// Interpolated URL, set for external correlations
+	// example: https://example.com/trace/abc
+	URL string `json:"url,omitempty"`
+	// Variables available for the interpolation
+	Variables map[string]string `json:"variables"`
+}
@@ -75,6 +75,27 @@ func TestCorrelationModels(t *testing.T) {
 		})
 	})
 
+	t.Run("Transformations Validate", func(t *testing.T) {
+		type test struct {
+			input     Transformations
+			assertion require.ErrorAssertionFunc
+		}
+
+		tests := []test{
+			{input: Transformations{{Type: "logfmt"}, {Type: "label"}}, assertion: require.NoError},
+			{input: Transformations{{Type: "regex", Expression: "(\\w+)"}, {Type: "jsonpath", Expression: "$.user.id"}}, assertion: require.NoError},
+			{input: Transformations{{Type: "regex"}}, assertion: require.Error},
+			{input: Transformations{{Type: "regex", Expression: "(unclosed"}}, assertion: require.Error},
+			{input: Transformations{{Type: "jsonpath"}}, assertion: require.Error},
+			{input: Transformations{{Type: "jsonpath", Expression: "$.spans[first]"}}, assertion: require.Error},
+			{input: Transformations{{Type: "xpath", Expression: "/user"}}, assertion: require.Error},
+		}
+
+		for _, tc := range tests {
+			tc.assertion(t, tc.input.Validate())
+		}
+	})
+
 	t.Run("CorrelationConfig JSON Marshaling", func(t *testing.T) {
 		t.Run("Applies a default empty object if target is not defined", func(t *testing.T) {
 			config := CorrelationConfig{
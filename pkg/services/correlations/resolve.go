@@ -0,0 +1,129 @@
+package correlations
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// variableRegex matches the $var, [[var]] and ${var} template variable syntaxes.
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::\w+)?\]\]|\$\{(\w+)(?:\.[^:}]+)?(?::[^}]+)?\}`)
+
+// Resolve interpolates the target of the correlation with the values of a row of the frame. Every field of the
+// frame is available as a variable named after the field, the value of the field the correlation is attached to
+// is available as __value, and transformations add the variables they extract.
+func (c Correlation) Resolve(frame *data.Frame, rowIndex int) (ResolvedCorrelation, error) {
+	if frame == nil || rowIndex < 0 || rowIndex >= frame.Rows() {
+		return ResolvedCorrelation{}, ErrInvalidRowIndex
+	}
+	field, _ := frame.FieldByName(c.Config.Field)
+	if field == nil {
+		return ResolvedCorrelation{}, fmt.Errorf("%w: %s", ErrFieldNotFound, c.Config.Field)
+	}
+
+	vars := map[string]string{}
+	for _, f := range frame.Fields {
+		vars[f.Name] = fieldValueString(f, rowIndex)
+	}
+	vars["__value"] = fieldValueString(field, rowIndex)
+
+	for _, t := range c.Config.Transformations {
+		source := field
+		if t.Field != "" {
+			if source, _ = frame.FieldByName(t.Field); source == nil {
+				return ResolvedCorrelation{}, fmt.Errorf("%w: %s", ErrFieldNotFound, t.Field)
+			}
+		}
+		extracted, err := t.apply(source, rowIndex, field.Name)
+		if err != nil {
+			return ResolvedCorrelation{}, err
+		}
+		for k, v := range extracted {
+			vars[k] = v
+		}
+	}
+
+	resolved := ResolvedCorrelation{
+		UID:       c.UID,
+		Label:     c.Label,
+		Type:      c.Type,
+		Variables: vars,
+	}
+	switch c.Type {
+	case external:
+		target, _ := c.Config.Target["url"].(string)
+		u, err := interpolate(target, vars, func(v string) string {
+			return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+		})
+		if err != nil {
+			return ResolvedCorrelation{}, err
+		}
+		resolved.URL = u
+	default:
+		query, err := interpolateValue(c.Config.Target, vars)
+		if err != nil {
+			return ResolvedCorrelation{}, err
+		}
+		resolved.TargetUID = c.TargetUID
+		resolved.Query, _ = query.(map[string]any)
+		if resolved.Query == nil {
+			resolved.Query = map[string]any{}
+		}
+	}
+	return resolved, nil
+}
+
+// interpolateValue returns a copy of the value with the variables in all its strings replaced.
+func interpolateValue(value any, vars map[string]string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return interpolate(v, vars, nil)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			interpolated, err := interpolateValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = interpolated
+		}
+		return m, nil
+	case []any:
+		a := make([]any, len(v))
+		for i, item := range v {
+			interpolated, err := interpolateValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = interpolated
+		}
+		return a, nil
+	default:
+		return v, nil
+	}
+}
+
+// interpolate replaces the variables in s. Undefined built-in variables like $__interval are kept for the
+// target data source to interpolate, any other undefined variable is an error.
+func interpolate(s string, vars map[string]string, format func(string) string) (string, error) {
+	var err error
+	result := variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		name := groups[1] + groups[2] + groups[3]
+		v, ok := vars[name]
+		if !ok {
+			if !strings.HasPrefix(name, "__") && err == nil {
+				err = fmt.Errorf("%w: %s", ErrVariableNotDefined, name)
+			}
+			return match
+		}
+		if format != nil {
+			return format(v)
+		}
+		return v
+	})
+	return result, err
+}
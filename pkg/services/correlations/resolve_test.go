@@ -0,0 +1,120 @@
+package correlations
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationResolve(t *testing.T) {
+	targetUID := "loki"
+	frame := data.NewFrame("traces",
+		data.NewField("traceID", nil, []string{"abc", "def"}),
+		data.NewField("service", data.Labels{"cluster": "eu"}, []string{`{"namespace":"prod","pod":"app-1"}`, `{"namespace":"dev"}`}),
+		data.NewField("message", nil, []string{"level=error user=alice msg=failed", "level=info"}),
+		data.NewField("attributes", nil, []json.RawMessage{json.RawMessage(`{"http":{"status":500},"spans":[{"id":"s1"}]}`), json.RawMessage(`{}`)}),
+	)
+	correlation := func(target map[string]any, transformations ...Transformation) Correlation {
+		return Correlation{
+			UID:       "correlation",
+			TargetUID: &targetUID,
+			Type:      query,
+			Config: CorrelationConfig{
+				Field:           "message",
+				Target:          target,
+				Transformations: transformations,
+			},
+		}
+	}
+
+	t.Run("interpolates the target query with the field values", func(t *testing.T) {
+		resolved, err := correlation(map[string]any{
+			"expr":    `{job="app"} |= "${traceID}" |= "$__value"`,
+			"filters": []any{map[string]any{"value": "[[traceID]]"}},
+			"limit":   float64(10),
+		}).Resolve(frame, 0)
+		require.NoError(t, err)
+		require.Equal(t, &targetUID, resolved.TargetUID)
+		require.Equal(t, map[string]any{
+			"expr":    `{job="app"} |= "abc" |= "level=error user=alice msg=failed"`,
+			"filters": []any{map[string]any{"value": "abc"}},
+			"limit":   float64(10),
+		}, resolved.Query)
+	})
+
+	t.Run("keeps undefined built-in variables", func(t *testing.T) {
+		resolved, err := correlation(map[string]any{"expr": `rate(errors{trace="$traceID"}[$__rate_interval])`}).Resolve(frame, 1)
+		require.NoError(t, err)
+		require.Equal(t, `rate(errors{trace="def"}[$__rate_interval])`, resolved.Query["expr"])
+	})
+
+	t.Run("fails if a variable is not defined", func(t *testing.T) {
+		_, err := correlation(map[string]any{"expr": "${user}"}).Resolve(frame, 1)
+		require.ErrorIs(t, err, ErrVariableNotDefined)
+	})
+
+	t.Run("fails if the row or field does not exist", func(t *testing.T) {
+		_, err := correlation(map[string]any{}).Resolve(frame, 2)
+		require.ErrorIs(t, err, ErrInvalidRowIndex)
+
+		c := correlation(map[string]any{})
+		c.Config.Field = "unknown"
+		_, err = c.Resolve(frame, 0)
+		require.ErrorIs(t, err, ErrFieldNotFound)
+	})
+
+	t.Run("applies transformations", func(t *testing.T) {
+		resolved, err := correlation(map[string]any{"expr": "$user $status $span $pod $cluster $id"},
+			Transformation{Type: TransformationLogfmt},
+			Transformation{Type: TransformationJSONPath, Field: "attributes", Expression: "$.http.status", MapValue: "status"},
+			Transformation{Type: TransformationJSONPath, Field: "attributes", Expression: "spans[0]['id']", MapValue: "span"},
+			Transformation{Type: TransformationLabel, Field: "service"},
+			Transformation{Type: TransformationRegex, Field: "traceID", Expression: "(?P<id>b.)"},
+		).Resolve(frame, 0)
+		require.NoError(t, err)
+		require.Equal(t, "alice 500 s1 app-1 eu bc", resolved.Query["expr"])
+		require.Equal(t, "error", resolved.Variables["level"])
+		require.Equal(t, "prod", resolved.Variables["namespace"])
+	})
+
+	t.Run("escapes variables in external URLs", func(t *testing.T) {
+		c := correlation(map[string]any{"url": "https://example.com/search?q=${level}&trace=${traceID}"},
+			Transformation{Type: TransformationRegex, Expression: `level=(\S+) user`, MapValue: "level"})
+		c.Type = external
+		c.TargetUID = nil
+		frame := data.NewFrame("", data.NewField("message", nil, []string{"level=a&b user"}), data.NewField("traceID", nil, []string{"a b"}))
+
+		resolved, err := c.Resolve(frame, 0)
+		require.NoError(t, err)
+		require.Nil(t, resolved.Query)
+		require.Equal(t, "https://example.com/search?q=a%26b&trace=a%20b", resolved.URL)
+	})
+}
+
+func TestTransformationApply(t *testing.T) {
+	field := data.NewField("labels", data.Labels{"job": "app"}, []string{`{level="error", pod="app-1"}`})
+
+	t.Run("regex without groups uses the field name", func(t *testing.T) {
+		vars, err := Transformation{Type: TransformationRegex, Expression: "ERROR"}.apply(field, 0, "message")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"message": "error"}, vars)
+	})
+
+	t.Run("label extracts a single label", func(t *testing.T) {
+		vars, err := Transformation{Type: TransformationLabel, Expression: "pod", MapValue: "instance"}.apply(field, 0, "message")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"instance": "app-1"}, vars)
+
+		vars, err = Transformation{Type: TransformationLabel, Expression: "job"}.apply(field, 0, "message")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"job": "app"}, vars)
+	})
+
+	t.Run("jsonpath ignores values which are not JSON", func(t *testing.T) {
+		vars, err := Transformation{Type: TransformationJSONPath, Expression: "$.level"}.apply(data.NewField("message", nil, []string{"level=error"}), 0, "message")
+		require.NoError(t, err)
+		require.Empty(t, vars)
+	})
+}
@@ -0,0 +1,228 @@
+package correlations
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// apply extracts variables from the value of the field at the given row. Variables which are not named by the
+// transformation are named after the field the correlation is attached to, like the frontend does.
+func (t Transformation) apply(field *data.Field, rowIndex int, fieldName string) (map[string]string, error) {
+	value := fieldValueString(field, rowIndex)
+	name := fieldName
+	if t.MapValue != "" {
+		name = t.MapValue
+	}
+
+	vars := map[string]string{}
+	switch t.Type {
+	case TransformationRegex:
+		re, err := regexp.Compile("(?i)" + t.Expression)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range re.FindAllStringSubmatch(value, -1) {
+			named := false
+			for i, group := range re.SubexpNames() {
+				if group != "" {
+					vars[group] = match[i]
+					named = true
+				}
+			}
+			if named {
+				continue
+			}
+			if len(match) > 1 && match[1] != "" {
+				vars[name] = match[1]
+			} else {
+				vars[name] = match[0]
+			}
+		}
+	case TransformationLogfmt:
+		d := logfmt.NewDecoder(strings.NewReader(value))
+		for d.ScanRecord() {
+			for d.ScanKeyval() {
+				vars[string(d.Key())] = string(d.Value())
+			}
+		}
+	case TransformationJSONPath:
+		path, err := parseJSONPath(t.Expression)
+		if err != nil {
+			return nil, err
+		}
+		var obj any
+		if err := json.Unmarshal([]byte(value), &obj); err != nil {
+			// Values which are not JSON have nothing to extract.
+			return vars, nil
+		}
+		if v, ok := path.find(obj); ok {
+			vars[name] = jsonValueString(v)
+		}
+	case TransformationLabel:
+		labels := fieldLabels(field, value)
+		if t.Expression == "" {
+			for k, v := range labels {
+				vars[k] = v
+			}
+			break
+		}
+		if v, ok := labels[t.Expression]; ok {
+			if t.MapValue == "" {
+				name = t.Expression
+			}
+			vars[name] = v
+		}
+	default:
+		return nil, fmt.Errorf("%s: \"%s\"", ErrInvalidTransformationType, t.Type)
+	}
+	return vars, nil
+}
+
+// fieldLabels returns the labels of the field merged with the labels in the value, which can be a JSON object
+// like the labels field of Loki frames or a string like {job="app", level="error"}.
+func fieldLabels(field *data.Field, value string) data.Labels {
+	labels := data.Labels{}
+	for k, v := range field.Labels {
+		labels[k] = v
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(value), &obj); err == nil {
+		for k, v := range obj {
+			labels[k] = jsonValueString(v)
+		}
+		return labels
+	}
+	if parsed, err := data.LabelsFromString(value); err == nil {
+		for k, v := range parsed {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+func fieldValueString(field *data.Field, rowIndex int) string {
+	v, ok := field.ConcreteAt(rowIndex)
+	if !ok {
+		return ""
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.RawMessage:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func jsonValueString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// jsonPath is a parsed JSONPath expression. Only child and index selectors are supported, for example
+// $.user.id, $.spans[0].traceId or $['service name'].
+type jsonPath []jsonPathSegment
+
+type jsonPathSegment struct {
+	key   string
+	index int
+	isKey bool
+}
+
+func parseJSONPath(expr string) (jsonPath, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	var path jsonPath
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in JSONPath %q", expr)
+			}
+			path = append(path, jsonPathSegment{key: s[:end], isKey: true})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in JSONPath %q", expr)
+			}
+			selector := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				path = append(path, jsonPathSegment{key: selector[1 : len(selector)-1], isKey: true})
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %q in JSONPath %q", selector, expr)
+			}
+			path = append(path, jsonPathSegment{index: index})
+		default:
+			if len(path) > 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q", expr)
+			}
+			// Allow paths without the root, like user.id.
+			s = "." + s
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty JSONPath %q", expr)
+	}
+	return path, nil
+}
+
+func (p jsonPath) find(obj any) (any, bool) {
+	for _, segment := range p {
+		if segment.isKey {
+			m, ok := obj.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if obj, ok = m[segment.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		a, ok := obj.([]any)
+		if !ok {
+			return nil, false
+		}
+		index := segment.index
+		if index < 0 {
+			index += len(a)
+		}
+		if index < 0 || index >= len(a) {
+			return nil, false
+		}
+		obj = a[index]
+	}
+	return obj, true
+}
@@ -221,6 +221,7 @@ type DashboardProvisioning struct {
 	ExternalID  string `xorm:"external_id"`
 	CheckSum    string
 	Updated     int64
+	CommitSHA   string `xorm:"commit_sha"`
 }
 
 type DeleteDashboardCommand struct {
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -19,22 +20,38 @@ type DashboardSnapshotStore struct {
 // DashboardStore implements the Store interface
 var _ dashboardsnapshots.Store = (*DashboardSnapshotStore)(nil)
 
-func ProvideStore(db db.DB, cfg *setting.Cfg) *DashboardSnapshotStore {
+func ProvideStore(db db.DB, cfg *setting.Cfg, quotaService quota.Service) (*DashboardSnapshotStore, error) {
 	// nolint:staticcheck
-	return NewStore(db)
+	s := NewStore(db)
+
+	defaultLimits, err := readQuotaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
+		TargetSrv:     dashboardsnapshots.QuotaTargetSrv,
+		DefaultLimits: defaultLimits,
+		Reporter:      s.Count,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
 func NewStore(db db.DB) *DashboardSnapshotStore {
 	return &DashboardSnapshotStore{store: db}
 }
 
-// DeleteExpiredSnapshots removes snapshots with old expiry dates.
+// DeleteExpiredSnapshots removes snapshots with old expiry dates whose payload is stored in the database.
+// Snapshots with a payload in the blob storage are deleted one by one with their payload.
 // SnapShotRemoveExpired is deprecated and should be removed in the future.
 // Snapshot expiry is decided by the user when they share the snapshot.
 func (d *DashboardSnapshotStore) DeleteExpiredSnapshots(ctx context.Context, cmd *dashboardsnapshots.DeleteExpiredSnapshotsCommand) error {
 	return d.store.WithDbSession(ctx, func(sess *db.Session) error {
-		deleteExpiredSQL := "DELETE FROM dashboard_snapshot WHERE expires < ?"
-		expiredResponse, err := sess.Exec(deleteExpiredSQL, time.Now())
+		deleteExpiredSQL := "DELETE FROM dashboard_snapshot WHERE expires < ? AND storage = ?"
+		expiredResponse, err := sess.Exec(deleteExpiredSQL, time.Now(), dashboardsnapshots.StorageDatabase)
 		if err != nil {
 			return err
 		}
@@ -63,6 +80,9 @@ func (d *DashboardSnapshotStore) CreateDashboardSnapshot(ctx context.Context, cm
 			ExternalDeleteURL:  cmd.ExternalDeleteURL,
 			Dashboard:          simplejson.New(),
 			DashboardEncrypted: cmd.DashboardEncrypted,
+			Storage:            cmd.Storage,
+			Size:               cmd.Size,
+			Version:            1,
 			Expires:            expires,
 			Created:            time.Now(),
 			Updated:            time.Now(),
@@ -107,6 +127,50 @@ func (d *DashboardSnapshotStore) GetDashboardSnapshot(ctx context.Context, query
 	return queryResult, nil
 }
 
+// GetExpiredSnapshots returns the snapshots with old expiry dates stored in the given storage, without their payload.
+func (d *DashboardSnapshotStore) GetExpiredSnapshots(ctx context.Context, query *dashboardsnapshots.GetExpiredSnapshotsQuery) ([]*dashboardsnapshots.DashboardSnapshot, error) {
+	snapshots := make([]*dashboardsnapshots.DashboardSnapshot, 0)
+	err := d.store.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("dashboard_snapshot").
+			Omit("dashboard", "dashboard_encrypted").
+			Where("expires < ? AND storage = ?", time.Now(), query.Storage).
+			Find(&snapshots)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// UpdateDashboardSnapshotPayload replaces the payload of a snapshot with a new version, if the snapshot is still at
+// the previous version of the command.
+func (d *DashboardSnapshotStore) UpdateDashboardSnapshotPayload(ctx context.Context, cmd *dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand) error {
+	return d.store.WithDbSession(ctx, func(sess *db.Session) error {
+		snapshot := &dashboardsnapshots.DashboardSnapshot{
+			DashboardEncrypted: cmd.DashboardEncrypted,
+			Size:               cmd.Size,
+			Version:            cmd.Version,
+			Updated:            time.Now(),
+		}
+		affected, err := sess.ID(cmd.ID).Where("version = ?", cmd.PreviousVersion).Cols("dashboard_encrypted", "size", "version", "updated").Update(snapshot)
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			return nil
+		}
+
+		exists, err := sess.Table("dashboard_snapshot").Where("id = ?", cmd.ID).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return dashboardsnapshots.ErrVersionConflict.Errorf("dashboard snapshot %d is not at version %d", cmd.ID, cmd.PreviousVersion)
+		}
+		return dashboardsnapshots.ErrBaseNotFound.Errorf("dashboard snapshot not found")
+	})
+}
+
 // SearchDashboardSnapshots returns a list of all snapshots for admins
 // for other roles, it returns snapshots created by the user
 func (d *DashboardSnapshotStore) SearchDashboardSnapshots(ctx context.Context, query *dashboardsnapshots.GetDashboardSnapshotsQuery) (dashboardsnapshots.DashboardSnapshotsList, error) {
@@ -151,3 +215,77 @@ func (d *DashboardSnapshotStore) SearchDashboardSnapshots(ctx context.Context, q
 	}
 	return queryResult, nil
 }
+
+func (d *DashboardSnapshotStore) Count(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	u := &quota.Map{}
+
+	count, size, err := d.countSnapshots(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := setQuotaValues(u, quota.GlobalScope, count, size); err != nil {
+		return nil, err
+	}
+
+	if scopeParams != nil && scopeParams.OrgID != 0 {
+		count, size, err := d.countSnapshots(ctx, scopeParams.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		if err := setQuotaValues(u, quota.OrgScope, count, size); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+// countSnapshots returns the number of snapshots and the total size of their payloads, in all orgs if orgID is 0.
+func (d *DashboardSnapshotStore) countSnapshots(ctx context.Context, orgID int64) (int64, int64, error) {
+	r := struct {
+		Count int64
+		Size  int64
+	}{}
+	err := d.store.WithDbSession(ctx, func(sess *db.Session) error {
+		rawSQL := "SELECT COUNT(*) AS count, COALESCE(SUM(size), 0) AS size FROM dashboard_snapshot"
+		args := []any{}
+		if orgID != 0 {
+			rawSQL += " WHERE org_id = ?"
+			args = append(args, orgID)
+		}
+		_, err := sess.SQL(rawSQL, args...).Get(&r)
+		return err
+	})
+	return r.Count, r.Size, err
+}
+
+func setQuotaValues(u *quota.Map, scope quota.Scope, count, size int64) error {
+	tag, err := quota.NewTag(dashboardsnapshots.QuotaTargetSrv, dashboardsnapshots.QuotaTarget, scope)
+	if err != nil {
+		return err
+	}
+	u.Set(tag, count)
+
+	tag, err = quota.NewTag(dashboardsnapshots.QuotaTargetSrv, dashboardsnapshots.QuotaTargetSize, scope)
+	if err != nil {
+		return err
+	}
+	u.Set(tag, size)
+	return nil
+}
+
+func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
+	limits := &quota.Map{}
+
+	if cfg == nil {
+		return limits, nil
+	}
+
+	if err := setQuotaValues(limits, quota.GlobalScope, cfg.Quota.Global.Snapshot, cfg.Quota.Global.SnapshotSize); err != nil {
+		return &quota.Map{}, err
+	}
+	if err := setQuotaValues(limits, quota.OrgScope, cfg.Quota.Org.Snapshot, cfg.Quota.Org.SnapshotSize); err != nil {
+		return &quota.Map{}, err
+	}
+	return limits, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -31,7 +32,8 @@ func TestIntegrationDashboardSnapshotDBAccess(t *testing.T) {
 	}
 	sqlstore := db.InitTestDB(t)
 	cfg := setting.NewCfg()
-	dashStore := ProvideStore(sqlstore, cfg)
+	dashStore, err := ProvideStore(sqlstore, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
 
 	origSecret := cfg.SecretKey
 	cfg.SecretKey = "dashboard_snapshot_testing"
@@ -167,9 +169,9 @@ func TestIntegrationDeleteExpiredSnapshots(t *testing.T) {
 	dashStore := NewStore(sqlstore)
 
 	t.Run("Testing dashboard snapshots clean up", func(t *testing.T) {
-		nonExpiredSnapshot := createTestSnapshot(t, dashStore, "key1", 48000)
-		createTestSnapshot(t, dashStore, "key2", -1200)
-		createTestSnapshot(t, dashStore, "key3", -1200)
+		nonExpiredSnapshot := createTestSnapshot(t, dashStore, "key1", 48000, dashboardsnapshots.StorageDatabase)
+		createTestSnapshot(t, dashStore, "key2", -1200, dashboardsnapshots.StorageDatabase)
+		createTestSnapshot(t, dashStore, "key3", -1200, dashboardsnapshots.StorageDatabase)
 
 		err := dashStore.DeleteExpiredSnapshots(context.Background(), &dashboardsnapshots.DeleteExpiredSnapshotsCommand{})
 		require.NoError(t, err)
@@ -197,9 +199,73 @@ func TestIntegrationDeleteExpiredSnapshots(t *testing.T) {
 		require.Len(t, queryResult, 1)
 		require.Equal(t, nonExpiredSnapshot.Key, queryResult[0].Key)
 	})
+
+	t.Run("Expired snapshots in blob storage are left to the service", func(t *testing.T) {
+		blobSnapshot := createTestSnapshot(t, dashStore, "key4", -1200, dashboardsnapshots.StorageBlob)
+
+		err := dashStore.DeleteExpiredSnapshots(context.Background(), &dashboardsnapshots.DeleteExpiredSnapshotsCommand{})
+		require.NoError(t, err)
+
+		expired, err := dashStore.GetExpiredSnapshots(context.Background(), &dashboardsnapshots.GetExpiredSnapshotsQuery{Storage: dashboardsnapshots.StorageBlob})
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		require.Equal(t, blobSnapshot.ID, expired[0].ID)
+		require.Equal(t, dashboardsnapshots.StorageBlob, expired[0].Storage)
+	})
+}
+
+func TestIntegrationUpdateDashboardSnapshotPayload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlstore := db.InitTestDB(t)
+	dashStore := NewStore(sqlstore)
+
+	snapshot := createTestSnapshot(t, dashStore, "key", 48000, dashboardsnapshots.StorageDatabase)
+	require.Equal(t, int64(1), snapshot.Version)
+
+	err := dashStore.UpdateDashboardSnapshotPayload(context.Background(), &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+		ID:                 snapshot.ID,
+		DashboardEncrypted: []byte("recaptured"),
+		Size:               10,
+		Version:            2,
+		PreviousVersion:    1,
+	})
+	require.NoError(t, err)
+
+	result, err := dashStore.GetDashboardSnapshot(context.Background(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "key"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("recaptured"), result.DashboardEncrypted)
+	require.Equal(t, int64(10), result.Size)
+	require.Equal(t, int64(2), result.Version)
+
+	err = dashStore.UpdateDashboardSnapshotPayload(context.Background(), &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{ID: -1, Version: 2, PreviousVersion: 1})
+	require.ErrorIs(t, err, dashboardsnapshots.ErrBaseNotFound)
+
+	t.Run("should not update a snapshot which is not at the previous version", func(t *testing.T) {
+		err := dashStore.UpdateDashboardSnapshotPayload(context.Background(), &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+			ID:                 snapshot.ID,
+			DashboardEncrypted: []byte("concurrent"),
+			Size:               20,
+			Version:            2,
+			PreviousVersion:    1,
+		})
+		require.ErrorIs(t, err, dashboardsnapshots.ErrVersionConflict)
+
+		result, err := dashStore.GetDashboardSnapshot(context.Background(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "key"})
+		require.NoError(t, err)
+		require.Equal(t, []byte("recaptured"), result.DashboardEncrypted)
+	})
+
+	t.Run("Count reports the number and size of the snapshots", func(t *testing.T) {
+		count, size, err := dashStore.countSnapshots(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+		require.Equal(t, int64(10), size)
+	})
 }
 
-func createTestSnapshot(t *testing.T, dashStore *DashboardSnapshotStore, key string, expires int64) *dashboardsnapshots.DashboardSnapshot {
+func createTestSnapshot(t *testing.T, dashStore *DashboardSnapshotStore, key string, expires int64, storage string) *dashboardsnapshots.DashboardSnapshot {
 	cmd := dashboardsnapshots.CreateDashboardSnapshotCommand{
 		Key:       key,
 		DeleteKey: "delete" + key,
@@ -209,8 +275,9 @@ func createTestSnapshot(t *testing.T, dashStore *DashboardSnapshotStore, key str
 				"hello": "mupp",
 			}},
 		},
-		UserID: 1000,
-		OrgID:  1,
+		UserID:  1000,
+		OrgID:   1,
+		Storage: storage,
 	}
 	result, err := dashStore.CreateDashboardSnapshot(context.Background(), &cmd)
 	require.NoError(t, err)
@@ -4,4 +4,11 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 )
 
-var ErrBaseNotFound = errutil.NotFound("dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
+var (
+	ErrBaseNotFound         = errutil.NotFound("dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
+	ErrStorageNotConfigured = errutil.Internal("dashboardsnapshots.storage-not-configured", errutil.WithPublicMessage("Snapshot storage is not configured"))
+	ErrRecaptureExternal    = errutil.BadRequest("dashboardsnapshots.recapture-external", errutil.WithPublicMessage("External snapshots can not be re-captured"))
+	ErrRecaptureDashboard   = errutil.BadRequest("dashboardsnapshots.recapture-dashboard", errutil.WithPublicMessage("The dashboard of the snapshot was not found"))
+	ErrVersionConflict      = errutil.Conflict("dashboardsnapshots.version-conflict", errutil.WithPublicMessage("The snapshot was changed by another request, try again"))
+	ErrQuotaReached         = errutil.Forbidden("dashboardsnapshots.quota-reached", errutil.WithPublicMessage("Quota reached"))
+)
@@ -6,6 +6,19 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	dashboardsnapshot "github.com/grafana/grafana/pkg/apis/dashboardsnapshot/v0alpha1"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/quota"
+)
+
+const (
+	QuotaTargetSrv  quota.TargetSrv = "dashboard_snapshot"
+	QuotaTarget     quota.Target    = "dashboard_snapshot"
+	QuotaTargetSize quota.Target    = "dashboard_snapshot_size"
+)
+
+// Where the encrypted payload of a snapshot is stored
+const (
+	StorageDatabase = ""
+	StorageBlob     = "blob"
 )
 
 // DashboardSnapshot model
@@ -24,6 +37,13 @@ type DashboardSnapshot struct {
 	Created time.Time
 	Updated time.Time
 
+	// Storage is StorageBlob if the payload is stored in the blob bucket instead of DashboardEncrypted
+	Storage string
+	// Size of the encrypted payload in bytes
+	Size int64
+	// Version is incremented every time the data of the snapshot is re-captured
+	Version int64
+
 	Dashboard          *simplejson.Json
 	DashboardEncrypted []byte
 }
@@ -37,6 +57,8 @@ type DashboardSnapshotDTO struct {
 	UserID      int64  `json:"-" xorm:"user_id"`
 	External    bool   `json:"external"`
 	ExternalURL string `json:"externalUrl" xorm:"external_url"`
+	Size        int64  `json:"size"`
+	Version     int64  `json:"version"`
 
 	Expires time.Time `json:"expires"`
 	Created time.Time `json:"created"`
@@ -67,6 +89,8 @@ type CreateDashboardSnapshotCommand struct {
 	UserID int64 `json:"-"`
 
 	DashboardEncrypted []byte `json:"-"`
+	Storage            string `json:"-"`
+	Size               int64  `json:"-"`
 }
 
 type DeleteDashboardSnapshotCommand struct {
@@ -77,6 +101,33 @@ type DeleteExpiredSnapshotsCommand struct {
 	DeletedRows int64
 }
 
+type GetExpiredSnapshotsQuery struct {
+	Storage string
+}
+
+type UpdateDashboardSnapshotPayloadCommand struct {
+	ID                 int64
+	DashboardEncrypted []byte
+	Size               int64
+	Version            int64
+	// PreviousVersion is the version the snapshot must still have for the payload to be replaced
+	PreviousVersion int64
+}
+
+type RecaptureDashboardSnapshotCommand struct {
+	Key          string
+	SignedInUser identity.Requester
+}
+
+// swagger:model
+type RecaptureDashboardSnapshotResult struct {
+	// Version of the snapshot with the re-captured data
+	// example: 2
+	Version int64 `json:"version"`
+	// IDs of the panels whose queries failed, they keep the data of the previous version
+	FailedPanels []int64 `json:"failedPanels"`
+}
+
 type GetDashboardSnapshotQuery struct {
 	Key       string
 	DeleteKey string
@@ -27,6 +27,7 @@ type Service interface {
 	DeleteDashboardSnapshot(context.Context, *DeleteDashboardSnapshotCommand) error
 	DeleteExpiredSnapshots(context.Context, *DeleteExpiredSnapshotsCommand) error
 	GetDashboardSnapshot(context.Context, *GetDashboardSnapshotQuery) (*DashboardSnapshot, error)
+	RecaptureDashboardSnapshot(context.Context, *RecaptureDashboardSnapshotCommand) (*RecaptureDashboardSnapshotResult, error)
 	SearchDashboardSnapshots(context.Context, *GetDashboardSnapshotsQuery) (DashboardSnapshotsList, error)
 	ValidateDashboardExists(context.Context, int64, string) error
 }
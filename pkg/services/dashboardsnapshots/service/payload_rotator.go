@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/secrets/migrator"
+)
+
+// payloadRotator re-encrypts the payloads of the snapshots stored in the blob storage, which are not in the
+// dashboard_snapshot table re-encrypted by the secrets migrator.
+type payloadRotator struct {
+	payloads *payloadStorage
+	log      log.Logger
+}
+
+var _ migrator.SecretsRotator = payloadRotator{}
+
+func (r payloadRotator) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsService, sqlStore db.DB) bool {
+	return r.reEncrypt(ctx, secretsSrv, sqlStore, func(ctx context.Context, decrypted []byte) ([]byte, error) {
+		return secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
+	})
+}
+
+func (r payloadRotator) Rollback(ctx context.Context, secretsSrv *manager.SecretsService, encryptionSrv encryption.Internal, sqlStore db.DB, secretKey string) bool {
+	return !r.reEncrypt(ctx, secretsSrv, sqlStore, func(ctx context.Context, decrypted []byte) ([]byte, error) {
+		return encryptionSrv.Encrypt(ctx, decrypted, secretKey)
+	})
+}
+
+// reEncrypt decrypts the current payload of every blob snapshot and replaces it with the result of encrypt. It
+// returns false if any payload could not be re-encrypted.
+func (r payloadRotator) reEncrypt(ctx context.Context, secretsSrv *manager.SecretsService, sqlStore db.DB, encrypt func(context.Context, []byte) ([]byte, error)) bool {
+	var snapshots []*dashboardsnapshots.DashboardSnapshot
+	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("dashboard_snapshot").Cols("id", "org_id", "version").Where("storage = ?", dashboardsnapshots.StorageBlob).Find(&snapshots)
+	}); err != nil {
+		r.log.Warn("Could not find any snapshot payload to re-encrypt", "error", err)
+		return false
+	}
+
+	var anyFailure bool
+	for _, snapshot := range snapshots {
+		payload, err := r.payloads.get(ctx, snapshot, snapshot.Version)
+		if err != nil {
+			if errors.Is(err, dashboardsnapshots.ErrBaseNotFound) {
+				continue
+			}
+			r.log.Warn("Could not read snapshot payload while re-encrypting it", "id", snapshot.ID, "error", err)
+			anyFailure = true
+			continue
+		}
+
+		decrypted, err := secretsSrv.Decrypt(ctx, payload)
+		if err != nil {
+			r.log.Warn("Could not decrypt snapshot payload while re-encrypting it", "id", snapshot.ID, "error", err)
+			anyFailure = true
+			continue
+		}
+
+		encrypted, err := encrypt(ctx, decrypted)
+		if err != nil {
+			r.log.Warn("Could not encrypt snapshot payload while re-encrypting it", "id", snapshot.ID, "error", err)
+			anyFailure = true
+			continue
+		}
+
+		if err := r.payloads.put(ctx, snapshot, snapshot.Version, encrypted); err != nil {
+			r.log.Warn("Could not store snapshot payload while re-encrypting it", "id", snapshot.ID, "error", err)
+			anyFailure = true
+		}
+	}
+
+	if anyFailure {
+		r.log.Warn("Snapshot payloads have been re-encrypted with errors")
+	} else {
+		r.log.Info("Snapshot payloads have been re-encrypted successfully")
+	}
+	return !anyFailure
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"gocloud.dev/blob"
+
+	// Supported drivers
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/memblob"
+
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+)
+
+// payloadStorage stores the encrypted payloads of snapshots in a blob bucket, one file per snapshot version.
+type payloadStorage struct {
+	files filestorage.FileStorage
+}
+
+func newPayloadStorage(ctx context.Context, url string) (*payloadStorage, error) {
+	bucket, err := blob.OpenBucket(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot storage: %w", err)
+	}
+	logger := log.New("dashboard-snapshots.storage")
+	return &payloadStorage{
+		files: filestorage.NewCdkBlobStorage(logger, bucket, "", filestorage.NewAllowAllPathFilter()),
+	}, nil
+}
+
+func payloadPath(snapshot *dashboardsnapshots.DashboardSnapshot, version int64) string {
+	return filestorage.Join(
+		strconv.FormatInt(snapshot.OrgID, 10),
+		strconv.FormatInt(snapshot.ID, 10),
+		strconv.FormatInt(version, 10),
+	)
+}
+
+func (p *payloadStorage) put(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot, version int64, payload []byte) error {
+	return p.files.Upsert(ctx, &filestorage.UpsertFileCommand{
+		Path:     payloadPath(snapshot, version),
+		MimeType: "application/octet-stream",
+		Contents: payload,
+	})
+}
+
+func (p *payloadStorage) get(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot, version int64) ([]byte, error) {
+	file, found, err := p.files.Get(ctx, payloadPath(snapshot, version), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, dashboardsnapshots.ErrBaseNotFound.Errorf("dashboard snapshot payload not found")
+	}
+	return file.Contents, nil
+}
+
+func (p *payloadStorage) delete(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot, version int64) error {
+	return p.files.Delete(ctx, payloadPath(snapshot, version))
+}
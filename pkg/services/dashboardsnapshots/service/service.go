@@ -2,30 +2,57 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/migrator"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 type ServiceImpl struct {
 	store            dashboardsnapshots.Store
 	secretsService   secrets.Service
 	dashboardService dashboards.DashboardService
+	queryService     query.Service
+	quotaService     quota.Service
+	// payloads is nil if the payloads of new snapshots are stored in the database
+	payloads *payloadStorage
+	log      log.Logger
 }
 
 // ServiceImpl implements the dashboardsnapshots Service interface
 var _ dashboardsnapshots.Service = (*ServiceImpl)(nil)
 
-func ProvideService(store dashboardsnapshots.Store, secretsService secrets.Service, dashboardService dashboards.DashboardService) *ServiceImpl {
+func ProvideService(cfg *setting.Cfg, store dashboardsnapshots.Store, secretsService secrets.Service, dashboardService dashboards.DashboardService, queryService query.Service, quotaService quota.Service, secretsMigrator *migrator.SecretsMigrator) (*ServiceImpl, error) {
 	s := &ServiceImpl{
 		store:            store,
 		secretsService:   secretsService,
 		dashboardService: dashboardService,
+		queryService:     queryService,
+		quotaService:     quotaService,
+		log:              log.New("dashboard-snapshots"),
 	}
 
-	return s
+	if cfg != nil && cfg.SnapshotStorageURL != "" {
+		payloads, err := newPayloadStorage(context.Background(), cfg.SnapshotStorageURL)
+		if err != nil {
+			return nil, err
+		}
+		s.payloads = payloads
+		if secretsMigrator != nil {
+			secretsMigrator.RegisterRotators(payloadRotator{payloads: payloads, log: s.log})
+		}
+	}
+
+	return s, nil
 }
 
 func (s *ServiceImpl) ValidateDashboardExists(ctx context.Context, orgId int64, dashboardUid string) error {
@@ -44,9 +71,29 @@ func (s *ServiceImpl) CreateDashboardSnapshot(ctx context.Context, cmd *dashboar
 		return nil, err
 	}
 
-	cmd.DashboardEncrypted = encryptedDashboard
+	cmd.Size = int64(len(encryptedDashboard))
+	if s.payloads == nil {
+		cmd.DashboardEncrypted = encryptedDashboard
+		return s.store.CreateDashboardSnapshot(ctx, cmd)
+	}
+
+	// The payload is stored once the snapshot has an ID, which is part of its path.
+	cmd.Storage = dashboardsnapshots.StorageBlob
+	cmd.DashboardEncrypted = nil
+	snapshot, err := s.store.CreateDashboardSnapshot(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.payloads.put(ctx, snapshot, snapshot.Version, encryptedDashboard); err != nil {
+		if err := s.store.DeleteDashboardSnapshot(ctx, &dashboardsnapshots.DeleteDashboardSnapshotCommand{DeleteKey: snapshot.DeleteKey}); err != nil {
+			s.log.Error("Failed to delete snapshot without payload", "id", snapshot.ID, "error", err)
+		}
+		return nil, err
+	}
 
-	return s.store.CreateDashboardSnapshot(ctx, cmd)
+	snapshot.DashboardEncrypted = encryptedDashboard
+	return snapshot, nil
 }
 
 func (s *ServiceImpl) GetDashboardSnapshot(ctx context.Context, query *dashboardsnapshots.GetDashboardSnapshotQuery) (*dashboardsnapshots.DashboardSnapshot, error) {
@@ -55,6 +102,16 @@ func (s *ServiceImpl) GetDashboardSnapshot(ctx context.Context, query *dashboard
 		return nil, err
 	}
 
+	if queryResult.Storage == dashboardsnapshots.StorageBlob {
+		if s.payloads == nil {
+			return nil, dashboardsnapshots.ErrStorageNotConfigured.Errorf("snapshot %d is stored in the blob storage", queryResult.ID)
+		}
+		queryResult.DashboardEncrypted, err = s.getPayload(ctx, queryResult)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if queryResult.DashboardEncrypted != nil {
 		decryptedDashboard, err := s.secretsService.Decrypt(ctx, queryResult.DashboardEncrypted)
 		if err != nil {
@@ -73,6 +130,19 @@ func (s *ServiceImpl) GetDashboardSnapshot(ctx context.Context, query *dashboard
 }
 
 func (s *ServiceImpl) DeleteDashboardSnapshot(ctx context.Context, cmd *dashboardsnapshots.DeleteDashboardSnapshotCommand) error {
+	snapshot, err := s.store.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{DeleteKey: cmd.DeleteKey})
+	if err != nil && !errors.Is(err, dashboardsnapshots.ErrBaseNotFound) {
+		return err
+	}
+
+	// The payload is deleted first, so that a failure leaves the snapshot to be deleted again rather than an
+	// orphaned payload.
+	if snapshot != nil && snapshot.Storage == dashboardsnapshots.StorageBlob {
+		if err := s.deletePayload(ctx, snapshot); err != nil {
+			return err
+		}
+	}
+
 	return s.store.DeleteDashboardSnapshot(ctx, cmd)
 }
 
@@ -81,5 +151,236 @@ func (s *ServiceImpl) SearchDashboardSnapshots(ctx context.Context, query *dashb
 }
 
 func (s *ServiceImpl) DeleteExpiredSnapshots(ctx context.Context, cmd *dashboardsnapshots.DeleteExpiredSnapshotsCommand) error {
-	return s.store.DeleteExpiredSnapshots(ctx, cmd)
+	if err := s.store.DeleteExpiredSnapshots(ctx, cmd); err != nil {
+		return err
+	}
+
+	// Snapshots stored in the blob storage are deleted one by one, so that no payload is left behind.
+	expired, err := s.store.GetExpiredSnapshots(ctx, &dashboardsnapshots.GetExpiredSnapshotsQuery{Storage: dashboardsnapshots.StorageBlob})
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range expired {
+		if err := s.deletePayload(ctx, snapshot); err != nil {
+			s.log.Warn("Failed to delete payload of expired snapshot", "id", snapshot.ID, "error", err)
+			continue
+		}
+		if err := s.store.DeleteDashboardSnapshot(ctx, &dashboardsnapshots.DeleteDashboardSnapshotCommand{DeleteKey: snapshot.DeleteKey}); err != nil {
+			return err
+		}
+		cmd.DeletedRows++
+	}
+	return nil
+}
+
+// RecaptureDashboardSnapshot re-runs the queries of the dashboard the snapshot was taken from, for the time range of
+// the snapshot, and stores a new version of the snapshot with the new data. Panels whose queries fail keep their data.
+func (s *ServiceImpl) RecaptureDashboardSnapshot(ctx context.Context, cmd *dashboardsnapshots.RecaptureDashboardSnapshotCommand) (*dashboardsnapshots.RecaptureDashboardSnapshotResult, error) {
+	snapshot, err := s.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: cmd.Key})
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.External {
+		return nil, dashboardsnapshots.ErrRecaptureExternal.Errorf("snapshot %d is external", snapshot.ID)
+	}
+
+	dashboard, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{
+		UID:   snapshot.Dashboard.Get("uid").MustString(),
+		OrgID: snapshot.OrgID,
+	})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, dashboardsnapshots.ErrRecaptureDashboard.Errorf("dashboard of snapshot %d not found", snapshot.ID)
+		}
+		return nil, err
+	}
+
+	failedPanels := s.recapturePanels(ctx, cmd, snapshot.Dashboard, dashboard.Data)
+
+	marshalledData, err := snapshot.Dashboard.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	encryptedDashboard, err := s.secretsService.Encrypt(ctx, marshalledData, secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(len(encryptedDashboard))
+	if err := s.checkSizeQuota(ctx, snapshot, size); err != nil {
+		return nil, err
+	}
+
+	// The update only applies to the version which was re-captured, so that concurrent re-captures do not both
+	// write the next version.
+	update := &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+		ID:                 snapshot.ID,
+		DashboardEncrypted: encryptedDashboard,
+		Size:               size,
+		Version:            snapshot.Version + 1,
+		PreviousVersion:    snapshot.Version,
+	}
+	if snapshot.Storage != dashboardsnapshots.StorageBlob {
+		if err := s.store.UpdateDashboardSnapshotPayload(ctx, update); err != nil {
+			return nil, err
+		}
+	} else {
+		// The next version is reserved before its payload is stored, the previous payload is read until then.
+		update.DashboardEncrypted = nil
+		if err := s.store.UpdateDashboardSnapshotPayload(ctx, update); err != nil {
+			return nil, err
+		}
+		if err := s.payloads.put(ctx, snapshot, update.Version, encryptedDashboard); err != nil {
+			revert := &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+				ID:              snapshot.ID,
+				Size:            snapshot.Size,
+				Version:         snapshot.Version,
+				PreviousVersion: update.Version,
+			}
+			if err := s.store.UpdateDashboardSnapshotPayload(ctx, revert); err != nil {
+				s.log.Error("Failed to revert snapshot version without payload", "id", snapshot.ID, "version", update.Version, "error", err)
+			}
+			return nil, err
+		}
+		if err := s.payloads.delete(ctx, snapshot, snapshot.Version); err != nil {
+			s.log.Warn("Failed to delete previous version of snapshot payload", "id", snapshot.ID, "version", snapshot.Version, "error", err)
+		}
+	}
+
+	return &dashboardsnapshots.RecaptureDashboardSnapshotResult{
+		Version:      update.Version,
+		FailedPanels: failedPanels,
+	}, nil
+}
+
+// checkSizeQuota returns ErrQuotaReached if replacing the payload of the snapshot with a payload of the given size
+// would exceed the snapshot size quota of the organization or of the instance.
+func (s *ServiceImpl) checkSizeQuota(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot, size int64) error {
+	if s.quotaService == nil || size <= snapshot.Size {
+		return nil
+	}
+
+	for scope, id := range map[quota.Scope]int64{quota.OrgScope: snapshot.OrgID, quota.GlobalScope: 0} {
+		quotas, err := s.quotaService.GetQuotasByScope(ctx, scope, id)
+		if err != nil {
+			if errors.Is(err, quota.ErrDisabled) {
+				return nil
+			}
+			return err
+		}
+		for _, q := range quotas {
+			if q.Target != string(dashboardsnapshots.QuotaTargetSize) || q.Limit < 0 {
+				continue
+			}
+			if q.Used-snapshot.Size+size > q.Limit {
+				return dashboardsnapshots.ErrQuotaReached.Errorf("snapshot %d exceeds the %s snapshot size quota", snapshot.ID, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// getPayload returns the payload of the current version of a snapshot stored in the blob storage. The previous
+// version is returned while a re-capture has not stored the payload of the current version yet.
+func (s *ServiceImpl) getPayload(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot) ([]byte, error) {
+	payload, err := s.payloads.get(ctx, snapshot, snapshot.Version)
+	if errors.Is(err, dashboardsnapshots.ErrBaseNotFound) && snapshot.Version > 1 {
+		return s.payloads.get(ctx, snapshot, snapshot.Version-1)
+	}
+	return payload, err
+}
+
+func (s *ServiceImpl) deletePayload(ctx context.Context, snapshot *dashboardsnapshots.DashboardSnapshot) error {
+	if s.payloads == nil {
+		return dashboardsnapshots.ErrStorageNotConfigured.Errorf("snapshot %d is stored in the blob storage", snapshot.ID)
+	}
+	return s.payloads.delete(ctx, snapshot, snapshot.Version)
+}
+
+// recapturePanels replaces the snapshot data of the panels of the snapshot with the results of the queries of the
+// matching panels of the dashboard. It returns the IDs of the panels whose queries failed.
+func (s *ServiceImpl) recapturePanels(ctx context.Context, cmd *dashboardsnapshots.RecaptureDashboardSnapshotCommand, snapshot, dashboard *simplejson.Json) []int64 {
+	from := snapshot.GetPath("time", "from").MustString("now-6h")
+	to := snapshot.GetPath("time", "to").MustString("now")
+
+	dashboardPanels := map[int64]*simplejson.Json{}
+	for _, panel := range panels(dashboard) {
+		dashboardPanels[panel.Get("id").MustInt64()] = panel
+	}
+
+	failedPanels := []int64{}
+	for _, panel := range panels(snapshot) {
+		id := panel.Get("id").MustInt64()
+		source, ok := dashboardPanels[id]
+		if !ok || len(source.Get("targets").MustArray()) == 0 {
+			continue
+		}
+
+		frames, err := s.queryPanel(ctx, cmd, source, from, to)
+		if err != nil {
+			s.log.Debug("Failed to re-capture snapshot panel", "panel", id, "error", err)
+			failedPanels = append(failedPanels, id)
+			continue
+		}
+		panel.Set("snapshotData", frames)
+	}
+	return failedPanels
+}
+
+func (s *ServiceImpl) queryPanel(ctx context.Context, cmd *dashboardsnapshots.RecaptureDashboardSnapshotCommand, panel *simplejson.Json, from, to string) ([]any, error) {
+	reqDTO := dtos.MetricRequest{From: from, To: to}
+	refIDs := []string{}
+	for i := range panel.Get("targets").MustArray() {
+		target := panel.Get("targets").GetIndex(i)
+		if target.Get("hide").MustBool(false) {
+			continue
+		}
+		q := simplejson.NewFromAny(target.MustMap())
+		if _, ok := q.CheckGet("datasource"); !ok {
+			q.Set("datasource", panel.Get("datasource").Interface())
+		}
+		reqDTO.Queries = append(reqDTO.Queries, q)
+		refIDs = append(refIDs, q.Get("refId").MustString())
+	}
+	if len(reqDTO.Queries) == 0 {
+		return []any{}, nil
+	}
+
+	resp, err := s.queryService.QueryData(ctx, cmd.SignedInUser, false, reqDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []any{}
+	for _, refID := range refIDs {
+		r, ok := resp.Responses[refID]
+		if !ok {
+			continue
+		}
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		for _, frame := range r.Frames {
+			frame.RefID = refID
+			b, err := json.Marshal(frame)
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, json.RawMessage(b))
+		}
+	}
+	return frames, nil
+}
+
+// panels returns the panels of the dashboard, including the panels of collapsed rows.
+func panels(dashboard *simplejson.Json) []*simplejson.Json {
+	var result []*simplejson.Json
+	for i := range dashboard.Get("panels").MustArray() {
+		panel := dashboard.Get("panels").GetIndex(i)
+		result = append(result, panel)
+		for j := range panel.Get("panels").MustArray() {
+			result = append(result, panel.Get("panels").GetIndex(j))
+		}
+	}
+	return result
 }
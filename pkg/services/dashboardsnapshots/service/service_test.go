@@ -3,13 +3,19 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	common "github.com/grafana/grafana/pkg/apimachinery/apis/common/v0alpha1"
 	dashboardsnapshot "github.com/grafana/grafana/pkg/apis/dashboardsnapshot/v0alpha1"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -21,6 +27,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
 	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/secrets/database"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
@@ -36,10 +44,12 @@ func TestMain(m *testing.M) {
 func TestDashboardSnapshotsService(t *testing.T) {
 	sqlStore := db.InitTestDB(t)
 	cfg := setting.NewCfg()
-	dsStore := dashsnapdb.ProvideStore(sqlStore, cfg)
+	dsStore, err := dashsnapdb.ProvideStore(sqlStore, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
 	fakeDashboardService := &dashboards.FakeDashboardService{}
 	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
-	s := ProvideService(dsStore, secretsService, fakeDashboardService)
+	s, err := ProvideService(cfg, dsStore, secretsService, fakeDashboardService, nil, quotatest.New(false, nil), nil)
+	require.NoError(t, err)
 
 	origSecret := cfg.SecretKey
 	cfg.SecretKey = "dashboard_snapshot_service_test"
@@ -51,7 +61,7 @@ func TestDashboardSnapshotsService(t *testing.T) {
 
 	dashboard := &common.Unstructured{}
 	rawDashboard := []byte(`{"id":123}`)
-	err := json.Unmarshal(rawDashboard, dashboard)
+	err = json.Unmarshal(rawDashboard, dashboard)
 	require.NoError(t, err)
 
 	t.Run("create dashboard snapshot should encrypt the dashboard", func(t *testing.T) {
@@ -95,13 +105,15 @@ func TestDashboardSnapshotsService(t *testing.T) {
 func TestValidateDashboardExists(t *testing.T) {
 	sqlStore := db.InitTestDB(t)
 	cfg := setting.NewCfg()
-	dsStore := dashsnapdb.ProvideStore(sqlStore, cfg)
+	dsStore, err := dashsnapdb.ProvideStore(sqlStore, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
 	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
 	dashboardStore, err := dashdb.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore), quotatest.New(false, nil))
 	require.NoError(t, err)
 	dashSvc, err := dashsvc.ProvideDashboardServiceImpl(cfg, dashboardStore, folderimpl.ProvideDashboardFolderStore(sqlStore), nil, nil, nil, acmock.New(), foldertest.NewFakeService(), folder.NewFakeStore(), nil)
 	require.NoError(t, err)
-	s := ProvideService(dsStore, secretsService, dashSvc)
+	s, err := ProvideService(cfg, dsStore, secretsService, dashSvc, nil, quotatest.New(false, nil), nil)
+	require.NoError(t, err)
 	ctx := context.Background()
 
 	t.Run("returns false when dashboard does not exist", func(t *testing.T) {
@@ -133,3 +145,185 @@ func createDashboard(store db.DB) error {
 		return err
 	})
 }
+
+func TestDashboardSnapshotsBlobStorage(t *testing.T) {
+	sqlStore := db.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.SnapshotStorageURL = "mem://"
+	dsStore, err := dashsnapdb.ProvideStore(sqlStore, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	s, err := ProvideService(cfg, dsStore, secretsService, &dashboards.FakeDashboardService{}, nil, quotatest.New(false, nil), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cmd := dashboardsnapshots.CreateDashboardSnapshotCommand{
+		Key:       "blob",
+		DeleteKey: "deleteblob",
+		DashboardCreateCommand: dashboardsnapshot.DashboardCreateCommand{
+			Dashboard: &common.Unstructured{Object: map[string]any{"id": float64(123)}},
+		},
+		OrgID: 1,
+	}
+	result, err := s.CreateDashboardSnapshot(ctx, &cmd)
+	require.NoError(t, err)
+	require.Equal(t, dashboardsnapshots.StorageBlob, result.Storage)
+	require.Equal(t, int64(len(result.DashboardEncrypted)), result.Size)
+
+	stored, err := dsStore.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "blob"})
+	require.NoError(t, err)
+	require.Empty(t, stored.DashboardEncrypted)
+
+	snapshot, err := s.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "blob"})
+	require.NoError(t, err)
+	require.Equal(t, int64(123), snapshot.Dashboard.Get("id").MustInt64())
+
+	t.Run("should re-encrypt the payloads with the secrets migrator", func(t *testing.T) {
+		payload, err := s.payloads.get(ctx, snapshot, snapshot.Version)
+		require.NoError(t, err)
+		require.NoError(t, secretsService.RotateDataKeys(ctx))
+
+		rotator := payloadRotator{payloads: s.payloads, log: s.log}
+		require.True(t, rotator.ReEncrypt(ctx, secretsService, sqlStore))
+
+		reEncrypted, err := s.payloads.get(ctx, snapshot, snapshot.Version)
+		require.NoError(t, err)
+		require.NotEqual(t, payload, reEncrypted)
+		decrypted, err := secretsService.Decrypt(ctx, reEncrypted)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id": 123}`, string(decrypted))
+	})
+
+	t.Run("should read the previous payload while the next version is reserved", func(t *testing.T) {
+		err := dsStore.UpdateDashboardSnapshotPayload(ctx, &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+			ID:              snapshot.ID,
+			Size:            snapshot.Size,
+			Version:         2,
+			PreviousVersion: 1,
+		})
+		require.NoError(t, err)
+
+		reserved, err := s.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "blob"})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), reserved.Version)
+		require.Equal(t, int64(123), reserved.Dashboard.Get("id").MustInt64())
+
+		err = dsStore.UpdateDashboardSnapshotPayload(ctx, &dashboardsnapshots.UpdateDashboardSnapshotPayloadCommand{
+			ID:              snapshot.ID,
+			Size:            snapshot.Size,
+			Version:         1,
+			PreviousVersion: 2,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should keep the snapshot if its payload can not be deleted", func(t *testing.T) {
+		payloads := s.payloads
+		s.payloads = nil
+		err := s.DeleteDashboardSnapshot(ctx, &dashboardsnapshots.DeleteDashboardSnapshotCommand{DeleteKey: "deleteblob"})
+		require.ErrorIs(t, err, dashboardsnapshots.ErrStorageNotConfigured)
+		s.payloads = payloads
+
+		_, err = dsStore.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "blob"})
+		require.NoError(t, err)
+	})
+
+	err = s.DeleteDashboardSnapshot(ctx, &dashboardsnapshots.DeleteDashboardSnapshotCommand{DeleteKey: "deleteblob"})
+	require.NoError(t, err)
+
+	_, err = s.payloads.get(ctx, snapshot, snapshot.Version)
+	require.ErrorIs(t, err, dashboardsnapshots.ErrBaseNotFound)
+	_, err = dsStore.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "blob"})
+	require.ErrorIs(t, err, dashboardsnapshots.ErrBaseNotFound)
+}
+
+func TestRecaptureDashboardSnapshot(t *testing.T) {
+	sqlStore := db.InitTestDB(t)
+	cfg := setting.NewCfg()
+	dsStore, err := dashsnapdb.ProvideStore(sqlStore, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	dashboardService := &dashboards.FakeDashboardService{}
+	queryService := &query.FakeQueryService{}
+	s, err := ProvideService(cfg, dsStore, secretsService, dashboardService, queryService, quotatest.New(false, nil), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = s.CreateDashboardSnapshot(ctx, &dashboardsnapshots.CreateDashboardSnapshotCommand{
+		Key:       "recapture",
+		DeleteKey: "deleterecapture",
+		DashboardCreateCommand: dashboardsnapshot.DashboardCreateCommand{
+			Dashboard: &common.Unstructured{Object: map[string]any{
+				"uid":  "dash",
+				"time": map[string]any{"from": "now-1h", "to": "now"},
+				"panels": []any{
+					map[string]any{"id": float64(1), "snapshotData": []any{}},
+					map[string]any{"id": float64(2), "snapshotData": []any{"old"}},
+				},
+			}},
+		},
+		OrgID: 1,
+	})
+	require.NoError(t, err)
+
+	dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "dash", OrgID: 1}).Return(&dashboards.Dashboard{
+		UID: "dash",
+		Data: simplejson.NewFromAny(map[string]any{
+			"panels": []any{
+				map[string]any{"id": float64(1), "datasource": map[string]any{"uid": "ds"}, "targets": []any{
+					map[string]any{"refId": "A"},
+					map[string]any{"refId": "B", "hide": true},
+				}},
+				map[string]any{"id": float64(2), "targets": []any{map[string]any{"refId": "A", "datasource": map[string]any{"uid": "broken"}}}},
+			},
+		}),
+	}, nil)
+	queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+		return req.From == "now-1h" && len(req.Queries) == 1 && req.Queries[0].GetPath("datasource", "uid").MustString() == "ds"
+	})).Return(&backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("series", data.NewField("value", nil, []float64{1}))}},
+	}}, nil)
+	queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).Return(&backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Error: errors.New("data source unavailable")},
+	}}, nil)
+
+	result, err := s.RecaptureDashboardSnapshot(ctx, &dashboardsnapshots.RecaptureDashboardSnapshotCommand{Key: "recapture"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Version)
+	require.Equal(t, []int64{2}, result.FailedPanels)
+
+	snapshot, err := s.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "recapture"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), snapshot.Version)
+	panels := snapshot.Dashboard.Get("panels")
+	require.Equal(t, "series", panels.GetIndex(0).Get("snapshotData").GetIndex(0).GetPath("schema", "name").MustString())
+	require.Equal(t, []any{"old"}, panels.GetIndex(1).Get("snapshotData").MustArray())
+
+	t.Run("should fail if the re-captured snapshot exceeds the size quota", func(t *testing.T) {
+		// the size quota is used by the current size of the snapshot, which the re-captured snapshot exceeds
+		require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE dashboard_snapshot SET size = 1 WHERE id = ?", snapshot.ID)
+			return err
+		}))
+		s.quotaService = &sizeQuotaService{used: 1, limit: 1}
+		defer func() { s.quotaService = quotatest.New(false, nil) }()
+
+		_, err := s.RecaptureDashboardSnapshot(ctx, &dashboardsnapshots.RecaptureDashboardSnapshotCommand{Key: "recapture"})
+		require.ErrorIs(t, err, dashboardsnapshots.ErrQuotaReached)
+
+		unchanged, err := s.GetDashboardSnapshot(ctx, &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "recapture"})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), unchanged.Version)
+	})
+}
+
+// sizeQuotaService reports the usage and the limit of the snapshot size quota.
+type sizeQuotaService struct {
+	quota.Service
+	used  int64
+	limit int64
+}
+
+func (s *sizeQuotaService) GetQuotasByScope(context.Context, quota.Scope, int64) ([]quota.QuotaDTO, error) {
+	return []quota.QuotaDTO{{Target: string(dashboardsnapshots.QuotaTargetSize), Used: s.used, Limit: s.limit}}, nil
+}
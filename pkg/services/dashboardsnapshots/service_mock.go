@@ -93,6 +93,32 @@ func (_m *MockService) GetDashboardSnapshot(_a0 context.Context, _a1 *GetDashboa
 	return r0, r1
 }
 
+// RecaptureDashboardSnapshot provides a mock function with given fields: _a0, _a1
+func (_m *MockService) RecaptureDashboardSnapshot(_a0 context.Context, _a1 *RecaptureDashboardSnapshotCommand) (*RecaptureDashboardSnapshotResult, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *RecaptureDashboardSnapshotResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *RecaptureDashboardSnapshotCommand) (*RecaptureDashboardSnapshotResult, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *RecaptureDashboardSnapshotCommand) *RecaptureDashboardSnapshotResult); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*RecaptureDashboardSnapshotResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *RecaptureDashboardSnapshotCommand) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SearchDashboardSnapshots provides a mock function with given fields: _a0, _a1
 func (_m *MockService) SearchDashboardSnapshots(_a0 context.Context, _a1 *GetDashboardSnapshotsQuery) (DashboardSnapshotsList, error) {
 	ret := _m.Called(_a0, _a1)
@@ -9,5 +9,7 @@ type Store interface {
 	DeleteDashboardSnapshot(context.Context, *DeleteDashboardSnapshotCommand) error
 	DeleteExpiredSnapshots(context.Context, *DeleteExpiredSnapshotsCommand) error
 	GetDashboardSnapshot(context.Context, *GetDashboardSnapshotQuery) (*DashboardSnapshot, error)
+	GetExpiredSnapshots(context.Context, *GetExpiredSnapshotsQuery) ([]*DashboardSnapshot, error)
 	SearchDashboardSnapshots(context.Context, *GetDashboardSnapshotsQuery) (DashboardSnapshotsList, error)
+	UpdateDashboardSnapshotPayload(context.Context, *UpdateDashboardSnapshotPayloadCommand) error
 }
@@ -1,14 +1,23 @@
 package osskmsproviders
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/kmsproviders"
 	grafana "github.com/grafana/grafana/pkg/services/kmsproviders/defaultprovider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/pkcs11provider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/vaultprovider"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+// providersSectionPrefix is the prefix of the sections configuring the external providers,
+// for example [security.encryption.hashicorpvault.v1] configures the provider hashicorpvault.v1.
+const providersSectionPrefix = "security.encryption."
+
 type Service struct {
 	enc      encryption.Internal
 	cfg      *setting.Cfg
@@ -24,7 +33,37 @@ func ProvideService(enc encryption.Internal, cfg *setting.Cfg, features featurem
 }
 
 func (s Service) Provide() (map[secrets.ProviderID]secrets.Provider, error) {
-	return map[secrets.ProviderID]secrets.Provider{
+	providers := map[secrets.ProviderID]secrets.Provider{
 		kmsproviders.Default: grafana.New(s.cfg, s.enc),
-	}, nil
+	}
+
+	for _, section := range s.cfg.Raw.Sections() {
+		if !strings.HasPrefix(section.Name(), providersSectionPrefix) {
+			continue
+		}
+
+		// Sections which are not in the <provider>.<keyName> format do not configure a provider.
+		id := secrets.ProviderID(strings.TrimPrefix(section.Name(), providersSectionPrefix))
+		kind, err := id.Kind()
+		if err != nil {
+			continue
+		}
+
+		var provider secrets.Provider
+		switch kind {
+		case vaultprovider.Kind:
+			provider, err = vaultprovider.New(s.cfg.SectionWithEnvOverrides(section.Name()))
+		case pkcs11provider.Kind:
+			provider, err = pkcs11provider.New(s.cfg.SectionWithEnvOverrides(section.Name()))
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure encryption provider %s: %w", id, err)
+		}
+
+		providers[id] = provider
+	}
+
+	return providers, nil
 }
@@ -0,0 +1,419 @@
+//go:build cgo && !windows
+
+package pkcs11provider
+
+/*
+#cgo linux LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+// Subset of the PKCS#11 v2.40 API, see pkcs11t.h and pkcs11f.h of the OASIS specification.
+// The module is loaded with dlopen so that Grafana does not link against a specific library.
+
+typedef unsigned char CK_BYTE;
+typedef unsigned long CK_ULONG;
+typedef CK_ULONG CK_RV;
+typedef CK_ULONG CK_FLAGS;
+typedef CK_ULONG CK_SLOT_ID;
+typedef CK_ULONG CK_SESSION_HANDLE;
+typedef CK_ULONG CK_OBJECT_HANDLE;
+
+typedef struct { CK_BYTE major; CK_BYTE minor; } CK_VERSION;
+
+typedef struct {
+	CK_BYTE label[32];
+	CK_BYTE manufacturerID[32];
+	CK_BYTE model[16];
+	CK_BYTE serialNumber[16];
+	CK_FLAGS flags;
+	CK_ULONG ulMaxSessionCount;
+	CK_ULONG ulSessionCount;
+	CK_ULONG ulMaxRwSessionCount;
+	CK_ULONG ulRwSessionCount;
+	CK_ULONG ulMaxPinLen;
+	CK_ULONG ulMinPinLen;
+	CK_ULONG ulTotalPublicMemory;
+	CK_ULONG ulFreePublicMemory;
+	CK_ULONG ulTotalPrivateMemory;
+	CK_ULONG ulFreePrivateMemory;
+	CK_VERSION hardwareVersion;
+	CK_VERSION firmwareVersion;
+	CK_BYTE utcTime[16];
+} CK_TOKEN_INFO;
+
+typedef struct { CK_ULONG type; void *pValue; CK_ULONG ulValueLen; } CK_ATTRIBUTE;
+typedef struct { CK_ULONG mechanism; void *pParameter; CK_ULONG ulParameterLen; } CK_MECHANISM;
+
+typedef struct {
+	CK_BYTE *pIv;
+	CK_ULONG ulIvLen;
+	CK_ULONG ulIvBits;
+	CK_BYTE *pAAD;
+	CK_ULONG ulAADLen;
+	CK_ULONG ulTagBits;
+} CK_GCM_PARAMS;
+
+typedef struct {
+	void *CreateMutex;
+	void *DestroyMutex;
+	void *LockMutex;
+	void *UnlockMutex;
+	CK_FLAGS flags;
+	void *pReserved;
+} CK_C_INITIALIZE_ARGS;
+
+typedef void *CK_NOTIFY;
+
+// The functions of the list are in the order of the specification, the ones after C_Decrypt are not used.
+typedef struct {
+	CK_VERSION version;
+	CK_RV (*C_Initialize)(void *);
+	CK_RV (*C_Finalize)(void *);
+	void *C_GetInfo;
+	void *C_GetFunctionList;
+	CK_RV (*C_GetSlotList)(CK_BYTE, CK_SLOT_ID *, CK_ULONG *);
+	void *C_GetSlotInfo;
+	CK_RV (*C_GetTokenInfo)(CK_SLOT_ID, CK_TOKEN_INFO *);
+	void *C_GetMechanismList;
+	void *C_GetMechanismInfo;
+	void *C_InitToken;
+	void *C_InitPIN;
+	void *C_SetPIN;
+	CK_RV (*C_OpenSession)(CK_SLOT_ID, CK_FLAGS, void *, CK_NOTIFY, CK_SESSION_HANDLE *);
+	CK_RV (*C_CloseSession)(CK_SESSION_HANDLE);
+	void *C_CloseAllSessions;
+	void *C_GetSessionInfo;
+	void *C_GetOperationState;
+	void *C_SetOperationState;
+	CK_RV (*C_Login)(CK_SESSION_HANDLE, CK_ULONG, CK_BYTE *, CK_ULONG);
+	CK_RV (*C_Logout)(CK_SESSION_HANDLE);
+	void *C_CreateObject;
+	void *C_CopyObject;
+	void *C_DestroyObject;
+	void *C_GetObjectSize;
+	void *C_GetAttributeValue;
+	void *C_SetAttributeValue;
+	CK_RV (*C_FindObjectsInit)(CK_SESSION_HANDLE, CK_ATTRIBUTE *, CK_ULONG);
+	CK_RV (*C_FindObjects)(CK_SESSION_HANDLE, CK_OBJECT_HANDLE *, CK_ULONG, CK_ULONG *);
+	CK_RV (*C_FindObjectsFinal)(CK_SESSION_HANDLE);
+	CK_RV (*C_EncryptInit)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_OBJECT_HANDLE);
+	CK_RV (*C_Encrypt)(CK_SESSION_HANDLE, CK_BYTE *, CK_ULONG, CK_BYTE *, CK_ULONG *);
+	void *C_EncryptUpdate;
+	void *C_EncryptFinal;
+	CK_RV (*C_DecryptInit)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_OBJECT_HANDLE);
+	CK_RV (*C_Decrypt)(CK_SESSION_HANDLE, CK_BYTE *, CK_ULONG, CK_BYTE *, CK_ULONG *);
+} CK_FUNCTION_LIST;
+
+#define CKR_OK                           0x000
+#define CKR_HOST_MEMORY                  0x002
+#define CKR_CRYPTOKI_ALREADY_INITIALIZED 0x191
+#define CKR_USER_ALREADY_LOGGED_IN       0x100
+#define CKR_VENDOR_DEFINED               0x80000000UL
+#define P11_ERR_LOAD                     (CKR_VENDOR_DEFINED | 1)
+#define P11_ERR_TOKEN_NOT_FOUND          (CKR_VENDOR_DEFINED | 2)
+#define P11_ERR_KEY_NOT_FOUND            (CKR_VENDOR_DEFINED | 3)
+
+#define CKF_OS_LOCKING_OK  0x2
+#define CKF_SERIAL_SESSION 0x4
+#define CKU_USER           1
+#define CKA_CLASS          0x0
+#define CKA_LABEL          0x3
+#define CKO_SECRET_KEY     0x4
+#define CKM_AES_GCM        0x1087
+
+typedef struct {
+	void *handle;
+	CK_FUNCTION_LIST *fns;
+	CK_SESSION_HANDLE session;
+	CK_OBJECT_HANDLE key;
+} p11_module;
+
+static CK_RV p11_load(p11_module *m, const char *path) {
+	m->handle = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (m->handle == NULL) {
+		return P11_ERR_LOAD;
+	}
+	CK_RV (*getFunctionList)(CK_FUNCTION_LIST **) = (CK_RV (*)(CK_FUNCTION_LIST **))dlsym(m->handle, "C_GetFunctionList");
+	if (getFunctionList == NULL) {
+		dlclose(m->handle);
+		return P11_ERR_LOAD;
+	}
+	CK_RV rv = getFunctionList(&m->fns);
+	if (rv != CKR_OK) {
+		dlclose(m->handle);
+		return rv;
+	}
+
+	CK_C_INITIALIZE_ARGS args;
+	memset(&args, 0, sizeof(args));
+	args.flags = CKF_OS_LOCKING_OK;
+	rv = m->fns->C_Initialize(&args);
+	if (rv != CKR_OK && rv != CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		dlclose(m->handle);
+		return rv;
+	}
+	return CKR_OK;
+}
+
+// p11_find_slot returns the slot of the token with the label, labels are padded with spaces.
+static CK_RV p11_find_slot(p11_module *m, const char *label, CK_SLOT_ID *slot) {
+	CK_ULONG count = 0;
+	CK_RV rv = m->fns->C_GetSlotList(1, NULL, &count);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	if (count == 0) {
+		return P11_ERR_TOKEN_NOT_FOUND;
+	}
+	CK_SLOT_ID *slots = calloc(count, sizeof(CK_SLOT_ID));
+	if (slots == NULL) {
+		return CKR_HOST_MEMORY;
+	}
+	rv = m->fns->C_GetSlotList(1, slots, &count);
+	if (rv != CKR_OK) {
+		free(slots);
+		return rv;
+	}
+
+	CK_BYTE padded[32];
+	memset(padded, ' ', sizeof(padded));
+	size_t len = strlen(label);
+	memcpy(padded, label, len > sizeof(padded) ? sizeof(padded) : len);
+
+	rv = P11_ERR_TOKEN_NOT_FOUND;
+	for (CK_ULONG i = 0; i < count; i++) {
+		CK_TOKEN_INFO info;
+		if (m->fns->C_GetTokenInfo(slots[i], &info) == CKR_OK && memcmp(info.label, padded, sizeof(padded)) == 0) {
+			*slot = slots[i];
+			rv = CKR_OK;
+			break;
+		}
+	}
+	free(slots);
+	return rv;
+}
+
+static CK_RV p11_open(p11_module *m, const char *tokenLabel, const char *pin, const char *keyLabel) {
+	CK_SLOT_ID slot;
+	CK_RV rv = p11_find_slot(m, tokenLabel, &slot);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	rv = m->fns->C_OpenSession(slot, CKF_SERIAL_SESSION, NULL, NULL, &m->session);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	rv = m->fns->C_Login(m->session, CKU_USER, (CK_BYTE *)pin, strlen(pin));
+	if (rv != CKR_OK && rv != CKR_USER_ALREADY_LOGGED_IN) {
+		m->fns->C_CloseSession(m->session);
+		return rv;
+	}
+
+	CK_ULONG class = CKO_SECRET_KEY;
+	CK_ATTRIBUTE template[2] = {
+		{CKA_CLASS, &class, sizeof(class)},
+		{CKA_LABEL, (void *)keyLabel, strlen(keyLabel)},
+	};
+	CK_ULONG found = 0;
+	rv = m->fns->C_FindObjectsInit(m->session, template, 2);
+	if (rv == CKR_OK) {
+		rv = m->fns->C_FindObjects(m->session, &m->key, 1, &found);
+		m->fns->C_FindObjectsFinal(m->session);
+	}
+	if (rv == CKR_OK && found == 0) {
+		rv = P11_ERR_KEY_NOT_FOUND;
+	}
+	if (rv != CKR_OK) {
+		m->fns->C_Logout(m->session);
+		m->fns->C_CloseSession(m->session);
+	}
+	return rv;
+}
+
+static CK_RV p11_crypt(p11_module *m, int encrypt, CK_BYTE *iv, CK_ULONG ivLen, CK_BYTE *in, CK_ULONG inLen, CK_BYTE *out, CK_ULONG *outLen) {
+	CK_GCM_PARAMS params = {iv, ivLen, ivLen * 8, NULL, 0, 128};
+	CK_MECHANISM mechanism = {CKM_AES_GCM, &params, sizeof(params)};
+	if (encrypt) {
+		CK_RV rv = m->fns->C_EncryptInit(m->session, &mechanism, m->key);
+		if (rv != CKR_OK) {
+			return rv;
+		}
+		return m->fns->C_Encrypt(m->session, in, inLen, out, outLen);
+	}
+	CK_RV rv = m->fns->C_DecryptInit(m->session, &mechanism, m->key);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	return m->fns->C_Decrypt(m->session, in, inLen, out, outLen);
+}
+
+// p11_unload finalizes the module only if finalize is set, as C_Finalize applies to every session of the process.
+static void p11_unload(p11_module *m, int finalize) {
+	if (finalize) {
+		m->fns->C_Finalize(NULL);
+	}
+	dlclose(m->handle);
+}
+
+static CK_RV p11_close(p11_module *m, int finalize) {
+	m->fns->C_Logout(m->session);
+	CK_RV rv = m->fns->C_CloseSession(m->session);
+	p11_unload(m, finalize);
+	return rv;
+}
+
+static const char *p11_dlerror(void) {
+	const char *err = dlerror();
+	return err != NULL ? err : "unknown error";
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// gcmTagSize is the size in bytes of the authentication tag appended to the ciphertext.
+const gcmTagSize = 16
+
+// PKCS#11 return values after which the session cannot be used anymore.
+var unavailableReturnValues = map[C.CK_RV]bool{
+	0x030: true, // CKR_DEVICE_ERROR
+	0x031: true, // CKR_DEVICE_MEMORY
+	0x032: true, // CKR_DEVICE_REMOVED
+	0x0B0: true, // CKR_SESSION_CLOSED
+	0x0B3: true, // CKR_SESSION_HANDLE_INVALID
+	0x0E0: true, // CKR_TOKEN_NOT_PRESENT
+	0x101: true, // CKR_USER_NOT_LOGGED_IN
+	0x190: true, // CKR_CRYPTOKI_NOT_INITIALIZED
+}
+
+// loadedModules counts the open tokens of each loaded module by dlopen handle, several tokens can share a module
+// and C_Finalize must only be called when the last one is closed.
+var (
+	loadedModules    = map[unsafe.Pointer]int{}
+	loadedModulesMtx sync.Mutex
+)
+
+type module struct {
+	m *C.p11_module
+}
+
+func openToken(cfg config) (token, error) {
+	m := (*C.p11_module)(C.calloc(1, C.sizeof_p11_module))
+	if m == nil {
+		return nil, errors.New("failed to allocate PKCS#11 module")
+	}
+
+	path := C.CString(cfg.module)
+	defer C.free(unsafe.Pointer(path))
+	if err := load(m, path); err != nil {
+		C.free(unsafe.Pointer(m))
+		return nil, err
+	}
+
+	tokenLabel, pin, keyLabel := C.CString(cfg.tokenLabel), C.CString(cfg.pin), C.CString(cfg.keyLabel)
+	defer C.free(unsafe.Pointer(tokenLabel))
+	defer C.free(unsafe.Pointer(pin))
+	defer C.free(unsafe.Pointer(keyLabel))
+	if rv := C.p11_open(m, tokenLabel, pin, keyLabel); rv != C.CKR_OK {
+		loadedModulesMtx.Lock()
+		C.p11_unload(m, release(m))
+		loadedModulesMtx.Unlock()
+		C.free(unsafe.Pointer(m))
+		switch rv {
+		case C.P11_ERR_TOKEN_NOT_FOUND:
+			return nil, fmt.Errorf("PKCS#11 token %q not found", cfg.tokenLabel)
+		case C.P11_ERR_KEY_NOT_FOUND:
+			return nil, fmt.Errorf("secret key %q not found in PKCS#11 token %q", cfg.keyLabel, cfg.tokenLabel)
+		}
+		return nil, rvError("open session", rv)
+	}
+
+	return &module{m: m}, nil
+}
+
+// load loads and initializes the module, or only loads it if another token already initialized it.
+func load(m *C.p11_module, path *C.char) error {
+	loadedModulesMtx.Lock()
+	defer loadedModulesMtx.Unlock()
+
+	if rv := C.p11_load(m, path); rv != C.CKR_OK {
+		if rv == C.P11_ERR_LOAD {
+			return fmt.Errorf("failed to load PKCS#11 module %s: %s", C.GoString(path), C.GoString(C.p11_dlerror()))
+		}
+		return rvError("C_Initialize", rv)
+	}
+	loadedModules[m.handle]++
+	return nil
+}
+
+// release decrements the count of open tokens of the module, and returns 1 if it was the last one and the module
+// must be finalized. loadedModulesMtx must be held until the module is unloaded.
+func release(m *C.p11_module) C.int {
+	loadedModules[m.handle]--
+	if loadedModules[m.handle] > 0 {
+		return 0
+	}
+	delete(loadedModules, m.handle)
+	return 1
+}
+
+func (t *module) encrypt(iv, plaintext []byte) ([]byte, error) {
+	return t.crypt(true, iv, plaintext, len(plaintext)+gcmTagSize)
+}
+
+func (t *module) decrypt(iv, ciphertext []byte) ([]byte, error) {
+	return t.crypt(false, iv, ciphertext, len(ciphertext))
+}
+
+func (t *module) crypt(encrypt bool, iv, in []byte, outSize int) ([]byte, error) {
+	if len(in) == 0 || outSize == 0 {
+		return nil, fmt.Errorf("empty PKCS#11 %s input", operation(encrypt))
+	}
+	out := make([]byte, outSize)
+	outLen := C.CK_ULONG(len(out))
+	mode := C.int(0)
+	if encrypt {
+		mode = 1
+	}
+
+	rv := C.p11_crypt(t.m, mode,
+		(*C.CK_BYTE)(unsafe.Pointer(&iv[0])), C.CK_ULONG(len(iv)),
+		(*C.CK_BYTE)(unsafe.Pointer(&in[0])), C.CK_ULONG(len(in)),
+		(*C.CK_BYTE)(unsafe.Pointer(&out[0])), &outLen)
+	if rv != C.CKR_OK {
+		return nil, rvError(operation(encrypt), rv)
+	}
+	return out[:outLen], nil
+}
+
+func (t *module) close() error {
+	loadedModulesMtx.Lock()
+	rv := C.p11_close(t.m, release(t.m))
+	loadedModulesMtx.Unlock()
+	C.free(unsafe.Pointer(t.m))
+	if rv != C.CKR_OK {
+		return rvError("C_CloseSession", rv)
+	}
+	return nil
+}
+
+func operation(encrypt bool) string {
+	if encrypt {
+		return "C_Encrypt"
+	}
+	return "C_Decrypt"
+}
+
+func rvError(operation string, rv C.CK_RV) error {
+	if unavailableReturnValues[rv] {
+		return fmt.Errorf("%w: %s failed with CKR 0x%X", errTokenUnavailable, operation, uint64(rv))
+	}
+	return fmt.Errorf("PKCS#11 %s failed with CKR 0x%X", operation, uint64(rv))
+}
@@ -0,0 +1,9 @@
+//go:build !cgo || windows
+
+package pkcs11provider
+
+import "errors"
+
+func openToken(_ config) (token, error) {
+	return nil, errors.New("PKCS#11 encryption providers require Grafana to be built with cgo on Linux or macOS")
+}
@@ -0,0 +1,151 @@
+package pkcs11provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Kind is the kind of the encryption providers backed by a PKCS#11 token, like an HSM or SoftHSM,
+// they are configured in [security.encryption.pkcs11.<key name>] sections.
+const Kind = "pkcs11"
+
+// ivSize is the size of the AES-GCM nonce prepended to the encrypted data keys.
+const ivSize = 12
+
+var (
+	// errTokenUnavailable is returned by tokens whose session can no longer be used,
+	// for example because the HSM was restarted. The provider opens a new session.
+	errTokenUnavailable = errors.New("PKCS#11 token unavailable")
+)
+
+type config struct {
+	module     string
+	tokenLabel string
+	pin        string
+	keyLabel   string
+}
+
+// token is a logged in session of a PKCS#11 token with the AES key used to encrypt the data keys.
+type token interface {
+	encrypt(iv, plaintext []byte) ([]byte, error)
+	decrypt(iv, ciphertext []byte) ([]byte, error)
+	close() error
+}
+
+// pkcs11Provider encrypts data keys with an AES key stored in a PKCS#11 token using AES-GCM,
+// the key never leaves the token. The session is opened on first use and shared, as PKCS#11
+// sessions cannot be used concurrently, the operations are serialized.
+type pkcs11Provider struct {
+	cfg  config
+	open func(config) (token, error)
+	log  log.Logger
+
+	mtx   sync.Mutex
+	token token
+}
+
+var _ secrets.BackgroundProvider = (*pkcs11Provider)(nil)
+
+func New(section *setting.DynamicSection) (secrets.Provider, error) {
+	cfg := config{
+		module:     section.Key("module").String(),
+		tokenLabel: section.Key("token_label").String(),
+		pin:        section.Key("pin").String(),
+		keyLabel:   section.Key("key_label").String(),
+	}
+	for name, value := range map[string]string{
+		"module":      cfg.module,
+		"token_label": cfg.tokenLabel,
+		"pin":         cfg.pin,
+		"key_label":   cfg.keyLabel,
+	} {
+		if value == "" {
+			return nil, fmt.Errorf("%s is required", name)
+		}
+	}
+
+	return &pkcs11Provider{
+		cfg:  cfg,
+		open: openToken,
+		log:  log.New("secrets.pkcs11"),
+	}, nil
+}
+
+func (p *pkcs11Provider) Encrypt(_ context.Context, blob []byte) ([]byte, error) {
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	var encrypted []byte
+	err := p.withToken(func(t token) (err error) {
+		encrypted, err = t.encrypt(iv, blob)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(iv, encrypted...), nil
+}
+
+func (p *pkcs11Provider) Decrypt(_ context.Context, blob []byte) ([]byte, error) {
+	if len(blob) < ivSize {
+		return nil, errors.New("encrypted data key is too short")
+	}
+
+	var decrypted []byte
+	err := p.withToken(func(t token) (err error) {
+		decrypted, err = t.decrypt(blob[:ivSize], blob[ivSize:])
+		return err
+	})
+	return decrypted, err
+}
+
+// Run closes the session of the token when Grafana shuts down.
+func (p *pkcs11Provider) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.closeToken()
+
+	return nil
+}
+
+func (p *pkcs11Provider) withToken(f func(token) error) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.token == nil {
+		t, err := p.open(p.cfg)
+		if err != nil {
+			return err
+		}
+		p.token = t
+	}
+
+	err := f(p.token)
+	if errors.Is(err, errTokenUnavailable) {
+		p.log.Warn("PKCS#11 token unavailable, the session will be reopened", "error", err)
+		p.closeToken()
+	}
+	return err
+}
+
+func (p *pkcs11Provider) closeToken() {
+	if p.token == nil {
+		return
+	}
+	if err := p.token.close(); err != nil {
+		p.log.Debug("Failed to close PKCS#11 session", "error", err)
+	}
+	p.token = nil
+}
@@ -0,0 +1,120 @@
+package pkcs11provider
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeToken encrypts with AES-GCM in software, like a token with the key would.
+type fakeToken struct {
+	aead   cipher.AEAD
+	fail   error
+	closed bool
+}
+
+func newFakeToken(t *testing.T) *fakeToken {
+	block, err := aes.NewCipher([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return &fakeToken{aead: aead}
+}
+
+func (f *fakeToken) encrypt(iv, plaintext []byte) ([]byte, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	return f.aead.Seal(nil, iv, plaintext, nil), nil
+}
+
+func (f *fakeToken) decrypt(iv, ciphertext []byte) ([]byte, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	return f.aead.Open(nil, iv, ciphertext, nil)
+}
+
+func (f *fakeToken) close() error {
+	f.closed = true
+	return nil
+}
+
+func TestPKCS11Provider(t *testing.T) {
+	ctx := context.Background()
+	var opened []*fakeToken
+	p := &pkcs11Provider{
+		cfg: config{module: "/usr/lib/softhsm/libsofthsm2.so", tokenLabel: "grafana", pin: "1234", keyLabel: "kek"},
+		open: func(cfg config) (token, error) {
+			require.Equal(t, "kek", cfg.keyLabel)
+			token := newFakeToken(t)
+			opened = append(opened, token)
+			return token, nil
+		},
+		log: log.NewNopLogger(),
+	}
+
+	t.Run("encrypts with a random nonce", func(t *testing.T) {
+		encrypted, err := p.Encrypt(ctx, []byte("data key"))
+		require.NoError(t, err)
+		again, err := p.Encrypt(ctx, []byte("data key"))
+		require.NoError(t, err)
+		require.NotEqual(t, encrypted, again)
+
+		decrypted, err := p.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), decrypted)
+		require.Len(t, opened, 1)
+
+		_, err = p.Decrypt(ctx, encrypted[:ivSize-1])
+		require.Error(t, err)
+	})
+
+	t.Run("reopens the session once the token is unavailable", func(t *testing.T) {
+		encrypted, err := p.Encrypt(ctx, []byte("data key"))
+		require.NoError(t, err)
+
+		opened[0].fail = fmt.Errorf("%w: C_Decrypt failed with CKR 0xB3", errTokenUnavailable)
+		_, err = p.Decrypt(ctx, encrypted)
+		require.ErrorIs(t, err, errTokenUnavailable)
+		require.True(t, opened[0].closed)
+
+		decrypted, err := p.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), decrypted)
+		require.Len(t, opened, 2)
+	})
+
+	t.Run("closes the session on shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		require.NoError(t, p.Run(ctx))
+		require.True(t, opened[1].closed)
+	})
+}
+
+func TestNew(t *testing.T) {
+	raw, err := ini.Load([]byte(`
+		[security.encryption.pkcs11.v1]
+		module = /usr/lib/softhsm/libsofthsm2.so
+		token_label = grafana
+		key_label = kek`))
+	require.NoError(t, err)
+	cfg := &setting.Cfg{Raw: raw}
+
+	_, err = New(cfg.SectionWithEnvOverrides("security.encryption.pkcs11.v1"))
+	require.ErrorContains(t, err, "pin is required")
+
+	cfg.Raw.Section("security.encryption.pkcs11.v1").Key("pin").SetValue("1234")
+	p, err := New(cfg.SectionWithEnvOverrides("security.encryption.pkcs11.v1"))
+	require.NoError(t, err)
+	require.Equal(t, config{module: "/usr/lib/softhsm/libsofthsm2.so", tokenLabel: "grafana", pin: "1234", keyLabel: "kek"}, p.(*pkcs11Provider).cfg)
+}
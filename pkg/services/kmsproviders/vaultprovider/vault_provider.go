@@ -0,0 +1,178 @@
+package vaultprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Kind is the kind of the encryption providers backed by the Vault Transit secrets engine,
+// they are configured in [security.encryption.hashicorpvault.<key name>] sections.
+const Kind = "hashicorpvault"
+
+// vaultProvider encrypts data keys with a key of the HashiCorp Vault Transit secrets engine.
+// The ciphertext returned by Vault embeds the version of the key, so rotating the key in Vault
+// does not break the decryption of the data keys encrypted with previous versions.
+type vaultProvider struct {
+	client          *http.Client
+	url             string
+	token           string
+	namespace       string
+	mount           string
+	keyRing         string
+	renewalInterval time.Duration
+	log             log.Logger
+}
+
+var _ secrets.BackgroundProvider = vaultProvider{}
+
+func New(section *setting.DynamicSection) (secrets.Provider, error) {
+	p := vaultProvider{
+		url:             strings.TrimSuffix(section.Key("url").String(), "/"),
+		token:           section.Key("token").String(),
+		namespace:       section.Key("namespace").String(),
+		mount:           strings.Trim(section.Key("transit_engine_path").MustString("transit"), "/"),
+		keyRing:         section.Key("key_ring").String(),
+		renewalInterval: section.Key("token_renewal_interval").MustDuration(5 * time.Minute),
+		log:             log.New("secrets.hashicorpvault"),
+	}
+	if p.url == "" {
+		return nil, errors.New("url is required")
+	}
+	if p.keyRing == "" {
+		return nil, errors.New("key_ring is required")
+	}
+	if p.token == "" {
+		p.token = os.Getenv("VAULT_TOKEN")
+	}
+	if p.token == "" {
+		return nil, errors.New("token is required")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCert := section.Key("ca_cert").String(); caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	p.client = &http.Client{
+		Transport: transport,
+		Timeout:   section.Key("timeout").MustDuration(10 * time.Second),
+	}
+
+	return p, nil
+}
+
+func (p vaultProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := p.do(ctx, p.transitPath("encrypt"), map[string]string{"plaintext": base64.StdEncoding.EncodeToString(blob)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Ciphertext), nil
+}
+
+func (p vaultProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err := p.do(ctx, p.transitPath("decrypt"), map[string]string{"ciphertext": string(blob)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// Run renews the token periodically, so that periodic service tokens do not expire.
+func (p vaultProvider) Run(ctx context.Context) error {
+	if p.renewalInterval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(p.renewalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			var auth struct{}
+			if err := p.do(ctx, "auth/token/renew-self", map[string]string{}, &auth); err != nil {
+				p.log.Warn("Failed to renew the Vault token", "error", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p vaultProvider) transitPath(operation string) string {
+	return fmt.Sprintf("%s/%s/%s", p.mount, operation, url.PathEscape(p.keyRing))
+}
+
+// do calls an endpoint of the Vault API and decodes the data of the response.
+func (p vaultProvider) do(ctx context.Context, path string, body any, data any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/v1/"+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s request failed: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("failed to decode vault %s response: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s request failed with status %d: %s", path, resp.StatusCode, strings.Join(result.Errors, ", "))
+	}
+	if len(result.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(result.Data, data)
+}
@@ -0,0 +1,107 @@
+package vaultprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestVaultProvider(t *testing.T) {
+	renewed := make(chan struct{}, 1)
+	// fake Transit engine "encrypting" by prefixing the base64 encoded plaintext
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.Header.Get("X-Vault-Namespace") != "grafana" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/grafana-key":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}})
+		case "/v1/auth/token/renew-self":
+			renewed <- struct{}{}
+			_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"lease_duration": 3600}})
+		case "/v1/transit/decrypt/grafana-key":
+			if !strings.HasPrefix(body["ciphertext"], "vault:v1:") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["invalid ciphertext: no prefix"]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	newProvider := func(t *testing.T, config string) (vaultProvider, error) {
+		raw, err := ini.Load([]byte("[security.encryption.hashicorpvault.v1]\n" + config))
+		require.NoError(t, err)
+		cfg := &setting.Cfg{Raw: raw}
+		p, err := New(cfg.SectionWithEnvOverrides("security.encryption.hashicorpvault.v1"))
+		if err != nil {
+			return vaultProvider{}, err
+		}
+		return p.(vaultProvider), nil
+	}
+
+	t.Run("encrypts and decrypts with the transit key", func(t *testing.T) {
+		p, err := newProvider(t, "url = "+server.URL+"/\ntoken = s.token\nnamespace = grafana\nkey_ring = grafana-key")
+		require.NoError(t, err)
+
+		encrypted, err := p.Encrypt(context.Background(), []byte("data key"))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(encrypted), "vault:v1:"))
+
+		decrypted, err := p.Decrypt(context.Background(), encrypted)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), decrypted)
+	})
+
+	t.Run("returns the errors of vault", func(t *testing.T) {
+		p, err := newProvider(t, "url = "+server.URL+"\ntoken = s.token\nnamespace = grafana\nkey_ring = grafana-key")
+		require.NoError(t, err)
+
+		_, err = p.Decrypt(context.Background(), []byte("invalid"))
+		require.ErrorContains(t, err, "status 400: invalid ciphertext: no prefix")
+
+		p.token = "s.other"
+		_, err = p.Encrypt(context.Background(), []byte("data key"))
+		require.ErrorContains(t, err, "status 403: permission denied")
+	})
+
+	t.Run("renews the token periodically", func(t *testing.T) {
+		p, err := newProvider(t, "url = "+server.URL+"\ntoken = s.token\nnamespace = grafana\nkey_ring = grafana-key\ntoken_renewal_interval = 10ms")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- p.Run(ctx) }()
+
+		<-renewed
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("requires the url, token and key ring", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "")
+		_, err := newProvider(t, "token = s.token\nkey_ring = grafana-key")
+		require.ErrorContains(t, err, "url is required")
+		_, err = newProvider(t, "url = "+server.URL+"\nkey_ring = grafana-key")
+		require.ErrorContains(t, err, "token is required")
+		_, err = newProvider(t, "url = "+server.URL+"\ntoken = s.token")
+		require.ErrorContains(t, err, "key_ring is required")
+	})
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/services/live/telemetry"
+	"github.com/grafana/grafana/pkg/services/live/telemetry/otlp"
 	"github.com/grafana/grafana/pkg/services/live/telemetry/telegraf"
 )
 
 type Converter struct {
 	telegrafConverterWide         *telegraf.Converter
 	telegrafConverterLabelsColumn *telegraf.Converter
+	otlpConverter                 *otlp.Converter
 }
 
 func NewConverter() *Converter {
@@ -22,11 +24,15 @@ func NewConverter() *Converter {
 			telegraf.WithUseLabelsColumn(true),
 			telegraf.WithFloat64Numbers(true),
 		),
+		otlpConverter: otlp.NewConverter(),
 	}
 }
 
 var ErrUnsupportedFrameFormat = errors.New("unsupported frame format")
 
+// FrameFormatOTLP is the frame format of OTLP metrics export requests in protobuf encoding.
+const FrameFormatOTLP = "otlp"
+
 func (c *Converter) Convert(data []byte, frameFormat string) ([]telemetry.FrameWrapper, error) {
 	var converter telemetry.Converter
 	switch frameFormat {
@@ -34,6 +40,8 @@ func (c *Converter) Convert(data []byte, frameFormat string) ([]telemetry.FrameW
 		converter = c.telegrafConverterWide
 	case "labels_column":
 		converter = c.telegrafConverterLabelsColumn
+	case FrameFormatOTLP:
+		converter = c.otlpConverter
 	default:
 		return nil, ErrUnsupportedFrameFormat
 	}
@@ -0,0 +1,160 @@
+package managedstream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// FrameHistory keeps the recent rows of managed stream channels in a ring buffer bounded by
+// the number of rows and their age. The rows are replayed to new subscribers of a channel and
+// can be queried for a time range. History is kept in memory of the Grafana instance the data
+// is pushed to. A nil FrameHistory keeps no history.
+type FrameHistory struct {
+	maxPoints int
+	maxAge    time.Duration
+	now       func() time.Time
+
+	mu       sync.RWMutex
+	channels map[int64]map[string]*channelHistory
+}
+
+func ProvideFrameHistory(cfg *setting.Cfg) *FrameHistory {
+	if cfg.LiveHistoryMaxPoints <= 0 {
+		return nil
+	}
+	return NewFrameHistory(cfg.LiveHistoryMaxPoints, cfg.LiveHistoryMaxAge)
+}
+
+// NewFrameHistory creates a FrameHistory which keeps up to maxPoints rows for each channel.
+// Rows older than maxAge are dropped, zero maxAge keeps rows until the buffer is full.
+func NewFrameHistory(maxPoints int, maxAge time.Duration) *FrameHistory {
+	return &FrameHistory{
+		maxPoints: maxPoints,
+		maxAge:    maxAge,
+		now:       time.Now,
+		channels:  map[int64]map[string]*channelHistory{},
+	}
+}
+
+// channelHistory is the ring buffer of a channel. The rows share the schema of the last
+// frame pushed, the buffer is reset when the schema changes.
+type channelHistory struct {
+	schema    *data.Frame
+	timeIndex int
+	rows      []historyRow
+	start     int
+	size      int
+}
+
+type historyRow struct {
+	time   time.Time
+	values []any
+}
+
+// Append adds the rows of a frame pushed to a channel.
+func (h *FrameHistory) Append(orgID int64, channel string, frame *data.Frame) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.channels[orgID]; !ok {
+		h.channels[orgID] = map[string]*channelHistory{}
+	}
+	c, ok := h.channels[orgID][channel]
+	if !ok || !sameSchema(c.schema, frame) {
+		c = &channelHistory{
+			schema:    frame.EmptyCopy(),
+			timeIndex: timeFieldIndex(frame),
+			rows:      make([]historyRow, h.maxPoints),
+		}
+		h.channels[orgID][channel] = c
+	}
+
+	now := h.now()
+	for i := 0; i < frame.Rows(); i++ {
+		row := historyRow{time: now, values: frame.RowCopy(i)}
+		if c.timeIndex >= 0 {
+			if t, ok := frame.Fields[c.timeIndex].ConcreteAt(i); ok {
+				row.time = t.(time.Time)
+			}
+		}
+		end := (c.start + c.size) % len(c.rows)
+		c.rows[end] = row
+		if c.size < len(c.rows) {
+			c.size++
+		} else {
+			c.start = (c.start + 1) % len(c.rows)
+		}
+	}
+	h.prune(c, now)
+}
+
+// Get returns the rows of a channel between from and to as a frame. Zero from or to
+// leave the range open. The second return value is false if the channel has no history.
+func (h *FrameHistory) Get(orgID int64, channel string, from, to time.Time) (*data.Frame, bool) {
+	if h == nil {
+		return nil, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c, ok := h.channels[orgID][channel]
+	if !ok {
+		return nil, false
+	}
+	h.prune(c, h.now())
+	if c.size == 0 {
+		return nil, false
+	}
+
+	frame := c.schema.EmptyCopy()
+	for i := 0; i < c.size; i++ {
+		row := c.rows[(c.start+i)%len(c.rows)]
+		if (!from.IsZero() && row.time.Before(from)) || (!to.IsZero() && row.time.After(to)) {
+			continue
+		}
+		frame.AppendRow(row.values...)
+	}
+	return frame, true
+}
+
+// prune drops the oldest rows of a channel which exceed the max age.
+func (h *FrameHistory) prune(c *channelHistory, now time.Time) {
+	if h.maxAge <= 0 {
+		return
+	}
+	cutoff := now.Add(-h.maxAge)
+	for c.size > 0 && c.rows[c.start].time.Before(cutoff) {
+		c.rows[c.start] = historyRow{}
+		c.start = (c.start + 1) % len(c.rows)
+		c.size--
+	}
+}
+
+func timeFieldIndex(frame *data.Frame) int {
+	for i, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameSchema(a, b *data.Frame) bool {
+	if a.Name != b.Name || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i, f := range a.Fields {
+		other := b.Fields[i]
+		if f.Name != other.Name || f.Type() != other.Type() || f.Labels.String() != other.Labels.String() {
+			return false
+		}
+	}
+	return true
+}
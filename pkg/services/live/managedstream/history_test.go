@@ -0,0 +1,82 @@
+package managedstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func historyTestFrame(start time.Time, values ...float64) *data.Frame {
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = start.Add(time.Duration(i) * time.Second)
+	}
+	return data.NewFrame("cpu",
+		data.NewField("time", nil, times),
+		data.NewField("value", nil, values),
+	)
+}
+
+func TestFrameHistory(t *testing.T) {
+	now := time.Unix(1000, 0)
+	h := NewFrameHistory(3, time.Minute)
+	h.now = func() time.Time { return now }
+
+	h.Append(1, "stream/test/cpu", historyTestFrame(now.Add(-10*time.Second), 1, 2))
+	h.Append(1, "stream/test/cpu", historyTestFrame(now.Add(-8*time.Second), 3, 4))
+
+	t.Run("keeps the last rows", func(t *testing.T) {
+		frame, ok := h.Get(1, "stream/test/cpu", time.Time{}, time.Time{})
+		require.True(t, ok)
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, []float64{2, 3, 4}, []float64{
+			frame.Fields[1].At(0).(float64),
+			frame.Fields[1].At(1).(float64),
+			frame.Fields[1].At(2).(float64),
+		})
+	})
+
+	t.Run("filters rows by time range", func(t *testing.T) {
+		frame, ok := h.Get(1, "stream/test/cpu", now.Add(-8*time.Second), now.Add(-8*time.Second))
+		require.True(t, ok)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, 3.0, frame.Fields[1].At(0))
+	})
+
+	t.Run("keeps channels of orgs separate", func(t *testing.T) {
+		_, ok := h.Get(2, "stream/test/cpu", time.Time{}, time.Time{})
+		require.False(t, ok)
+	})
+
+	t.Run("drops rows older than max age", func(t *testing.T) {
+		now = now.Add(time.Minute - 7*time.Second)
+		frame, ok := h.Get(1, "stream/test/cpu", time.Time{}, time.Time{})
+		require.True(t, ok)
+		require.Equal(t, 1, frame.Rows())
+
+		now = now.Add(time.Minute)
+		_, ok = h.Get(1, "stream/test/cpu", time.Time{}, time.Time{})
+		require.False(t, ok)
+	})
+
+	t.Run("resets the history when the schema changes", func(t *testing.T) {
+		h.Append(1, "stream/test/mem", historyTestFrame(now, 1))
+		h.Append(1, "stream/test/mem", data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{now}),
+			data.NewField("value", nil, []string{"high"}),
+		))
+		frame, ok := h.Get(1, "stream/test/mem", time.Time{}, time.Time{})
+		require.True(t, ok)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, "high", frame.Fields[1].At(0))
+	})
+}
+
+func TestFrameHistory_Nil(t *testing.T) {
+	var h *FrameHistory
+	h.Append(1, "stream/test/cpu", historyTestFrame(time.Now(), 1))
+	_, ok := h.Get(1, "stream/test/cpu", time.Time{}, time.Time{})
+	require.False(t, ok)
+}
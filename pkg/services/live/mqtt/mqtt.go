@@ -0,0 +1,130 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/at-wat/mqtt-go"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	logger = log.New("live.mqtt")
+)
+
+// inputProcessor processes the data of a channel with the channel rule its name matches.
+type inputProcessor interface {
+	ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error)
+}
+
+func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Input {
+	return &Input{
+		cfg:         cfg.LiveMQTT,
+		GrafanaLive: live,
+	}
+}
+
+// Input subscribes to topics of an MQTT broker and publishes their messages to Grafana Live.
+// A message of topic <topic> is processed as input of the channel <channel>/<topic>, so it
+// flows through the channel rule whose pattern matches that channel, like pushed data.
+type Input struct {
+	GrafanaLive *live.GrafanaLive
+
+	cfg setting.LiveMQTTSettings
+}
+
+func (i *Input) IsDisabled() bool {
+	return !i.cfg.Enabled
+}
+
+// Run Input.
+func (i *Input) Run(ctx context.Context) error {
+	if i.GrafanaLive.Pipeline == nil {
+		logger.Warn("MQTT input requires the live pipeline, not subscribing to topics")
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	u, err := url.Parse(i.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid MQTT broker url: %w", err)
+	}
+	dialOptions := []mqtt.DialOption{
+		mqtt.WithConnStateHandler(func(state mqtt.ConnState, err error) {
+			logger.Debug("MQTT connection state changed", "state", state.String(), "error", err)
+		}),
+	}
+	if u.Scheme == "mqtts" || u.Scheme == "wss" {
+		dialOptions = append(dialOptions, mqtt.WithTLSConfig(&tls.Config{
+			ServerName: u.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		}))
+	}
+	client, err := mqtt.NewReconnectClient(&mqtt.URLDialer{URL: i.cfg.URL, Options: dialOptions})
+	if err != nil {
+		return fmt.Errorf("error creating MQTT client: %w", err)
+	}
+	client.Handle(mqtt.HandlerFunc(func(msg *mqtt.Message) {
+		i.handle(ctx, i.GrafanaLive.Pipeline, msg.Topic, msg.Payload)
+	}))
+
+	connectOptions := []mqtt.ConnectOption{
+		mqtt.WithCleanSession(true),
+		mqtt.WithKeepAlive(30),
+	}
+	if i.cfg.Username != "" {
+		connectOptions = append(connectOptions, mqtt.WithUserNamePassword(i.cfg.Username, i.cfg.Password))
+	}
+	// Blocks until the broker accepts the first connection, the client reconnects on its own afterwards.
+	if _, err := client.Connect(ctx, i.cfg.ClientID, connectOptions...); err != nil {
+		return fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+
+	subscriptions := make([]mqtt.Subscription, 0, len(i.cfg.Topics))
+	for _, topic := range i.cfg.Topics {
+		subscriptions = append(subscriptions, mqtt.Subscription{Topic: topic, QoS: mqtt.QoS1})
+	}
+	if _, err := client.Subscribe(ctx, subscriptions...); err != nil {
+		return fmt.Errorf("error subscribing to MQTT topics: %w", err)
+	}
+	logger.Info("MQTT input subscribed to topics", "url", u.Redacted(), "topics", i.cfg.Topics)
+
+	<-ctx.Done()
+	disconnectCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Disconnect(disconnectCtx); err != nil {
+		logger.Warn("Error disconnecting from MQTT broker", "error", err)
+	}
+	return ctx.Err()
+}
+
+func (i *Input) handle(ctx context.Context, processor inputProcessor, topic string, payload []byte) {
+	channelID := topicToChannel(i.cfg.Channel, topic)
+	logger.Debug("MQTT message", "topic", topic, "channel", channelID, "bodyLength", len(payload))
+
+	ruleFound, err := processor.ProcessInput(ctx, i.cfg.OrgID, channelID, payload)
+	if err != nil {
+		logger.Error("Pipeline input processing error", "error", err, "topic", topic, "channel", channelID)
+		return
+	}
+	if !ruleFound {
+		logger.Warn("No conversion rule for a channel", "topic", topic, "channel", channelID)
+	}
+}
+
+var invalidChannelPathChars = regexp.MustCompile(`[^A-Za-z0-9_\-/=.]`)
+
+// topicToChannel returns the channel of a topic. Characters of the topic which are not
+// allowed in channel paths are replaced with underscores.
+func topicToChannel(prefix, topic string) string {
+	path := invalidChannelPathChars.ReplaceAllString(strings.Trim(topic, "/"), "_")
+	return prefix + "/" + path
+}
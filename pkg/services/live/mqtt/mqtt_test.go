@@ -0,0 +1,56 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeInputProcessor struct {
+	ruleFound bool
+	err       error
+
+	orgID     int64
+	channelID string
+	body      []byte
+}
+
+func (p *fakeInputProcessor) ProcessInput(_ context.Context, orgID int64, channelID string, body []byte) (bool, error) {
+	p.orgID = orgID
+	p.channelID = channelID
+	p.body = body
+	return p.ruleFound, p.err
+}
+
+func TestTopicToChannel(t *testing.T) {
+	for topic, channel := range map[string]string{
+		"sensors/room1/temp":   "stream/mqtt/sensors/room1/temp",
+		"/sensors/temp/":       "stream/mqtt/sensors/temp",
+		"home/living room/co2": "stream/mqtt/home/living_room/co2",
+		"a+b$c":                "stream/mqtt/a_b_c",
+	} {
+		require.Equal(t, channel, topicToChannel("stream/mqtt", topic), topic)
+	}
+}
+
+func TestInput_Handle(t *testing.T) {
+	i := &Input{cfg: setting.LiveMQTTSettings{Channel: "stream/mqtt", OrgID: 2}}
+
+	t.Run("processes the message as input of the topic channel", func(t *testing.T) {
+		p := &fakeInputProcessor{ruleFound: true}
+		i.handle(context.Background(), p, "sensors/temp", []byte("temp value=1"))
+		require.Equal(t, int64(2), p.orgID)
+		require.Equal(t, "stream/mqtt/sensors/temp", p.channelID)
+		require.Equal(t, []byte("temp value=1"), p.body)
+	})
+
+	t.Run("does not fail on processing errors", func(t *testing.T) {
+		p := &fakeInputProcessor{err: errors.New("boom")}
+		i.handle(context.Background(), p, "sensors/temp", []byte("invalid"))
+		require.Equal(t, "stream/mqtt/sensors/temp", p.channelID)
+	})
+}
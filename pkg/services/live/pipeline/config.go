@@ -40,6 +40,7 @@ type ConverterConfig struct {
 	ExactJsonConverterConfig  *ExactJsonConverterConfig  `json:"jsonExact,omitempty"`
 	AutoInfluxConverterConfig *AutoInfluxConverterConfig `json:"influxAuto,omitempty"`
 	JsonFrameConverterConfig  *JsonFrameConverterConfig  `json:"jsonFrame,omitempty"`
+	OTLPConverterConfig       *OTLPConverterConfig       `json:"otlp,omitempty"`
 }
 
 type DropFieldsFrameProcessorConfig struct {
@@ -55,12 +56,23 @@ type FrameProcessorConfig struct {
 	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
 	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
 	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	WindowProcessorConfig     *WindowFrameProcessorConfig     `json:"window,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
 	Processors []FrameProcessorConfig `json:"processors"`
 }
 
+type WindowFrameProcessorConfig struct {
+	// SizeMilliseconds is the length of a window.
+	SizeMilliseconds int64 `json:"sizeMilliseconds"`
+	// StepMilliseconds is how often a window is emitted. Windows are tumbling when it is
+	// zero or equal to the size, and sliding when it is smaller than the size.
+	StepMilliseconds int64 `json:"stepMilliseconds,omitempty"`
+	// Aggregations to calculate for every numeric field, all of them by default.
+	Aggregations []WindowAggregation `json:"aggregations,omitempty"`
+}
+
 type MultipleOutputterConfig struct {
 	Outputters []FrameOutputterConfig `json:"outputs"`
 }
@@ -152,4 +164,6 @@ type AutoInfluxConverterConfig struct {
 
 type JsonFrameConverterConfig struct{}
 
+type OTLPConverterConfig struct{}
+
 type ManagedStreamOutputConfig struct{}
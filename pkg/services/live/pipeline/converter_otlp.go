@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/live/convert"
+)
+
+// OTLPConverter decodes OTLP metrics export requests in protobuf encoding and
+// transforms them to several ChannelFrame objects where Channel is constructed
+// from original channel + / + <metric_name>.
+type OTLPConverter struct {
+	config    OTLPConverterConfig
+	converter *convert.Converter
+}
+
+// NewOTLPConverter creates new OTLPConverter.
+func NewOTLPConverter(config OTLPConverterConfig) *OTLPConverter {
+	return &OTLPConverter{config: config, converter: convert.NewConverter()}
+}
+
+const ConverterTypeOTLP = "otlp"
+
+func (c *OTLPConverter) Type() string {
+	return ConverterTypeOTLP
+}
+
+func (c *OTLPConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	frameWrappers, err := c.converter.Convert(body, convert.FrameFormatOTLP)
+	if err != nil {
+		return nil, err
+	}
+	channelFrames := make([]*ChannelFrame, 0, len(frameWrappers))
+	for _, fw := range frameWrappers {
+		channelFrames = append(channelFrames, &ChannelFrame{
+			Channel: vars.Channel + "/" + fw.Key(),
+			Frame:   fw.Frame(),
+		})
+	}
+	return channelFrames, nil
+}
@@ -0,0 +1,295 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// WindowAggregation is an aggregation calculated over the values of a field in a window.
+type WindowAggregation string
+
+// Known window aggregations.
+const (
+	WindowAggregationAvg   WindowAggregation = "avg"
+	WindowAggregationMin   WindowAggregation = "min"
+	WindowAggregationMax   WindowAggregation = "max"
+	WindowAggregationCount WindowAggregation = "count"
+	WindowAggregationLast  WindowAggregation = "last"
+)
+
+var windowAggregations = []WindowAggregation{
+	WindowAggregationAvg,
+	WindowAggregationMin,
+	WindowAggregationMax,
+	WindowAggregationCount,
+	WindowAggregationLast,
+}
+
+// WindowFrameProcessor buffers the frames of a channel and replaces them with aggregates of
+// their numeric fields over tumbling or sliding windows. Fields with the same name and labels
+// are aggregated together. A window is emitted when the first row after its end arrives, frames
+// which do not complete a window are dropped.
+type WindowFrameProcessor struct {
+	size         time.Duration
+	step         time.Duration
+	aggregations []WindowAggregation
+
+	mu       sync.Mutex
+	channels map[string]*windowState
+	now      func() time.Time
+}
+
+func NewWindowFrameProcessor(config WindowFrameProcessorConfig) (*WindowFrameProcessor, error) {
+	size := time.Duration(config.SizeMilliseconds) * time.Millisecond
+	step := time.Duration(config.StepMilliseconds) * time.Millisecond
+	if size <= 0 {
+		return nil, fmt.Errorf("window size must be positive")
+	}
+	if step < 0 || step > size {
+		return nil, fmt.Errorf("window step must be between 0 and the window size")
+	}
+	if step == 0 {
+		step = size
+	}
+	aggregations := config.Aggregations
+	if len(aggregations) == 0 {
+		aggregations = windowAggregations
+	}
+	for _, a := range aggregations {
+		if !isWindowAggregation(a) {
+			return nil, fmt.Errorf("unknown window aggregation: %s", a)
+		}
+	}
+	return &WindowFrameProcessor{
+		size:         size,
+		step:         step,
+		aggregations: aggregations,
+		channels:     map[string]*windowState{},
+		now:          time.Now,
+	}, nil
+}
+
+const FrameProcessorTypeWindow = "window"
+
+func (p *WindowFrameProcessor) Type() string {
+	return FrameProcessorTypeWindow
+}
+
+func (p *WindowFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIndex := -1
+	for i, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			timeIndex = i
+			break
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := fmt.Sprintf("%d/%s", vars.OrgID, vars.Channel)
+	state, ok := p.channels[key]
+	if !ok {
+		state = &windowState{series: map[string]*windowSeries{}}
+		p.channels[key] = state
+	}
+
+	var windows []windowAggregate
+	for row := 0; row < frame.Rows(); row++ {
+		t := p.now()
+		if timeIndex >= 0 {
+			if v, ok := frame.Fields[timeIndex].ConcreteAt(row); ok {
+				t = v.(time.Time)
+			}
+		}
+		windows = append(windows, p.advance(state, t)...)
+		if t.Before(state.boundary.Add(p.step - p.size)) {
+			// The row belongs to windows that were already emitted.
+			continue
+		}
+		for _, f := range frame.Fields {
+			if !f.Type().Numeric() {
+				continue
+			}
+			v, err := f.FloatAt(row)
+			if err != nil || math.IsNaN(v) {
+				continue
+			}
+			state.add(f, t, v)
+		}
+	}
+	if len(state.series) == 0 {
+		delete(p.channels, key)
+	}
+
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	return p.frame(frame.Name, windows), nil
+}
+
+// advance emits the windows which end at or before t.
+func (p *WindowFrameProcessor) advance(state *windowState, t time.Time) []windowAggregate {
+	if state.boundary.IsZero() {
+		state.boundary = t.Truncate(p.step)
+		return nil
+	}
+	var windows []windowAggregate
+	for end := state.boundary.Add(p.step); !end.After(t); end = state.boundary.Add(p.step) {
+		if len(state.series) == 0 {
+			// Nothing to aggregate until t, skip the empty windows.
+			state.boundary = t.Truncate(p.step)
+			break
+		}
+		windows = append(windows, state.aggregate(end.Add(-p.size), end))
+		state.boundary = end
+		state.prune(end.Add(p.step - p.size))
+	}
+	return windows
+}
+
+func (p *WindowFrameProcessor) frame(name string, windows []windowAggregate) *data.Frame {
+	keys := map[string]*windowSeries{}
+	for _, w := range windows {
+		for k, s := range w.series {
+			keys[k] = s.series
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	timeField := data.NewField("time", nil, make([]time.Time, len(windows)))
+	fields := []*data.Field{timeField}
+	for i, w := range windows {
+		timeField.Set(i, w.end)
+	}
+	for _, k := range sorted {
+		series := keys[k]
+		for _, a := range p.aggregations {
+			field := data.NewField(series.name+"_"+string(a), series.labels.Copy(), make([]*float64, len(windows)))
+			for i, w := range windows {
+				if s, ok := w.series[k]; ok {
+					v := s.value(a)
+					field.Set(i, &v)
+				}
+			}
+			fields = append(fields, field)
+		}
+	}
+	return data.NewFrame(name, fields...)
+}
+
+func isWindowAggregation(a WindowAggregation) bool {
+	for _, known := range windowAggregations {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+// windowState holds the values of a channel that are part of windows not emitted yet.
+type windowState struct {
+	// boundary is the end of the last emitted window.
+	boundary time.Time
+	series   map[string]*windowSeries
+}
+
+type windowSeries struct {
+	name   string
+	labels data.Labels
+	times  []time.Time
+	values []float64
+}
+
+type windowAggregate struct {
+	end    time.Time
+	series map[string]windowSeriesAggregate
+}
+
+type windowSeriesAggregate struct {
+	series           *windowSeries
+	sum, min, max, n float64
+	last             float64
+}
+
+func (s *windowState) add(f *data.Field, t time.Time, v float64) {
+	key := f.Name + "{" + f.Labels.String() + "}"
+	series, ok := s.series[key]
+	if !ok {
+		series = &windowSeries{name: f.Name, labels: f.Labels}
+		s.series[key] = series
+	}
+	series.times = append(series.times, t)
+	series.values = append(series.values, v)
+}
+
+func (s *windowState) aggregate(start, end time.Time) windowAggregate {
+	w := windowAggregate{end: end, series: map[string]windowSeriesAggregate{}}
+	for key, series := range s.series {
+		a := windowSeriesAggregate{series: series, min: math.Inf(1), max: math.Inf(-1)}
+		var last time.Time
+		for i, t := range series.times {
+			if t.Before(start) || !t.Before(end) {
+				continue
+			}
+			v := series.values[i]
+			a.sum += v
+			a.n++
+			a.min = math.Min(a.min, v)
+			a.max = math.Max(a.max, v)
+			if !t.Before(last) {
+				last = t
+				a.last = v
+			}
+		}
+		if a.n > 0 {
+			w.series[key] = a
+		}
+	}
+	return w
+}
+
+// prune removes the values before start, which are not part of any window that can still be emitted.
+func (s *windowState) prune(start time.Time) {
+	for key, series := range s.series {
+		n := 0
+		for i, t := range series.times {
+			if t.Before(start) {
+				continue
+			}
+			series.times[n] = t
+			series.values[n] = series.values[i]
+			n++
+		}
+		series.times = series.times[:n]
+		series.values = series.values[:n]
+		if n == 0 {
+			delete(s.series, key)
+		}
+	}
+}
+
+func (a windowSeriesAggregate) value(aggregation WindowAggregation) float64 {
+	switch aggregation {
+	case WindowAggregationAvg:
+		return a.sum / a.n
+	case WindowAggregationMin:
+		return a.min
+	case WindowAggregationMax:
+		return a.max
+	case WindowAggregationCount:
+		return a.n
+	default:
+		return a.last
+	}
+}
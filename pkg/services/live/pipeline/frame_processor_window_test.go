@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func windowTestFrame(t time.Time, host string, value float64) *data.Frame {
+	return data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{t}),
+		data.NewField("value", data.Labels{"host": host}, []float64{value}),
+		data.NewField("status", nil, []string{"ok"}),
+	)
+}
+
+func TestWindowFrameProcessor_Tumbling(t *testing.T) {
+	p, err := NewWindowFrameProcessor(WindowFrameProcessorConfig{SizeMilliseconds: 10000})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+	start := time.Unix(100, 0)
+
+	for i, v := range []float64{1, 5, 3} {
+		frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(time.Duration(i)*time.Second), "a", v))
+		require.NoError(t, err)
+		require.Nil(t, frame)
+	}
+	frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(2*time.Second), "b", 10))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(10*time.Second), "a", 7))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, start.Add(10*time.Second), frame.Fields[0].At(0))
+
+	values := map[string]float64{}
+	for _, f := range frame.Fields[1:] {
+		values[f.Name+"{"+f.Labels.String()+"}"] = *f.At(0).(*float64)
+	}
+	require.Equal(t, map[string]float64{
+		"value_avg{host=a}":   3,
+		"value_min{host=a}":   1,
+		"value_max{host=a}":   5,
+		"value_count{host=a}": 3,
+		"value_last{host=a}":  3,
+		"value_avg{host=b}":   10,
+		"value_min{host=b}":   10,
+		"value_max{host=b}":   10,
+		"value_count{host=b}": 1,
+		"value_last{host=b}":  10,
+	}, values)
+
+	t.Run("drops rows of emitted windows", func(t *testing.T) {
+		frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(5*time.Second), "a", 100))
+		require.NoError(t, err)
+		require.Nil(t, frame)
+
+		frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(25*time.Second), "a", 0))
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+		require.Len(t, frame.Fields, 6)
+		require.Equal(t, 7.0, *frame.Fields[1].At(0).(*float64))
+	})
+
+	t.Run("keeps windows of channels separate", func(t *testing.T) {
+		frame, err := p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, windowTestFrame(start.Add(40*time.Second), "a", 1))
+		require.NoError(t, err)
+		require.Nil(t, frame)
+	})
+}
+
+func TestWindowFrameProcessor_Sliding(t *testing.T) {
+	p, err := NewWindowFrameProcessor(WindowFrameProcessorConfig{
+		SizeMilliseconds: 10000,
+		StepMilliseconds: 5000,
+		Aggregations:     []WindowAggregation{WindowAggregationCount},
+	})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+	start := time.Unix(100, 0)
+
+	for i := 0; i < 12; i++ {
+		frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start.Add(time.Duration(i)*time.Second), "a", 1))
+		require.NoError(t, err)
+		switch i {
+		case 5:
+			// [95s, 105s)
+			require.Equal(t, 5.0, *frame.Fields[1].At(0).(*float64))
+		case 10:
+			// [100s, 110s)
+			require.Equal(t, 10.0, *frame.Fields[1].At(0).(*float64))
+		default:
+			require.Nil(t, frame)
+		}
+	}
+}
+
+func TestNewWindowFrameProcessor_InvalidConfig(t *testing.T) {
+	for _, config := range []WindowFrameProcessorConfig{
+		{},
+		{SizeMilliseconds: 1000, StepMilliseconds: 2000},
+		{SizeMilliseconds: 1000, Aggregations: []WindowAggregation{"median"}},
+	} {
+		_, err := NewWindowFrameProcessor(config)
+		require.Error(t, err)
+	}
+}
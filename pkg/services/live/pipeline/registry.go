@@ -66,6 +66,10 @@ var ConvertersRegistry = []EntityInfo{
 		Type:        ConverterTypeJsonFrame,
 		Description: "JSON-encoded Grafana data frame",
 	},
+	{
+		Type:        ConverterTypeOTLP,
+		Description: "accept OTLP metrics in protobuf encoding",
+	},
 }
 
 var FrameProcessorsRegistry = []EntityInfo{
@@ -79,6 +83,14 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeWindow,
+		Description: "aggregate numeric fields over tumbling or sliding time windows",
+		Example: WindowFrameProcessorConfig{
+			SizeMilliseconds: 10000,
+			Aggregations:     []WindowAggregation{WindowAggregationAvg, WindowAggregationMax},
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
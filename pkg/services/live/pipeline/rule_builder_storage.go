@@ -69,6 +69,11 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 			return nil, missingConfiguration
 		}
 		return NewAutoInfluxConverter(*config.AutoInfluxConverterConfig), nil
+	case ConverterTypeOTLP:
+		if config.OTLPConverterConfig == nil {
+			config.OTLPConverterConfig = &OTLPConverterConfig{}
+		}
+		return NewOTLPConverter(*config.OTLPConverterConfig), nil
 	default:
 		return nil, fmt.Errorf("unknown converter type: %s", config.Type)
 	}
@@ -104,6 +109,11 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			processors = append(processors, proc)
 		}
 		return NewMultipleFrameProcessor(processors...), nil
+	case FrameProcessorTypeWindow:
+		if config.WindowProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewWindowFrameProcessor(*config.WindowProcessorConfig)
 	default:
 		return nil, fmt.Errorf("unknown processor type: %s", config.Type)
 	}
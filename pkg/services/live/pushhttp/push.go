@@ -1,9 +1,11 @@
 package pushhttp
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
 
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
@@ -19,6 +21,9 @@ import (
 
 var (
 	logger = log.New("live.push_http")
+
+	// errBodyTooLarge is returned when the body of a push request is larger than the configured maximum.
+	errBodyTooLarge = errors.New("request body too large")
 )
 
 func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Gateway {
@@ -58,11 +63,14 @@ func (g *Gateway) Handle(ctx *contextmodel.ReqContext) {
 	// TODO Grafana 8: decide which formats to use or keep all.
 	urlValues := ctx.Req.URL.Query()
 	frameFormat := pushurl.FrameFormatFromValues(urlValues)
+	if isOTLPRequest(ctx.Req) {
+		frameFormat = convert.FrameFormatOTLP
+	}
 
-	body, err := io.ReadAll(ctx.Req.Body)
+	body, err := readBody(ctx.Req, g.Cfg.LivePushMaxBodySize)
 	if err != nil {
 		logger.Error("Error reading body", "error", err)
-		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		ctx.Resp.WriteHeader(readBodyErrorStatus(err))
 		return
 	}
 	logger.Debug("Live Push request",
@@ -98,13 +106,50 @@ func (g *Gateway) Handle(ctx *contextmodel.ReqContext) {
 	ctx.Resp.WriteHeader(http.StatusOK)
 }
 
+// isOTLPRequest returns true for requests of OTLP/HTTP exporters, which send
+// metrics export requests in protobuf encoding.
+func isOTLPRequest(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-protobuf"
+}
+
+// readBody reads the body of the request, decompressed if it is gzip encoded. It returns
+// errBodyTooLarge if the body is larger than maxSize bytes.
+func readBody(req *http.Request, maxSize int64) ([]byte, error) {
+	var reader io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = gzipReader.Close() }()
+		reader = gzipReader
+	}
+	// one more byte than the maximum is read to know if the body is too large
+	body, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, errBodyTooLarge
+	}
+	return body, nil
+}
+
+func readBodyErrorStatus(err error) int {
+	if errors.Is(err, errBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
 func (g *Gateway) HandlePipelinePush(ctx *contextmodel.ReqContext) {
 	channelID := web.Params(ctx.Req)["*"]
 
-	body, err := io.ReadAll(ctx.Req.Body)
+	body, err := readBody(ctx.Req, g.Cfg.LivePushMaxBodySize)
 	if err != nil {
 		logger.Error("Error reading body", "error", err)
-		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		ctx.Resp.WriteHeader(readBodyErrorStatus(err))
 		return
 	}
 	logger.Debug("Live channel push request",
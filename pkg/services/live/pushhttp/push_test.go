@@ -0,0 +1,50 @@
+package pushhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBody(t *testing.T) {
+	gzipped := func(t *testing.T, body string) *http.Request {
+		t.Helper()
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(body))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		req := httptest.NewRequest(http.MethodPost, "/", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		return req
+	}
+
+	t.Run("reads the body up to the maximum size", func(t *testing.T) {
+		body, err := readBody(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("test")), 4)
+		require.NoError(t, err)
+		require.Equal(t, "test", string(body))
+
+		body, err = readBody(gzipped(t, "test"), 4)
+		require.NoError(t, err)
+		require.Equal(t, "test", string(body))
+	})
+
+	t.Run("rejects a body larger than the maximum size", func(t *testing.T) {
+		_, err := readBody(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("test")), 3)
+		require.ErrorIs(t, err, errBodyTooLarge)
+	})
+
+	t.Run("rejects a gzip body larger than the maximum size once decompressed", func(t *testing.T) {
+		req := gzipped(t, strings.Repeat("a", 1024*1024))
+		require.Less(t, req.ContentLength, int64(1024*1024))
+
+		_, err := readBody(req, 1024)
+		require.ErrorIs(t, err, errBodyTooLarge)
+		require.Equal(t, http.StatusRequestEntityTooLarge, readBodyErrorStatus(err))
+	})
+}
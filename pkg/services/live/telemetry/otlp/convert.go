@@ -0,0 +1,190 @@
+package otlp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/grafana/grafana/pkg/services/live/telemetry"
+)
+
+var _ telemetry.Converter = (*Converter)(nil)
+
+// Converter converts OTLP metrics export requests in protobuf encoding to Grafana frames.
+type Converter struct{}
+
+// NewConverter creates new Converter from OTLP metrics to Grafana Data Frames.
+// This converter generates one frame for each metric name, with a labels column
+// built from the resource and data point attributes.
+func NewConverter() *Converter {
+	return &Converter{}
+}
+
+// Convert metrics.
+func (c *Converter) Convert(body []byte) ([]telemetry.FrameWrapper, error) {
+	req := pmetricotlp.NewExportRequest()
+	if err := req.UnmarshalProto(body); err != nil {
+		return nil, fmt.Errorf("error parsing metrics: %w", err)
+	}
+
+	// maintain the order of frames as they appear in input.
+	var frameKeyOrder []string
+	metricFrames := make(map[string]*metricFrame)
+
+	rms := req.Metrics().ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				frame, ok := metricFrames[m.Name()]
+				if !ok {
+					frame = newMetricFrame(m)
+					if frame == nil {
+						// Metrics without a data point type.
+						continue
+					}
+					frameKeyOrder = append(frameKeyOrder, m.Name())
+					metricFrames[m.Name()] = frame
+				}
+				if err := frame.append(rm.Resource().Attributes(), m); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	frameWrappers := make([]telemetry.FrameWrapper, 0, len(metricFrames))
+	for _, key := range frameKeyOrder {
+		frameWrappers = append(frameWrappers, metricFrames[key])
+	}
+	return frameWrappers, nil
+}
+
+type metricFrame struct {
+	key        string
+	metricType pmetric.MetricType
+	fields     []*data.Field
+}
+
+// newMetricFrame returns an empty frame for the type of the metric. Gauges and sums
+// have a value field, histograms and summaries have count and sum fields.
+func newMetricFrame(m pmetric.Metric) *metricFrame {
+	s := &metricFrame{
+		key:        m.Name(),
+		metricType: m.Type(),
+		fields: []*data.Field{
+			data.NewField("labels", nil, []string{}),
+			data.NewField("time", nil, []time.Time{}),
+		},
+	}
+	switch m.Type() {
+	case pmetric.MetricTypeGauge, pmetric.MetricTypeSum:
+		s.fields = append(s.fields, data.NewField("value", nil, []*float64{}))
+	case pmetric.MetricTypeHistogram, pmetric.MetricTypeExponentialHistogram, pmetric.MetricTypeSummary:
+		s.fields = append(s.fields,
+			data.NewField("count", nil, []*float64{}),
+			data.NewField("sum", nil, []*float64{}),
+		)
+	default:
+		return nil
+	}
+	return s
+}
+
+// Key returns a key which describes Frame metrics.
+func (s *metricFrame) Key() string {
+	return s.key
+}
+
+// Frame transforms metricFrame to Grafana data.Frame.
+func (s *metricFrame) Frame() *data.Frame {
+	return data.NewFrame(s.key, s.fields...)
+}
+
+// append the data points of a metric to the frame.
+func (s *metricFrame) append(resource pcommon.Map, m pmetric.Metric) error {
+	if m.Type() != s.metricType {
+		return fmt.Errorf("metric %s has data points of type %s and %s", m.Name(), s.metricType, m.Type())
+	}
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		s.appendNumberDataPoints(resource, m.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		s.appendNumberDataPoints(resource, m.Sum().DataPoints())
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			s.appendRow(resource, dp.Attributes(), dp.Timestamp(), countValue(dp.Count()), sumValue(dp.Sum(), dp.HasSum()))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			s.appendRow(resource, dp.Attributes(), dp.Timestamp(), countValue(dp.Count()), sumValue(dp.Sum(), dp.HasSum()))
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			s.appendRow(resource, dp.Attributes(), dp.Timestamp(), countValue(dp.Count()), sumValue(dp.Sum(), true))
+		}
+	}
+	return nil
+}
+
+func (s *metricFrame) appendNumberDataPoints(resource pcommon.Map, dps pmetric.NumberDataPointSlice) {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		var v *float64
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeDouble:
+			f := dp.DoubleValue()
+			v = &f
+		case pmetric.NumberDataPointValueTypeInt:
+			f := float64(dp.IntValue())
+			v = &f
+		}
+		s.appendRow(resource, dp.Attributes(), dp.Timestamp(), v)
+	}
+}
+
+func (s *metricFrame) appendRow(resource, attributes pcommon.Map, ts pcommon.Timestamp, values ...*float64) {
+	s.fields[0].Append(attributesToLabels(resource, attributes).String())
+	s.fields[1].Append(ts.AsTime())
+	for i, v := range values {
+		s.fields[2+i].Append(v)
+	}
+}
+
+// attributesToLabels merges the attributes of a resource and a data point, the
+// attributes of the data point take precedence.
+func attributesToLabels(resource, attributes pcommon.Map) data.Labels {
+	labels := data.Labels{}
+	for _, attrs := range []pcommon.Map{resource, attributes} {
+		attrs.Range(func(k string, v pcommon.Value) bool {
+			labels[k] = v.AsString()
+			return true
+		})
+	}
+	return labels
+}
+
+func countValue(count uint64) *float64 {
+	v := float64(count)
+	return &v
+}
+
+func sumValue(sum float64, ok bool) *float64 {
+	if !ok {
+		return nil
+	}
+	return &sum
+}
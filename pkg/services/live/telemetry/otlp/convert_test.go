@@ -0,0 +1,65 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+)
+
+func TestConverter_Convert(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("cpu.usage")
+	dps := gauge.SetEmptyGauge().DataPoints()
+	for i, host := range []string{"a", "b"} {
+		dp := dps.AppendEmpty()
+		dp.Attributes().PutStr("host", host)
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		dp.SetDoubleValue(float64(i) + 0.5)
+	}
+
+	histogram := metrics.AppendEmpty()
+	histogram.SetName("http.duration")
+	hdp := histogram.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+	hdp.SetCount(3)
+	hdp.SetSum(1.5)
+
+	body, err := pmetricotlp.NewExportRequestFromMetrics(md).MarshalProto()
+	require.NoError(t, err)
+
+	frameWrappers, err := NewConverter().Convert(body)
+	require.NoError(t, err)
+	require.Len(t, frameWrappers, 2)
+
+	require.Equal(t, "cpu.usage", frameWrappers[0].Key())
+	frame := frameWrappers[0].Frame()
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, "host=a, service.name=checkout", frame.Fields[0].At(0))
+	require.Equal(t, "host=b, service.name=checkout", frame.Fields[0].At(1))
+	require.Equal(t, now, frame.Fields[1].At(0))
+	require.Equal(t, 0.5, *frame.Fields[2].At(0).(*float64))
+	require.Equal(t, 1.5, *frame.Fields[2].At(1).(*float64))
+
+	require.Equal(t, "http.duration", frameWrappers[1].Key())
+	frame = frameWrappers[1].Frame()
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, "count", frame.Fields[2].Name)
+	require.Equal(t, 3.0, *frame.Fields[2].At(0).(*float64))
+	require.Equal(t, 1.5, *frame.Fields[3].At(0).(*float64))
+}
+
+func TestConverter_Convert_InvalidBody(t *testing.T) {
+	_, err := NewConverter().Convert([]byte("cpu,host=a value=1"))
+	require.Error(t, err)
+}
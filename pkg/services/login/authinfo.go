@@ -32,6 +32,7 @@ const (
 	AuthProxyAuthModule = "authproxy"
 	JWTModule           = "jwt"
 	ExtendedJWTModule   = "extendedjwt"
+	MTLSAuthModule      = "mtls"
 	RenderModule        = "render"
 	// OAuth provider modules
 	AzureADAuthModule    = "oauth_azuread"
@@ -47,6 +48,7 @@ const (
 	SAMLLabel = "SAML"
 	LDAPLabel = "LDAP"
 	JWTLabel  = "JWT"
+	MTLSLabel = "Client certificate"
 	// OAuth provider labels
 	AuthProxyLabel    = "Auth Proxy"
 	AzureADLabel      = "AzureAD"
@@ -77,6 +79,8 @@ func IsExternallySynced(cfg *setting.Cfg, authModule string, oauthInfo *social.O
 		return !cfg.LDAPSkipOrgRoleSync
 	case JWTModule:
 		return !cfg.JWTAuth.SkipOrgRoleSync
+	case MTLSAuthModule:
+		return !cfg.AuthMTLS.SkipOrgRoleSync
 	}
 	switch authModule {
 	case GoogleAuthModule, OktaAuthModule, AzureADAuthModule, GitLabAuthModule, GithubAuthModule, GrafanaComAuthModule, GenericOAuthModule:
@@ -99,6 +103,8 @@ func IsGrafanaAdminExternallySynced(cfg *setting.Cfg, oauthInfo *social.OAuthInf
 	switch authModule {
 	case JWTModule:
 		return cfg.JWTAuth.AllowAssignGrafanaAdmin
+	case MTLSAuthModule:
+		return cfg.AuthMTLS.AllowAssignGrafanaAdmin
 	case SAMLAuthModule:
 		return cfg.SAMLRoleValuesGrafanaAdmin != ""
 	case LDAPAuthModule:
@@ -116,6 +122,8 @@ func IsProviderEnabled(cfg *setting.Cfg, authModule string, oauthInfo *social.OA
 		return cfg.LDAPAuthEnabled
 	case JWTModule:
 		return cfg.JWTAuth.Enabled
+	case MTLSAuthModule:
+		return cfg.AuthMTLS.Enabled
 	case GoogleAuthModule, OktaAuthModule, AzureADAuthModule, GitLabAuthModule, GithubAuthModule, GrafanaComAuthModule, GenericOAuthModule:
 		if oauthInfo == nil {
 			return false
@@ -146,6 +154,8 @@ func GetAuthProviderLabel(authModule string) string {
 		return LDAPLabel
 	case JWTModule:
 		return JWTLabel
+	case MTLSAuthModule:
+		return MTLSLabel
 	case AuthProxyAuthModule:
 		return AuthProxyLabel
 	case GenericOAuthModule:
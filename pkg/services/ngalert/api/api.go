@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -78,6 +79,8 @@ type API struct {
 	Historian            Historian
 	Tracer               tracing.Tracer
 	AppUrl               *url.URL
+	// RecordedMetrics is the query API of the local TSDB of the recording rules, if they are written to it.
+	RecordedMetrics http.Handler
 
 	// Hooks can be used to replace API handlers for specific paths.
 	Hooks *Hooks
@@ -150,6 +153,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			appUrl:          api.AppUrl,
 			tracer:          api.Tracer,
 			folderService:   api.RuleStore,
+			ruleStore:       api.RuleStore,
 		}), m)
 	api.RegisterConfigurationApiEndpoints(NewConfiguration(
 		&ConfigSrv{
@@ -168,6 +172,9 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		templates:           api.Templates,
 		muteTimings:         api.MuteTimings,
 		alertRules:          api.AlertRules,
+		namespaces:          api.RuleStore,
+		datasourceCache:     api.DatasourceCache,
+		cfg:                 &api.Cfg.UnifiedAlerting,
 		// XXX: Used to flag recording rules, remove when FT is removed
 		featureManager: api.FeatureManager,
 	}), m)
@@ -182,4 +189,8 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		receiverService:   api.ReceiverService,
 		muteTimingService: api.MuteTimings,
 	}), m)
+
+	if api.RecordedMetrics != nil {
+		api.RegisterRecordedMetricsApiEndpoints(api.RecordedMetrics)
+	}
 }
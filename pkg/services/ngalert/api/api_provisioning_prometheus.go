@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	alerting_models "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+)
+
+// RoutePostPrometheusRulesImport converts the Prometheus rule groups to Grafana-managed rule groups of the folder, and
+// replaces the groups of the folder with the same names. The groups are imported independently of each other, and the
+// result reports the groups which could not be imported. In a dry run, the groups are only converted and validated.
+func (srv *ProvisioningSrv) RoutePostPrometheusRulesImport(c *contextmodel.ReqContext, body definitions.PrometheusRulesImport, folderUID string) response.Response {
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	dryRun := c.QueryBoolWithDefault("dry_run", false)
+
+	if _, err := srv.namespaces.GetNamespaceByUID(ctx, folderUID, orgID, c.SignedInUser); err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	ds, err := srv.datasourceCache.GetDatasourceByUID(ctx, body.DatasourceUID, c.SignedInUser, false)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return ErrResp(http.StatusBadRequest, err, "failed to get data source %s", body.DatasourceUID)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get data source", err)
+	}
+	if ds.Type != datasources.DS_PROMETHEUS {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("data source %s of type %s is not a Prometheus data source", ds.UID, ds.Type), "")
+	}
+
+	converter, err := prom.NewConverter(prom.Config{
+		DatasourceUID:   ds.UID,
+		DatasourceType:  ds.Type,
+		DefaultInterval: srv.cfg.DefaultRuleEvaluationInterval,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to create the converter", err)
+	}
+
+	provenance := alerting_models.Provenance(determineProvenance(c))
+	result := definitions.PrometheusRulesImportResult{
+		DryRun: dryRun,
+		Groups: make([]definitions.PrometheusRuleGroupImportResult, 0, len(body.Groups)),
+	}
+	groupNames := make(map[string]struct{}, len(body.Groups))
+	titles, err := srv.ruleTitlesOutsideGroups(ctx, c.SignedInUser, folderUID, body.Groups)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get the rules of the folder", err)
+	}
+	for _, g := range body.Groups {
+		groupResult := definitions.PrometheusRuleGroupImportResult{Name: g.Name}
+
+		group, warnings, err := converter.PrometheusRulesToGrafana(orgID, folderUID, g)
+		groupResult.Warnings = warnings
+		if err == nil {
+			if _, ok := groupNames[g.Name]; ok {
+				err = fmt.Errorf("rule group '%s' is defined more than once", g.Name)
+			}
+			groupNames[g.Name] = struct{}{}
+		}
+		if err == nil {
+			warnings, err = srv.prepareImportedRuleGroup(ctx, c.SignedInUser, group, titles)
+			groupResult.Warnings = append(groupResult.Warnings, warnings...)
+		}
+		if err == nil && !dryRun {
+			err = srv.alertRules.ReplaceRuleGroup(ctx, c.SignedInUser, *group, provenance)
+			groupResult.Imported = err == nil
+		}
+
+		if err != nil {
+			srv.log.FromContext(ctx).Warn("Failed to import Prometheus rule group", "group", g.Name, "dryRun", dryRun, "error", err)
+			groupResult.Error = err.Error()
+		} else {
+			group.Provenance = provenance
+			converted := ApiAlertRuleGroupFromAlertRuleGroup(*group)
+			groupResult.Group = &converted
+		}
+		result.Groups = append(result.Groups, groupResult)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// ruleTitlesOutsideGroups returns the titles of the rules of the folder which are not in the groups to import. These
+// rules are kept, so the imported rules can not have their titles.
+func (srv *ProvisioningSrv) ruleTitlesOutsideGroups(ctx context.Context, user identity.Requester, folderUID string, groups []definitions.PrometheusRuleGroup) (map[string]struct{}, error) {
+	replaced := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		replaced[g.Name] = struct{}{}
+	}
+
+	folderGroups, err := srv.alertRules.GetAlertGroupsWithFolderFullpath(ctx, user, []string{folderUID})
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]struct{})
+	for _, g := range folderGroups {
+		if _, ok := replaced[g.Title]; ok {
+			continue
+		}
+		for _, r := range g.Rules {
+			titles[r.Title] = struct{}{}
+		}
+	}
+	return titles, nil
+}
+
+// prepareImportedRuleGroup makes the titles of the rules unique in the folder, and validates the rules. The rules of the
+// group get the UIDs of the existing rules with the same titles, so that importing a rule file again updates the rules.
+func (srv *ProvisioningSrv) prepareImportedRuleGroup(ctx context.Context, user identity.Requester, group *alerting_models.AlertRuleGroup, titles map[string]struct{}) ([]string, error) {
+	existing, err := srv.alertRules.GetRuleGroup(ctx, user, group.FolderUID, group.Title)
+	if err != nil && !errors.Is(err, alerting_models.ErrAlertRuleGroupNotFound) {
+		return nil, err
+	}
+	uids := make(map[string]string, len(existing.Rules))
+	for _, r := range existing.Rules {
+		uids[r.Title] = r.UID
+	}
+
+	var warnings []string
+	for i := range group.Rules {
+		rule := &group.Rules[i]
+		if rule.Type() == alerting_models.RuleTypeRecording && !srv.featureManager.IsEnabledGlobally(featuremgmt.FlagGrafanaManagedRecordingRules) {
+			return warnings, fmt.Errorf("%w: recording rules cannot be created on this instance", alerting_models.ErrAlertRuleFailedValidation)
+		}
+
+		// Prometheus rules of a group often share the same name, while the titles of Grafana rules are unique in a folder
+		title := rule.Title
+		for n := 2; ; n++ {
+			if _, ok := titles[title]; !ok {
+				break
+			}
+			title = fmt.Sprintf("%s (%d)", rule.Title, n)
+		}
+		if title != rule.Title {
+			warnings = append(warnings, fmt.Sprintf("rule '%s': renamed to '%s' because rule titles must be unique in a folder", rule.Title, title))
+			rule.Title = title
+		}
+		titles[title] = struct{}{}
+		rule.UID = uids[title]
+
+		if err := rule.ValidateAlertRule(*srv.cfg); err != nil {
+			return warnings, fmt.Errorf("invalid rule '%s': %w", title, err)
+		}
+	}
+	return warnings, nil
+}
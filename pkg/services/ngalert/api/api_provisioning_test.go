@@ -31,6 +31,8 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	datasourcefakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
@@ -613,6 +615,146 @@ func TestProvisioningApi(t *testing.T) {
 		})
 	})
 
+	t.Run("prometheus rules import", func(t *testing.T) {
+		body := func() definitions.PrometheusRulesImport {
+			return definitions.PrometheusRulesImport{
+				DatasourceUID: "prometheus-uid",
+				Groups: []definitions.PrometheusRuleGroup{{
+					Name:     "node",
+					Interval: model.Duration(time.Minute),
+					Rules: []definitions.ApiRuleNode{
+						{Alert: "InstanceDown", Expr: "up == 0", Labels: map[string]string{"severity": "critical"}},
+						{Alert: "InstanceDown", Expr: "up{job=\"node\"} == 0"},
+					},
+				}},
+			}
+		}
+		decode := func(t *testing.T, body []byte) definitions.PrometheusRulesImportResult {
+			t.Helper()
+			var result definitions.PrometheusRulesImportResult
+			require.NoError(t, json.Unmarshal(body, &result))
+			return result
+		}
+
+		t.Run("POST with dry run returns 200 and does not save the groups", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			rc.Req.Form.Set("dry_run", "true")
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, body(), "folder-uid")
+
+			require.Equal(t, 200, response.Status())
+			result := decode(t, response.Body())
+			require.True(t, result.DryRun)
+			require.Len(t, result.Groups, 1)
+			require.False(t, result.Groups[0].Imported)
+			require.Empty(t, result.Groups[0].Error)
+			require.Equal(t, []string{"rule 'InstanceDown': renamed to 'InstanceDown (2)' because rule titles must be unique in a folder"}, result.Groups[0].Warnings)
+			require.Len(t, result.Groups[0].Group.Rules, 2)
+
+			response = sut.RouteGetAlertRuleGroup(&rc, "folder-uid", "node")
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("POST returns 200 and replaces the groups", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, body(), "folder-uid")
+			require.Equal(t, 200, response.Status())
+			result := decode(t, response.Body())
+			require.False(t, result.DryRun)
+			require.True(t, result.Groups[0].Imported)
+
+			group, err := sut.alertRules.GetRuleGroup(context.Background(), rc.SignedInUser, "folder-uid", "node")
+			require.NoError(t, err)
+			require.Len(t, group.Rules, 2)
+			require.Equal(t, "InstanceDown", group.Rules[0].Title)
+			require.Equal(t, "InstanceDown (2)", group.Rules[1].Title)
+			require.Equal(t, int64(60), group.Interval)
+
+			// importing the rules again updates the existing rules
+			response = sut.RoutePostPrometheusRulesImport(&rc, body(), "folder-uid")
+			require.Equal(t, 200, response.Status())
+			require.True(t, decode(t, response.Body()).Groups[0].Imported)
+
+			updated, err := sut.alertRules.GetRuleGroup(context.Background(), rc.SignedInUser, "folder-uid", "node")
+			require.NoError(t, err)
+			require.Len(t, updated.Rules, 2)
+			for i := range updated.Rules {
+				require.Equal(t, group.Rules[i].UID, updated.Rules[i].UID)
+			}
+		})
+
+		t.Run("POST renames the rules with the titles of the other groups of the folder", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			insertRule(t, sut, createTestAlertRuleWithFolderAndGroup("InstanceDown", 1, "folder-uid", "other"))
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, body(), "folder-uid")
+
+			require.Equal(t, 200, response.Status())
+			result := decode(t, response.Body())
+			require.True(t, result.Groups[0].Imported)
+			require.Equal(t, []string{
+				"rule 'InstanceDown': renamed to 'InstanceDown (2)' because rule titles must be unique in a folder",
+				"rule 'InstanceDown': renamed to 'InstanceDown (3)' because rule titles must be unique in a folder",
+			}, result.Groups[0].Warnings)
+
+			group, err := sut.alertRules.GetRuleGroup(context.Background(), rc.SignedInUser, "folder-uid", "node")
+			require.NoError(t, err)
+			require.Equal(t, "InstanceDown (2)", group.Rules[0].Title)
+			require.Equal(t, "InstanceDown (3)", group.Rules[1].Title)
+		})
+
+		t.Run("POST reports the groups that cannot be imported", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			b := body()
+			b.Groups = append(b.Groups,
+				definitions.PrometheusRuleGroup{Name: "invalid", Rules: []definitions.ApiRuleNode{{Alert: "Invalid", Expr: "sum(up"}}},
+				definitions.PrometheusRuleGroup{Name: "recording", Rules: []definitions.ApiRuleNode{{Record: "job:up:sum", Expr: "sum by (job) (up)"}}},
+				definitions.PrometheusRuleGroup{Name: "node", Rules: []definitions.ApiRuleNode{{Alert: "Duplicate", Expr: "up"}}},
+			)
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, b, "folder-uid")
+
+			require.Equal(t, 200, response.Status())
+			result := decode(t, response.Body())
+			require.Len(t, result.Groups, 4)
+			require.True(t, result.Groups[0].Imported)
+			require.False(t, result.Groups[1].Imported)
+			require.Contains(t, result.Groups[1].Error, "invalid expression")
+			require.False(t, result.Groups[2].Imported)
+			require.Contains(t, result.Groups[2].Error, "recording rules cannot be created on this instance")
+			require.False(t, result.Groups[3].Imported)
+			require.Contains(t, result.Groups[3].Error, "is defined more than once")
+		})
+
+		t.Run("POST returns 400 if the data source is not a Prometheus data source", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			b := body()
+			b.DatasourceUID = "loki-uid"
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, b, "folder-uid")
+			require.Equal(t, 400, response.Status())
+
+			b.DatasourceUID = "does-not-exist"
+			response = sut.RoutePostPrometheusRulesImport(&rc, b, "folder-uid")
+			require.Equal(t, 400, response.Status())
+		})
+
+		t.Run("POST returns 403 if the folder does not exist", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePostPrometheusRulesImport(&rc, body(), "does-not-exist")
+
+			require.Equal(t, 403, response.Status())
+		})
+	})
+
 	t.Run("exports", func(t *testing.T) {
 		t.Run("alert rule group", func(t *testing.T) {
 			t.Run("are present, GET returns 200", func(t *testing.T) {
@@ -1956,7 +2098,16 @@ func createProvisioningSrvSutFromEnv(t *testing.T, env *testEnvironment) Provisi
 		muteTimings:         provisioning.NewMuteTimingService(configStore, env.prov, env.xact, env.log, env.store),
 		alertRules:          provisioning.NewAlertRuleService(env.store, env.prov, env.folderService, env.quotas, env.xact, 60, 10, 100, env.log, &provisioning.NotificationSettingsValidatorProviderFake{}, env.rulesAuthz),
 		folderSvc:           env.folderService,
-		featureManager:      env.features,
+		namespaces:          env.store,
+		datasourceCache: &datasourcefakes.FakeCacheService{DataSources: []*datasources.DataSource{
+			{UID: "prometheus-uid", Type: datasources.DS_PROMETHEUS},
+			{UID: "loki-uid", Type: datasources.DS_LOKI},
+		}},
+		cfg: &setting.UnifiedAlertingSettings{
+			BaseInterval:                  10 * time.Second,
+			DefaultRuleEvaluationInterval: time.Minute,
+		},
+		featureManager: env.features,
 	}
 }
 
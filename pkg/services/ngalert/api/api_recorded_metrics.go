@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/middleware/requestmeta"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// RegisterRecordedMetricsApiEndpoints registers the Prometheus compatible query API of the local TSDB of the
+// recording rules. The data source of the TSDB of each organization queries it with a service account token, and
+// the handler only returns the series of the organization of the signed in user.
+func (api *API) RegisterRecordedMetricsApiEndpoints(handler http.Handler) {
+	authorize := ac.Middleware(api.AccessControl)
+	api.RouteRegister.Group("/"+writer.TSDBAPIPath, func(group routing.RouteRegister) {
+		group.Any(
+			"/api/v1/*",
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			authorize(ac.EvalPermission(datasources.ActionQuery, datasources.ScopeProvider.GetResourceScopeUID(writer.TSDBDataSourceUID))),
+			func(c *contextmodel.ReqContext) {
+				// The handler routes on the path of the Prometheus API.
+				r := c.Req.Clone(c.Req.Context())
+				r.URL.Path = "/api/v1/" + web.Params(c.Req)["*"]
+				r.URL.RawPath = ""
+				handler.ServeHTTP(c.Resp, r)
+			},
+		)
+	}, middleware.ReqSignedIn)
+}
@@ -537,16 +537,21 @@ func toGettableRuleGroupConfig(groupName string, rules ngmodels.RulesGroup, prov
 	rules.SortByGroupIndex()
 	ruleNodes := make([]apimodels.GettableExtendedRuleNode, 0, len(rules))
 	var interval time.Duration
+	var queryOffset *model.Duration
 	if len(rules) > 0 {
 		interval = time.Duration(rules[0].IntervalSeconds) * time.Second
+		if rules[0].QueryOffset > 0 {
+			queryOffset = util.Pointer(model.Duration(rules[0].QueryOffset))
+		}
 	}
 	for _, r := range rules {
 		ruleNodes = append(ruleNodes, toGettableExtendedRuleNode(*r, provenanceRecords))
 	}
 	return apimodels.GettableRuleGroupConfig{
-		Name:     groupName,
-		Interval: model.Duration(interval),
-		Rules:    ruleNodes,
+		Name:        groupName,
+		Interval:    model.Duration(interval),
+		QueryOffset: queryOffset,
+		Rules:       ruleNodes,
 	}
 }
 
@@ -584,6 +589,10 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, provenanceRecords map[stri
 		Annotations: r.Annotations,
 		Labels:      r.Labels,
 	}
+	if r.KeepFiringFor > 0 {
+		keepFiringFor := model.Duration(r.KeepFiringFor)
+		gettableExtendedRuleNode.ApiRuleNode.KeepFiringFor = &keepFiringFor
+	}
 	return gettableExtendedRuleNode
 }
 
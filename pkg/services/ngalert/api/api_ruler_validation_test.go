@@ -226,6 +226,17 @@ func TestValidateRuleGroup(t *testing.T) {
 		}
 	})
 
+	t.Run("should set query offset of the group to all rules", func(t *testing.T) {
+		g := validGroup(cfg, rules...)
+		offset := model.Duration(time.Minute)
+		g.QueryOffset = &offset
+		alerts, err := ValidateRuleGroup(&g, orgId, folder.UID, limits)
+		require.NoError(t, err)
+		for _, alert := range alerts {
+			require.Equal(t, time.Minute, alert.QueryOffset)
+		}
+	})
+
 	t.Run("should show the payload has isPaused field", func(t *testing.T) {
 		for _, rule := range rules {
 			isPaused := true
@@ -286,6 +297,15 @@ func TestValidateRuleGroupFailures(t *testing.T) {
 				return &g
 			},
 		},
+		{
+			name: "fail if query offset is negative",
+			group: func() *apimodels.PostableRuleGroupConfig {
+				g := validGroup(cfg)
+				offset := model.Duration(-time.Minute)
+				g.QueryOffset = &offset
+				return &g
+			},
+		},
 		{
 			name: "fail if two rules have same UID",
 			group: func() *apimodels.PostableRuleGroupConfig {
@@ -366,6 +386,7 @@ func TestValidateRuleNode_NoUID(t *testing.T) {
 			},
 			assert: func(t *testing.T, api *apimodels.PostableExtendedRuleNode, alert *models.AlertRule) {
 				require.Equal(t, time.Duration(0), alert.For)
+				require.Equal(t, time.Duration(0), alert.KeepFiringFor)
 				require.Nil(t, alert.Annotations)
 				require.Nil(t, alert.Labels)
 			},
@@ -560,6 +581,15 @@ func TestValidateRuleNodeFailures_NoUID(t *testing.T) {
 				return &r
 			},
 		},
+		{
+			name: "fail if keep_firing_for is negative",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				keepFiringFor := model.Duration(-time.Minute)
+				r.ApiRuleNode.KeepFiringFor = &keepFiringFor
+				return &r
+			},
+		},
 		{
 			name: "fail if there are not data (empty)",
 			rule: func() *apimodels.PostableExtendedRuleNode {
@@ -39,6 +39,10 @@ type folderService interface {
 	GetNamespaceByUID(ctx context.Context, uid string, orgID int64, user identity.Requester) (*folder.Folder, error)
 }
 
+type testingRuleStore interface {
+	GetAlertRuleByUID(ctx context.Context, query *ngmodels.GetAlertRuleByUIDQuery) (*ngmodels.AlertRule, error)
+}
+
 type TestingApiSrv struct {
 	*AlertingProxy
 	DatasourceCache datasources.CacheService
@@ -51,6 +55,7 @@ type TestingApiSrv struct {
 	appUrl          *url.URL
 	tracer          tracing.Tracer
 	folderService   folderService
+	ruleStore       testingRuleStore
 }
 
 // RouteTestGrafanaRuleConfig returns a list of potential alerts for a given rule configuration. This is intended to be
@@ -229,27 +234,104 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 		return ErrResp(400, nil, "From cannot be greater than To")
 	}
 
+	rule, errResp := srv.backtestingRule(c, apimodels.BacktestRule{
+		Interval:    cmd.Interval,
+		Condition:   cmd.Condition,
+		Data:        cmd.Data,
+		For:         cmd.For,
+		Title:       cmd.Title,
+		Labels:      cmd.Labels,
+		Annotations: cmd.Annotations,
+		NoDataState: cmd.NoDataState,
+	})
+	if errResp != nil {
+		return errResp
+	}
+
+	result, err := srv.backtesting.Test(c.Req.Context(), c.SignedInUser, rule, cmd.From, cmd.To)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(400, err, "Failed to evaluate")
+		}
+		return ErrResp(500, err, "Failed to evaluate")
+	}
+
+	body, err := data.FrameToJSON(result, data.IncludeAll)
+	if err != nil {
+		return ErrResp(500, err, "Failed to convert frame to JSON")
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+// BacktestCompareAlertRule backtests the current and the proposed version of a rule over the same time range, and
+// returns the difference between their transitions and the number of notifications each would have sent.
+func (srv TestingApiSrv) BacktestCompareAlertRule(c *contextmodel.ReqContext, cmd apimodels.BacktestCompareConfig) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backtesting API is not enabled")
+	}
+
+	if cmd.From.After(cmd.To) {
+		return ErrResp(400, nil, "From cannot be greater than To")
+	}
+
+	current, err := srv.ruleStore.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: cmd.RuleUID, OrgID: c.SignedInUser.GetOrgID()})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "Failed to get rule")
+	}
+	if err := srv.authz.AuthorizeAccessInFolder(c.Req.Context(), c.SignedInUser, current); err != nil {
+		return errorToResponse(err)
+	}
+	if err := srv.authz.AuthorizeDatasourceAccessForRule(c.Req.Context(), c.SignedInUser, current); err != nil {
+		return errorToResponse(err)
+	}
+
+	keepFiringFor := time.Duration(cmd.Proposed.KeepFiringFor)
+	if keepFiringFor < 0 {
+		return ErrResp(400, nil, "Bad KeepFiringFor interval")
+	}
+	proposed, errResp := srv.backtestingRule(c, cmd.Proposed)
+	if errResp != nil {
+		return errResp
+	}
+	proposed.KeepFiringFor = keepFiringFor
+
+	result, err := srv.backtesting.Compare(c.Req.Context(), c.SignedInUser, current, proposed, cmd.From, cmd.To)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(400, err, "Failed to evaluate")
+		}
+		return ErrResp(500, err, "Failed to evaluate")
+	}
+
+	return response.JSON(http.StatusOK, toBacktestCompareResult(result))
+}
+
+// backtestingRule validates the rule to backtest, and checks that the user can query its data sources.
+func (srv TestingApiSrv) backtestingRule(c *contextmodel.ReqContext, cmd apimodels.BacktestRule) (*ngmodels.AlertRule, response.Response) {
 	noDataState, err := ngmodels.NoDataStateFromString(string(cmd.NoDataState))
 
 	if err != nil {
-		return ErrResp(400, err, "")
+		return nil, ErrResp(400, err, "")
 	}
 	forInterval := time.Duration(cmd.For)
 	if forInterval < 0 {
-		return ErrResp(400, nil, "Bad For interval")
+		return nil, ErrResp(400, nil, "Bad For interval")
 	}
 
 	intervalSeconds, err := validateInterval(time.Duration(cmd.Interval), srv.cfg.BaseInterval)
 	if err != nil {
-		return ErrResp(400, err, "")
+		return nil, ErrResp(400, err, "")
 	}
 
 	queries := AlertQueriesFromApiAlertQueries(cmd.Data)
 	if err := srv.authz.AuthorizeDatasourceAccessForRule(c.Req.Context(), c.SignedInUser, &ngmodels.AlertRule{Data: queries}); err != nil {
-		return errorToResponse(err)
+		return nil, errorToResponse(err)
 	}
 
-	rule := &ngmodels.AlertRule{
+	return &ngmodels.AlertRule{
 		// ID:             0,
 		// Updated:        time.Time{},
 		// Version:        0,
@@ -270,19 +352,41 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 		For:             forInterval,
 		Annotations:     cmd.Annotations,
 		Labels:          cmd.Labels,
+	}, nil
+}
+
+func toBacktestCompareResult(c *backtesting.Comparison) apimodels.BacktestCompareResult {
+	result := apimodels.BacktestCompareResult{
+		Current:  toBacktestSummary(c.Current),
+		Proposed: toBacktestSummary(c.Proposed),
+		Diff:     make([]apimodels.BacktestInstanceDiff, 0, len(c.Diff)),
 	}
+	for _, d := range c.Diff {
+		result.Diff = append(result.Diff, apimodels.BacktestInstanceDiff{
+			Labels:   d.Labels,
+			Current:  toBacktestTransitions(d.Current),
+			Proposed: toBacktestTransitions(d.Proposed),
+		})
+	}
+	return result
+}
 
-	result, err := srv.backtesting.Test(c.Req.Context(), c.SignedInUser, rule, cmd.From, cmd.To)
-	if err != nil {
-		if errors.Is(err, backtesting.ErrInvalidInputData) {
-			return ErrResp(400, err, "Failed to evaluate")
-		}
-		return ErrResp(500, err, "Failed to evaluate")
+func toBacktestSummary(s backtesting.Summary) apimodels.BacktestSummary {
+	return apimodels.BacktestSummary{
+		Transitions:           s.Transitions,
+		FiringNotifications:   s.FiringNotifications,
+		ResolvedNotifications: s.ResolvedNotifications,
 	}
+}
 
-	body, err := data.FrameToJSON(result, data.IncludeAll)
-	if err != nil {
-		return ErrResp(500, err, "Failed to convert frame to JSON")
+func toBacktestTransitions(transitions []backtesting.Transition) []apimodels.BacktestTransition {
+	result := make([]apimodels.BacktestTransition, 0, len(transitions))
+	for _, t := range transitions {
+		result = append(result, apimodels.BacktestTransition{
+			At:   t.At,
+			From: t.From.String(),
+			To:   t.To.String(),
+		})
 	}
-	return response.JSON(http.StatusOK, body)
+	return result
 }
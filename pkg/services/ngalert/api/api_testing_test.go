@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
@@ -391,6 +392,47 @@ func TestRouteEvalQueries(t *testing.T) {
 	})
 }
 
+func TestBacktestCompareAlertRule(t *testing.T) {
+	rc := &contextmodel.ReqContext{
+		Context: &web.Context{
+			Req: &http.Request{},
+		},
+		SignedInUser: &user.SignedInUser{
+			OrgID: 1,
+		},
+	}
+	features := featuremgmt.WithFeatures(featuremgmt.FlagAlertingBacktesting)
+	rule := models.RuleGen.With(models.RuleGen.WithOrgID(rc.OrgID)).GenerateRef()
+	cmd := definitions.BacktestCompareConfig{
+		From:    time.Unix(0, 0),
+		To:      time.Unix(600, 0),
+		RuleUID: rule.UID,
+	}
+
+	t.Run("should return NotFound if the rule does not exist", func(t *testing.T) {
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.PutRule(context.Background(), rule)
+		srv := createTestingApiSrv(t, nil, acMock.New(), eval_mocks.NewEvaluatorFactory(&eval_mocks.ConditionEvaluatorMock{}), features, ruleStore)
+
+		missing := cmd
+		missing.RuleUID = "missing"
+		response := srv.BacktestCompareAlertRule(rc, missing)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return Forbidden if user cannot access the folder of the rule", func(t *testing.T) {
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.PutRule(context.Background(), rule)
+		ac := acMock.New().WithPermissions([]ac.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceAllScope()},
+		})
+		srv := createTestingApiSrv(t, nil, ac, eval_mocks.NewEvaluatorFactory(&eval_mocks.ConditionEvaluatorMock{}), features, ruleStore)
+
+		response := srv.BacktestCompareAlertRule(rc, cmd)
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+}
+
 func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mock, evaluator eval.EvaluatorFactory, featureManager featuremgmt.FeatureToggles, ruleStore RuleStore) *TestingApiSrv {
 	if ac == nil {
 		ac = acMock.New()
@@ -404,5 +446,6 @@ func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mo
 		tracer:          tracing.InitializeTracerForTest(),
 		featureManager:  featureManager,
 		folderService:   ruleStore,
+		ruleStore:       ruleStore,
 	}
 }
@@ -128,6 +128,10 @@ func (f *ProvisioningApiHandler) handleRoutePutAlertRuleGroup(ctx *contextmodel.
 	return f.svc.RoutePutAlertRuleGroup(ctx, ag, folder, group)
 }
 
+func (f *ProvisioningApiHandler) handleRoutePostPrometheusRulesImport(ctx *contextmodel.ReqContext, body apimodels.PrometheusRulesImport, folder string) response.Response {
+	return f.svc.RoutePostPrometheusRulesImport(ctx, body, folder)
+}
+
 func (f *ProvisioningApiHandler) handleRouteExportMuteTiming(ctx *contextmodel.ReqContext, name string) response.Response {
 	return f.svc.RouteGetMuteTimingExport(ctx, name)
 }
@@ -29,6 +29,10 @@ func (f *TestingApiHandler) handleRouteEvalQueries(c *contextmodel.ReqContext, b
 	return f.svc.RouteEvalQueries(c, body)
 }
 
+func (f *TestingApiHandler) handleBacktestCompareConfig(ctx *contextmodel.ReqContext, conf apimodels.BacktestCompareConfig) response.Response {
+	return f.svc.BacktestCompareAlertRule(ctx, conf)
+}
+
 func (f *TestingApiHandler) handleBacktestConfig(ctx *contextmodel.ReqContext, conf apimodels.BacktestConfig) response.Response {
 	return f.svc.BacktestAlertRule(ctx, conf)
 }
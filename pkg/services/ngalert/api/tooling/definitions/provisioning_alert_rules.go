@@ -112,7 +112,7 @@ type AlertRulePayload struct {
 	Body ProvisionedAlertRule
 }
 
-// swagger:parameters RoutePostAlertRule RoutePutAlertRule RouteDeleteAlertRule RoutePutAlertRuleGroup
+// swagger:parameters RoutePostAlertRule RoutePutAlertRule RouteDeleteAlertRule RoutePutAlertRuleGroup RoutePostPrometheusRulesImport
 type AlertRuleHeaders struct {
 	// in:header
 	XDisableProvenance string `json:"X-Disable-Provenance"`
@@ -158,6 +158,9 @@ type ProvisionedAlertRule struct {
 	// required: true
 	// swagger:strfmt duration
 	For model.Duration `json:"for"`
+	// swagger:strfmt duration
+	// example: 5m
+	KeepFiringFor model.Duration `json:"keepFiringFor,omitempty"`
 	// example: {"runbook_url": "https://supercoolrunbook.com/page/13"}
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// example: {"team": "sre-team-1"}
@@ -215,7 +218,7 @@ type ProvisionedAlertRule struct {
 //       200: AlertRuleGroup
 //       400: ValidationError
 
-// swagger:parameters RouteGetAlertRuleGroup RoutePutAlertRuleGroup RouteGetAlertRuleGroupExport RouteDeleteAlertRuleGroup
+// swagger:parameters RouteGetAlertRuleGroup RoutePutAlertRuleGroup RouteGetAlertRuleGroupExport RouteDeleteAlertRuleGroup RoutePostPrometheusRulesImport
 type FolderUIDPathParam struct {
 	// in:path
 	FolderUID string `json:"FolderUID"`
@@ -240,21 +243,27 @@ type AlertRuleGroupMetadata struct {
 
 // swagger:model
 type AlertRuleGroup struct {
-	Title     string                 `json:"title"`
-	FolderUID string                 `json:"folderUid"`
-	Interval  int64                  `json:"interval"`
-	Rules     []ProvisionedAlertRule `json:"rules"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid"`
+	Interval  int64  `json:"interval"`
+	// swagger:strfmt duration
+	// example: 1m
+	QueryOffset model.Duration         `json:"queryOffset,omitempty"`
+	Rules       []ProvisionedAlertRule `json:"rules"`
 }
 
 // AlertRuleGroupExport is the provisioned file export of AlertRuleGroupV1.
 type AlertRuleGroupExport struct {
-	OrgID           int64             `json:"orgId" yaml:"orgId" hcl:"org_id"`
-	Name            string            `json:"name" yaml:"name" hcl:"name"`
-	Folder          string            `json:"folder" yaml:"folder"`
-	FolderUID       string            `json:"-" yaml:"-" hcl:"folder_uid"`
-	Interval        model.Duration    `json:"interval" yaml:"interval"`
-	IntervalSeconds int64             `json:"-" yaml:"-" hcl:"interval_seconds"`
-	Rules           []AlertRuleExport `json:"rules" yaml:"rules" hcl:"rule,block"`
+	OrgID           int64          `json:"orgId" yaml:"orgId" hcl:"org_id"`
+	Name            string         `json:"name" yaml:"name" hcl:"name"`
+	Folder          string         `json:"folder" yaml:"folder"`
+	FolderUID       string         `json:"-" yaml:"-" hcl:"folder_uid"`
+	Interval        model.Duration `json:"interval" yaml:"interval"`
+	IntervalSeconds int64          `json:"-" yaml:"-" hcl:"interval_seconds"`
+	QueryOffset     model.Duration `json:"queryOffset,omitempty" yaml:"queryOffset,omitempty"`
+	// QueryOffsetString is used to only export the query offset for HCL if it is non-zero.
+	QueryOffsetString *string           `json:"-" yaml:"-" hcl:"query_offset"`
+	Rules             []AlertRuleExport `json:"rules" yaml:"rules" hcl:"rule,block"`
 }
 
 // AlertRuleExport is the provisioned file export of models.AlertRule.
@@ -271,7 +280,10 @@ type AlertRuleExport struct {
 	// ForString is used to:
 	// - Only export the for field for HCL if it is non-zero.
 	// - Format the Prometheus model.Duration type properly for HCL.
-	ForString            *string                              `json:"-" yaml:"-" hcl:"for"`
+	ForString     *string        `json:"-" yaml:"-" hcl:"for"`
+	KeepFiringFor model.Duration `json:"keepFiringFor,omitempty" yaml:"keepFiringFor,omitempty"`
+	// KeepFiringForString is used to only export the keep_firing_for field for HCL if it is non-zero.
+	KeepFiringForString  *string                              `json:"-" yaml:"-" hcl:"keep_firing_for"`
 	Annotations          *map[string]string                   `json:"annotations,omitempty" yaml:"annotations,omitempty" hcl:"annotations"`
 	Labels               *map[string]string                   `json:"labels,omitempty" yaml:"labels,omitempty" hcl:"labels"`
 	IsPaused             bool                                 `json:"isPaused" yaml:"isPaused" hcl:"is_paused"`
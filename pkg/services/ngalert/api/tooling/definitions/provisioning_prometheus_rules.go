@@ -0,0 +1,79 @@
+package definitions
+
+import (
+	"github.com/prometheus/common/model"
+)
+
+// swagger:route POST /v1/provisioning/folder/{FolderUID}/import/prometheus provisioning stable RoutePostPrometheusRulesImport
+//
+// Import Prometheus rule groups into a folder, replacing the rule groups with the same names.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: PrometheusRulesImportResult
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: description: Not found.
+
+// swagger:parameters RoutePostPrometheusRulesImport
+type PrometheusRulesImportPayload struct {
+	// in:body
+	Body PrometheusRulesImport
+}
+
+// swagger:parameters RoutePostPrometheusRulesImport
+type PrometheusRulesImportParams struct {
+	// Convert and validate the rule groups without saving them.
+	// in:query
+	// required: false
+	// default: false
+	DryRun bool `json:"dry_run"`
+}
+
+// swagger:model
+type PrometheusRulesImport struct {
+	// UID of the Prometheus data source queried by the rules.
+	// required: true
+	DatasourceUID string `json:"datasourceUid"`
+	// Rule groups in the format of the Prometheus rule files.
+	// required: true
+	Groups []PrometheusRuleGroup `json:"groups"`
+}
+
+// PrometheusRuleGroup is a rule group of a Prometheus, Mimir or Cortex rule file.
+// swagger:model
+type PrometheusRuleGroup struct {
+	Name     string            `yaml:"name" json:"name"`
+	Interval model.Duration    `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Labels   map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Limit    int               `yaml:"limit,omitempty" json:"limit,omitempty"`
+	Rules    []ApiRuleNode     `yaml:"rules" json:"rules"`
+
+	QueryOffset *model.Duration `yaml:"query_offset,omitempty" json:"query_offset,omitempty"`
+
+	// fields below are used by Mimir/Loki rulers
+
+	SourceTenants                 []string        `yaml:"source_tenants,omitempty" json:"source_tenants,omitempty"`
+	EvaluationDelay               *model.Duration `yaml:"evaluation_delay,omitempty" json:"evaluation_delay,omitempty"`
+	AlignEvaluationTimeOnInterval bool            `yaml:"align_evaluation_time_on_interval,omitempty" json:"align_evaluation_time_on_interval,omitempty"`
+}
+
+// swagger:model
+type PrometheusRulesImportResult struct {
+	DryRun bool                              `json:"dryRun"`
+	Groups []PrometheusRuleGroupImportResult `json:"groups"`
+}
+
+type PrometheusRuleGroupImportResult struct {
+	Name string `json:"name"`
+	// Imported is false if the group could not be converted or saved, and in a dry run.
+	Imported bool `json:"imported"`
+	// Error is the reason the group could not be converted or saved.
+	Error string `json:"error,omitempty"`
+	// Warnings lists the constructs of the group that are not supported by Grafana and were dropped or changed.
+	Warnings []string `json:"warnings,omitempty"`
+	// Group is the Grafana-managed rule group the group was converted to.
+	Group *AlertRuleGroup `json:"group,omitempty"`
+}
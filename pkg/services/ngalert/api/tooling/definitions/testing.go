@@ -68,6 +68,19 @@ import (
 //     Responses:
 //       200: BacktestResult
 
+// swagger:route Post /v1/rule/backtest/compare testing BacktestCompareConfig
+//
+// Compare the backtesting results of two versions of a rule
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BacktestCompareResult
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -234,3 +247,67 @@ type BacktestConfig struct {
 
 // swagger:model
 type BacktestResult data.Frame
+
+// swagger:parameters BacktestCompareConfig
+type BacktestCompareConfigRequest struct {
+	// in:body
+	Body BacktestCompareConfig
+}
+
+// swagger:model
+type BacktestCompareConfig struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// RuleUID is the UID of the saved rule, which is the current version of the rule.
+	RuleUID string `json:"rule_uid"`
+	// Proposed is the version of the rule with the change under review.
+	Proposed BacktestRule `json:"proposed"`
+}
+
+// swagger:model
+type BacktestRule struct {
+	Interval model.Duration `json:"interval,omitempty"`
+
+	Condition     string         `json:"condition"`
+	Data          []AlertQuery   `json:"data"`
+	For           model.Duration `json:"for,omitempty"`
+	KeepFiringFor model.Duration `json:"keep_firing_for,omitempty"`
+
+	Title       string            `json:"title"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	NoDataState NoDataState `json:"no_data_state"`
+}
+
+// swagger:model
+type BacktestCompareResult struct {
+	Current  BacktestSummary `json:"current"`
+	Proposed BacktestSummary `json:"proposed"`
+	// Diff contains the alert instances whose transitions differ between the versions.
+	Diff []BacktestInstanceDiff `json:"diff"`
+}
+
+// swagger:model
+type BacktestSummary struct {
+	Transitions int `json:"transitions"`
+	// FiringNotifications is the number of times an alert instance started firing.
+	FiringNotifications int `json:"firing_notifications"`
+	// ResolvedNotifications is the number of times a firing alert instance was resolved.
+	ResolvedNotifications int `json:"resolved_notifications"`
+}
+
+// swagger:model
+type BacktestInstanceDiff struct {
+	Labels   map[string]string    `json:"labels"`
+	Current  []BacktestTransition `json:"current"`
+	Proposed []BacktestTransition `json:"proposed"`
+}
+
+// swagger:model
+type BacktestTransition struct {
+	At   time.Time `json:"at"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
@@ -0,0 +1,162 @@
+package backtesting
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// Transition is a change of the state of an alert instance during backtesting.
+type Transition struct {
+	At   time.Time
+	From eval.State
+	To   eval.State
+}
+
+// Summary counts the transitions of all alert instances of a version of the rule.
+type Summary struct {
+	Transitions int
+	// FiringNotifications is the number of times an alert instance started firing.
+	FiringNotifications int
+	// ResolvedNotifications is the number of times a firing alert instance was resolved.
+	ResolvedNotifications int
+}
+
+// InstanceDiff is an alert instance whose transitions differ between the two versions of the rule.
+type InstanceDiff struct {
+	// Labels are the labels of the result the instance was created for.
+	Labels   data.Labels
+	Current  []Transition
+	Proposed []Transition
+}
+
+// Comparison is the result of backtesting two versions of a rule over the same time range.
+type Comparison struct {
+	Current  Summary
+	Proposed Summary
+	// Diff contains the alert instances whose transitions differ, ordered by labels.
+	Diff []InstanceDiff
+}
+
+// Compare backtests the current and the proposed version of a rule over the same time range, and returns the
+// difference between the transitions of their alert instances. Instances are matched by the labels of the results,
+// so that changes to the labels of the rule do not make every instance differ.
+func (e *Engine) Compare(ctx context.Context, user identity.Requester, current, proposed *models.AlertRule, from, to time.Time) (*Comparison, error) {
+	currentTimeline, err := e.timeline(ctx, user, current, from, to)
+	if err != nil {
+		return nil, err
+	}
+	proposedTimeline, err := e.timeline(ctx, user, proposed, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Comparison{
+		Current:  currentTimeline.summary(),
+		Proposed: proposedTimeline.summary(),
+	}
+	fingerprints := make(map[data.Fingerprint]struct{}, len(currentTimeline.instances))
+	for fp := range currentTimeline.instances {
+		fingerprints[fp] = struct{}{}
+	}
+	for fp := range proposedTimeline.instances {
+		fingerprints[fp] = struct{}{}
+	}
+	for fp := range fingerprints {
+		c, p := currentTimeline.instances[fp], proposedTimeline.instances[fp]
+		diff := InstanceDiff{}
+		if c != nil {
+			diff.Labels = c.labels
+			diff.Current = c.transitions
+		}
+		if p != nil {
+			diff.Labels = p.labels
+			diff.Proposed = p.transitions
+		}
+		if slices.EqualFunc(diff.Current, diff.Proposed, func(a, b Transition) bool {
+			return a.At.Equal(b.At) && a.From == b.From && a.To == b.To
+		}) {
+			continue
+		}
+		result.Diff = append(result.Diff, diff)
+	}
+	sort.Slice(result.Diff, func(i, j int) bool {
+		return result.Diff[i].Labels.String() < result.Diff[j].Labels.String()
+	})
+	return result, nil
+}
+
+func (e *Engine) timeline(ctx context.Context, user identity.Requester, rule *models.AlertRule, from, to time.Time) (*timeline, error) {
+	length, err := evaluationsInRange(rule, from, to)
+	if err != nil {
+		return nil, err
+	}
+	t := newTimeline()
+	err = e.evaluate(ctx, user, rule, from, to, length, func(_ int, now time.Time, states []state.StateTransition) {
+		for _, s := range states {
+			t.observe(now, s)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// timeline records the transitions of the alert instances of a rule over consecutive evaluations.
+type timeline struct {
+	instances map[data.Fingerprint]*instanceTimeline
+}
+
+type instanceTimeline struct {
+	labels      data.Labels
+	state       eval.State
+	transitions []Transition
+}
+
+func newTimeline() *timeline {
+	return &timeline{
+		instances: make(map[data.Fingerprint]*instanceTimeline),
+	}
+}
+
+// observe records the state of an alert instance at an evaluation, as computed by the state manager, so that pending
+// periods and keep firing for apply like in the scheduler. Instances start as Normal.
+func (t *timeline) observe(at time.Time, s state.StateTransition) {
+	instance, ok := t.instances[s.ResultFingerprint]
+	if !ok {
+		instance = &instanceTimeline{labels: s.Labels, state: eval.Normal}
+		t.instances[s.ResultFingerprint] = instance
+	}
+
+	next := s.State.State
+	if next == instance.state {
+		return
+	}
+	instance.transitions = append(instance.transitions, Transition{At: at, From: instance.state, To: next})
+	instance.state = next
+}
+
+func (t *timeline) summary() Summary {
+	var s Summary
+	for _, instance := range t.instances {
+		s.Transitions += len(instance.transitions)
+		for _, tr := range instance.transitions {
+			if tr.To == eval.Alerting {
+				s.FiringNotifications++
+			}
+			if tr.From == eval.Alerting && tr.To == eval.Normal {
+				s.ResolvedNotifications++
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package backtesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+func TestTimeline(t *testing.T) {
+	from := time.Unix(0, 0)
+	labels := data.Labels{"a": "1"}
+	observeAll := func(tl *timeline, states ...eval.State) {
+		for i, s := range states {
+			tl.observe(from.Add(time.Duration(i)*time.Minute), state.StateTransition{
+				State: &state.State{ResultFingerprint: labels.Fingerprint(), Labels: labels, State: s},
+			})
+		}
+	}
+
+	t.Run("records changes of state", func(t *testing.T) {
+		tl := newTimeline()
+		observeAll(tl, eval.Normal, eval.Pending, eval.Alerting, eval.Alerting, eval.Normal)
+
+		require.Equal(t, []Transition{
+			{At: from.Add(time.Minute), From: eval.Normal, To: eval.Pending},
+			{At: from.Add(2 * time.Minute), From: eval.Pending, To: eval.Alerting},
+			{At: from.Add(4 * time.Minute), From: eval.Alerting, To: eval.Normal},
+		}, tl.instances[labels.Fingerprint()].transitions)
+		require.Equal(t, Summary{Transitions: 3, FiringNotifications: 1, ResolvedNotifications: 1}, tl.summary())
+	})
+}
+
+func TestEngineCompareKeepFiringFor(t *testing.T) {
+	from := time.Unix(0, 0)
+	// The condition stops being met at the second evaluation, and the query returns no data from the third one.
+	results := []eval.State{eval.Alerting, eval.Normal, eval.NoData, eval.NoData}
+	evaluator := &fakeBacktestingEvaluator{
+		evalCallback: func(now time.Time) (eval.Results, error) {
+			idx := int(now.Sub(from) / time.Minute)
+			return eval.Results{{Instance: data.Labels{}, State: results[idx], EvaluatedAt: now}}, nil
+		},
+	}
+	backtestingEvaluatorFactory = func(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, r eval.AlertingResultsReader) (backtestingEvaluator, error) {
+		return evaluator, nil
+	}
+	t.Cleanup(func() {
+		backtestingEvaluatorFactory = newBacktestingEvaluator
+	})
+
+	engine := NewEngine(nil, nil, tracing.InitializeTracerForTest())
+	gen := models.RuleGen.With(models.RuleGen.WithInterval(time.Minute), models.RuleGen.WithFor(0), models.RuleGen.WithNoDataExecAs(models.NoData))
+	current := gen.With(gen.WithKeepFiringFor(5 * time.Minute)).GenerateRef()
+	proposed := gen.With(gen.WithKeepFiringFor(0)).GenerateRef()
+
+	result, err := engine.Compare(context.Background(), nil, current, proposed, from, from.Add(4*time.Minute))
+	require.NoError(t, err)
+
+	// keep firing for applies to the Normal results only, so the instance stops firing on NoData.
+	require.Len(t, result.Diff, 1)
+	require.Equal(t, []Transition{
+		{At: from, From: eval.Normal, To: eval.Alerting},
+		{At: from.Add(2 * time.Minute), From: eval.Alerting, To: eval.NoData},
+	}, result.Diff[0].Current)
+	require.Equal(t, []Transition{
+		{At: from, From: eval.Normal, To: eval.Alerting},
+		{At: from.Add(time.Minute), From: eval.Alerting, To: eval.Normal},
+		{At: from.Add(2 * time.Minute), From: eval.Normal, To: eval.NoData},
+	}, result.Diff[0].Proposed)
+}
+
+func TestEngineCompare(t *testing.T) {
+	from := time.Unix(0, 0)
+	same := data.Labels{"instance": "same"}
+	changed := data.Labels{"instance": "changed"}
+	stateOf := func(labels data.Labels, s eval.State) state.StateTransition {
+		return state.StateTransition{State: &state.State{ResultFingerprint: labels.Fingerprint(), Labels: labels, State: s}}
+	}
+
+	// The current version fires for "changed" at the second evaluation, the proposed version never does.
+	managers := []*fakeStateManager{
+		{stateCallback: func(now time.Time) []state.StateTransition {
+			s := eval.Normal
+			if now.After(from) {
+				s = eval.Alerting
+			}
+			return []state.StateTransition{stateOf(same, eval.Alerting), stateOf(changed, s)}
+		}},
+		{stateCallback: func(now time.Time) []state.StateTransition {
+			return []state.StateTransition{stateOf(same, eval.Alerting), stateOf(changed, eval.Normal)}
+		}},
+	}
+	evaluator := &fakeBacktestingEvaluator{
+		evalCallback: func(now time.Time) (eval.Results, error) {
+			return eval.Results{}, nil
+		},
+	}
+	backtestingEvaluatorFactory = func(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, r eval.AlertingResultsReader) (backtestingEvaluator, error) {
+		return evaluator, nil
+	}
+	t.Cleanup(func() {
+		backtestingEvaluatorFactory = newBacktestingEvaluator
+	})
+
+	created := 0
+	engine := &Engine{
+		createStateManager: func() stateManager {
+			m := managers[created]
+			created++
+			return m
+		},
+	}
+	gen := models.RuleGen
+	current := gen.With(gen.WithInterval(time.Minute)).GenerateRef()
+	proposed := gen.With(gen.WithInterval(time.Minute)).GenerateRef()
+
+	result, err := engine.Compare(context.Background(), nil, current, proposed, from, from.Add(3*time.Minute))
+	require.NoError(t, err)
+
+	require.Equal(t, Summary{Transitions: 2, FiringNotifications: 2}, result.Current)
+	require.Equal(t, Summary{Transitions: 1, FiringNotifications: 1}, result.Proposed)
+	require.Equal(t, []InstanceDiff{{
+		Labels:  changed,
+		Current: []Transition{{At: from.Add(time.Minute), From: eval.Normal, To: eval.Alerting}},
+	}}, result.Diff)
+
+	t.Run("fails if the time range is shorter than an interval", func(t *testing.T) {
+		_, err := engine.Compare(context.Background(), nil, current, proposed, from, from.Add(time.Second))
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+}
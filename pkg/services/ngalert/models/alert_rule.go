@@ -192,11 +192,12 @@ var (
 
 // AlertRuleGroup is the base model for a rule group in unified alerting.
 type AlertRuleGroup struct {
-	Title      string
-	FolderUID  string
-	Interval   int64
-	Provenance Provenance
-	Rules      []AlertRule
+	Title       string
+	FolderUID   string
+	Interval    int64
+	QueryOffset time.Duration
+	Provenance  Provenance
+	Rules       []AlertRule
 }
 
 // AlertRuleGroupWithFolderFullpath extends AlertRuleGroup with orgID and folder title
@@ -209,15 +210,18 @@ type AlertRuleGroupWithFolderFullpath struct {
 func NewAlertRuleGroupWithFolderFullpath(groupKey AlertRuleGroupKey, rules []AlertRule, folderFullpath string) AlertRuleGroupWithFolderFullpath {
 	SortAlertRulesByGroupIndex(rules)
 	var interval int64
+	var queryOffset time.Duration
 	if len(rules) > 0 {
 		interval = rules[0].IntervalSeconds
+		queryOffset = rules[0].QueryOffset
 	}
 	var result = AlertRuleGroupWithFolderFullpath{
 		AlertRuleGroup: &AlertRuleGroup{
-			Title:     groupKey.RuleGroup,
-			FolderUID: groupKey.NamespaceUID,
-			Interval:  interval,
-			Rules:     rules,
+			Title:       groupKey.RuleGroup,
+			FolderUID:   groupKey.NamespaceUID,
+			Interval:    interval,
+			QueryOffset: queryOffset,
+			Rules:       rules,
 		},
 		FolderFullpath: folderFullpath,
 		OrgID:          groupKey.OrgID,
@@ -264,7 +268,12 @@ type AlertRule struct {
 	ExecErrState    ExecutionErrorState
 	// ideally this field should have been apimodels.ApiDuration
 	// but this is currently not possible because of circular dependencies
-	For                  time.Duration
+	For time.Duration
+	// KeepFiringFor is how long an alerting instance keeps firing after its condition stopped being met.
+	KeepFiringFor time.Duration
+	// QueryOffset is how far in the past the queries of the rule are evaluated, to account for late-arriving data.
+	// It is set on the rule group and is the same for all rules of the group, like IntervalSeconds.
+	QueryOffset          time.Duration
 	Annotations          map[string]string
 	Labels               map[string]string
 	IsPaused             bool
@@ -581,6 +590,14 @@ func (alertRule *AlertRule) ValidateAlertRule(cfg setting.UnifiedAlertingSetting
 		return fmt.Errorf("%w: field `for` cannot be negative", ErrAlertRuleFailedValidation)
 	}
 
+	if alertRule.KeepFiringFor < 0 {
+		return fmt.Errorf("%w: field `keep_firing_for` cannot be negative", ErrAlertRuleFailedValidation)
+	}
+
+	if alertRule.QueryOffset < 0 {
+		return fmt.Errorf("%w: field `query_offset` cannot be negative", ErrAlertRuleFailedValidation)
+	}
+
 	if len(alertRule.Labels) > 0 {
 		for label := range alertRule.Labels {
 			if _, ok := LabelsUserCannotSpecify[label]; ok {
@@ -657,6 +674,7 @@ func ClearRecordingRuleIgnoredFields(rule *AlertRule) {
 	rule.ExecErrState = ""
 	rule.Condition = ""
 	rule.For = 0
+	rule.KeepFiringFor = 0
 	rule.NotificationSettings = nil
 }
 
@@ -802,6 +820,9 @@ func PatchPartialAlertRule(existingRule *AlertRule, ruleToPatch *AlertRuleWithOp
 	if ruleToPatch.For == -1 {
 		ruleToPatch.For = existingRule.For
 	}
+	if ruleToPatch.KeepFiringFor == -1 {
+		ruleToPatch.KeepFiringFor = existingRule.KeepFiringFor
+	}
 	if !ruleToPatch.HasPause {
 		ruleToPatch.IsPaused = existingRule.IsPaused
 	}
@@ -0,0 +1,336 @@
+// Package prom converts the rule groups of Prometheus rule files to Grafana-managed rule groups.
+package prom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	queryRefID     = "A"
+	reduceRefID    = "B"
+	firingRefID    = "C"
+	thresholdRefID = "D"
+
+	// queryTimeRange is the relative time range of the queries. The queries are instant queries evaluated at the end
+	// of the range, its start only matters to the data sources that need it to look up the series.
+	queryTimeRange = 10 * time.Minute
+)
+
+// firingExpression is 1 for every series returned by the query, whatever its value. Prometheus fires an alert for
+// each series in the result of the expression of an alerting rule.
+var firingExpression = fmt.Sprintf("is_number($%[1]s) || is_nan($%[1]s) || is_inf($%[1]s)", reduceRefID)
+
+var (
+	// valueVariable matches the $value variable of the Prometheus templates but not $values.
+	valueVariable = regexp.MustCompile(`\$value\b`)
+	// unsupportedVariable matches the variables of the Prometheus templates that are not defined by Grafana.
+	unsupportedVariable = regexp.MustCompile(`\$(externalLabels|externalURL)\b`)
+	// queryFunction matches the query function of the Prometheus templates, which returns no data in Grafana.
+	queryFunction = regexp.MustCompile(`{{[^}]*\bquery\b`)
+)
+
+// Config defines how the Prometheus rules are converted.
+type Config struct {
+	// DatasourceUID is the UID of the Prometheus data source queried by the converted rules.
+	DatasourceUID string
+	// DatasourceType is the type of the data source.
+	DatasourceType string
+	// DefaultInterval is the evaluation interval of the groups that do not define one.
+	DefaultInterval time.Duration
+}
+
+// Converter converts Prometheus rule groups to Grafana-managed rule groups.
+type Converter struct {
+	cfg Config
+}
+
+func NewConverter(cfg Config) (*Converter, error) {
+	if cfg.DatasourceUID == "" {
+		return nil, errors.New("data source UID is required")
+	}
+	if cfg.DatasourceType == "" {
+		return nil, errors.New("data source type is required")
+	}
+	if cfg.DefaultInterval <= 0 {
+		return nil, errors.New("default interval must be positive")
+	}
+	return &Converter{cfg: cfg}, nil
+}
+
+// PrometheusRulesToGrafana converts the Prometheus rule group to a Grafana-managed rule group of the folder.
+// It returns the constructs of the group that are not supported by Grafana and were dropped or changed as warnings,
+// and an error if a rule cannot be converted.
+func (c *Converter) PrometheusRulesToGrafana(orgID int64, namespaceUID string, group definitions.PrometheusRuleGroup) (*models.AlertRuleGroup, []string, error) {
+	if group.Name == "" {
+		return nil, nil, errors.New("rule group name cannot be empty")
+	}
+	if len(group.Rules) == 0 {
+		return nil, nil, fmt.Errorf("rule group '%s' has no rules", group.Name)
+	}
+
+	var warnings []string
+	if len(group.SourceTenants) > 0 {
+		warnings = append(warnings, "field source_tenants is not supported, the rules query only the data source")
+	}
+	if group.AlignEvaluationTimeOnInterval {
+		warnings = append(warnings, "field align_evaluation_time_on_interval is not supported and is ignored")
+	}
+	if group.Limit > 0 {
+		warnings = append(warnings, "field limit is not supported and is ignored")
+	}
+
+	interval := c.cfg.DefaultInterval
+	if group.Interval > 0 {
+		interval = time.Duration(group.Interval)
+	}
+
+	var queryOffset time.Duration
+	switch {
+	case group.QueryOffset != nil:
+		queryOffset = time.Duration(*group.QueryOffset)
+	case group.EvaluationDelay != nil:
+		// evaluation_delay is the deprecated name of query_offset in Mimir
+		queryOffset = time.Duration(*group.EvaluationDelay)
+	}
+
+	result := &models.AlertRuleGroup{
+		Title:       group.Name,
+		FolderUID:   namespaceUID,
+		Interval:    int64(interval.Seconds()),
+		QueryOffset: queryOffset,
+		Rules:       make([]models.AlertRule, 0, len(group.Rules)),
+	}
+	for i, rule := range group.Rules {
+		converted, ruleWarnings, err := c.convertRule(rule, group.Labels)
+		name := rule.Alert
+		if name == "" {
+			name = rule.Record
+		}
+		for _, w := range ruleWarnings {
+			warnings = append(warnings, fmt.Sprintf("rule '%s': %s", name, w))
+		}
+		if err != nil {
+			return nil, warnings, fmt.Errorf("failed to convert rule %d '%s' of group '%s': %w", i+1, name, group.Name, err)
+		}
+
+		converted.OrgID = orgID
+		converted.NamespaceUID = namespaceUID
+		converted.RuleGroup = group.Name
+		converted.RuleGroupIndex = i + 1
+		converted.IntervalSeconds = result.Interval
+		converted.QueryOffset = queryOffset
+		result.Rules = append(result.Rules, converted)
+	}
+
+	return result, warnings, nil
+}
+
+func (c *Converter) convertRule(rule definitions.ApiRuleNode, groupLabels map[string]string) (models.AlertRule, []string, error) {
+	if rule.Alert != "" && rule.Record != "" {
+		return models.AlertRule{}, nil, errors.New("only one of 'alert' and 'record' must be set")
+	}
+	if rule.Alert == "" && rule.Record == "" {
+		return models.AlertRule{}, nil, errors.New("one of 'alert' or 'record' must be set")
+	}
+	if _, err := parser.ParseExpr(rule.Expr); err != nil {
+		return models.AlertRule{}, nil, fmt.Errorf("invalid expression: %w", err)
+	}
+
+	var labels map[string]string
+	if len(groupLabels) > 0 || len(rule.Labels) > 0 {
+		labels = make(map[string]string, len(groupLabels)+len(rule.Labels))
+		for k, v := range groupLabels {
+			labels[k] = v
+		}
+		// the labels of the rule take precedence over the labels of the group
+		for k, v := range rule.Labels {
+			labels[k] = v
+		}
+	}
+
+	query, err := c.query(rule.Expr)
+	if err != nil {
+		return models.AlertRule{}, nil, err
+	}
+
+	if rule.Record != "" {
+		return c.convertRecordingRule(rule, labels, query)
+	}
+	return c.convertAlertingRule(rule, labels, query)
+}
+
+func (c *Converter) convertRecordingRule(rule definitions.ApiRuleNode, labels map[string]string, query models.AlertQuery) (models.AlertRule, []string, error) {
+	if !prommodel.IsValidMetricName(prommodel.LabelValue(rule.Record)) {
+		return models.AlertRule{}, nil, fmt.Errorf("invalid recording rule name: %s", rule.Record)
+	}
+	// these fields are rejected by Prometheus as well
+	if rule.For != nil && *rule.For != 0 {
+		return models.AlertRule{}, nil, errors.New("invalid field 'for' in recording rule")
+	}
+	if rule.KeepFiringFor != nil && *rule.KeepFiringFor != 0 {
+		return models.AlertRule{}, nil, errors.New("invalid field 'keep_firing_for' in recording rule")
+	}
+	if len(rule.Annotations) > 0 {
+		return models.AlertRule{}, nil, errors.New("invalid field 'annotations' in recording rule")
+	}
+
+	return models.AlertRule{
+		Title:  rule.Record,
+		Data:   []models.AlertQuery{query},
+		Labels: labels,
+		Record: &models.Record{
+			Metric: rule.Record,
+			From:   queryRefID,
+		},
+	}, nil, nil
+}
+
+func (c *Converter) convertAlertingRule(rule definitions.ApiRuleNode, labels map[string]string, query models.AlertQuery) (models.AlertRule, []string, error) {
+	var warnings []string
+	convert := func(kind string, templates map[string]string) map[string]string {
+		if len(templates) == 0 {
+			return templates
+		}
+		result := make(map[string]string, len(templates))
+		for _, key := range sortedKeys(templates) {
+			text, templateWarnings := convertTemplate(templates[key])
+			for _, w := range templateWarnings {
+				warnings = append(warnings, fmt.Sprintf("%s '%s': %s", kind, key, w))
+			}
+			result[key] = text
+		}
+		return result
+	}
+
+	var forDuration, keepFiringFor time.Duration
+	if rule.For != nil {
+		forDuration = time.Duration(*rule.For)
+	}
+	if rule.KeepFiringFor != nil {
+		keepFiringFor = time.Duration(*rule.KeepFiringFor)
+	}
+
+	reduce, err := expressionQuery(reduceRefID, map[string]any{
+		"type":       "reduce",
+		"expression": queryRefID,
+		"reducer":    "last",
+	})
+	if err != nil {
+		return models.AlertRule{}, nil, err
+	}
+	firing, err := expressionQuery(firingRefID, map[string]any{
+		"type":       "math",
+		"expression": firingExpression,
+	})
+	if err != nil {
+		return models.AlertRule{}, nil, err
+	}
+	threshold, err := expressionQuery(thresholdRefID, map[string]any{
+		"type":       "threshold",
+		"expression": firingRefID,
+		"conditions": []any{
+			map[string]any{
+				"evaluator": map[string]any{
+					"type":   "gt",
+					"params": []float64{0},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return models.AlertRule{}, nil, err
+	}
+
+	return models.AlertRule{
+		Title:         rule.Alert,
+		Condition:     thresholdRefID,
+		Data:          []models.AlertQuery{query, reduce, firing, threshold},
+		For:           forDuration,
+		KeepFiringFor: keepFiringFor,
+		Labels:        convert("label", labels),
+		Annotations:   convert("annotation", rule.Annotations),
+		// Prometheus does not fire alerts for a query without series, and keeps the alerts of a rule unchanged
+		// when its query fails.
+		NoDataState:  models.OK,
+		ExecErrState: models.KeepLastErrState,
+	}, warnings, nil
+}
+
+// query returns the instant query of the expression on the data source.
+func (c *Converter) query(expression string) (models.AlertQuery, error) {
+	model, err := json.Marshal(map[string]any{
+		"refId": queryRefID,
+		"datasource": map[string]any{
+			"type": c.cfg.DatasourceType,
+			"uid":  c.cfg.DatasourceUID,
+		},
+		"expr":    expression,
+		"instant": true,
+		"range":   false,
+	})
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	return models.AlertQuery{
+		RefID:         queryRefID,
+		DatasourceUID: c.cfg.DatasourceUID,
+		RelativeTimeRange: models.RelativeTimeRange{
+			From: models.Duration(queryTimeRange),
+			To:   0,
+		},
+		Model: model,
+	}, nil
+}
+
+func expressionQuery(refID string, properties map[string]any) (models.AlertQuery, error) {
+	properties["refId"] = refID
+	properties["datasource"] = map[string]any{
+		"type": expr.DatasourceType,
+		"uid":  expr.DatasourceUID,
+	}
+	model, err := json.Marshal(properties)
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	return models.AlertQuery{
+		RefID:         refID,
+		QueryType:     expr.DatasourceType,
+		DatasourceUID: expr.DatasourceUID,
+		Model:         model,
+	}, nil
+}
+
+// convertTemplate converts the Prometheus template of a label or an annotation to a Grafana template. It returns
+// the constructs that cannot be converted as warnings.
+func convertTemplate(text string) (string, []string) {
+	var warnings []string
+	for _, name := range unsupportedVariable.FindAllString(text, -1) {
+		warnings = append(warnings, fmt.Sprintf("variable %s is not supported, the template fails to expand", name))
+	}
+	if queryFunction.MatchString(text) {
+		warnings = append(warnings, "function query is not supported and returns no data")
+	}
+	// $value is the value of the alert in Prometheus, and a description of all the values of the expressions in Grafana
+	return valueVariable.ReplaceAllString(text, fmt.Sprintf("$$values.%s.Value", reduceRefID)), warnings
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+package prom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func durationPtr(d time.Duration) *prommodel.Duration {
+	result := prommodel.Duration(d)
+	return &result
+}
+
+func newTestConverter(t *testing.T) *Converter {
+	t.Helper()
+	c, err := NewConverter(Config{
+		DatasourceUID:   "prometheus-uid",
+		DatasourceType:  "prometheus",
+		DefaultInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestNewConverter(t *testing.T) {
+	_, err := NewConverter(Config{DatasourceType: "prometheus", DefaultInterval: time.Minute})
+	require.Error(t, err)
+	_, err = NewConverter(Config{DatasourceUID: "prometheus-uid", DefaultInterval: time.Minute})
+	require.Error(t, err)
+	_, err = NewConverter(Config{DatasourceUID: "prometheus-uid", DatasourceType: "prometheus"})
+	require.Error(t, err)
+}
+
+func TestPrometheusRulesToGrafana(t *testing.T) {
+	c := newTestConverter(t)
+
+	t.Run("should convert alerting rules", func(t *testing.T) {
+		group := definitions.PrometheusRuleGroup{
+			Name:     "node",
+			Interval: prommodel.Duration(30 * time.Second),
+			Labels:   map[string]string{"team": "infra", "severity": "warning"},
+			Rules: []definitions.ApiRuleNode{{
+				Alert:         "InstanceDown",
+				Expr:          `up == 0`,
+				For:           durationPtr(5 * time.Minute),
+				KeepFiringFor: durationPtr(10 * time.Minute),
+				Labels:        map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "Instance {{ $labels.instance }} down",
+					"description": "Value is {{ $value | humanize }}, values are {{ $values }}",
+				},
+			}},
+		}
+
+		result, warnings, err := c.PrometheusRulesToGrafana(1, "folder-uid", group)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+
+		require.Equal(t, "node", result.Title)
+		require.Equal(t, "folder-uid", result.FolderUID)
+		require.Equal(t, int64(30), result.Interval)
+		require.Len(t, result.Rules, 1)
+
+		rule := result.Rules[0]
+		require.Equal(t, int64(1), rule.OrgID)
+		require.Equal(t, "InstanceDown", rule.Title)
+		require.Equal(t, "folder-uid", rule.NamespaceUID)
+		require.Equal(t, "node", rule.RuleGroup)
+		require.Equal(t, 1, rule.RuleGroupIndex)
+		require.Equal(t, int64(30), rule.IntervalSeconds)
+		require.Equal(t, 5*time.Minute, rule.For)
+		require.Equal(t, 10*time.Minute, rule.KeepFiringFor)
+		require.Equal(t, models.OK, rule.NoDataState)
+		require.Equal(t, models.KeepLastErrState, rule.ExecErrState)
+		require.Nil(t, rule.Record)
+		require.Equal(t, map[string]string{"team": "infra", "severity": "critical"}, rule.Labels)
+		require.Equal(t, map[string]string{
+			"summary":     "Instance {{ $labels.instance }} down",
+			"description": "Value is {{ $values.B.Value | humanize }}, values are {{ $values }}",
+		}, rule.Annotations)
+
+		require.Equal(t, thresholdRefID, rule.Condition)
+		require.Len(t, rule.Data, 4)
+		query := rule.Data[0]
+		require.Equal(t, queryRefID, query.RefID)
+		require.Equal(t, "prometheus-uid", query.DatasourceUID)
+		require.Equal(t, models.Duration(queryTimeRange), query.RelativeTimeRange.From)
+		var model map[string]any
+		require.NoError(t, json.Unmarshal(query.Model, &model))
+		require.Equal(t, "up == 0", model["expr"])
+		require.Equal(t, true, model["instant"])
+		require.Equal(t, map[string]any{"type": "prometheus", "uid": "prometheus-uid"}, model["datasource"])
+
+		for i, expected := range []struct {
+			refID      string
+			typ        string
+			expression string
+		}{
+			{reduceRefID, "reduce", queryRefID},
+			{firingRefID, "math", firingExpression},
+			{thresholdRefID, "threshold", firingRefID},
+		} {
+			q := rule.Data[i+1]
+			require.Equal(t, expected.refID, q.RefID)
+			require.Equal(t, "__expr__", q.DatasourceUID)
+			var model map[string]any
+			require.NoError(t, json.Unmarshal(q.Model, &model))
+			require.Equal(t, expected.typ, model["type"])
+			require.Equal(t, expected.expression, model["expression"])
+		}
+	})
+
+	t.Run("should convert recording rules", func(t *testing.T) {
+		group := definitions.PrometheusRuleGroup{
+			Name: "recording",
+			Rules: []definitions.ApiRuleNode{{
+				Record: "job:http_requests:rate5m",
+				Expr:   `sum by (job) (rate(http_requests_total[5m]))`,
+				Labels: map[string]string{"source": "prometheus"},
+			}},
+		}
+
+		result, warnings, err := c.PrometheusRulesToGrafana(1, "folder-uid", group)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+		// the default interval is used when the group has none
+		require.Equal(t, int64(60), result.Interval)
+
+		rule := result.Rules[0]
+		require.Equal(t, "job:http_requests:rate5m", rule.Title)
+		require.Equal(t, &models.Record{Metric: "job:http_requests:rate5m", From: queryRefID}, rule.Record)
+		require.Len(t, rule.Data, 1)
+		require.Empty(t, rule.Condition)
+		require.Equal(t, map[string]string{"source": "prometheus"}, rule.Labels)
+	})
+
+	t.Run("should set the query offset of the group", func(t *testing.T) {
+		group := definitions.PrometheusRuleGroup{
+			Name:        "offset",
+			QueryOffset: durationPtr(time.Minute),
+			Rules:       []definitions.ApiRuleNode{{Alert: "Test", Expr: "up"}},
+		}
+		result, _, err := c.PrometheusRulesToGrafana(1, "folder-uid", group)
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, result.QueryOffset)
+		require.Equal(t, time.Minute, result.Rules[0].QueryOffset)
+
+		group.QueryOffset = nil
+		group.EvaluationDelay = durationPtr(2 * time.Minute)
+		result, _, err = c.PrometheusRulesToGrafana(1, "folder-uid", group)
+		require.NoError(t, err)
+		require.Equal(t, 2*time.Minute, result.QueryOffset)
+	})
+
+	t.Run("should report unsupported constructs", func(t *testing.T) {
+		group := definitions.PrometheusRuleGroup{
+			Name:                          "unsupported",
+			Limit:                         10,
+			SourceTenants:                 []string{"tenant-a", "tenant-b"},
+			AlignEvaluationTimeOnInterval: true,
+			Rules: []definitions.ApiRuleNode{{
+				Alert: "Test",
+				Expr:  "up",
+				Annotations: map[string]string{
+					"cluster":   "{{ $externalLabels.cluster }}",
+					"dashboard": "{{ $externalURL }}/d/abc",
+					"count":     `{{ with query "count(up)" }}{{ . | first | value }}{{ end }}`,
+				},
+			}},
+		}
+
+		_, warnings, err := c.PrometheusRulesToGrafana(1, "folder-uid", group)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"field source_tenants is not supported, the rules query only the data source",
+			"field align_evaluation_time_on_interval is not supported and is ignored",
+			"field limit is not supported and is ignored",
+			"rule 'Test': annotation 'cluster': variable $externalLabels is not supported, the template fails to expand",
+			"rule 'Test': annotation 'count': function query is not supported and returns no data",
+			"rule 'Test': annotation 'dashboard': variable $externalURL is not supported, the template fails to expand",
+		}, warnings)
+	})
+
+	t.Run("should fail to convert invalid rules", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			rule definitions.ApiRuleNode
+		}{
+			{name: "both alert and record", rule: definitions.ApiRuleNode{Alert: "Test", Record: "test", Expr: "up"}},
+			{name: "neither alert nor record", rule: definitions.ApiRuleNode{Expr: "up"}},
+			{name: "invalid expression", rule: definitions.ApiRuleNode{Alert: "Test", Expr: "sum(up"}},
+			{name: "invalid metric name", rule: definitions.ApiRuleNode{Record: "invalid-name", Expr: "up"}},
+			{name: "recording rule with for", rule: definitions.ApiRuleNode{Record: "test", Expr: "up", For: durationPtr(time.Minute)}},
+			{name: "recording rule with annotations", rule: definitions.ApiRuleNode{Record: "test", Expr: "up", Annotations: map[string]string{"summary": "test"}}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				group := definitions.PrometheusRuleGroup{Name: "invalid", Rules: []definitions.ApiRuleNode{tc.rule}}
+				_, _, err := c.PrometheusRulesToGrafana(1, "folder-uid", group)
+				require.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("should fail to convert a group without name or rules", func(t *testing.T) {
+		_, _, err := c.PrometheusRulesToGrafana(1, "folder-uid", definitions.PrometheusRuleGroup{Rules: []definitions.ApiRuleNode{{Alert: "Test", Expr: "up"}}})
+		require.Error(t, err)
+		_, _, err = c.PrometheusRulesToGrafana(1, "folder-uid", definitions.PrometheusRuleGroup{Name: "empty"})
+		require.Error(t, err)
+	})
+}
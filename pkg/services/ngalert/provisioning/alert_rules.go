@@ -185,6 +185,7 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, user ident
 		return models.AlertRule{}, errors.Join(models.ErrAlertRuleFailedValidation, fmt.Errorf("cannot create rule with UID '%s': %w", rule.UID, err))
 	}
 	var interval = service.defaultIntervalSeconds
+	var queryOffset time.Duration
 	if err := service.ensureNamespace(ctx, user, rule.OrgID, rule.NamespaceUID); err != nil {
 		return models.AlertRule{}, err
 	}
@@ -194,13 +195,19 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, user ident
 		return models.AlertRule{}, err
 	}
 	if canWriteAllRules {
-		groupInterval, err := service.ruleStore.GetRuleGroupInterval(ctx, rule.OrgID, rule.NamespaceUID, rule.RuleGroup)
-		// if the alert group does not exist we just use the default interval
-		if err == nil {
-			interval = groupInterval
-		} else if !errors.Is(err, models.ErrAlertRuleGroupNotFound) {
+		existingGroup, err := service.ruleStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{
+			OrgID:         rule.OrgID,
+			NamespaceUIDs: []string{rule.NamespaceUID},
+			RuleGroups:    []string{rule.RuleGroup},
+		})
+		if err != nil {
 			return models.AlertRule{}, err
 		}
+		// if the alert group does not exist we just use the default interval
+		if len(existingGroup) > 0 {
+			interval = existingGroup[0].IntervalSeconds
+			queryOffset = existingGroup[0].QueryOffset
+		}
 	} else {
 		delta, err := store.CalculateRuleCreate(ctx, service.ruleStore, &rule)
 		if err != nil {
@@ -212,9 +219,11 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, user ident
 		existingGroup := delta.AffectedGroups[rule.GetGroupKey()]
 		if len(existingGroup) > 0 {
 			interval = existingGroup[0].IntervalSeconds
+			queryOffset = existingGroup[0].QueryOffset
 		}
 	}
 	rule.IntervalSeconds = interval
+	rule.QueryOffset = queryOffset
 	err = rule.SetDashboardAndPanelFromAnnotations()
 	if err != nil {
 		return models.AlertRule{}, err
@@ -286,10 +295,11 @@ func (service *AlertRuleService) GetRuleGroup(ctx context.Context, user identity
 		}
 	}
 	res := models.AlertRuleGroup{
-		Title:     ruleList[0].RuleGroup,
-		FolderUID: ruleList[0].NamespaceUID,
-		Interval:  ruleList[0].IntervalSeconds,
-		Rules:     make([]models.AlertRule, 0, len(ruleList)),
+		Title:       ruleList[0].RuleGroup,
+		FolderUID:   ruleList[0].NamespaceUID,
+		Interval:    ruleList[0].IntervalSeconds,
+		QueryOffset: ruleList[0].QueryOffset,
+		Rules:       make([]models.AlertRule, 0, len(ruleList)),
 	}
 	for _, r := range ruleList {
 		if r != nil {
@@ -299,11 +309,14 @@ func (service *AlertRuleService) GetRuleGroup(ctx context.Context, user identity
 	return res, nil
 }
 
-// UpdateRuleGroup will update the interval for all rules in the group.
-func (service *AlertRuleService) UpdateRuleGroup(ctx context.Context, user identity.Requester, namespaceUID string, ruleGroup string, intervalSeconds int64) error {
+// UpdateRuleGroup will update the interval and the query offset for all rules in the group.
+func (service *AlertRuleService) UpdateRuleGroup(ctx context.Context, user identity.Requester, namespaceUID string, ruleGroup string, intervalSeconds int64, queryOffset time.Duration) error {
 	if err := models.ValidateRuleGroupInterval(intervalSeconds, service.baseIntervalSeconds); err != nil {
 		return err
 	}
+	if queryOffset < 0 {
+		return fmt.Errorf("%w: query offset cannot be negative", models.ErrAlertRuleFailedValidation)
+	}
 	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
 		query := &models.ListAlertRulesQuery{
 			OrgID:         user.GetOrgID(),
@@ -316,11 +329,12 @@ func (service *AlertRuleService) UpdateRuleGroup(ctx context.Context, user ident
 		}
 		updateRules := make([]models.UpdateRule, 0, len(ruleList))
 		for _, rule := range ruleList {
-			if rule.IntervalSeconds == intervalSeconds {
+			if rule.IntervalSeconds == intervalSeconds && rule.QueryOffset == queryOffset {
 				continue
 			}
 			newRule := *rule
 			newRule.IntervalSeconds = intervalSeconds
+			newRule.QueryOffset = queryOffset
 			updateRules = append(updateRules, models.UpdateRule{
 				Existing: rule,
 				New:      newRule,
@@ -601,6 +615,7 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, user ident
 	rule.Updated = time.Now()
 	rule.ID = storedRule.ID
 	rule.IntervalSeconds = storedRule.IntervalSeconds
+	rule.QueryOffset = storedRule.QueryOffset
 
 	// Currently metadata contains only editor settings, so we can just copy it.
 	// If we add more fields to metadata, we might need to handle them separately,
@@ -810,6 +825,7 @@ func (service *AlertRuleService) GetAlertGroupsWithFolderFullpath(ctx context.Co
 func syncGroupRuleFields(group *models.AlertRuleGroup, orgID int64) *models.AlertRuleGroup {
 	for i := range group.Rules {
 		group.Rules[i].IntervalSeconds = group.Interval
+		group.Rules[i].QueryOffset = group.QueryOffset
 		group.Rules[i].RuleGroup = group.Title
 		group.Rules[i].NamespaceUID = group.FolderUID
 		group.Rules[i].OrgID = orgID
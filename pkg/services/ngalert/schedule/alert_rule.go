@@ -379,11 +379,18 @@ func (a *alertRule) evaluate(ctx context.Context, e *Evaluation, span trace.Span
 		dur = a.clock.Now().Sub(start)
 		logger.Error("Failed to build rule evaluator", "error", err)
 	} else {
-		results, err = ruleEval.Evaluate(ctx, e.scheduledAt)
+		// The queries are evaluated query offset in the past to account for late-arriving data,
+		// while the state of the rule is still evaluated at the scheduled time.
+		results, err = ruleEval.Evaluate(ctx, e.queryTime())
 		dur = a.clock.Now().Sub(start)
 		if err != nil {
 			logger.Error("Failed to evaluate rule", "error", err, "duration", dur)
 		}
+		if e.rule.QueryOffset > 0 {
+			for i := range results {
+				results[i].EvaluatedAt = e.scheduledAt
+			}
+		}
 	}
 
 	evalAttemptTotal.Inc()
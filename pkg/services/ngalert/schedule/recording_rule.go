@@ -265,7 +265,7 @@ func (r *recordingRule) tryEvaluation(ctx context.Context, ev *Evaluation, logge
 	}
 
 	writeStart := r.clock.Now()
-	err = r.writer.Write(ctx, ev.rule.Record.Metric, ev.scheduledAt, frames, ev.rule.OrgID, ev.rule.Labels)
+	err = r.writer.Write(ctx, ev.rule.Record.Metric, ev.queryTime(), frames, ev.rule.OrgID, ev.rule.Labels)
 	writeDur := r.clock.Now().Sub(writeStart)
 
 	if err != nil {
@@ -289,7 +289,7 @@ func (r *recordingRule) buildAndExecutePipeline(ctx context.Context, evalCtx eva
 		logger.Error("Failed to build rule evaluator", "error", err)
 		return nil, err
 	}
-	results, err := evaluator.EvaluateRaw(ctx, ev.scheduledAt)
+	results, err := evaluator.EvaluateRaw(ctx, ev.queryTime())
 	if err != nil {
 		logger.Error("Failed to evaluate rule", "error", err, "duration", r.clock.Now().Sub(start))
 	}
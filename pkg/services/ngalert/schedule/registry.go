@@ -102,6 +102,11 @@ func (e *Evaluation) Fingerprint() fingerprint {
 	return ruleWithFolder{e.rule, e.folderTitle}.Fingerprint()
 }
 
+// queryTime returns the time the queries of the rule are evaluated at, which is the scheduled time shifted by the query offset of the rule group.
+func (e *Evaluation) queryTime() time.Time {
+	return e.scheduledAt.Add(-e.rule.QueryOffset)
+}
+
 type alertRulesRegistry struct {
 	rules        map[models.AlertRuleKey]*models.AlertRule
 	folderTitles map[models.FolderKey]string
@@ -317,6 +322,7 @@ func (r ruleWithFolder) Fingerprint() fingerprint {
 	writeInt(rule.ID)
 	writeInt(rule.OrgID)
 	writeInt(int64(rule.For))
+	writeInt(int64(rule.KeepFiringFor))
 	if rule.DashboardUID != nil {
 		writeString(*rule.DashboardUID)
 	}
@@ -151,11 +151,12 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 		f2 := ruleWithFolder{rule: rule, folderTitle: uuid.NewString()}.Fingerprint()
 		require.NotEqual(t, f, f2)
 	})
-	t.Run("Version, Updated, IntervalSeconds and Annotations should be excluded from fingerprint", func(t *testing.T) {
+	t.Run("Version, Updated, IntervalSeconds, QueryOffset and Annotations should be excluded from fingerprint", func(t *testing.T) {
 		cp := models.CopyRule(rule)
 		cp.Version++
 		cp.Updated = cp.Updated.Add(1 * time.Second)
 		cp.IntervalSeconds++
+		cp.QueryOffset += time.Minute
 		cp.Annotations = make(map[string]string)
 		cp.Annotations["test"] = "test"
 
@@ -194,6 +195,8 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 			ExecErrState:    "test-err",
 			Record:          &models.Record{Metric: "my_metric", From: "A"},
 			For:             12,
+			KeepFiringFor:   30,
+			QueryOffset:     60,
 			Annotations: map[string]string{
 				"key-annotation": "value-annotation",
 			},
@@ -238,6 +241,8 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 			ExecErrState:    "test-err2",
 			Record:          &models.Record{Metric: "my_metric2", From: "B"},
 			For:             1141,
+			KeepFiringFor:   300,
+			QueryOffset:     600,
 			Annotations: map[string]string{
 				"key-annotation2": "value-annotation",
 			},
@@ -259,6 +264,7 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 			"Version":         {},
 			"Updated":         {},
 			"IntervalSeconds": {},
+			"QueryOffset":     {},
 			"Annotations":     {},
 		}
 
@@ -0,0 +1,374 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const (
+	defaultSQLQueryLimit = 1000
+	// sqlPruneInterval is how often state history older than the retention is deleted.
+	sqlPruneInterval = time.Hour
+)
+
+// stateHistoryEntry is a row of the alert_state_history table.
+type stateHistoryEntry struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	OrgID         int64  `xorm:"org_id"`
+	RuleUID       string `xorm:"rule_uid"`
+	RuleID        int64  `xorm:"rule_id"`
+	RuleTitle     string `xorm:"rule_title"`
+	RuleGroup     string `xorm:"rule_group"`
+	RuleCondition string `xorm:"rule_condition"`
+	FolderUID     string `xorm:"folder_uid"`
+	DashboardUID  string `xorm:"dashboard_uid"`
+	PanelID       int64  `xorm:"panel_id"`
+	Fingerprint   string `xorm:"fingerprint"`
+	PreviousState string `xorm:"previous_state"`
+	CurrentState  string `xorm:"current_state"`
+	Error         string `xorm:"error"`
+	Labels        string `xorm:"labels"`
+	StateValues   string `xorm:"state_values"`
+	Epoch         int64  `xorm:"epoch"`
+
+	// The labels are stored in alert_state_history_label.
+	labels data.Labels `xorm:"-"`
+}
+
+func (stateHistoryEntry) TableName() string {
+	return "alert_state_history"
+}
+
+// stateHistoryLabel is a row of the alert_state_history_label table, used to filter state history by label.
+type stateHistoryLabel struct {
+	HistoryID  int64  `xorm:"history_id"`
+	LabelKey   string `xorm:"label_key"`
+	LabelValue string `xorm:"label_value"`
+}
+
+// SQLBackend is a state.Historian that records state history to Grafana's database.
+type SQLBackend struct {
+	db        db.DB
+	retention time.Duration
+	clock     clock.Clock
+	metrics   *metrics.Historian
+	log       log.Logger
+	ac        AccessControl
+	ruleStore RuleStore
+
+	pruneMtx  sync.Mutex
+	lastPrune time.Time
+}
+
+// NewSQLBackend creates a SQLBackend. State history older than the retention is deleted, unless the retention is zero.
+func NewSQLBackend(logger log.Logger, db db.DB, retention time.Duration, metrics *metrics.Historian, ruleStore RuleStore, ac AccessControl) *SQLBackend {
+	return &SQLBackend{
+		db:        db,
+		retention: retention,
+		clock:     clock.New(),
+		metrics:   metrics,
+		log:       logger,
+		ac:        ac,
+		ruleStore: ruleStore,
+	}
+}
+
+// Record writes a number of state transitions for a given rule to Grafana's database.
+func (h *SQLBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	logger := h.log.FromContext(ctx)
+	entries := statesToEntries(rule, states, logger)
+
+	errCh := make(chan error, 1)
+	if len(entries) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	// This is a new background job, so let's create a brand new context for it.
+	// We want it to be isolated, i.e. we don't want grafana shutdowns to interrupt this work
+	// immediately but rather try to flush writes.
+	// This also prevents timeouts or other lingering objects (like transactions) from being
+	// incorrectly propagated here from other areas.
+	writeCtx := context.Background()
+	writeCtx, cancel := context.WithTimeout(writeCtx, StateHistoryWriteTimeout)
+	writeCtx = history_model.WithRuleData(writeCtx, rule)
+	writeCtx = trace.ContextWithSpan(writeCtx, trace.SpanFromContext(ctx))
+
+	go func(ctx context.Context) {
+		defer cancel()
+		defer close(errCh)
+		logger := h.log.FromContext(ctx)
+		logger.Debug("Saving state history batch", "samples", len(entries))
+		org := fmt.Sprint(rule.OrgID)
+		h.metrics.WritesTotal.WithLabelValues(org, BackendTypeSQL.String()).Inc()
+		h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(entries)))
+
+		if err := h.save(ctx, entries); err != nil {
+			logger.Error("Failed to save alert state history batch", "error", err)
+			h.metrics.WritesFailed.WithLabelValues(org, BackendTypeSQL.String()).Inc()
+			h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(len(entries)))
+			errCh <- fmt.Errorf("failed to save alert state history batch: %w", err)
+			return
+		}
+		logger.Debug("Done saving alert state history batch", "samples", len(entries))
+
+		if err := h.pruneIfDue(ctx); err != nil {
+			logger.Error("Failed to delete expired alert state history", "error", err)
+		}
+	}(writeCtx)
+	return errCh
+}
+
+func statesToEntries(rule history_model.RuleMeta, states []state.StateTransition, logger log.Logger) []*stateHistoryEntry {
+	entries := make([]*stateHistoryEntry, 0, len(states))
+	for _, state := range states {
+		if !shouldRecord(state) {
+			continue
+		}
+
+		sanitizedLabels := removePrivateLabels(state.Labels)
+		labels, err := json.Marshal(sanitizedLabels)
+		if err != nil {
+			logger.Error("Failed to serialize the labels of a state, skipping", "error", err)
+			continue
+		}
+		values, err := valuesAsDataBlob(state.State).Encode()
+		if err != nil {
+			logger.Error("Failed to serialize the values of a state, skipping", "error", err)
+			continue
+		}
+
+		entry := &stateHistoryEntry{
+			OrgID:         rule.OrgID,
+			RuleUID:       rule.UID,
+			RuleID:        rule.ID,
+			RuleTitle:     rule.Title,
+			RuleGroup:     rule.Group,
+			RuleCondition: rule.Condition,
+			FolderUID:     rule.NamespaceUID,
+			DashboardUID:  rule.DashboardUID,
+			PanelID:       rule.PanelID,
+			Fingerprint:   labelFingerprint(sanitizedLabels),
+			PreviousState: state.PreviousFormatted(),
+			CurrentState:  state.Formatted(),
+			Labels:        string(labels),
+			StateValues:   string(values),
+			Epoch:         state.State.LastEvaluationTime.UnixMilli(),
+			labels:        sanitizedLabels,
+		}
+		if state.State.State == eval.Error && state.Error != nil {
+			entry.Error = state.Error.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (h *SQLBackend) save(ctx context.Context, entries []*stateHistoryEntry) error {
+	return h.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var labels []stateHistoryLabel
+		for _, entry := range entries {
+			if _, err := sess.Insert(entry); err != nil {
+				return err
+			}
+			for k, v := range entry.labels {
+				labels = append(labels, stateHistoryLabel{HistoryID: entry.ID, LabelKey: k, LabelValue: v})
+			}
+		}
+		if len(labels) == 0 {
+			return nil
+		}
+		_, err := sess.BulkInsert("alert_state_history_label", labels, sqlstore.NativeSettingsForDialect(h.db.GetDialect()))
+		return err
+	})
+}
+
+// pruneIfDue deletes the state history older than the retention, at most once per sqlPruneInterval.
+func (h *SQLBackend) pruneIfDue(ctx context.Context) error {
+	if h.retention <= 0 {
+		return nil
+	}
+
+	now := h.clock.Now()
+	h.pruneMtx.Lock()
+	if now.Sub(h.lastPrune) < sqlPruneInterval {
+		h.pruneMtx.Unlock()
+		return nil
+	}
+	h.lastPrune = now
+	h.pruneMtx.Unlock()
+
+	return h.prune(ctx, now.Add(-h.retention))
+}
+
+// prune deletes the state history recorded before the given time.
+func (h *SQLBackend) prune(ctx context.Context, before time.Time) error {
+	return h.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		epoch := before.UnixMilli()
+		if _, err := sess.Exec("DELETE FROM alert_state_history_label WHERE history_id IN (SELECT id FROM alert_state_history WHERE epoch < ?)", epoch); err != nil {
+			return err
+		}
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE epoch < ?", epoch)
+		if err != nil {
+			return err
+		}
+		if deleted, err := res.RowsAffected(); err == nil && deleted > 0 {
+			h.log.FromContext(ctx).Debug("Deleted expired alert state history", "transitions", deleted)
+		}
+		return nil
+	})
+}
+
+// Query retrieves state history entries from Grafana's database and formats the results into a dataframe
+// in the same format as the Loki backend.
+func (h *SQLBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	uids, err := folderUIDsForFilter(ctx, h.ac, h.ruleStore, query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = now.Add(-defaultQueryRange)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSQLQueryLimit
+	}
+
+	sql, params := buildSQLQuery(h.db.GetDialect(), query, uids)
+	var entries []*stateHistoryEntry
+	err = h.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(sql, params...).Find(&entries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert state history: %w", err)
+	}
+	// The most recent entries are selected, but the frame is in chronological order.
+	slices.Reverse(entries)
+
+	return entriesToFrame(entries)
+}
+
+// buildSQLQuery returns the SQL query and its parameters that selects the most recent state history entries
+// that match the query. If the list of folder UIDs is not empty, only the entries of these folders are selected.
+func buildSQLQuery(dialect migrator.Dialect, query models.HistoryQuery, folderUIDs []string) (string, []any) {
+	b := strings.Builder{}
+	b.WriteString("SELECT * FROM alert_state_history WHERE org_id = ? AND epoch >= ? AND epoch <= ?")
+	params := []any{query.OrgID, query.From.UnixMilli(), query.To.UnixMilli()}
+
+	if query.RuleUID != "" {
+		b.WriteString(" AND rule_uid = ?")
+		params = append(params, query.RuleUID)
+	}
+	if query.DashboardUID != "" {
+		b.WriteString(" AND dashboard_uid = ?")
+		params = append(params, query.DashboardUID)
+	}
+	if query.PanelID != 0 {
+		b.WriteString(" AND panel_id = ?")
+		params = append(params, query.PanelID)
+	}
+	if len(folderUIDs) > 0 {
+		b.WriteString(" AND folder_uid IN (?" + strings.Repeat(",?", len(folderUIDs)-1) + ")")
+		for _, uid := range folderUIDs {
+			params = append(params, uid)
+		}
+	}
+
+	// Ensure that all queries we build are deterministic.
+	labelKeys := make([]string, 0, len(query.Labels))
+	for k := range query.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		b.WriteString(" AND EXISTS (SELECT 1 FROM alert_state_history_label WHERE history_id = alert_state_history.id AND label_key = ? AND label_value = ?)")
+		params = append(params, k, query.Labels[k])
+	}
+
+	b.WriteString(" ORDER BY epoch DESC, id DESC")
+	b.WriteString(dialect.Limit(int64(query.Limit)))
+	return b.String(), params
+}
+
+func entriesToFrame(entries []*stateHistoryEntry) (*data.Frame, error) {
+	frame := data.NewFrame("states")
+	lbls := data.Labels(map[string]string{})
+
+	times := make([]time.Time, 0, len(entries))
+	lines := make([]json.RawMessage, 0, len(entries))
+	labels := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		var instanceLabels map[string]string
+		if err := json.Unmarshal([]byte(entry.Labels), &instanceLabels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the labels of entry %d: %w", entry.ID, err)
+		}
+		values, err := simplejson.NewJson([]byte(entry.StateValues))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the values of entry %d: %w", entry.ID, err)
+		}
+
+		line, err := json.Marshal(LokiEntry{
+			SchemaVersion:  1,
+			Previous:       entry.PreviousState,
+			Current:        entry.CurrentState,
+			Error:          entry.Error,
+			Values:         values,
+			Condition:      entry.RuleCondition,
+			DashboardUID:   entry.DashboardUID,
+			PanelID:        entry.PanelID,
+			Fingerprint:    entry.Fingerprint,
+			RuleTitle:      entry.RuleTitle,
+			RuleID:         entry.RuleID,
+			RuleUID:        entry.RuleUID,
+			InstanceLabels: instanceLabels,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize entry %d: %w", entry.ID, err)
+		}
+		// The same labels as the log streams of the Loki backend.
+		streamLabels, err := json.Marshal(map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           fmt.Sprint(entry.OrgID),
+			GroupLabel:           entry.RuleGroup,
+			FolderUIDLabel:       entry.FolderUID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize stream labels: %w", err)
+		}
+
+		times = append(times, time.UnixMilli(entry.Epoch))
+		lines = append(lines, line)
+		labels = append(labels, streamLabels)
+	}
+
+	frame.Fields = append(frame.Fields, data.NewField(dfTime, lbls, times))
+	frame.Fields = append(frame.Fields, data.NewField(dfLine, lbls, lines))
+	frame.Fields = append(frame.Fields, data.NewField(dfLabels, lbls, labels))
+
+	return frame, nil
+}
@@ -0,0 +1,169 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	acfakes "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestBuildSQLQuery(t *testing.T) {
+	from := time.UnixMilli(1000)
+	to := time.UnixMilli(2000)
+	dialect := migrator.NewSQLite3Dialect()
+
+	t.Run("filters by org and time range", func(t *testing.T) {
+		sql, params := buildSQLQuery(dialect, models.HistoryQuery{OrgID: 1, From: from, To: to, Limit: 10}, nil)
+
+		require.Equal(t, "SELECT * FROM alert_state_history WHERE org_id = ? AND epoch >= ? AND epoch <= ? ORDER BY epoch DESC, id DESC LIMIT 10", sql)
+		require.Equal(t, []any{int64(1), int64(1000), int64(2000)}, params)
+	})
+
+	t.Run("filters by rule, dashboard, folders and labels", func(t *testing.T) {
+		sql, params := buildSQLQuery(dialect, models.HistoryQuery{
+			OrgID:        1,
+			RuleUID:      "rule-uid",
+			DashboardUID: "dash-uid",
+			PanelID:      2,
+			Labels:       map[string]string{"b": "2", "a": "1"},
+			From:         from,
+			To:           to,
+			Limit:        10,
+		}, []string{"f1", "f2"})
+
+		require.Equal(t, "SELECT * FROM alert_state_history WHERE org_id = ? AND epoch >= ? AND epoch <= ?"+
+			" AND rule_uid = ? AND dashboard_uid = ? AND panel_id = ? AND folder_uid IN (?,?)"+
+			" AND EXISTS (SELECT 1 FROM alert_state_history_label WHERE history_id = alert_state_history.id AND label_key = ? AND label_value = ?)"+
+			" AND EXISTS (SELECT 1 FROM alert_state_history_label WHERE history_id = alert_state_history.id AND label_key = ? AND label_value = ?)"+
+			" ORDER BY epoch DESC, id DESC LIMIT 10", sql)
+		require.Equal(t, []any{int64(1), int64(1000), int64(2000), "rule-uid", "dash-uid", int64(2), "f1", "f2", "a", "1", "b", "2"}, params)
+	})
+}
+
+func TestIntegrationSQLBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	rules := fakes.NewRuleStore(t)
+	ac := &acfakes.FakeRuleService{
+		CanReadAllRulesFunc: func(ctx context.Context, requester identity.Requester) (bool, error) {
+			return true, nil
+		},
+	}
+	met := metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem)
+	sut := NewSQLBackend(log.NewNopLogger(), sqlStore, time.Hour, met, rules, ac)
+	clk := clock.NewMock()
+	sut.clock = clk
+
+	rule := createTestRule()
+	now := time.Now().Truncate(time.Millisecond)
+	record := func(t *testing.T, at time.Time, lbls data.Labels) {
+		t.Helper()
+		states := singleFromNormal(&state.State{
+			State:              eval.Alerting,
+			Labels:             lbls,
+			LastEvaluationTime: at,
+		})
+		require.NoError(t, <-sut.Record(context.Background(), rule, states))
+	}
+	query := func(t *testing.T, q models.HistoryQuery) []LokiEntry {
+		t.Helper()
+		q.OrgID = rule.OrgID
+		q.SignedInUser = &identity.StaticRequester{OrgID: rule.OrgID}
+		frame, err := sut.Query(context.Background(), q)
+		require.NoError(t, err)
+		entries := make([]LokiEntry, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			var entry LokiEntry
+			require.NoError(t, json.Unmarshal(frame.Fields[1].At(i).(json.RawMessage), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	record(t, now.Add(-2*time.Minute), data.Labels{"a": "1", "b": "1"})
+	record(t, now.Add(-time.Minute), data.Labels{"a": "1", "b": "2", "__private__": "x"})
+
+	t.Run("returns the transitions in chronological order", func(t *testing.T) {
+		frame, err := sut.Query(context.Background(), models.HistoryQuery{OrgID: rule.OrgID, SignedInUser: &identity.StaticRequester{OrgID: rule.OrgID}})
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, now.Add(-2*time.Minute).UnixMilli(), frame.Fields[0].At(0).(time.Time).UnixMilli())
+		require.Equal(t, now.Add(-time.Minute).UnixMilli(), frame.Fields[0].At(1).(time.Time).UnixMilli())
+
+		var streamLabels map[string]string
+		require.NoError(t, json.Unmarshal(frame.Fields[2].At(0).(json.RawMessage), &streamLabels))
+		require.Equal(t, map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           "1",
+			GroupLabel:           rule.Group,
+			FolderUIDLabel:       rule.NamespaceUID,
+		}, streamLabels)
+
+		entries := query(t, models.HistoryQuery{})
+		require.Equal(t, rule.UID, entries[1].RuleUID)
+		require.Equal(t, "Alerting", entries[1].Current)
+		require.Equal(t, "Normal", entries[1].Previous)
+		require.Equal(t, map[string]string{"a": "1", "b": "2"}, entries[1].InstanceLabels)
+	})
+
+	t.Run("filters by labels", func(t *testing.T) {
+		require.Len(t, query(t, models.HistoryQuery{Labels: map[string]string{"a": "1"}}), 2)
+		entries := query(t, models.HistoryQuery{Labels: map[string]string{"a": "1", "b": "2"}})
+		require.Len(t, entries, 1)
+		require.Equal(t, "2", entries[0].InstanceLabels["b"])
+		require.Empty(t, query(t, models.HistoryQuery{Labels: map[string]string{"__private__": "x"}}))
+	})
+
+	t.Run("filters by rule and time range", func(t *testing.T) {
+		require.Len(t, query(t, models.HistoryQuery{RuleUID: rule.UID}), 2)
+		require.Empty(t, query(t, models.HistoryQuery{RuleUID: "other"}))
+		require.Len(t, query(t, models.HistoryQuery{From: now.Add(-90 * time.Second), To: now}), 1)
+	})
+
+	t.Run("keeps the most recent transitions within the limit", func(t *testing.T) {
+		entries := query(t, models.HistoryQuery{Limit: 1})
+		require.Len(t, entries, 1)
+		require.Equal(t, "2", entries[0].InstanceLabels["b"])
+	})
+
+	t.Run("deletes transitions older than the retention", func(t *testing.T) {
+		clk.Set(now.Add(time.Hour - 90*time.Second))
+		record(t, now, data.Labels{"a": "2"})
+
+		entries := query(t, models.HistoryQuery{From: now.Add(-time.Hour), To: now.Add(time.Minute)})
+		require.Len(t, entries, 2)
+		require.Equal(t, "2", entries[0].InstanceLabels["b"])
+
+		var labels int64
+		err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			var err error
+			labels, err = sess.Table("alert_state_history_label").Count()
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), labels)
+	})
+}
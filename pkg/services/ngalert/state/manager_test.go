@@ -1975,6 +1975,77 @@ func TestStaleResults(t *testing.T) {
 	})
 }
 
+func TestKeepFiringFor(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+
+	cfg := state.ManagerCfg{
+		Metrics:       metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+		ExternalURL:   nil,
+		InstanceStore: &state.FakeInstanceStore{},
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+		Tracer:        tracing.InitializeTracerForTest(),
+		Log:           log.New("ngalert.state.manager"),
+	}
+	st := state.NewManager(cfg, state.NewNoopPersister())
+
+	gen := models.RuleGen
+	rule := gen.With(gen.WithFor(0), gen.WithKeepFiringFor(5*time.Minute), gen.WithIntervalSeconds(60)).GenerateRef()
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+
+	process := func(s eval.State) *state.State {
+		t.Helper()
+		result := eval.ResultGen(eval.WithState(s), eval.WithEvaluatedAt(clk.Now()))()
+		result.Instance = data.Labels{"instance": "1"}
+		processed := st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil, nil)
+		require.Len(t, processed, 1)
+		return processed[0].State
+	}
+
+	s := process(eval.Alerting)
+	require.Equal(t, eval.Alerting, s.State)
+	startsAt := s.StartsAt
+
+	t.Run("should keep firing while the condition is resolved for less than keep_firing_for", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			clk.Add(interval)
+			s = process(eval.Normal)
+			require.Equal(t, eval.Alerting, s.State)
+			require.Equal(t, startsAt, s.StartsAt)
+			require.Equal(t, clk.Now().Add(4*interval), s.EndsAt)
+			require.Nil(t, s.ResolvedAt)
+		}
+	})
+
+	t.Run("should restart keep_firing_for when the condition fires again", func(t *testing.T) {
+		clk.Add(interval)
+		s = process(eval.Alerting)
+		require.Equal(t, eval.Alerting, s.State)
+		require.Nil(t, s.KeepFiringSince)
+
+		for i := 0; i < 4; i++ {
+			clk.Add(interval)
+			s = process(eval.Normal)
+			require.Equal(t, eval.Alerting, s.State)
+		}
+	})
+
+	t.Run("should resolve once the condition is resolved for keep_firing_for", func(t *testing.T) {
+		clk.Add(interval)
+		s = process(eval.Normal)
+		require.Equal(t, eval.Alerting, s.State)
+
+		clk.Add(interval)
+		s = process(eval.Normal)
+		require.Equal(t, eval.Normal, s.State)
+		require.Nil(t, s.KeepFiringSince)
+		require.NotNil(t, s.ResolvedAt)
+		require.Equal(t, clk.Now(), *s.ResolvedAt)
+	})
+}
+
 func TestDeleteStateByRuleUID(t *testing.T) {
 	interval := time.Minute
 	ctx := context.Background()
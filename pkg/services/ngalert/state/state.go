@@ -68,7 +68,11 @@ type State struct {
 	// ResolvedAt is set when the state is first resolved. That is to say, when the state first transitions
 	// from Alerting, NoData, or Error to Normal. It is reset to zero when the state transitions from Normal
 	// to any other state.
-	ResolvedAt           *time.Time
+	ResolvedAt *time.Time
+	// KeepFiringSince is set when the condition of an Alerting state is first no longer met, and the state
+	// keeps firing for the keep_firing_for duration of the rule. It is reset once the condition is met again
+	// or the state is resolved. It is not persisted, so the duration starts over after a restart.
+	KeepFiringSince      *time.Time
 	LastSentAt           *time.Time
 	LastEvaluationString string
 	LastEvaluationTime   time.Time
@@ -263,7 +267,28 @@ func NewEvaluationValues(m map[string]eval.NumberValueCapture) map[string]float6
 	return result
 }
 
-func resultNormal(state *State, _ *models.AlertRule, result eval.Result, logger log.Logger, reason string) {
+func resultNormal(state *State, rule *models.AlertRule, result eval.Result, logger log.Logger, reason string) {
+	if state.State == eval.Alerting && rule.KeepFiringFor > 0 {
+		if state.KeepFiringSince == nil {
+			state.KeepFiringSince = &result.EvaluatedAt
+		}
+		if result.EvaluatedAt.Sub(*state.KeepFiringSince) < rule.KeepFiringFor {
+			prevEndsAt := state.EndsAt
+			state.Maintain(rule.IntervalSeconds, result.EvaluatedAt)
+			logger.Debug("Keeping state",
+				"state",
+				state.State,
+				"keep_firing_since",
+				state.KeepFiringSince,
+				"previous_ends_at",
+				prevEndsAt,
+				"next_ends_at",
+				state.EndsAt)
+			return
+		}
+	}
+	state.KeepFiringSince = nil
+
 	if state.State == eval.Normal {
 		logger.Debug("Keeping state", "state", state.State)
 	} else {
@@ -283,6 +308,8 @@ func resultNormal(state *State, _ *models.AlertRule, result eval.Result, logger
 }
 
 func resultAlerting(state *State, rule *models.AlertRule, result eval.Result, logger log.Logger, reason string) {
+	state.KeepFiringSince = nil
+
 	switch state.State {
 	case eval.Alerting:
 		prevEndsAt := state.EndsAt
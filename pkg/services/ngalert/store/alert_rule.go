@@ -572,22 +572,6 @@ func (st DBstore) Count(ctx context.Context, orgID int64) (int64, error) {
 	return r.Count, err
 }
 
-func (st DBstore) GetRuleGroupInterval(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string) (int64, error) {
-	var interval int64 = 0
-	return interval, st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-		ruleGroups := make([]alertRule, 0)
-		err := sess.Find(
-			&ruleGroups,
-			alertRule{OrgID: orgID, RuleGroup: ruleGroup, NamespaceUID: namespaceUID},
-		)
-		if len(ruleGroups) == 0 {
-			return ngmodels.ErrAlertRuleGroupNotFound.Errorf("")
-		}
-		interval = ruleGroups[0].IntervalSeconds
-		return err
-	})
-}
-
 // GetUserVisibleNamespaces returns the folders that are visible to the user
 func (st DBstore) GetUserVisibleNamespaces(ctx context.Context, orgID int64, user identity.Requester) (map[string]*folder.Folder, error) {
 	folders, err := st.FolderService.GetFolders(ctx, folder.GetFoldersQuery{
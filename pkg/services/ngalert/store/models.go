@@ -22,6 +22,8 @@ type alertRule struct {
 	NoDataState          string
 	ExecErrState         string
 	For                  time.Duration
+	KeepFiringFor        time.Duration `xorm:"keep_firing_for"`
+	QueryOffset          time.Duration `xorm:"query_offset"`
 	Annotations          string
 	Labels               string
 	IsPaused             bool
@@ -56,6 +58,8 @@ type alertRuleVersion struct {
 	// ideally this field should have been apimodels.ApiDuration
 	// but this is currently not possible because of circular dependencies
 	For                  time.Duration
+	KeepFiringFor        time.Duration `xorm:"keep_firing_for"`
+	QueryOffset          time.Duration `xorm:"query_offset"`
 	Annotations          string
 	Labels               string
 	IsPaused             bool
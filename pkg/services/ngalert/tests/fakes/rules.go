@@ -289,21 +289,6 @@ func (f *RuleStore) InTransaction(ctx context.Context, fn func(c context.Context
 	return fn(ctx)
 }
 
-func (f *RuleStore) GetRuleGroupInterval(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string) (int64, error) {
-	f.mtx.Lock()
-	defer f.mtx.Unlock()
-	f.RecordedOps = append(f.RecordedOps, GenericRecordedQuery{
-		Name:   "GetRuleGroupInterval",
-		Params: []any{orgID, namespaceUID, ruleGroup},
-	})
-	for _, rule := range f.Rules[orgID] {
-		if rule.RuleGroup == ruleGroup && rule.NamespaceUID == namespaceUID {
-			return rule.IntervalSeconds, nil
-		}
-	}
-	return 0, models.ErrAlertRuleGroupNotFound.Errorf("")
-}
-
 func (f *RuleStore) UpdateRuleGroup(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string, interval int64) error {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
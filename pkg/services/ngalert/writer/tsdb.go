@@ -0,0 +1,324 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+
+	"github.com/grafana/grafana/pkg/components/satokengen"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const tsdbBackendType = "tsdb"
+
+const (
+	// OrgLabel is added to every series written to the local TSDB to keep the series of organizations apart.
+	// It is never returned by the query API.
+	OrgLabel = "__grafana_org_id__"
+
+	// TSDBDataSourceUID is the UID of the data source that queries the local TSDB in each organization.
+	TSDBDataSourceUID  = "grafana-recorded-metrics"
+	TSDBDataSourceName = "Grafana recorded metrics"
+
+	// TSDBAPIPath is the path of the query API of the local TSDB, relative to the Grafana URL.
+	TSDBAPIPath = "api/ngalert/recorded-metrics"
+)
+
+const (
+	tsdbQueryTimeout    = 2 * time.Minute
+	tsdbQueryMaxSamples = 50000000
+	tsdbLookbackDelta   = 5 * time.Minute
+)
+
+// DataSourceService is the part of datasources.DataSourceService used to add the data source of the local TSDB.
+type DataSourceService interface {
+	GetDataSource(ctx context.Context, query *datasources.GetDataSourceQuery) (*datasources.DataSource, error)
+	AddDataSource(ctx context.Context, cmd *datasources.AddDataSourceCommand) (*datasources.DataSource, error)
+}
+
+// ServiceAccountService is the part of serviceaccounts.Service used to authenticate the queries of the data source
+// of the local TSDB.
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, orgID int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error)
+	RetrieveServiceAccountIdByName(ctx context.Context, orgID int64, name string) (int64, error)
+	AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error)
+}
+
+// TSDBWriter writes recorded series to a TSDB embedded in Grafana. The series are queried through a
+// Prometheus compatible API served by Handler on the Grafana HTTP server, which is added as a Prometheus
+// data source to each organization the first time a series is written for it. The data source
+// authenticates with the token of a viewer service account of the organization.
+type TSDBWriter struct {
+	db              *tsdb.DB
+	engine          *promql.Engine
+	grafanaURL      string
+	dataSources     DataSourceService
+	serviceAccounts ServiceAccountService
+	clock           clock.Clock
+	logger          log.Logger
+	metrics         *metrics.RemoteWriter
+
+	// closed is set once the TSDB is closed, writes after that fail.
+	mtx    sync.RWMutex
+	closed bool
+
+	// The organizations that are known to have the data source.
+	dataSourceOrgs sync.Map
+}
+
+func NewTSDBWriter(
+	settings setting.RecordingRuleSettings,
+	dataSources DataSourceService,
+	serviceAccounts ServiceAccountService,
+	clock clock.Clock,
+	l log.Logger,
+	metrics *metrics.RemoteWriter,
+) (*TSDBWriter, error) {
+	if err := validateTSDBSettings(settings); err != nil {
+		return nil, err
+	}
+
+	opts := tsdb.DefaultOptions()
+	opts.RetentionDuration = settings.TSDBRetention.Milliseconds()
+	db, err := tsdb.Open(settings.TSDBPath, l, nil, opts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the recording rules TSDB at %s: %w", settings.TSDBPath, err)
+	}
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:               l,
+		MaxSamples:           tsdbQueryMaxSamples,
+		Timeout:              tsdbQueryTimeout,
+		LookbackDelta:        tsdbLookbackDelta,
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+
+	return &TSDBWriter{
+		db:              db,
+		engine:          engine,
+		grafanaURL:      settings.TSDBGrafanaURL,
+		dataSources:     dataSources,
+		serviceAccounts: serviceAccounts,
+		clock:           clock,
+		logger:          l,
+		metrics:         metrics,
+	}, nil
+}
+
+func validateTSDBSettings(settings setting.RecordingRuleSettings) error {
+	if settings.TSDBPath == "" {
+		return fmt.Errorf("TSDB path is required")
+	}
+
+	if settings.TSDBRetention <= 0 {
+		return fmt.Errorf("TSDB retention must be greater than 0")
+	}
+
+	u, err := url.Parse(settings.TSDBGrafanaURL)
+	if err != nil {
+		return fmt.Errorf("invalid TSDB Grafana URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid TSDB Grafana URL %q: must be an absolute http or https URL", settings.TSDBGrafanaURL)
+	}
+
+	return nil
+}
+
+// Write appends the given frames to the local TSDB.
+func (w *TSDBWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	l := w.logger.FromContext(ctx)
+	lvs := []string{fmt.Sprint(orgID), tsdbBackendType}
+
+	points, err := PointsFromFrames(name, t, frames, extraLabels)
+	if err != nil {
+		return errors.Join(ErrBadFrame, err)
+	}
+
+	if err := w.ensureDataSource(ctx, orgID); err != nil {
+		l.Warn("Failed to add the data source of the recorded series", "error", err)
+	}
+
+	l.Debug("Writing metric", "name", name)
+	writeStart := w.clock.Now()
+	writeErr := w.append(ctx, points, orgID)
+	w.metrics.WriteDuration.WithLabelValues(lvs...).Observe(w.clock.Now().Sub(writeStart).Seconds())
+
+	// Report the status codes remote write would have returned, so that both backends can be monitored alike.
+	status := http.StatusOK
+	switch {
+	case errors.Is(writeErr, ErrRejectedWrite):
+		status = http.StatusBadRequest
+	case writeErr != nil:
+		status = http.StatusInternalServerError
+	}
+	lvs = append(lvs, fmt.Sprint(status))
+	w.metrics.WritesTotal.WithLabelValues(lvs...).Inc()
+
+	return writeErr
+}
+
+func (w *TSDBWriter) append(ctx context.Context, points []Point, orgID int64) error {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+	if w.closed {
+		return errors.Join(ErrUnexpectedWriteFailure, errors.New("TSDB is closed"))
+	}
+
+	var rejected error
+	app := w.db.Appender(ctx)
+	for _, p := range points {
+		_, err := app.Append(0, tsdbLabelsFromPoint(p, orgID), p.Metric.T.UnixMilli(), p.Metric.V)
+		switch {
+		case err == nil:
+		// HA may write the same sample for the same timestamp, so we ignore this error like remote write does.
+		case errors.Is(err, storage.ErrDuplicateSampleForTimestamp):
+			w.logger.FromContext(ctx).Debug("Ignored write error", "error", err)
+		case errors.Is(err, storage.ErrOutOfOrderSample),
+			errors.Is(err, storage.ErrOutOfBounds),
+			errors.Is(err, storage.ErrTooOldSample),
+			errors.Is(err, tsdb.ErrInvalidSample):
+			rejected = errors.Join(rejected, err)
+		default:
+			_ = app.Rollback()
+			return errors.Join(ErrUnexpectedWriteFailure, err)
+		}
+	}
+
+	if err := app.Commit(); err != nil {
+		return errors.Join(ErrUnexpectedWriteFailure, err)
+	}
+
+	if rejected != nil {
+		return errors.Join(ErrRejectedWrite, rejected)
+	}
+
+	return nil
+}
+
+func tsdbLabelsFromPoint(point Point, orgID int64) labels.Labels {
+	b := labels.NewScratchBuilder(len(point.Labels) + 2)
+	b.Add(labels.MetricName, point.Name)
+	b.Add(OrgLabel, fmt.Sprint(orgID))
+	for k, v := range point.Labels {
+		b.Add(k, v)
+	}
+	b.Sort()
+	return b.Labels()
+}
+
+// ensureDataSource adds the data source of the local TSDB to the organization if it does not have it yet.
+func (w *TSDBWriter) ensureDataSource(ctx context.Context, orgID int64) error {
+	if _, ok := w.dataSourceOrgs.Load(orgID); ok {
+		return nil
+	}
+
+	_, err := w.dataSources.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: TSDBDataSourceUID, OrgID: orgID})
+	if errors.Is(err, datasources.ErrDataSourceNotFound) {
+		w.logger.Info("Adding the data source of the recorded series", "org", orgID, "uid", TSDBDataSourceUID)
+		var token string
+		token, err = w.createToken(ctx, orgID)
+		if err != nil {
+			return fmt.Errorf("failed to create the token of the data source: %w", err)
+		}
+		_, err = w.dataSources.AddDataSource(ctx, &datasources.AddDataSourceCommand{
+			OrgID:  orgID,
+			UID:    TSDBDataSourceUID,
+			Name:   TSDBDataSourceName,
+			Type:   datasources.DS_PROMETHEUS,
+			Access: datasources.DS_ACCESS_PROXY,
+			URL:    w.dataSourceURL(),
+			JsonData: simplejson.NewFromAny(map[string]any{
+				"httpMethod":      http.MethodPost,
+				"httpHeaderName1": "Authorization",
+			}),
+			SecureJsonData: map[string]string{"httpHeaderValue1": "Bearer " + token},
+			ReadOnly:       true,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	w.dataSourceOrgs.Store(orgID, struct{}{})
+	return nil
+}
+
+// createToken returns a new token of the service account the data source of the organization queries the local
+// TSDB with. The service account is created if it does not exist.
+func (w *TSDBWriter) createToken(ctx context.Context, orgID int64) (string, error) {
+	role := org.RoleViewer
+	var saID int64
+	sa, err := w.serviceAccounts.CreateServiceAccount(ctx, orgID, &serviceaccounts.CreateServiceAccountForm{
+		Name: TSDBDataSourceUID,
+		Role: &role,
+	})
+	switch {
+	case err == nil:
+		saID = sa.Id
+	case errors.Is(err, serviceaccounts.ErrServiceAccountAlreadyExists):
+		// The data source was deleted, but not its service account.
+		if saID, err = w.serviceAccounts.RetrieveServiceAccountIdByName(ctx, orgID, TSDBDataSourceUID); err != nil {
+			return "", err
+		}
+	default:
+		return "", err
+	}
+
+	// the prefix of the tokens created in the service accounts UI
+	key, err := satokengen.New("sa")
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.serviceAccounts.AddServiceAccountToken(ctx, saID, &serviceaccounts.AddServiceAccountTokenCommand{
+		Name:  fmt.Sprintf("%s-%d", TSDBDataSourceUID, w.clock.Now().Unix()),
+		OrgId: orgID,
+		Key:   key.HashedKey,
+	}); err != nil {
+		return "", err
+	}
+	return key.ClientSecret, nil
+}
+
+func (w *TSDBWriter) dataSourceURL() string {
+	return strings.TrimSuffix(w.grafanaURL, "/") + "/" + TSDBAPIPath
+}
+
+// Run closes the TSDB once the context is done.
+func (w *TSDBWriter) Run(ctx context.Context) error {
+	<-ctx.Done()
+	w.close()
+	return nil
+}
+
+func (w *TSDBWriter) close() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if err := w.db.Close(); err != nil {
+		w.logger.Error("Failed to close the recording rules TSDB", "error", err)
+	}
+}
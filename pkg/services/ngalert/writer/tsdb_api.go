@@ -0,0 +1,456 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+)
+
+// tsdbAPIVersion is reported by the build info endpoint so that the Prometheus data source
+// enables the features of the Prometheus version the TSDB comes from.
+const tsdbAPIVersion = "2.52.0"
+
+type apiError struct {
+	typ string
+	err error
+}
+
+const (
+	errorBadData   = "bad_data"
+	errorExec      = "execution"
+	errorTimeout   = "timeout"
+	errorCanceled  = "canceled"
+	errorInternal  = "internal"
+	statusSuccess  = "success"
+	statusError    = "error"
+	minTimeDefault = math.MinInt64 / 2
+	maxTimeDefault = math.MaxInt64 / 2
+)
+
+// Prometheus does not return these errors, it has no authentication and does not close its TSDB
+// while serving queries.
+const (
+	errorUnauthorized = "unauthorized"
+	errorUnavailable  = "unavailable"
+)
+
+type apiResponse struct {
+	Status    string   `json:"status"`
+	Data      any      `json:"data,omitempty"`
+	ErrorType string   `json:"errorType,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+type queryData struct {
+	ResultType parser.ValueType `json:"resultType"`
+	Result     parser.Value     `json:"result"`
+}
+
+type apiFunc func(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError)
+
+// Handler returns the Prometheus compatible query API of the local TSDB, e.g. /api/v1/query. It must be
+// served behind the Grafana authentication, and only returns the series of the organization of the signed
+// in user of the request.
+func (w *TSDBWriter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for path, f := range map[string]apiFunc{
+		"/api/v1/query":               w.query,
+		"/api/v1/query_range":         w.queryRange,
+		"/api/v1/labels":              labelNames,
+		"/api/v1/label/{name}/values": labelValues,
+		"/api/v1/series":              series,
+		"/api/v1/metadata":            metadata,
+		"/api/v1/status/buildinfo":    buildInfo,
+	} {
+		mux.HandleFunc(path, w.serve(f))
+	}
+	return mux
+}
+
+func (w *TSDBWriter) serve(f apiFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, err := identity.GetRequester(r.Context())
+		if err != nil {
+			writeAPIResponse(rw, nil, nil, &apiError{errorUnauthorized, err})
+			return
+		}
+
+		// The TSDB must not be closed while it is queried.
+		w.mtx.RLock()
+		defer w.mtx.RUnlock()
+		if w.closed {
+			writeAPIResponse(rw, nil, nil, &apiError{errorUnavailable, errors.New("TSDB is closed")})
+			return
+		}
+
+		data, warnings, apiErr := f(r, orgQueryable{Queryable: w.db, orgID: user.GetOrgID()})
+		writeAPIResponse(rw, data, warnings, apiErr)
+	}
+}
+
+func writeAPIResponse(rw http.ResponseWriter, data any, warnings annotations.Annotations, apiErr *apiError) {
+	res := apiResponse{Status: statusSuccess, Data: data}
+	for _, w := range warnings.AsErrors() {
+		res.Warnings = append(res.Warnings, w.Error())
+	}
+
+	status := http.StatusOK
+	if apiErr != nil {
+		res.Status, res.Data, res.ErrorType, res.Error = statusError, nil, apiErr.typ, apiErr.err.Error()
+		switch apiErr.typ {
+		case errorBadData:
+			status = http.StatusBadRequest
+		case errorExec:
+			status = http.StatusUnprocessableEntity
+		case errorCanceled:
+			status = 499
+		case errorTimeout, errorUnavailable:
+			status = http.StatusServiceUnavailable
+		case errorUnauthorized:
+			status = http.StatusUnauthorized
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(res)
+}
+
+func (w *TSDBWriter) query(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError) {
+	ts, err := parseTimeParam(r, "time", time.Now())
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	ctx, cancel, err := queryContext(r)
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	defer cancel()
+
+	qry, err := w.engine.NewInstantQuery(ctx, q, nil, r.FormValue("query"), ts)
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	return execQuery(ctx, qry)
+}
+
+func (w *TSDBWriter) queryRange(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError) {
+	start, err := parseTime(r.FormValue("start"))
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, fmt.Errorf("invalid parameter \"start\": %w", err)}
+	}
+	end, err := parseTime(r.FormValue("end"))
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, fmt.Errorf("invalid parameter \"end\": %w", err)}
+	}
+	if end.Before(start) {
+		return nil, nil, &apiError{errorBadData, errors.New("end timestamp must not be before start time")}
+	}
+	step, err := parseDuration(r.FormValue("step"))
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, fmt.Errorf("invalid parameter \"step\": %w", err)}
+	}
+	if step <= 0 {
+		return nil, nil, &apiError{errorBadData, errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer")}
+	}
+	// Prometheus limits the number of points per series in the same way.
+	if end.Sub(start)/step > 11000 {
+		return nil, nil, &apiError{errorBadData, errors.New("exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)")}
+	}
+	ctx, cancel, err := queryContext(r)
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	defer cancel()
+
+	qry, err := w.engine.NewRangeQuery(ctx, q, nil, r.FormValue("query"), start, end, step)
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	return execQuery(ctx, qry)
+}
+
+func execQuery(ctx context.Context, qry promql.Query) (any, annotations.Annotations, *apiError) {
+	defer qry.Close()
+	res := qry.Exec(ctx)
+	if res.Err != nil {
+		return nil, res.Warnings, queryError(res.Err)
+	}
+	return queryData{ResultType: res.Value.Type(), Result: res.Value}, res.Warnings, nil
+}
+
+func queryError(err error) *apiError {
+	var (
+		canceled promql.ErrQueryCanceled
+		timeout  promql.ErrQueryTimeout
+		storage  promql.ErrStorage
+	)
+	switch {
+	case errors.As(err, &canceled), errors.Is(err, context.Canceled):
+		return &apiError{errorCanceled, err}
+	case errors.As(err, &timeout), errors.Is(err, context.DeadlineExceeded):
+		return &apiError{errorTimeout, err}
+	case errors.As(err, &storage):
+		return &apiError{errorInternal, err}
+	}
+	return &apiError{errorExec, err}
+}
+
+func labelNames(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError) {
+	querier, matcherSets, apiErr := selectParams(r, q)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	defer querier.Close()
+
+	names, warnings, err := collectLabels(matcherSets, func(matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+		return querier.LabelNames(r.Context(), matchers...)
+	})
+	if err != nil {
+		return nil, warnings, &apiError{errorExec, err}
+	}
+	return names, warnings, nil
+}
+
+func labelValues(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError) {
+	name := r.PathValue("name")
+	if !model.LabelNameRE.MatchString(name) {
+		return nil, nil, &apiError{errorBadData, fmt.Errorf("invalid label name: %q", name)}
+	}
+	querier, matcherSets, apiErr := selectParams(r, q)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	defer querier.Close()
+
+	values, warnings, err := collectLabels(matcherSets, func(matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+		return querier.LabelValues(r.Context(), name, matchers...)
+	})
+	if err != nil {
+		return nil, warnings, &apiError{errorExec, err}
+	}
+	return values, warnings, nil
+}
+
+// collectLabels returns the sorted union of the labels returned for each of the matcher sets,
+// or the labels of all the series if there are no matcher sets.
+func collectLabels(matcherSets [][]*labels.Matcher, f func(matchers ...*labels.Matcher) ([]string, annotations.Annotations, error)) ([]string, annotations.Annotations, error) {
+	if len(matcherSets) == 0 {
+		matcherSets = [][]*labels.Matcher{nil}
+	}
+	var warnings annotations.Annotations
+	var result []string
+	for _, matchers := range matcherSets {
+		values, w, err := f(matchers...)
+		warnings.Merge(w)
+		if err != nil {
+			return nil, warnings, err
+		}
+		result = append(result, values...)
+	}
+	slices.Sort(result)
+	result = slices.Compact(result)
+	if result == nil {
+		result = []string{}
+	}
+	return result, warnings, nil
+}
+
+func series(r *http.Request, q storage.Queryable) (any, annotations.Annotations, *apiError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	if len(r.Form["match[]"]) == 0 {
+		return nil, nil, &apiError{errorBadData, errors.New("no match[] parameter provided")}
+	}
+	querier, matcherSets, apiErr := selectParams(r, q)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	defer querier.Close()
+
+	sets := make([]storage.SeriesSet, 0, len(matcherSets))
+	for _, matchers := range matcherSets {
+		sets = append(sets, querier.Select(r.Context(), len(matcherSets) > 1, nil, matchers...))
+	}
+	set := storage.NewMergeSeriesSet(sets, storage.ChainedSeriesMerge)
+
+	result := []labels.Labels{}
+	for set.Next() {
+		result = append(result, set.At().Labels())
+	}
+	if err := set.Err(); err != nil {
+		return nil, set.Warnings(), &apiError{errorExec, err}
+	}
+	return result, set.Warnings(), nil
+}
+
+// selectParams parses the time range and the matcher sets of the labels and series endpoints
+// and returns a querier for the time range.
+func selectParams(r *http.Request, q storage.Queryable) (storage.Querier, [][]*labels.Matcher, *apiError) {
+	start, err := parseTimeParam(r, "start", time.UnixMilli(minTimeDefault))
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	end, err := parseTimeParam(r, "end", time.UnixMilli(maxTimeDefault))
+	if err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &apiError{errorBadData, err}
+	}
+
+	var matcherSets [][]*labels.Matcher
+	for _, s := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return nil, nil, &apiError{errorBadData, err}
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	querier, err := q.Querier(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, nil, &apiError{errorExec, err}
+	}
+	return querier, matcherSets, nil
+}
+
+// The local TSDB only has recorded series, which do not have metadata.
+func metadata(*http.Request, storage.Queryable) (any, annotations.Annotations, *apiError) {
+	return map[string]any{}, nil, nil
+}
+
+func buildInfo(*http.Request, storage.Queryable) (any, annotations.Annotations, *apiError) {
+	return map[string]string{"version": tsdbAPIVersion}, nil, nil
+}
+
+func queryContext(r *http.Request) (context.Context, context.CancelFunc, error) {
+	ctx := r.Context()
+	if to := r.FormValue("timeout"); to != "" {
+		timeout, err := parseDuration(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid parameter \"timeout\": %w", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, nil
+}
+
+func parseTimeParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	val := r.FormValue(name)
+	if val == "" {
+		return defaultValue, nil
+	}
+	t, err := parseTime(val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid parameter %q: %w", name, err)
+	}
+	return t, nil
+}
+
+// parseTime parses a Unix timestamp in seconds with an optional fraction or an RFC3339 time.
+func parseTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(t)
+		return time.Unix(int64(sec), int64(math.Round(frac*1000))*int64(time.Millisecond)).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parseDuration parses a number of seconds with an optional fraction or a Prometheus duration.
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration. It overflows int64", s)
+		}
+		return time.Duration(ts), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
+
+// orgQueryable only returns the series of an organization, without the organization label.
+type orgQueryable struct {
+	storage.Queryable
+	orgID int64
+}
+
+func (q orgQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &orgQuerier{
+		Querier: querier,
+		matcher: labels.MustNewMatcher(labels.MatchEqual, OrgLabel, strconv.FormatInt(q.orgID, 10)),
+	}, nil
+}
+
+type orgQuerier struct {
+	storage.Querier
+	matcher *labels.Matcher
+}
+
+func (q *orgQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	// The organization label has the same value in every series, so removing it keeps them sorted.
+	return orgSeriesSet{q.Querier.Select(ctx, sortSeries, hints, q.withOrg(matchers)...)}
+}
+
+func (q *orgQuerier) LabelValues(ctx context.Context, name string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	if name == OrgLabel {
+		return nil, nil, nil
+	}
+	return q.Querier.LabelValues(ctx, name, q.withOrg(matchers)...)
+}
+
+func (q *orgQuerier) LabelNames(ctx context.Context, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	names, warnings, err := q.Querier.LabelNames(ctx, q.withOrg(matchers)...)
+	return slices.DeleteFunc(names, func(name string) bool { return name == OrgLabel }), warnings, err
+}
+
+func (q *orgQuerier) withOrg(matchers []*labels.Matcher) []*labels.Matcher {
+	return append(slices.Clip(matchers), q.matcher)
+}
+
+type orgSeriesSet struct {
+	storage.SeriesSet
+}
+
+func (s orgSeriesSet) At() storage.Series {
+	return orgSeries{s.SeriesSet.At()}
+}
+
+type orgSeries struct {
+	storage.Series
+}
+
+func (s orgSeries) Labels() labels.Labels {
+	return labels.NewBuilder(s.Series.Labels()).Del(OrgLabel).Labels()
+}
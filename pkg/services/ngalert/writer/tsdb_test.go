@@ -0,0 +1,215 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/satokengen"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestValidateTSDBSettings(t *testing.T) {
+	valid := setting.RecordingRuleSettings{
+		TSDBPath:       "/var/lib/grafana/recording_rules",
+		TSDBRetention:  time.Hour,
+		TSDBGrafanaURL: "http://localhost:3000/",
+	}
+	require.NoError(t, validateTSDBSettings(valid))
+
+	for name, f := range map[string]func(s *setting.RecordingRuleSettings){
+		"missing path":         func(s *setting.RecordingRuleSettings) { s.TSDBPath = "" },
+		"retention is 0":       func(s *setting.RecordingRuleSettings) { s.TSDBRetention = 0 },
+		"relative Grafana URL": func(s *setting.RecordingRuleSettings) { s.TSDBGrafanaURL = "/grafana" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings := valid
+			f(&settings)
+			require.Error(t, validateTSDBSettings(settings))
+		})
+	}
+}
+
+type fakeDataSources struct {
+	added []*datasources.AddDataSourceCommand
+}
+
+func (f *fakeDataSources) GetDataSource(_ context.Context, query *datasources.GetDataSourceQuery) (*datasources.DataSource, error) {
+	for _, cmd := range f.added {
+		if cmd.OrgID == query.OrgID && cmd.UID == query.UID {
+			return &datasources.DataSource{OrgID: cmd.OrgID, UID: cmd.UID}, nil
+		}
+	}
+	return nil, datasources.ErrDataSourceNotFound
+}
+
+func (f *fakeDataSources) AddDataSource(_ context.Context, cmd *datasources.AddDataSourceCommand) (*datasources.DataSource, error) {
+	f.added = append(f.added, cmd)
+	return &datasources.DataSource{OrgID: cmd.OrgID, UID: cmd.UID}, nil
+}
+
+type fakeServiceAccounts struct {
+	accounts map[int64]int64
+	tokens   []*serviceaccounts.AddServiceAccountTokenCommand
+}
+
+func (f *fakeServiceAccounts) CreateServiceAccount(_ context.Context, orgID int64, form *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
+	if _, ok := f.accounts[orgID]; ok {
+		return nil, serviceaccounts.ErrServiceAccountAlreadyExists.Errorf("exists")
+	}
+	f.accounts[orgID] = orgID * 10
+	return &serviceaccounts.ServiceAccountDTO{Id: orgID * 10, Name: form.Name, Role: string(*form.Role)}, nil
+}
+
+func (f *fakeServiceAccounts) RetrieveServiceAccountIdByName(_ context.Context, orgID int64, _ string) (int64, error) {
+	return f.accounts[orgID], nil
+}
+
+func (f *fakeServiceAccounts) AddServiceAccountToken(_ context.Context, saID int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	f.tokens = append(f.tokens, cmd)
+	return &apikey.APIKey{OrgID: cmd.OrgId, Name: cmd.Name, Key: cmd.Key, ServiceAccountId: &saID}, nil
+}
+
+func TestTSDBWriter_Write(t *testing.T) {
+	dataSources := &fakeDataSources{}
+	serviceAccounts := &fakeServiceAccounts{accounts: map[int64]int64{}}
+	writer, err := NewTSDBWriter(setting.RecordingRuleSettings{
+		TSDBPath:       t.TempDir(),
+		TSDBRetention:  time.Hour,
+		TSDBGrafanaURL: "http://localhost:3000/grafana/",
+	}, dataSources, serviceAccounts, clock.New(), log.New("test"), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(writer.close)
+
+	// The organization of the request is the organization of the signed in user.
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if org := r.Header.Get("X-Grafana-Org-Id"); org != "" {
+			orgID, err := strconv.ParseInt(org, 10, 64)
+			require.NoError(t, err)
+			r = r.WithContext(identity.WithRequester(r.Context(), &user.SignedInUser{OrgID: orgID}))
+		}
+		writer.Handler().ServeHTTP(rw, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := ngmodels.WithRuleKey(context.Background(), ngmodels.GenerateRuleKey(1))
+	now := time.Now().Truncate(time.Second)
+	series := []map[string]string{{"foo": "1"}, {"foo": "2"}}
+	frames := frameGenFromLabels(t, data.FrameTypeNumericWide, series)
+	extraLabels := map[string]string{"extra": "label"}
+
+	require.NoError(t, writer.Write(ctx, "test", now, frames, 1, extraLabels))
+	require.NoError(t, writer.Write(ctx, "test", now, frameGenFromLabels(t, data.FrameTypeNumericWide, series[:1]), 2, nil))
+
+	query := func(t *testing.T, orgID int64, path string, params url.Values) apiResponse {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(params.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Grafana-Org-Id", strconv.FormatInt(orgID, 10))
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		var body apiResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		require.Equal(t, statusSuccess, body.Status, body.Error)
+		return body
+	}
+
+	t.Run("adds the data source once per organization", func(t *testing.T) {
+		require.NoError(t, writer.Write(ctx, "test", now.Add(time.Second), frames, 1, extraLabels))
+		require.Len(t, dataSources.added, 2)
+		require.Len(t, serviceAccounts.tokens, 2)
+		for i, cmd := range dataSources.added {
+			orgID := int64(i + 1)
+			require.Equal(t, orgID, cmd.OrgID)
+			require.Equal(t, TSDBDataSourceUID, cmd.UID)
+			require.Equal(t, datasources.DS_PROMETHEUS, cmd.Type)
+			require.Equal(t, "http://localhost:3000/grafana/api/ngalert/recorded-metrics", cmd.URL)
+			require.True(t, cmd.ReadOnly)
+
+			// the data source authenticates with a token of the service account of the organization
+			token := serviceAccounts.tokens[i]
+			require.Equal(t, orgID, token.OrgId)
+			require.Equal(t, "Authorization", cmd.JsonData.Get("httpHeaderName1").MustString())
+			decoded, err := satokengen.Decode(strings.TrimPrefix(cmd.SecureJsonData["httpHeaderValue1"], "Bearer "))
+			require.NoError(t, err)
+			hashed, err := decoded.Hash()
+			require.NoError(t, err)
+			require.Equal(t, token.Key, hashed)
+		}
+	})
+
+	t.Run("rejects queries without signed in user", func(t *testing.T) {
+		res, err := http.PostForm(srv.URL+"/api/v1/query", url.Values{"query": {"test"}})
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("queries only the series of the organization", func(t *testing.T) {
+		res := query(t, 1, "/api/v1/query", url.Values{
+			"query": {"test"},
+			"time":  {strconv.FormatInt(now.Unix(), 10)},
+		})
+		result := res.Data.(map[string]any)
+		require.Equal(t, "vector", result["resultType"])
+		require.ElementsMatch(t, []any{
+			map[string]any{"__name__": "test", "extra": "label", "foo": "1"},
+			map[string]any{"__name__": "test", "extra": "label", "foo": "2"},
+		}, []any{
+			result["result"].([]any)[0].(map[string]any)["metric"],
+			result["result"].([]any)[1].(map[string]any)["metric"],
+		})
+
+		res = query(t, 2, "/api/v1/query", url.Values{
+			"query": {`count(test) or count({` + OrgLabel + `="1"}) or vector(0)`},
+			"time":  {strconv.FormatInt(now.Unix(), 10)},
+		})
+		result = res.Data.(map[string]any)
+		require.Equal(t, "1", result["result"].([]any)[0].(map[string]any)["value"].([]any)[1])
+	})
+
+	t.Run("lists labels without the organization label", func(t *testing.T) {
+		res := query(t, 1, "/api/v1/labels", nil)
+		require.Equal(t, []any{"__name__", "extra", "foo"}, res.Data)
+
+		res = query(t, 2, "/api/v1/label/foo/values", nil)
+		require.Equal(t, []any{"1"}, res.Data)
+
+		res = query(t, 2, "/api/v1/label/"+OrgLabel+"/values", nil)
+		require.Equal(t, []any{}, res.Data)
+
+		res = query(t, 1, "/api/v1/series", url.Values{"match[]": {`test{foo="2"}`}})
+		require.Equal(t, []any{map[string]any{"__name__": "test", "extra": "label", "foo": "2"}}, res.Data)
+	})
+
+	t.Run("rejects out of order samples", func(t *testing.T) {
+		err := writer.Write(ctx, "test", now.Add(-time.Minute), frames, 1, extraLabels)
+		require.ErrorIs(t, err, ErrRejectedWrite)
+	})
+
+	t.Run("ignores duplicate samples", func(t *testing.T) {
+		frames := frameGenFromLabels(t, data.FrameTypeNumericWide, series)
+		require.NoError(t, writer.Write(ctx, "test", now.Add(time.Second), frames, 1, extraLabels))
+	})
+}
@@ -25,11 +25,12 @@ type RuleDeleteV1 struct {
 }
 
 type AlertRuleGroupV1 struct {
-	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
-	Name     values.StringValue `json:"name" yaml:"name"`
-	Folder   values.StringValue `json:"folder" yaml:"folder"`
-	Interval values.StringValue `json:"interval" yaml:"interval"`
-	Rules    []AlertRuleV1      `json:"rules" yaml:"rules"`
+	OrgID       values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name        values.StringValue `json:"name" yaml:"name"`
+	Folder      values.StringValue `json:"folder" yaml:"folder"`
+	Interval    values.StringValue `json:"interval" yaml:"interval"`
+	QueryOffset values.StringValue `json:"queryOffset" yaml:"queryOffset"`
+	Rules       []AlertRuleV1      `json:"rules" yaml:"rules"`
 }
 
 func (ruleGroupV1 *AlertRuleGroupV1) MapToModel() (models.AlertRuleGroupWithFolderFullpath, error) {
@@ -47,6 +48,13 @@ func (ruleGroupV1 *AlertRuleGroupV1) MapToModel() (models.AlertRuleGroupWithFold
 		return models.AlertRuleGroupWithFolderFullpath{}, err
 	}
 	ruleGroup.Interval = int64(time.Duration(interval).Seconds())
+	if ruleGroupV1.QueryOffset.Value() != "" {
+		queryOffset, err := model.ParseDuration(ruleGroupV1.QueryOffset.Value())
+		if err != nil {
+			return models.AlertRuleGroupWithFolderFullpath{}, fmt.Errorf("rule group '%s' failed to parse 'queryOffset' field: %w", ruleGroup.Title, err)
+		}
+		ruleGroup.QueryOffset = time.Duration(queryOffset)
+	}
 	ruleGroup.FolderFullpath = ruleGroupV1.Folder.Value()
 	if strings.TrimSpace(ruleGroup.FolderFullpath) == "" {
 		return models.AlertRuleGroupWithFolderFullpath{}, errors.New("rule group has no folder set")
@@ -56,6 +64,7 @@ func (ruleGroupV1 *AlertRuleGroupV1) MapToModel() (models.AlertRuleGroupWithFold
 		if err != nil {
 			return models.AlertRuleGroupWithFolderFullpath{}, err
 		}
+		rule.QueryOffset = ruleGroup.QueryOffset
 		ruleGroup.Rules = append(ruleGroup.Rules, rule)
 	}
 	return ruleGroup, nil
@@ -72,6 +81,7 @@ type AlertRuleV1 struct {
 	NoDataState          values.StringValue      `json:"noDataState" yaml:"noDataState"`
 	ExecErrState         values.StringValue      `json:"execErrState" yaml:"execErrState"`
 	For                  values.StringValue      `json:"for" yaml:"for"`
+	KeepFiringFor        values.StringValue      `json:"keepFiringFor" yaml:"keepFiringFor"`
 	Annotations          values.StringMapValue   `json:"annotations" yaml:"annotations"`
 	Labels               values.StringMapValue   `json:"labels" yaml:"labels"`
 	IsPaused             values.BoolValue        `json:"isPaused" yaml:"isPaused"`
@@ -108,6 +118,14 @@ func (rule *AlertRuleV1) mapToModel(orgID int64) (models.AlertRule, error) {
 	}
 	alertRule.For = time.Duration(duration)
 
+	if rule.KeepFiringFor.Value() != "" {
+		keepFiringFor, err := model.ParseDuration(rule.KeepFiringFor.Value())
+		if err != nil {
+			return models.AlertRule{}, fmt.Errorf("rule '%s' failed to parse 'keepFiringFor' field: %w", alertRule.Title, err)
+		}
+		alertRule.KeepFiringFor = time.Duration(keepFiringFor)
+	}
+
 	dasboardUID := rule.DasboardUID.Value()
 	dashboardUID := rule.DashboardUID.Value()
 	alertRule.DashboardUID = withFallback(dashboardUID, dasboardUID) // Use correct spelling over supported typo.
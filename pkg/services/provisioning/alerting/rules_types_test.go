@@ -65,6 +65,27 @@ func TestRuleGroup(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, int64(48*time.Hour/time.Second), rgMapped.Interval)
 	})
+	t.Run("a rule group with a query offset should set it to all rules", func(t *testing.T) {
+		rg := validRuleGroupV1(t)
+		rg.Rules = []AlertRuleV1{validRuleV1(t)}
+		var queryOffset values.StringValue
+		err := yaml.Unmarshal([]byte("1m"), &queryOffset)
+		require.NoError(t, err)
+		rg.QueryOffset = queryOffset
+		rgMapped, err := rg.MapToModel()
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, rgMapped.QueryOffset)
+		require.Equal(t, time.Minute, rgMapped.Rules[0].QueryOffset)
+	})
+	t.Run("a rule group with an invalid query offset should error", func(t *testing.T) {
+		rg := validRuleGroupV1(t)
+		var queryOffset values.StringValue
+		err := yaml.Unmarshal([]byte("10x"), &queryOffset)
+		require.NoError(t, err)
+		rg.QueryOffset = queryOffset
+		_, err = rg.MapToModel()
+		require.Error(t, err)
+	})
 	t.Run("a rule group with an empty org id should default to 1", func(t *testing.T) {
 		rg := validRuleGroupV1(t)
 		rg.OrgID = values.Int64Value{}
@@ -128,6 +149,25 @@ func TestRules(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 48*time.Hour, ruleMapped.For)
 	})
+	t.Run("a rule with a keep firing for duration should map it correctly", func(t *testing.T) {
+		rule := validRuleV1(t)
+		keepFiringFor := values.StringValue{}
+		err := yaml.Unmarshal([]byte("5m"), &keepFiringFor)
+		rule.KeepFiringFor = keepFiringFor
+		require.NoError(t, err)
+		ruleMapped, err := rule.mapToModel(1)
+		require.NoError(t, err)
+		require.Equal(t, 5*time.Minute, ruleMapped.KeepFiringFor)
+	})
+	t.Run("a rule with an invalid keep firing for duration should error", func(t *testing.T) {
+		rule := validRuleV1(t)
+		keepFiringFor := values.StringValue{}
+		err := yaml.Unmarshal([]byte("10x"), &keepFiringFor)
+		rule.KeepFiringFor = keepFiringFor
+		require.NoError(t, err)
+		_, err = rule.mapToModel(1)
+		require.Error(t, err)
+	})
 	t.Run("a rule with out a condition should error", func(t *testing.T) {
 		rule := validRuleV1(t)
 		rule.Condition = values.StringValue{}
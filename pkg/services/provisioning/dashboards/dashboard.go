@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
 )
 
@@ -21,10 +23,11 @@ type DashboardProvisioner interface {
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 	CleanUpOrphanedDashboards(ctx context.Context)
+	WriteBackDashboard(ctx context.Context, provisioning *dashboards.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore, folder.Service) (DashboardProvisioner, error)
+type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore, folder.Service, git.Repositories) (DashboardProvisioner, error)
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -40,7 +43,7 @@ func (provider *Provisioner) HasDashboardSources() bool {
 }
 
 // New returns a new DashboardProvisioner
-func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore, folderService folder.Service) (DashboardProvisioner, error) {
+func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore, folderService folder.Service, repositories git.Repositories) (DashboardProvisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger, orgService: orgService}
 	configs, err := cfgReader.readConfig(ctx)
@@ -48,7 +51,7 @@ func New(ctx context.Context, configDirectory string, provisioner dashboards.Das
 		return nil, fmt.Errorf("%v: %w", "Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore, folderService)
+	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore, folderService, repositories)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "Failed to initialize file readers", err)
 	}
@@ -70,6 +73,7 @@ func (provider *Provisioner) Provision(ctx context.Context) error {
 	provider.log.Info("starting to provision dashboards")
 
 	for _, reader := range provider.fileReaders {
+		reader.syncRepository(ctx)
 		if err := reader.walkDisk(ctx); err != nil {
 			if os.IsNotExist(err) {
 				// don't stop the provisioning service in case the folder is missing. The folder can appear after the startup
@@ -130,12 +134,24 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 	return false
 }
 
+// WriteBackDashboard commits a dashboard saved in the UI to the git repository of its provisioner, if the provisioner
+// has a write-back branch.
+func (provider *Provisioner) WriteBackDashboard(ctx context.Context, provisioning *dashboards.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error {
+	for _, reader := range provider.fileReaders {
+		if reader.Cfg.Name == provisioning.Name {
+			return reader.writeBackDashboard(ctx, provisioning.ExternalID, data, message, author)
+		}
+	}
+	return nil
+}
+
 func getFileReaders(
 	configs []*config,
 	logger log.Logger,
 	service dashboards.DashboardProvisioningService,
 	store utils.DashboardStore,
 	folderService folder.Service,
+	repositories git.Repositories,
 ) ([]*FileReader, error) {
 	var readers []*FileReader
 
@@ -153,6 +169,19 @@ func getFileReaders(
 				return nil, fmt.Errorf("failed to create file reader for config %v: %w", config.Name, err)
 			}
 			readers = append(readers, fileReader)
+		case "git":
+			fileReader, err := NewDashboardGitReader(
+				config,
+				logger.New("type", config.Type, "name", config.Name),
+				service,
+				store,
+				folderService,
+				repositories,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create git reader for config %v: %w", config.Name, err)
+			}
+			readers = append(readers, fileReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
@@ -1,6 +1,12 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -8,6 +14,7 @@ type calls struct {
 	PollChanges                 []any
 	GetProvisionerResolvedPath  []any
 	GetAllowUIUpdatesFromConfig []any
+	WriteBackDashboard          []any
 }
 
 // ProvisionerMock is a mock implementation of `Provisioner`
@@ -17,6 +24,7 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	WriteBackDashboardFunc          func(ctx context.Context, provisioning *dashboards.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -67,3 +75,12 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 
 // CleanUpOrphanedDashboards not implemented for mocks
 func (dpm *ProvisionerMock) CleanUpOrphanedDashboards(ctx context.Context) {}
+
+// WriteBackDashboard is a mock implementation of `Provisioner.WriteBackDashboard`
+func (dpm *ProvisionerMock) WriteBackDashboard(ctx context.Context, provisioning *dashboards.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error {
+	dpm.Calls.WriteBackDashboard = append(dpm.Calls.WriteBackDashboard, provisioning)
+	if dpm.WriteBackDashboardFunc != nil {
+		return dpm.WriteBackDashboardFunc(ctx, provisioning, data, message, author)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboardimport"
+	"github.com/grafana/grafana/pkg/services/dashboardimport/utils"
+)
+
+// dashboardFileFormats maps the extensions of the dashboard files to their format. Jsonnet libraries (.libsonnet) are
+// not dashboards, they are only imported by the .jsonnet dashboards.
+var dashboardFileFormats = map[string]string{
+	".json":    "json",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+	".jsonnet": "jsonnet",
+}
+
+func isDashboardFile(name string) bool {
+	_, ok := dashboardFileFormats[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// fileError is an error in a dashboard file, with the position of the error when it is known.
+type fileError struct {
+	path   string
+	line   int
+	column int
+	err    error
+}
+
+func (e *fileError) Error() string {
+	switch {
+	case e.line > 0 && e.column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.path, e.line, e.column, e.err)
+	case e.line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.path, e.line, e.err)
+	default:
+		return fmt.Sprintf("%s: %s", e.path, e.err)
+	}
+}
+
+func (e *fileError) Unwrap() error {
+	return e.err
+}
+
+// dashboardDecoder converts the dashboard files to dashboard JSON models. Jsonnet files are evaluated with the
+// library paths of the provider, and the inputs of the provider are applied to the dashboards declaring `__inputs`.
+type dashboardDecoder struct {
+	jsonnetLibraryPaths []string
+	inputs              []dashboardimport.ImportDashboardInput
+}
+
+func newDashboardDecoder(options map[string]any) (*dashboardDecoder, error) {
+	d := &dashboardDecoder{}
+
+	if raw, ok := options["jsonnetLibraryPaths"]; ok {
+		paths, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("jsonnetLibraryPaths should be a list of paths")
+		}
+		for _, p := range paths {
+			path, ok := p.(string)
+			if !ok || path == "" {
+				return nil, fmt.Errorf("jsonnetLibraryPaths should be a list of paths")
+			}
+			d.jsonnetLibraryPaths = append(d.jsonnetLibraryPaths, path)
+		}
+	}
+
+	if raw, ok := options["inputs"]; ok {
+		inputs, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("inputs should be a list of dashboard inputs")
+		}
+		for i, in := range inputs {
+			input, ok := in.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("input %d should be an object", i)
+			}
+			name, _ := input["name"].(string)
+			typ, _ := input["type"].(string)
+			if name == "" || typ == "" {
+				return nil, fmt.Errorf("input %d should have a name and a type", i)
+			}
+			pluginID, _ := input["pluginId"].(string)
+			value, _ := input["value"].(string)
+			d.inputs = append(d.inputs, dashboardimport.ImportDashboardInput{
+				Name:     name,
+				Type:     typ,
+				PluginId: pluginID,
+				Value:    value,
+			})
+		}
+	}
+
+	return d, nil
+}
+
+// decode returns the dashboard of the file, and whether the dashboard is not the content of the file as is.
+func (d *dashboardDecoder) decode(path string, content []byte) (*simplejson.Json, bool, error) {
+	var data *simplejson.Json
+	var err error
+	converted := true
+	switch dashboardFileFormats[strings.ToLower(filepath.Ext(path))] {
+	case "yaml":
+		data, err = decodeYAMLDashboard(path, content)
+	case "jsonnet":
+		data, err = d.evaluateJsonnetDashboard(path, content)
+	default:
+		data, err = decodeJSONDashboard(path, content)
+		converted = false
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(d.inputs) > 0 {
+		if _, ok := data.CheckGet("__inputs"); ok {
+			data, err = utils.NewDashTemplateEvaluator(data, d.inputs).Eval()
+			if err != nil {
+				return nil, false, &fileError{path: path, err: err}
+			}
+			converted = true
+		}
+	}
+	return data, converted, nil
+}
+
+func decodeJSONDashboard(path string, content []byte) (*simplejson.Json, error) {
+	data, err := simplejson.NewJson(content)
+	if err != nil {
+		var offset int64
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+		}
+		fileErr := &fileError{path: path, err: err}
+		if offset > 0 {
+			// the offset is the number of bytes read, including the invalid one
+			fileErr.line, fileErr.column = position(content, offset-1)
+		}
+		return nil, fileErr
+	}
+	return data, nil
+}
+
+// position returns the line and the column of the byte at the offset.
+func position(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+var yamlLineRegex = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func decodeYAMLDashboard(path string, content []byte) (*simplejson.Json, error) {
+	var dashboard map[string]any
+	if err := yaml.Unmarshal(content, &dashboard); err != nil {
+		fileErr := &fileError{path: path, err: err}
+		if m := yamlLineRegex.FindStringSubmatch(err.Error()); m != nil {
+			fileErr.line, _ = strconv.Atoi(m[1])
+			fileErr.err = errors.New(strings.TrimPrefix(err.Error(), m[0]))
+		}
+		return nil, fileErr
+	}
+	if dashboard == nil {
+		return nil, &fileError{path: path, err: errors.New("the file is empty")}
+	}
+
+	// the dashboard is converted to JSON, so that numbers are decoded as in JSON dashboards
+	encoded, err := json.Marshal(dashboard)
+	if err != nil {
+		return nil, &fileError{path: path, err: err}
+	}
+	return simplejson.NewJson(encoded)
+}
+
+func (d *dashboardDecoder) evaluateJsonnetDashboard(path string, content []byte) (*simplejson.Json, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.FileImporter{JPaths: d.jsonnetLibraryPaths})
+
+	// the errors of the evaluation report the file, line and column of the error
+	output, err := vm.EvaluateAnonymousSnippet(path, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate Jsonnet dashboard: %w", err)
+	}
+	data, err := simplejson.NewJson([]byte(output))
+	if err != nil {
+		return nil, &fileError{path: path, err: err}
+	}
+	return data, nil
+}
@@ -0,0 +1,141 @@
+package dashboards
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	formatsDashboards  = "testdata/test-dashboards/formats"
+	jsonnetLibraryPath = "testdata/jsonnet-lib"
+)
+
+func TestDashboardDecoder(t *testing.T) {
+	readFile := func(t *testing.T, path string) []byte {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return content
+	}
+
+	t.Run("should decode YAML dashboards and apply the inputs", func(t *testing.T) {
+		decoder, err := newDashboardDecoder(map[string]any{
+			"inputs": []any{
+				map[string]any{"name": "DS_PROMETHEUS", "type": "datasource", "pluginId": "prometheus", "value": "prometheus-uid"},
+			},
+		})
+		require.NoError(t, err)
+
+		path := formatsDashboards + "/yaml-dashboard.yaml"
+		data, converted, err := decoder.decode(path, readFile(t, path))
+		require.NoError(t, err)
+		require.True(t, converted)
+
+		require.Equal(t, "YAML dashboard", data.Get("title").MustString())
+		panel := data.Get("panels").GetIndex(0)
+		require.Equal(t, int64(12), panel.GetPath("gridPos", "w").MustInt64())
+		require.Equal(t, "prometheus-uid", panel.GetPath("datasource", "uid").MustString())
+		_, ok := data.CheckGet("__inputs")
+		require.False(t, ok)
+	})
+
+	t.Run("should fail if an input is missing", func(t *testing.T) {
+		decoder, err := newDashboardDecoder(map[string]any{
+			"inputs": []any{map[string]any{"name": "DS_LOKI", "type": "datasource", "value": "loki-uid"}},
+		})
+		require.NoError(t, err)
+
+		path := formatsDashboards + "/yaml-dashboard.yaml"
+		_, _, err = decoder.decode(path, readFile(t, path))
+		require.ErrorContains(t, err, "DS_PROMETHEUS")
+	})
+
+	t.Run("should evaluate Jsonnet dashboards with the library paths", func(t *testing.T) {
+		decoder, err := newDashboardDecoder(map[string]any{"jsonnetLibraryPaths": []any{jsonnetLibraryPath}})
+		require.NoError(t, err)
+
+		path := formatsDashboards + "/jsonnet-dashboard.jsonnet"
+		data, converted, err := decoder.decode(path, readFile(t, path))
+		require.NoError(t, err)
+		require.True(t, converted)
+
+		require.Equal(t, "Jsonnet dashboard", data.Get("title").MustString())
+		require.Len(t, data.Get("panels").MustArray(), 2)
+		require.Equal(t, "count(up)", data.Get("panels").GetIndex(1).Get("targets").GetIndex(0).Get("expr").MustString())
+		require.Equal(t, int64(6), data.Get("panels").GetIndex(1).GetPath("gridPos", "x").MustInt64())
+		// the libraries next to the dashboard are imported relative to it
+		require.Equal(t, "Deployments", data.GetPath("annotations", "list").GetIndex(0).Get("name").MustString())
+
+		// the library is not found without the library paths
+		decoder, err = newDashboardDecoder(map[string]any{})
+		require.NoError(t, err)
+		_, _, err = decoder.decode(path, readFile(t, path))
+		require.ErrorContains(t, err, "jsonnet-dashboard.jsonnet:1:")
+	})
+
+	t.Run("should not convert JSON dashboards without inputs", func(t *testing.T) {
+		decoder, err := newDashboardDecoder(map[string]any{})
+		require.NoError(t, err)
+
+		data, converted, err := decoder.decode("dashboard.json", []byte(`{"title": "JSON dashboard"}`))
+		require.NoError(t, err)
+		require.False(t, converted)
+		require.Equal(t, "JSON dashboard", data.Get("title").MustString())
+	})
+
+	t.Run("should report the file and the line of errors", func(t *testing.T) {
+		decoder, err := newDashboardDecoder(map[string]any{})
+		require.NoError(t, err)
+
+		testCases := []struct {
+			path     string
+			content  string
+			expected string
+		}{
+			{
+				path:     "broken.json",
+				content:  "{\n  \"title\": \"Broken\",\n  \"panels\": [\n}",
+				expected: "broken.json:4:1: invalid character '}' looking for beginning of value",
+			},
+			{
+				path:     "broken.yaml",
+				content:  "title: Broken\n  tags: [broken]\n",
+				expected: "broken.yaml:2: mapping values are not allowed in this context",
+			},
+			{
+				path:     "broken.jsonnet",
+				content:  "{\n  title: 'Broken',\n  panels: [error 'no panels'],\n}",
+				expected: "broken.jsonnet:3:",
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.path, func(t *testing.T) {
+				_, _, err := decoder.decode(tc.path, []byte(tc.content))
+				require.ErrorContains(t, err, tc.expected)
+			})
+		}
+	})
+
+	t.Run("should fail with invalid options", func(t *testing.T) {
+		_, err := newDashboardDecoder(map[string]any{"jsonnetLibraryPaths": "lib"})
+		require.Error(t, err)
+		_, err = newDashboardDecoder(map[string]any{"inputs": []any{map[string]any{"value": "prometheus-uid"}}})
+		require.Error(t, err)
+	})
+}
+
+func TestIsDashboardFile(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"dashboard.json":      true,
+		"dashboard.yaml":      true,
+		"dashboard.YML":       true,
+		"dashboard.jsonnet":   true,
+		"lib.libsonnet":       false,
+		"README.md":           false,
+		"dashboard.json.orig": false,
+	} {
+		require.Equal(t, expected, isDashboardFile(name), name)
+	}
+}
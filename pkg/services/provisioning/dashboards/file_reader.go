@@ -11,12 +11,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -39,6 +39,10 @@ type FileReader struct {
 	dashboardStore               utils.DashboardStore
 	FoldersFromFilesStructure    bool
 	folderService                folder.Service
+	decoder                      *dashboardDecoder
+	repository                   *git.Repository
+	repositoryPath               string
+	writeBackBranch              string
 
 	mux                     sync.RWMutex
 	usageTracker            *usageTracker
@@ -64,6 +68,11 @@ func NewDashboardFileReader(cfg *config, log log.Logger, service dashboards.Dash
 		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
 	}
 
+	decoder, err := newDashboardDecoder(cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboards: %w", err)
+	}
+
 	return &FileReader{
 		Cfg:                          cfg,
 		Path:                         path,
@@ -72,6 +81,7 @@ func NewDashboardFileReader(cfg *config, log log.Logger, service dashboards.Dash
 		dashboardStore:               dashboardStore,
 		folderService:                folderService,
 		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		decoder:                      decoder,
 		usageTracker:                 newUsageTracker(),
 	}, nil
 }
@@ -82,6 +92,7 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			fr.syncRepository(ctx)
 			if err := fr.walkDisk(ctx); err != nil {
 				fr.log.Error("failed to search for dashboards", "error", err)
 			}
@@ -304,6 +315,7 @@ func (fr *FileReader) saveDashboard(ctx context.Context, path string, folderID i
 			Name:       fr.Cfg.Name,
 			Updated:    resolvedFileInfo.ModTime().Unix(),
 			CheckSum:   jsonFile.checkSum,
+			CommitSHA:  fr.commitSHA(),
 		}
 		_, err := fr.dashboardProvisioningService.SaveProvisionedDashboard(ctx, dash, dp)
 		if err != nil {
@@ -425,7 +437,7 @@ func validateWalkablePath(fileInfo os.FileInfo) (bool, error) {
 		return false, nil
 	}
 
-	if !strings.HasSuffix(fileInfo.Name(), ".json") {
+	if !isDashboardFile(fileInfo.Name()) {
 		return false, nil
 	}
 
@@ -456,12 +468,19 @@ func (fr *FileReader) readDashboardFromFile(path string, lastModified time.Time,
 		return nil, err
 	}
 
-	checkSum, err := util.Md5SumString(string(all))
+	data, converted, err := fr.decoder.decode(path, all)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := simplejson.NewJson(all)
+	// the checksum of converted dashboards is computed on the dashboard, so that changes of the imported
+	// Jsonnet libraries and of the inputs update the dashboard
+	if converted {
+		if all, err = data.Encode(); err != nil {
+			return nil, err
+		}
+	}
+	checkSum, err := util.Md5SumString(string(all))
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +125,31 @@ func TestDashboardFileReader(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("Can read YAML and Jsonnet dashboards", func(t *testing.T) {
+			setup()
+			cfg.Options["path"] = formatsDashboards
+			cfg.Options["jsonnetLibraryPaths"] = []any{jsonnetLibraryPath}
+			cfg.Options["inputs"] = []any{
+				map[string]any{"name": "DS_PROMETHEUS", "type": "datasource", "pluginId": "prometheus", "value": "prometheus-uid"},
+			}
+
+			var titles []string
+			fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return(nil, nil).Once()
+			fakeService.On("SaveProvisionedDashboard", mock.Anything, mock.Anything, mock.Anything).
+				Return(&dashboards.Dashboard{}, nil).Times(2).
+				Run(func(args mock.Arguments) {
+					titles = append(titles, args.Get(1).(*dashboards.SaveDashboardDTO).Dashboard.Title)
+				})
+
+			reader, err := NewDashboardFileReader(cfg, logger, nil, fakeStore, nil)
+			reader.dashboardProvisioningService = fakeService
+			require.NoError(t, err)
+
+			err = reader.walkDisk(context.Background())
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"YAML dashboard", "Jsonnet dashboard"}, titles)
+		})
+
 		t.Run("Can read default dashboard and replace old version in database", func(t *testing.T) {
 			setup()
 			cfg.Options["path"] = oneDashboard
@@ -0,0 +1,117 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// ErrWriteBackUnsupported is returned when a dashboard saved in the UI can't be written back to its file.
+var ErrWriteBackUnsupported = errors.New("only JSON dashboard files without inputs can be written back")
+
+// NewDashboardGitReader returns a new reader of the dashboards of a git repository based on `config`. The path of the
+// config is the directory of the dashboards in the repository.
+func NewDashboardGitReader(cfg *config, log log.Logger, service dashboards.DashboardProvisioningService,
+	dashboardStore utils.DashboardStore, folderService folder.Service, repositories git.Repositories) (*FileReader, error) {
+	name, ok := cfg.Options["repository"].(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to load dashboards, repository param is not a string")
+	}
+	repository, ok := repositories[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to load dashboards, repository %q is not configured", name)
+	}
+
+	repositoryPath, _ := cfg.Options["path"].(string)
+	writeBackBranch, _ := cfg.Options["writeBackBranch"].(string)
+	if writeBackBranch != "" && !cfg.AllowUIUpdates {
+		return nil, fmt.Errorf("'allowUiUpdates' should be enabled using 'writeBackBranch' option")
+	}
+
+	options := make(map[string]any, len(cfg.Options))
+	for k, v := range cfg.Options {
+		options[k] = v
+	}
+	options["path"] = repository.Path(repositoryPath)
+	gitCfg := *cfg
+	gitCfg.Options = options
+
+	reader, err := NewDashboardFileReader(&gitCfg, log, service, dashboardStore, folderService)
+	if err != nil {
+		return nil, err
+	}
+	// the config is shared with the provisioner
+	reader.Cfg = cfg
+	reader.repository = repository
+	reader.repositoryPath = repositoryPath
+	reader.writeBackBranch = writeBackBranch
+	return reader, nil
+}
+
+// syncRepository updates the checkout of the git repository of the reader. The dashboards of the current checkout
+// are provisioned if the repository can't be synced.
+func (fr *FileReader) syncRepository(ctx context.Context) {
+	if fr.repository == nil {
+		return
+	}
+	if _, err := fr.repository.Sync(ctx); err != nil {
+		fr.log.Error("Failed to sync git repository", "repository", fr.repository.Name, "error", err)
+	}
+}
+
+// commitSHA returns the commit of the git repository the dashboards are provisioned from.
+func (fr *FileReader) commitSHA() string {
+	if fr.repository == nil {
+		return ""
+	}
+	return fr.repository.Head()
+}
+
+// writeBackDashboard commits the dashboard to the file of the dashboard in the git repository, on the write-back
+// branch of the reader.
+func (fr *FileReader) writeBackDashboard(ctx context.Context, path string, data *simplejson.Json, message string, author git.Signature) error {
+	if fr.repository == nil || fr.writeBackBranch == "" {
+		return nil
+	}
+	if dashboardFileFormats[strings.ToLower(filepath.Ext(path))] != "json" || len(fr.decoder.inputs) > 0 {
+		return ErrWriteBackUnsupported
+	}
+
+	rel, err := filepath.Rel(fr.resolvedPath(), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("dashboard file %s is not in the repository", path)
+	}
+
+	encoded, err := data.Encode()
+	if err != nil {
+		return err
+	}
+	dashboard, err := simplejson.NewJson(encoded)
+	if err != nil {
+		return err
+	}
+	// the ID and the version are specific to the database
+	dashboard.Del("id")
+	dashboard.Del("version")
+	content, err := dashboard.EncodePretty()
+	if err != nil {
+		return err
+	}
+
+	commitMessage := fmt.Sprintf("Update dashboard %q", dashboard.Get("title").MustString())
+	if message != "" {
+		commitMessage += "\n\n" + message
+	}
+
+	_, err = fr.repository.Commit(ctx, fr.writeBackBranch, filepath.Join(fr.repositoryPath, rel), append(content, '\n'), commitMessage, author)
+	return err
+}
@@ -0,0 +1,137 @@
+package dashboards
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+)
+
+func TestDashboardGitReader(t *testing.T) {
+	logger := log.New("test-logger")
+	author := git.Signature{Name: "Editor", Email: "editor@example.com"}
+
+	setup := func(t *testing.T, options map[string]any) (*FileReader, string) {
+		t.Helper()
+		remote := newDashboardsGitRemote(t)
+		repository := git.NewRepository("ops", "file://"+filepath.ToSlash(remote), "main", filepath.Join(t.TempDir(), "ops"))
+		cfg := &config{
+			Name:           configName,
+			Type:           "git",
+			OrgID:          1,
+			AllowUIUpdates: true,
+			Options:        options,
+		}
+		reader, err := NewDashboardGitReader(cfg, logger, nil, &fakeDashboardStore{}, nil, git.Repositories{"ops": repository})
+		require.NoError(t, err)
+		return reader, remote
+	}
+
+	t.Run("should provision the dashboards of the repository with the commit", func(t *testing.T) {
+		reader, remote := setup(t, map[string]any{"repository": "ops", "path": "dashboards"})
+
+		fakeService := &dashboards.FakeDashboardProvisioning{}
+		defer fakeService.AssertExpectations(t)
+		var provisioning *dashboards.DashboardProvisioning
+		fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return(nil, nil).Once()
+		fakeService.On("SaveProvisionedDashboard", mock.Anything, mock.Anything, mock.Anything).
+			Return(&dashboards.Dashboard{}, nil).Once().
+			Run(func(args mock.Arguments) {
+				provisioning = args.Get(2).(*dashboards.DashboardProvisioning)
+			})
+		reader.dashboardProvisioningService = fakeService
+
+		reader.syncRepository(context.Background())
+		require.NoError(t, reader.walkDisk(context.Background()))
+
+		require.Equal(t, filepath.Join(reader.resolvedPath(), "dashboard1.json"), provisioning.ExternalID)
+		require.Equal(t, gitCommand(t, remote, "rev-parse", "main"), provisioning.CommitSHA)
+	})
+
+	t.Run("should commit the dashboards saved in the UI on the write-back branch", func(t *testing.T) {
+		reader, remote := setup(t, map[string]any{"repository": "ops", "path": "dashboards", "writeBackBranch": "grafana-edits"})
+		reader.syncRepository(context.Background())
+
+		data := simplejson.MustJson([]byte(`{"id": 12, "uid": "edited", "title": "Edited", "version": 3}`))
+		path := filepath.Join(reader.resolvedPath(), "dashboard1.json")
+		require.NoError(t, reader.writeBackDashboard(context.Background(), path, data, "Add panel", author))
+
+		content := gitCommand(t, remote, "show", "grafana-edits:dashboards/dashboard1.json")
+		require.JSONEq(t, `{"uid": "edited", "title": "Edited"}`, content)
+		require.Equal(t, "Update dashboard \"Edited\"\n\nAdd panel", gitCommand(t, remote, "log", "-1", "--format=%B", "grafana-edits"))
+		// the saved dashboard is not changed
+		require.Equal(t, int64(12), data.Get("id").MustInt64())
+	})
+
+	t.Run("should not write back dashboards without write-back branch", func(t *testing.T) {
+		reader, remote := setup(t, map[string]any{"repository": "ops", "path": "dashboards"})
+		reader.syncRepository(context.Background())
+
+		path := filepath.Join(reader.resolvedPath(), "dashboard1.json")
+		require.NoError(t, reader.writeBackDashboard(context.Background(), path, simplejson.New(), "", author))
+		require.Equal(t, "refs/heads/main", gitCommand(t, remote, "for-each-ref", "--format=%(refname)"))
+	})
+
+	t.Run("should not write back dashboards of other formats", func(t *testing.T) {
+		reader, _ := setup(t, map[string]any{"repository": "ops", "path": "dashboards", "writeBackBranch": "grafana-edits"})
+		reader.syncRepository(context.Background())
+
+		path := filepath.Join(reader.resolvedPath(), "dashboard.jsonnet")
+		require.ErrorIs(t, reader.writeBackDashboard(context.Background(), path, simplejson.New(), "", author), ErrWriteBackUnsupported)
+	})
+
+	t.Run("should fail with invalid options", func(t *testing.T) {
+		repositories := git.Repositories{"ops": git.NewRepository("ops", "file:///srv/ops.git", "main", t.TempDir())}
+		for name, cfg := range map[string]*config{
+			"missing repository":            {Options: map[string]any{"path": "dashboards"}},
+			"unknown repository":            {Options: map[string]any{"repository": "other"}},
+			"write-back without UI updates": {Options: map[string]any{"repository": "ops", "writeBackBranch": "grafana-edits"}},
+		} {
+			_, err := NewDashboardGitReader(cfg, logger, nil, nil, nil, repositories)
+			require.Error(t, err, name)
+		}
+	})
+}
+
+// newDashboardsGitRemote returns a bare repository with the dashboards of oneDashboard in the dashboards directory.
+func newDashboardsGitRemote(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	work := t.TempDir()
+	content, err := os.ReadFile(filepath.Join(oneDashboard, "dashboard1.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(work, "dashboards"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(work, "dashboards", "dashboard1.json"), content, 0o600))
+
+	remote := filepath.Join(t.TempDir(), "ops.git")
+	gitCommand(t, "", "init", "--bare", "--initial-branch=main", remote)
+	gitCommand(t, "", "-C", work, "init", "--initial-branch=main")
+	gitCommand(t, "", "-C", work, "add", "--all")
+	gitCommand(t, "", "-C", work, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--message", "Add dashboards")
+	gitCommand(t, "", "-C", work, "push", remote, "main")
+	return remote
+}
+
+func gitCommand(t *testing.T, gitDir string, args ...string) string {
+	t.Helper()
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	// nolint:gosec
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,9 @@
+{
+  stat(id, title, expr):: {
+    id: id,
+    type: 'stat',
+    title: title,
+    gridPos: { x: (id - 1) * 6, y: 0, w: 6, h: 4 },
+    targets: [{ refId: 'A', expr: expr }],
+  },
+}
@@ -0,0 +1,5 @@
+{
+  list: [
+    { name: 'Deployments', enable: true, iconColor: 'blue' },
+  ],
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+const defaultBranch = "main"
+
+// the name of a repository is the name of its checkout directory
+var repositoryNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+// the SSH key path is passed to ssh through GIT_SSH_COMMAND, which is run by a shell
+var sshKeyPathRegex = regexp.MustCompile(`^[a-zA-Z0-9_./~@+:-]+$`)
+
+// Repositories are the repositories of the provisioning configuration by name.
+type Repositories map[string]*Repository
+
+type configV1 struct {
+	APIVersion   values.Int64Value     `json:"apiVersion" yaml:"apiVersion"`
+	Repositories []*repositoryConfigV1 `json:"repositories" yaml:"repositories"`
+}
+
+type repositoryConfigV1 struct {
+	Name               values.StringValue `json:"name" yaml:"name"`
+	URL                values.StringValue `json:"url" yaml:"url"`
+	Branch             values.StringValue `json:"branch" yaml:"branch"`
+	SSHKeyPath         values.StringValue `json:"sshKeyPath" yaml:"sshKeyPath"`
+	DatasourcesPath    values.StringValue `json:"datasourcesPath" yaml:"datasourcesPath"`
+	AlertingPath       values.StringValue `json:"alertingPath" yaml:"alertingPath"`
+	AllowInterpolation values.BoolValue   `json:"allowInterpolation" yaml:"allowInterpolation"`
+}
+
+// ReadConfig reads the repositories of the configuration files of configDirectory. The repositories are checked out in
+// checkoutDirectory, in a directory named after the repository.
+func ReadConfig(configDirectory, checkoutDirectory string) (Repositories, error) {
+	logger := log.New("provisioning.git")
+	repositories := Repositories{}
+
+	files, err := os.ReadDir(configDirectory)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("can't read git provisioning files from directory", "path", configDirectory, "error", err)
+		}
+		return repositories, nil
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+			continue
+		}
+
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because `configDirectory` comes from ps.Cfg.ProvisioningPath
+		yamlFile, err := os.ReadFile(filepath.Join(configDirectory, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := &configV1{}
+		if err := yaml.Unmarshal(yamlFile, cfg); err != nil {
+			return nil, fmt.Errorf("could not parse provisioning config file: %s error: %w", file.Name(), err)
+		}
+		if cfg.APIVersion.Value() != 1 {
+			return nil, fmt.Errorf("could not parse provisioning config file: %s error: unsupported apiVersion %d", file.Name(), cfg.APIVersion.Value())
+		}
+
+		for _, rc := range cfg.Repositories {
+			repository, err := rc.mapToRepository(checkoutDirectory)
+			if err != nil {
+				return nil, fmt.Errorf("invalid repository in provisioning config file: %s error: %w", file.Name(), err)
+			}
+			if _, ok := repositories[repository.Name]; ok {
+				return nil, fmt.Errorf("repository name %q is not unique", repository.Name)
+			}
+			repositories[repository.Name] = repository
+		}
+	}
+
+	return repositories, nil
+}
+
+func (rc *repositoryConfigV1) mapToRepository(checkoutDirectory string) (*Repository, error) {
+	name := rc.Name.Value()
+	if !repositoryNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("repository name %q should only contain letters, digits, '-', '_' and '.'", name)
+	}
+	if rc.URL.Value() == "" {
+		return nil, fmt.Errorf("repository %q has no url", name)
+	}
+
+	sshKeyPath := rc.SSHKeyPath.Value()
+	if sshKeyPath != "" && !sshKeyPathRegex.MatchString(sshKeyPath) {
+		return nil, fmt.Errorf("repository %q ssh key path %q should only contain letters, digits, '-', '_', '.', '/', '~', '@', '+' and ':'", name, sshKeyPath)
+	}
+
+	branch := rc.Branch.Value()
+	if branch == "" {
+		branch = defaultBranch
+	}
+
+	repository := NewRepository(name, rc.URL.Value(), branch, filepath.Join(checkoutDirectory, name))
+	repository.SSHKeyPath = sshKeyPath
+	repository.DatasourcesPath = rc.DatasourcesPath.Value()
+	repository.AlertingPath = rc.AlertingPath.Value()
+	repository.AllowInterpolation = rc.AllowInterpolation.Value()
+	return repository, nil
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrInterpolationNotAllowed is returned when a provisioning file of a repository uses environment variables or
+// expanders, and the repository does not allow it.
+var ErrInterpolationNotAllowed = errors.New("environment variables and expanders are not allowed in the provisioning files of the repository")
+
+// interpolationRegex matches the environment variables, e.g. $VAR and ${VAR}, and the expanders, e.g. $__file{path},
+// of the values of provisioning files once the escaped '$' are removed.
+var interpolationRegex = regexp.MustCompile(`\$(\{|[a-zA-Z_])`)
+
+// CheckInterpolation returns ErrInterpolationNotAllowed if a provisioning file of a directory of the repository would
+// be interpolated, unless the repository allows it. The values of the data source and alerting provisioning files are
+// interpolated with the environment and the files of the Grafana server, so anyone who can push to the repository
+// could read them through the provisioned resources.
+func (r *Repository) CheckInterpolation(path string) error {
+	if r.AllowInterpolation {
+		return nil
+	}
+
+	dir := r.Path(path)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the directory comes from the provisioning configuration.
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		// '$$' is a literal '$'
+		if m := interpolationRegex.Find(bytes.ReplaceAll(content, []byte("$$"), nil)); m != nil {
+			return fmt.Errorf("%w: %s uses %q", ErrInterpolationNotAllowed, strings.TrimPrefix(filepath.ToSlash(filepath.Join(path, file.Name())), "./"), m)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInterpolation(t *testing.T) {
+	setup := func(t *testing.T, content string) *Repository {
+		t.Helper()
+		repository := NewRepository("test", "file:///srv/test.git", "main", t.TempDir())
+		require.NoError(t, os.MkdirAll(repository.Path("datasources"), 0o750))
+		require.NoError(t, os.WriteFile(repository.Path("datasources/datasources.yaml"), []byte(content), 0o600))
+		return repository
+	}
+
+	for _, content := range []string{
+		"password: $DB_PASSWORD",
+		"password: ${DB_PASSWORD}",
+		"password: $__file{/etc/grafana/db-password}",
+		"password: $__env{DB_PASSWORD}",
+		"expr: rate(up[$$__rate_interval]) + $USER",
+	} {
+		t.Run("should reject "+content, func(t *testing.T) {
+			err := setup(t, content).CheckInterpolation("datasources")
+			require.ErrorIs(t, err, ErrInterpolationNotAllowed)
+			require.ErrorContains(t, err, "datasources/datasources.yaml")
+		})
+	}
+
+	t.Run("should accept escaped variables", func(t *testing.T) {
+		repository := setup(t, "expr: rate(up[$$__rate_interval]) > $$1 and price: 5$")
+		require.NoError(t, repository.CheckInterpolation("datasources"))
+	})
+
+	t.Run("should ignore the other files", func(t *testing.T) {
+		repository := setup(t, "name: test")
+		require.NoError(t, os.WriteFile(repository.Path("datasources/README.md"), []byte("$HOME"), 0o600))
+		require.NoError(t, os.MkdirAll(repository.Path("datasources/dashboards"), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(repository.Path("datasources/dashboards"), "home.json"), []byte(`{"query": "$datasource"}`), 0o600))
+		require.NoError(t, repository.CheckInterpolation("datasources"))
+		require.NoError(t, repository.CheckInterpolation("missing"))
+	})
+
+	t.Run("should accept variables if the repository allows them", func(t *testing.T) {
+		repository := setup(t, "password: $DB_PASSWORD")
+		repository.AllowInterpolation = true
+		require.NoError(t, repository.CheckInterpolation("datasources"))
+	})
+}
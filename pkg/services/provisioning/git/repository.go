@@ -0,0 +1,260 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+var (
+	// ErrGitNotFound is returned when the git executable is not in the PATH.
+	ErrGitNotFound = errors.New("git executable not found")
+	// ErrNotSynced is returned when the repository has not been cloned yet.
+	ErrNotSynced = errors.New("repository has not been cloned")
+)
+
+// Signature is the author of a commit.
+type Signature struct {
+	Name  string
+	Email string
+}
+
+// Repository is a local checkout of a branch of a remote git repository. The checkout is only updated by Sync, and
+// the commits of Commit are pushed to the remote without changing the checkout.
+type Repository struct {
+	Name       string
+	URL        string
+	Branch     string
+	SSHKeyPath string
+	// DatasourcesPath and AlertingPath are the directories of the repository with the data source and alerting
+	// provisioning files, if any.
+	DatasourcesPath string
+	AlertingPath    string
+	// AllowInterpolation allows environment variables and expanders in the data source and alerting provisioning
+	// files of the repository.
+	AllowInterpolation bool
+
+	dir  string
+	log  log.Logger
+	mux  sync.Mutex
+	head string
+}
+
+// NewRepository returns a repository checked out in dir.
+func NewRepository(name, url, branch, dir string) *Repository {
+	return &Repository{
+		Name:   name,
+		URL:    url,
+		Branch: branch,
+		dir:    dir,
+		log:    log.New("provisioning.git", "repository", name),
+	}
+}
+
+// Dir returns the directory of the checkout.
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// Path returns the path of a file or a directory of the repository in the checkout.
+func (r *Repository) Path(path string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(path))
+}
+
+// Head returns the commit SHA of the checkout, or an empty string if the repository has not been cloned yet.
+func (r *Repository) Head() string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.head
+}
+
+// Sync clones the repository, or updates the checkout to the last commit of the branch, and returns the commit SHA
+// of the checkout. Local changes of the checkout are discarded.
+func (r *Repository) Sync(ctx context.Context) (string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if err := r.clone(ctx); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := r.git(ctx, nil, "fetch", "--prune", "origin", r.Branch); err != nil {
+			return "", err
+		}
+		if _, err := r.git(ctx, nil, "checkout", "--force", "-B", r.Branch, "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+		if _, err := r.git(ctx, nil, "clean", "-fdx"); err != nil {
+			return "", err
+		}
+	}
+
+	head, err := r.git(ctx, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if head != r.head {
+		r.log.Info("Synced repository", "url", redactURL(r.URL), "branch", r.Branch, "commit", head)
+	}
+	r.head = head
+	return head, nil
+}
+
+func (r *Repository) clone(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(r.dir), 0o750); err != nil {
+		return err
+	}
+	// a partial checkout of a failed clone would be mistaken for a clone by the next sync
+	if err := os.RemoveAll(r.dir); err != nil {
+		return err
+	}
+
+	r.log.Info("Cloning repository", "url", redactURL(r.URL), "branch", r.Branch)
+	if _, err := r.git(ctx, nil, "clone", "--branch", r.Branch, "--single-branch", "--", r.URL, r.dir); err != nil {
+		_ = os.RemoveAll(r.dir)
+		return err
+	}
+	return nil
+}
+
+// Commit commits the content of a file of the repository on top of a branch and pushes the commit to the remote.
+// The branch is created from the followed branch if it does not exist. It returns the SHA of the commit, or an
+// empty string if the file is already up to date on the branch.
+func (r *Repository) Commit(ctx context.Context, branch, path string, content []byte, message string, author Signature) (string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.head == "" {
+		return "", ErrNotSynced
+	}
+
+	parent := r.head
+	remoteHead, err := r.git(ctx, nil, "ls-remote", "--heads", "origin", "refs/heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	if remoteHead != "" {
+		if _, err := r.git(ctx, nil, "fetch", "origin", branch); err != nil {
+			return "", err
+		}
+		if parent, err = r.git(ctx, nil, "rev-parse", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+
+	// the commit is built with a temporary index, so that the checkout of the followed branch is not modified
+	index, err := os.CreateTemp("", "grafana-git-index")
+	if err != nil {
+		return "", err
+	}
+	indexPath := index.Name()
+	defer func() {
+		_ = os.Remove(indexPath)
+	}()
+	if err := index.Close(); err != nil {
+		return "", err
+	}
+	// git reads an empty file as a corrupted index
+	if err := os.Remove(indexPath); err != nil {
+		return "", err
+	}
+	env := []string{"GIT_INDEX_FILE=" + indexPath}
+
+	blob, err := r.gitWithInput(ctx, env, content, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.git(ctx, env, "read-tree", parent); err != nil {
+		return "", err
+	}
+	if _, err := r.git(ctx, env, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+filepath.ToSlash(path)); err != nil {
+		return "", err
+	}
+	tree, err := r.git(ctx, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	parentTree, err := r.git(ctx, nil, "rev-parse", parent+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	if tree == parentTree {
+		return "", nil
+	}
+
+	env = append(env,
+		"GIT_AUTHOR_NAME="+author.Name,
+		"GIT_AUTHOR_EMAIL="+author.Email,
+		"GIT_COMMITTER_NAME="+author.Name,
+		"GIT_COMMITTER_EMAIL="+author.Email,
+	)
+	commit, err := r.gitWithInput(ctx, env, []byte(message), "commit-tree", tree, "-p", parent, "-F", "-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.git(ctx, nil, "push", "origin", commit+":refs/heads/"+branch); err != nil {
+		return "", err
+	}
+
+	r.log.Info("Pushed commit", "branch", branch, "path", path, "commit", commit)
+	return commit, nil
+}
+
+func (r *Repository) git(ctx context.Context, env []string, args ...string) (string, error) {
+	return r.gitWithInput(ctx, env, nil, args...)
+}
+
+func (r *Repository) gitWithInput(ctx context.Context, env []string, input []byte, args ...string) (string, error) {
+	executable, err := exec.LookPath("git")
+	if err != nil {
+		return "", ErrGitNotFound
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G204 warning on this one because the arguments come from the provisioning configuration.
+	cmd := exec.CommandContext(ctx, executable, args...)
+	if args[0] != "clone" {
+		cmd.Dir = r.dir
+	}
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if r.SSHKeyPath != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -i "+r.SSHKeyPath+" -o IdentitiesOnly=yes")
+	}
+	cmd.Env = append(cmd.Env, env...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the URL can contain credentials, and git prints it in its errors
+		msg := strings.ReplaceAll(strings.TrimSpace(stderr.String()), r.URL, redactURL(r.URL))
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// redactURL removes the password of the URL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
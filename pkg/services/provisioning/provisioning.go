@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
@@ -30,6 +31,7 @@ import (
 	prov_alerting "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/searchV2"
@@ -85,6 +87,10 @@ func ProvideService(
 		tracer:                       tracer,
 	}
 
+	if err := s.setGitRepositories(); err != nil {
+		return nil, err
+	}
+
 	if err := s.setDashboardProvisioner(); err != nil {
 		return nil, err
 	}
@@ -92,9 +98,19 @@ func ProvideService(
 	return s, nil
 }
 
+func (ps *ProvisioningServiceImpl) setGitRepositories() error {
+	gitPath := filepath.Join(ps.Cfg.ProvisioningPath, "git")
+	repositories, err := git.ReadConfig(gitPath, filepath.Join(ps.Cfg.DataPath, "provisioning", "git"))
+	if err != nil {
+		return fmt.Errorf("%v: %w", "Failed to read git repositories config", err)
+	}
+	ps.gitRepositories = repositories
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) setDashboardProvisioner() error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(context.Background(), dashboardPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService, ps.folderService)
+	dashProvisioner, err := ps.newDashboardProvisioner(context.Background(), dashboardPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService, ps.folderService, ps.gitRepositories)
 	if err != nil {
 		return fmt.Errorf("%v: %w", "Failed to create provisioner", err)
 	}
@@ -111,6 +127,7 @@ type ProvisioningService interface {
 	ProvisionAlerting(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	WriteBackDashboard(ctx context.Context, provisioning *dashboardservice.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error
 }
 
 // Used for testing purposes
@@ -152,6 +169,7 @@ type ProvisioningServiceImpl struct {
 	provisionDatasources         func(context.Context, string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error
 	provisionPlugins             func(context.Context, string, pluginstore.Store, pluginsettings.Service, org.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
+	gitRepositories              git.Repositories
 	mutex                        sync.Mutex
 	dashboardProvisioningService dashboardservice.DashboardProvisioningService
 	dashboardService             dashboardservice.DashboardService
@@ -231,6 +249,24 @@ func (ps *ProvisioningServiceImpl) ProvisionDatasources(ctx context.Context) err
 		ps.log.Error("Failed to provision data sources", "error", err)
 		return err
 	}
+
+	for _, repository := range ps.gitRepositories {
+		if repository.DatasourcesPath == "" {
+			continue
+		}
+		commit := ps.syncGitRepository(ctx, repository)
+		if err := repository.CheckInterpolation(repository.DatasourcesPath); err != nil {
+			err = fmt.Errorf("%v %q: %w", "Datasource provisioning error in git repository", repository.Name, err)
+			ps.log.Error("Failed to provision data sources", "error", err)
+			return err
+		}
+		if err := ps.provisionDatasources(ctx, repository.Path(repository.DatasourcesPath), ps.datasourceService, ps.correlationsService, ps.orgService); err != nil {
+			err = fmt.Errorf("%v %q: %w", "Datasource provisioning error in git repository", repository.Name, err)
+			ps.log.Error("Failed to provision data sources", "error", err)
+			return err
+		}
+		ps.log.Info("Provisioned data sources from git repository", "repository", repository.Name, "commit", commit)
+	}
 	return nil
 }
 
@@ -309,7 +345,36 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 		MuteTimingService:          *mutetimingsService,
 		TemplateService:            *templateService,
 	}
-	return ps.provisionAlerting(ctx, cfg)
+	if err := ps.provisionAlerting(ctx, cfg); err != nil {
+		return err
+	}
+
+	for _, repository := range ps.gitRepositories {
+		if repository.AlertingPath == "" {
+			continue
+		}
+		commit := ps.syncGitRepository(ctx, repository)
+		if err := repository.CheckInterpolation(repository.AlertingPath); err != nil {
+			return fmt.Errorf("%v %q: %w", "Alerting provisioning error in git repository", repository.Name, err)
+		}
+		cfg.Path = repository.Path(repository.AlertingPath)
+		if err := ps.provisionAlerting(ctx, cfg); err != nil {
+			return fmt.Errorf("%v %q: %w", "Alerting provisioning error in git repository", repository.Name, err)
+		}
+		ps.log.Info("Provisioned alerting resources from git repository", "repository", repository.Name, "commit", commit)
+	}
+	return nil
+}
+
+// syncGitRepository updates the checkout of a git repository and returns its commit. The files of the current
+// checkout are provisioned if the repository can't be synced.
+func (ps *ProvisioningServiceImpl) syncGitRepository(ctx context.Context, repository *git.Repository) string {
+	commit, err := repository.Sync(ctx)
+	if err != nil {
+		ps.log.Error("Failed to sync git repository", "repository", repository.Name, "error", err)
+		return repository.Head()
+	}
+	return commit
 }
 
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
@@ -320,6 +385,12 @@ func (ps *ProvisioningServiceImpl) GetAllowUIUpdatesFromConfig(name string) bool
 	return ps.dashboardProvisioner.GetAllowUIUpdatesFromConfig(name)
 }
 
+// WriteBackDashboard commits a provisioned dashboard saved in the UI to the git repository it is provisioned from, if
+// its provisioner has a write-back branch.
+func (ps *ProvisioningServiceImpl) WriteBackDashboard(ctx context.Context, provisioning *dashboardservice.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error {
+	return ps.dashboardProvisioner.WriteBackDashboard(ctx, provisioning, data, message, author)
+}
+
 func (ps *ProvisioningServiceImpl) cancelPolling() {
 	if ps.pollingCtxCancel != nil {
 		ps.log.Debug("Stop polling for dashboard changes")
@@ -1,6 +1,12 @@
 package provisioning
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	dashboardservice "github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+)
 
 type Calls struct {
 	RunInitProvisioners                 []any
@@ -10,6 +16,7 @@ type Calls struct {
 	ProvisionAlerting                   []any
 	GetDashboardProvisionerResolvedPath []any
 	GetAllowUIUpdatesFromConfig         []any
+	WriteBackDashboard                  []any
 	Run                                 []any
 }
 
@@ -21,6 +28,7 @@ type ProvisioningServiceMock struct {
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	WriteBackDashboardFunc                  func(ctx context.Context, provisioning *dashboardservice.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error
 	RunFunc                                 func(ctx context.Context) error
 }
 
@@ -83,6 +91,14 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	return false
 }
 
+func (mock *ProvisioningServiceMock) WriteBackDashboard(ctx context.Context, provisioning *dashboardservice.DashboardProvisioning, data *simplejson.Json, message string, author git.Signature) error {
+	mock.Calls.WriteBackDashboard = append(mock.Calls.WriteBackDashboard, provisioning)
+	if mock.WriteBackDashboardFunc != nil {
+		return mock.WriteBackDashboardFunc(ctx, provisioning, data, message, author)
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) Run(ctx context.Context) error {
 	mock.Calls.Run = append(mock.Calls.Run, nil)
 	if mock.RunFunc != nil {
@@ -0,0 +1,28 @@
+package queryhistory
+
+import (
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	// ActionShare allows sharing queries in query history with a team or the organization.
+	ActionShare = "query.history:share"
+)
+
+var sharerRole = ac.RoleDTO{
+	Name:        "fixed:query.history:sharer",
+	DisplayName: "Query history sharer",
+	Description: "Share queries in query history with teams and the organization",
+	Group:       "Query history",
+	Permissions: []ac.Permission{
+		{Action: ActionShare},
+	},
+}
+
+func declareFixedRoles(service ac.Service) error {
+	return service.DeclareFixedRoles(ac.RoleRegistration{
+		Role:   sharerRole,
+		Grants: []string{string(org.RoleEditor)},
+	})
+}
@@ -1,6 +1,7 @@
 package queryhistory
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
@@ -22,6 +23,11 @@ func (s *QueryHistoryService) registerAPIEndpoints() {
 		entities.Post("/star/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.starHandler, "Failed to star query history")))
 		entities.Delete("/star/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.unstarHandler, "Failed to unstar query history")))
 		entities.Patch("/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.patchCommentHandler, "Failed to update comment of query in query history")))
+		entities.Put("/tags/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.updateTagsHandler, "Failed to update tags of query in query history")))
+		entities.Put("/share/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.shareHandler, "Failed to share query in query history")))
+		entities.Post("/use/:uid", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.useHandler, "Failed to use query in query history")))
+		entities.Get("/export", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.exportHandler, "Failed to export query history")))
+		entities.Post("/import", middleware.ReqSignedIn, routing.Wrap(s.permissionsMiddleware(s.importHandler, "Failed to import query history")))
 	})
 }
 
@@ -81,6 +87,8 @@ func (s *QueryHistoryService) searchHandler(c *contextmodel.ReqContext) response
 		DatasourceUIDs: c.QueryStrings("datasourceUid"),
 		SearchString:   c.Query("searchString"),
 		OnlyStarred:    c.QueryBoolWithDefault("onlyStarred", false),
+		Tags:           c.QueryStrings("tag"),
+		Scope:          c.Query("scope"),
 		Sort:           c.Query("sort"),
 		Page:           c.QueryInt("page"),
 		Limit:          c.QueryInt("limit"),
@@ -201,7 +209,164 @@ func (s *QueryHistoryService) unstarHandler(c *contextmodel.ReqContext) response
 	return response.JSON(http.StatusOK, QueryHistoryResponse{Result: query})
 }
 
-// swagger:parameters starQuery patchQueryComment deleteQuery unstarQuery
+// swagger:route PUT /query-history/tags/{query_history_uid} query_history updateQueryTags
+//
+// Update tags of query in query history.
+//
+// Replaces the tags of query in query history as specified by the UID.
+//
+// Responses:
+// 200: getQueryHistoryResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (s *QueryHistoryService) updateTagsHandler(c *contextmodel.ReqContext) response.Response {
+	queryUID := web.Params(c.Req)[":uid"]
+	if len(queryUID) > 0 && !util.IsValidShortUID(queryUID) {
+		return response.Error(http.StatusNotFound, "Query in query history not found", nil)
+	}
+
+	cmd := UpdateQueryTagsInQueryHistoryCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	query, err := s.UpdateQueryTagsInQueryHistory(c.Req.Context(), c.SignedInUser, queryUID, cmd)
+	if err != nil {
+		return queryHistoryErrorResponse(err, "Failed to update tags of query in query history")
+	}
+
+	return response.JSON(http.StatusOK, QueryHistoryResponse{Result: query})
+}
+
+// swagger:route PUT /query-history/share/{query_history_uid} query_history shareQuery
+//
+// Share query in query history.
+//
+// Shares query in query history as specified by the UID with a team of the user or the organization.
+// Shared queries are returned by searches with the `shared` or `all` scope.
+//
+// Responses:
+// 200: getQueryHistoryResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (s *QueryHistoryService) shareHandler(c *contextmodel.ReqContext) response.Response {
+	queryUID := web.Params(c.Req)[":uid"]
+	if len(queryUID) > 0 && !util.IsValidShortUID(queryUID) {
+		return response.Error(http.StatusNotFound, "Query in query history not found", nil)
+	}
+
+	cmd := ShareQueryInQueryHistoryCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if cmd.SharedWith != SharedWithNone {
+		hasAccess := ac.HasAccess(s.accessControl, c)
+		if !hasAccess(ac.EvalPermission(ActionShare)) {
+			return response.Error(http.StatusForbidden, "Not allowed to share queries in query history", nil)
+		}
+	}
+
+	query, err := s.ShareQueryInQueryHistory(c.Req.Context(), c.SignedInUser, queryUID, cmd)
+	if err != nil {
+		return queryHistoryErrorResponse(err, "Failed to share query in query history")
+	}
+
+	return response.JSON(http.StatusOK, QueryHistoryResponse{Result: query})
+}
+
+// swagger:route POST /query-history/use/{query_history_uid} query_history useQuery
+//
+// Count use of query in query history.
+//
+// Increments the number of times query in query history as specified by the UID was used.
+// Search query history with the `use-desc` sort to get the most used queries.
+//
+// Responses:
+// 200: getQueryHistoryResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (s *QueryHistoryService) useHandler(c *contextmodel.ReqContext) response.Response {
+	queryUID := web.Params(c.Req)[":uid"]
+	if len(queryUID) > 0 && !util.IsValidShortUID(queryUID) {
+		return response.Error(http.StatusNotFound, "Query in query history not found", nil)
+	}
+
+	query, err := s.UseQueryInQueryHistory(c.Req.Context(), c.SignedInUser, queryUID)
+	if err != nil {
+		return queryHistoryErrorResponse(err, "Failed to use query in query history")
+	}
+
+	return response.JSON(http.StatusOK, QueryHistoryResponse{Result: query})
+}
+
+// swagger:route GET /query-history/export query_history exportQueries
+//
+// Export query history.
+//
+// Returns all queries in the query history of the user, in the format accepted by the import.
+//
+// Responses:
+// 200: getQueryHistoryExportResponse
+// 401: unauthorisedError
+// 500: internalServerError
+func (s *QueryHistoryService) exportHandler(c *contextmodel.ReqContext) response.Response {
+	export, err := s.ExportQueryHistory(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to export query history", err)
+	}
+
+	return response.JSON(http.StatusOK, export)
+}
+
+// swagger:route POST /query-history/import query_history importQueries
+//
+// Import query history.
+//
+// Adds exported queries to the query history of the user. Imported queries are private.
+//
+// Responses:
+// 200: getQueryHistoryImportResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (s *QueryHistoryService) importHandler(c *contextmodel.ReqContext) response.Response {
+	export := QueryHistoryExport{}
+	if err := web.Bind(c.Req, &export); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	imported, err := s.ImportQueryHistory(c.Req.Context(), c.SignedInUser, export)
+	if err != nil {
+		return queryHistoryErrorResponse(err, "Failed to import query history")
+	}
+
+	return response.JSON(http.StatusOK, QueryHistoryImportResponse{
+		Message:  "Query history imported",
+		Imported: imported,
+	})
+}
+
+func queryHistoryErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrQueryNotFound):
+		return response.Error(http.StatusNotFound, message, err)
+	case errors.Is(err, ErrNotTeamMember):
+		return response.Error(http.StatusForbidden, message, err)
+	case errors.Is(err, ErrInvalidShare), errors.Is(err, ErrInvalidTag), errors.Is(err, ErrInvalidImport):
+		return response.Error(http.StatusBadRequest, message, err)
+	default:
+		return response.Error(http.StatusInternalServerError, message, err)
+	}
+}
+
+// swagger:parameters starQuery patchQueryComment deleteQuery unstarQuery updateQueryTags shareQuery useQuery
 type QueryHistoryByUID struct {
 	// in:path
 	// required:true
@@ -224,11 +389,23 @@ type SearchQueriesParams struct {
 	// in:query
 	// required: false
 	OnlyStarred bool `json:"onlyStarred"`
+	// List of tags the queries must have
+	// in:query
+	// required: false
+	// type: array
+	// collectionFormat: multi
+	Tag []string `json:"tag"`
+	// Which queries are searched: the queries of the user, the queries shared with the user, or both
+	// in:query
+	// required: false
+	// default: own
+	// Enum: own,shared,all
+	Scope string `json:"scope"`
 	// Sort method
 	// in:query
 	// required: false
 	// default: time-desc
-	// Enum: time-desc,time-asc
+	// Enum: time-desc,time-asc,use-desc
 	Sort string `json:"sort"`
 	// Use this parameter to access hits beyond limit. Numbering starts at 1. limit param acts as page size.
 	// in:query
@@ -262,6 +439,27 @@ type PatchQueryCommentParams struct {
 	Body PatchQueryCommentInQueryHistoryCommand `json:"body"`
 }
 
+// swagger:parameters updateQueryTags
+type UpdateQueryTagsParams struct {
+	// in:body
+	// required:true
+	Body UpdateQueryTagsInQueryHistoryCommand `json:"body"`
+}
+
+// swagger:parameters shareQuery
+type ShareQueryParams struct {
+	// in:body
+	// required:true
+	Body ShareQueryInQueryHistoryCommand `json:"body"`
+}
+
+// swagger:parameters importQueries
+type ImportQueriesParams struct {
+	// in:body
+	// required:true
+	Body QueryHistoryExport `json:"body"`
+}
+
 //swagger:response getQueryHistorySearchResponse
 type GetQueryHistorySearchResponse struct {
 	// in: body
@@ -279,3 +477,15 @@ type GetQueryHistoryDeleteQueryResponse struct {
 	// in: body
 	Body QueryHistoryDeleteQueryResponse `json:"body"`
 }
+
+// swagger:response getQueryHistoryExportResponse
+type GetQueryHistoryExportResponse struct {
+	// in: body
+	Body QueryHistoryExport `json:"body"`
+}
+
+// swagger:response getQueryHistoryImportResponse
+type GetQueryHistoryImportResponse struct {
+	// in: body
+	Body QueryHistoryImportResponse `json:"body"`
+}
@@ -2,7 +2,9 @@ package queryhistory
 
 import (
 	"context"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -58,17 +60,7 @@ func (s QueryHistoryService) createQuery(ctx context.Context, user *user.SignedI
 		})
 	}
 
-	dto := QueryHistoryDTO{
-		UID:           queryHistory.UID,
-		DatasourceUID: queryHistory.DatasourceUID,
-		CreatedBy:     queryHistory.CreatedBy,
-		CreatedAt:     queryHistory.CreatedAt,
-		Comment:       queryHistory.Comment,
-		Queries:       queryHistory.Queries,
-		Starred:       false,
-	}
-
-	return dto, nil
+	return newQueryHistoryDTO(queryHistory, false, []string{}), nil
 }
 
 // searchQueries searches for queries in query history based on provided parameters
@@ -101,8 +93,11 @@ func (s QueryHistoryService) searchQueries(ctx context.Context, user *user.Signe
 			query_history.created_at AS created_at,
 			query_history.comment,
 			query_history.queries,
+			query_history.shared_with,
+			query_history.shared_team_id,
+			query_history.use_count,
 		`)
-		writeStarredSQL(query, s.store, &dtosBuilder, false)
+		writeStarredSQL(query, user, s.store, &dtosBuilder, false)
 		writeFiltersSQL(query, user, s.store, &dtosBuilder)
 		writeSortSQL(query, s.store, &dtosBuilder)
 		writeLimitSQL(query, s.store, &dtosBuilder)
@@ -113,10 +108,22 @@ func (s QueryHistoryService) searchQueries(ctx context.Context, user *user.Signe
 			return err
 		}
 
+		uids := make([]string, 0, len(dtos))
+		for _, dto := range dtos {
+			uids = append(uids, dto.UID)
+		}
+		tags, err := getQueryTags(session, uids...)
+		if err != nil {
+			return err
+		}
+		for i := range dtos {
+			dtos[i].Tags = tags[dtos[i].UID]
+		}
+
 		countBuilder := db.SQLBuilder{}
 		countBuilder.Write(`SELECT
 		`)
-		writeStarredSQL(query, s.store, &countBuilder, true)
+		writeStarredSQL(query, user, s.store, &countBuilder, true)
 		writeFiltersSQL(query, user, s.store, &countBuilder)
 		_, err = session.SQL(countBuilder.GetSQLString(), countBuilder.GetParams()...).Get(&totalCount)
 		return err
@@ -160,6 +167,11 @@ func (s QueryHistoryService) deleteQuery(ctx context.Context, user *user.SignedI
 			return ErrQueryNotFound
 		}
 
+		_, err = session.Table("query_history_tag").Where("query_uid = ?", UID).Delete(QueryHistoryTag{})
+		if err != nil {
+			return err
+		}
+
 		queryID = id
 		return nil
 	})
@@ -171,6 +183,7 @@ func (s QueryHistoryService) deleteQuery(ctx context.Context, user *user.SignedI
 func (s QueryHistoryService) patchQueryComment(ctx context.Context, user *user.SignedInUser, UID string, cmd PatchQueryCommentInQueryHistoryCommand) (QueryHistoryDTO, error) {
 	var queryHistory QueryHistory
 	var isStarred bool
+	var tags []string
 
 	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
 		exists, err := session.Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgID, user.UserID, UID).Get(&queryHistory)
@@ -192,34 +205,28 @@ func (s QueryHistoryService) patchQueryComment(ctx context.Context, user *user.S
 			return err
 		}
 		isStarred = starred
-		return nil
+
+		queryTags, err := getQueryTags(session, UID)
+		tags = queryTags[UID]
+		return err
 	})
 
 	if err != nil {
 		return QueryHistoryDTO{}, err
 	}
 
-	dto := QueryHistoryDTO{
-		UID:           queryHistory.UID,
-		DatasourceUID: queryHistory.DatasourceUID,
-		CreatedBy:     queryHistory.CreatedBy,
-		CreatedAt:     queryHistory.CreatedAt,
-		Comment:       queryHistory.Comment,
-		Queries:       queryHistory.Queries,
-		Starred:       isStarred,
-	}
-
-	return dto, nil
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
 }
 
 // starQuery adds query into query_history_star table together with user_id and org_id
 func (s QueryHistoryService) starQuery(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error) {
 	var queryHistory QueryHistory
 	var isStarred bool
+	var tags []string
 
 	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
-		// Check if query exists as we want to star only existing queries
-		exists, err := session.Table("query_history").Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgID, user.UserID, UID).Get(&queryHistory)
+		// Check if query exists as we want to star only existing queries, shared queries can be starred as well
+		exists, err := getVisibleQuery(session, user, UID, &queryHistory)
 		if err != nil {
 			return err
 		}
@@ -242,33 +249,26 @@ func (s QueryHistoryService) starQuery(ctx context.Context, user *user.SignedInU
 		}
 
 		isStarred = true
-		return nil
+		queryTags, err := getQueryTags(session, UID)
+		tags = queryTags[UID]
+		return err
 	})
 
 	if err != nil {
 		return QueryHistoryDTO{}, err
 	}
 
-	dto := QueryHistoryDTO{
-		UID:           queryHistory.UID,
-		DatasourceUID: queryHistory.DatasourceUID,
-		CreatedBy:     queryHistory.CreatedBy,
-		CreatedAt:     queryHistory.CreatedAt,
-		Comment:       queryHistory.Comment,
-		Queries:       queryHistory.Queries,
-		Starred:       isStarred,
-	}
-
-	return dto, nil
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
 }
 
 // unstarQuery deletes query with with user_id and org_id from query_history_star table
 func (s QueryHistoryService) unstarQuery(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error) {
 	var queryHistory QueryHistory
 	var isStarred bool
+	var tags []string
 
 	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
-		exists, err := session.Table("query_history").Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgID, user.UserID, UID).Get(&queryHistory)
+		exists, err := getVisibleQuery(session, user, UID, &queryHistory)
 		if err != nil {
 			return err
 		}
@@ -285,28 +285,23 @@ func (s QueryHistoryService) unstarQuery(ctx context.Context, user *user.SignedI
 		}
 
 		isStarred = false
-		return nil
+		queryTags, err := getQueryTags(session, UID)
+		tags = queryTags[UID]
+		return err
 	})
 
 	if err != nil {
 		return QueryHistoryDTO{}, err
 	}
 
-	dto := QueryHistoryDTO{
-		UID:           queryHistory.UID,
-		DatasourceUID: queryHistory.DatasourceUID,
-		CreatedBy:     queryHistory.CreatedBy,
-		CreatedAt:     queryHistory.CreatedAt,
-		Comment:       queryHistory.Comment,
-		Queries:       queryHistory.Queries,
-		Starred:       isStarred,
-	}
-
-	return dto, nil
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
 }
 
+// deleteStaleQueries deletes the queries created before olderThan which are not starred. The shared queries are kept for
+// sharedQueriesMaxAge at least, as they are used by other users.
 func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan int64) (int, error) {
 	var rowsCount int64
+	sharedOlderThan := min(olderThan, s.now().Add(-sharedQueriesMaxAge).Unix())
 
 	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
 		uids_sql := `SELECT uid FROM (
@@ -314,7 +309,7 @@ func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan i
 			LEFT JOIN query_history_star
 			ON query_history_star.query_uid = query_history.uid
 			WHERE query_history_star.query_uid IS NULL
-			AND query_history.created_at <= ?
+			AND ((query_history.shared_with = '' AND query_history.created_at <= ?) OR query_history.created_at <= ?)
 			ORDER BY query_history.id ASC
 			LIMIT 10000
 		) AS q`
@@ -323,16 +318,25 @@ func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan i
 			FROM query_history_details
 			WHERE query_history_item_uid IN (` + uids_sql + `)`
 
+		tags_sql := `DELETE
+			FROM query_history_tag
+			WHERE query_uid IN (` + uids_sql + `)`
+
 		sql := `DELETE
 			FROM query_history
 			WHERE uid IN (` + uids_sql + `)`
 
-		_, err := session.Exec(details_sql, strconv.FormatInt(olderThan, 10))
+		_, err := session.Exec(details_sql, strconv.FormatInt(olderThan, 10), strconv.FormatInt(sharedOlderThan, 10))
+		if err != nil {
+			return err
+		}
+
+		_, err = session.Exec(tags_sql, strconv.FormatInt(olderThan, 10), strconv.FormatInt(sharedOlderThan, 10))
 		if err != nil {
 			return err
 		}
 
-		res, err := session.Exec(sql, strconv.FormatInt(olderThan, 10))
+		res, err := session.Exec(sql, strconv.FormatInt(olderThan, 10), strconv.FormatInt(sharedOlderThan, 10))
 		if err != nil {
 			return err
 		}
@@ -352,7 +356,8 @@ func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan i
 	return int(rowsCount), nil
 }
 
-// enforceQueryHistoryRowLimit is run in scheduled cleanup and it removes queries and stars that exceeded limit
+// enforceQueryHistoryRowLimit is run in scheduled cleanup and it removes queries and stars that exceeded limit. The
+// shared queries are removed after the other unstarred queries.
 func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, limit int, starredQueries bool) (int, error) {
 	var deletedRowsCount int64
 
@@ -390,7 +395,7 @@ func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, li
 							LEFT JOIN query_history_star
 							ON query_history_star.query_uid = query_history.uid
 							WHERE query_history_star.query_uid IS NULL
-							ORDER BY query_history.id ASC
+							ORDER BY CASE WHEN query_history.shared_with = '' THEN 0 ELSE 1 END, query_history.id ASC
 							LIMIT ?
 						) AS q
 					)`
@@ -419,3 +424,292 @@ func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, li
 
 	return int(deletedRowsCount), nil
 }
+
+// updateQueryTags replaces the tags of a query in query history
+func (s QueryHistoryService) updateQueryTags(ctx context.Context, user *user.SignedInUser, UID string, cmd UpdateQueryTagsInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	var queryHistory QueryHistory
+	var isStarred bool
+
+	tags, err := normalizeTags(cmd.Tags)
+	if err != nil {
+		return QueryHistoryDTO{}, err
+	}
+
+	err = s.store.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		exists, err := session.Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgID, user.UserID, UID).Get(&queryHistory)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrQueryNotFound
+		}
+
+		if err := insertQueryTags(session, user.OrgID, UID, tags, true); err != nil {
+			return err
+		}
+
+		isStarred, err = session.Table("query_history_star").Where("user_id = ? AND query_uid = ?", user.UserID, UID).Exist()
+		return err
+	})
+
+	if err != nil {
+		return QueryHistoryDTO{}, err
+	}
+
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
+}
+
+// shareQuery shares a query in query history with a team of the user or the organization
+func (s QueryHistoryService) shareQuery(ctx context.Context, user *user.SignedInUser, UID string, cmd ShareQueryInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	var queryHistory QueryHistory
+	var isStarred bool
+	var tags []string
+
+	switch cmd.SharedWith {
+	case SharedWithNone, SharedWithOrg:
+		cmd.TeamID = 0
+	case SharedWithTeam:
+		if !slices.Contains(user.Teams, cmd.TeamID) {
+			return QueryHistoryDTO{}, ErrNotTeamMember
+		}
+	default:
+		return QueryHistoryDTO{}, ErrInvalidShare
+	}
+
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		exists, err := session.Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgID, user.UserID, UID).Get(&queryHistory)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrQueryNotFound
+		}
+
+		queryHistory.SharedWith = cmd.SharedWith
+		queryHistory.SharedTeamID = cmd.TeamID
+		_, err = session.ID(queryHistory.ID).Cols("shared_with", "shared_team_id").Update(&queryHistory)
+		if err != nil {
+			return err
+		}
+
+		isStarred, err = session.Table("query_history_star").Where("user_id = ? AND query_uid = ?", user.UserID, UID).Exist()
+		if err != nil {
+			return err
+		}
+
+		queryTags, err := getQueryTags(session, UID)
+		tags = queryTags[UID]
+		return err
+	})
+
+	if err != nil {
+		return QueryHistoryDTO{}, err
+	}
+
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
+}
+
+// useQuery increments the number of times a query of the user or a shared query was used
+func (s QueryHistoryService) useQuery(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	var queryHistory QueryHistory
+	var isStarred bool
+	var tags []string
+
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		exists, err := getVisibleQuery(session, user, UID, &queryHistory)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrQueryNotFound
+		}
+
+		_, err = session.Exec("UPDATE query_history SET use_count = use_count + 1 WHERE id = ?", queryHistory.ID)
+		if err != nil {
+			return err
+		}
+		queryHistory.UseCount++
+
+		isStarred, err = session.Table("query_history_star").Where("user_id = ? AND query_uid = ?", user.UserID, UID).Exist()
+		if err != nil {
+			return err
+		}
+
+		queryTags, err := getQueryTags(session, UID)
+		tags = queryTags[UID]
+		return err
+	})
+
+	if err != nil {
+		return QueryHistoryDTO{}, err
+	}
+
+	return newQueryHistoryDTO(queryHistory, isStarred, tags), nil
+}
+
+// exportQueries returns all queries of the user in query history
+func (s QueryHistoryService) exportQueries(ctx context.Context, user *user.SignedInUser) (QueryHistoryExport, error) {
+	export := QueryHistoryExport{Queries: []QueryHistoryDTO{}}
+
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		var queries []QueryHistory
+		err := session.Where("org_id = ? AND created_by = ?", user.OrgID, user.UserID).OrderBy("created_at ASC").Find(&queries)
+		if err != nil {
+			return err
+		}
+
+		var starredUIDs []string
+		err = session.Table("query_history_star").Where("user_id = ?", user.UserID).Cols("query_uid").Find(&starredUIDs)
+		if err != nil {
+			return err
+		}
+
+		uids := make([]string, 0, len(queries))
+		for _, query := range queries {
+			uids = append(uids, query.UID)
+		}
+		tags, err := getQueryTags(session, uids...)
+		if err != nil {
+			return err
+		}
+
+		for _, query := range queries {
+			export.Queries = append(export.Queries, newQueryHistoryDTO(query, slices.Contains(starredUIDs, query.UID), tags[query.UID]))
+		}
+		return nil
+	})
+
+	return export, err
+}
+
+// importQueries adds exported queries into query history of the user. The queries get new UIDs
+// and are private, their creation time, comment, tags and star are kept.
+func (s QueryHistoryService) importQueries(ctx context.Context, user *user.SignedInUser, export QueryHistoryExport) (int, error) {
+	err := s.store.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		for _, query := range export.Queries {
+			if query.Queries == nil {
+				return ErrInvalidImport
+			}
+			tags, err := normalizeTags(query.Tags)
+			if err != nil {
+				return err
+			}
+
+			queryHistory := QueryHistory{
+				OrgID:         user.OrgID,
+				UID:           util.GenerateShortUID(),
+				Queries:       query.Queries,
+				DatasourceUID: query.DatasourceUID,
+				CreatedBy:     user.UserID,
+				CreatedAt:     query.CreatedAt,
+				Comment:       query.Comment,
+			}
+			if queryHistory.CreatedAt <= 0 {
+				queryHistory.CreatedAt = s.now().Unix()
+			}
+			if _, err := session.Insert(&queryHistory); err != nil {
+				return err
+			}
+
+			if dsUIDs, err := FindDataSourceUIDs(query.Queries); err == nil {
+				for _, uid := range dsUIDs {
+					if _, err := session.Insert(&QueryHistoryDetails{QueryHistoryItemUID: queryHistory.UID, DatasourceUID: uid}); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := insertQueryTags(session, user.OrgID, queryHistory.UID, tags, false); err != nil {
+				return err
+			}
+
+			if query.Starred {
+				if _, err := session.Insert(&QueryHistoryStar{UserID: user.UserID, QueryUID: queryHistory.UID}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(export.Queries), nil
+}
+
+func newQueryHistoryDTO(queryHistory QueryHistory, isStarred bool, tags []string) QueryHistoryDTO {
+	if tags == nil {
+		tags = []string{}
+	}
+	return QueryHistoryDTO{
+		UID:           queryHistory.UID,
+		DatasourceUID: queryHistory.DatasourceUID,
+		CreatedBy:     queryHistory.CreatedBy,
+		CreatedAt:     queryHistory.CreatedAt,
+		Comment:       queryHistory.Comment,
+		Queries:       queryHistory.Queries,
+		Starred:       isStarred,
+		Tags:          tags,
+		SharedWith:    queryHistory.SharedWith,
+		SharedTeamID:  queryHistory.SharedTeamID,
+		UseCount:      queryHistory.UseCount,
+	}
+}
+
+// getVisibleQuery gets a query of the user or a query shared with the user
+func getVisibleQuery(session *db.Session, user *user.SignedInUser, UID string, queryHistory *QueryHistory) (bool, error) {
+	visibleSQL, params := visibleQueriesSQL(SearchScopeAll, user)
+	params = append([]any{user.OrgID, UID}, params...)
+	return session.Table("query_history").Where("org_id = ? AND uid = ? AND "+visibleSQL, params...).Get(queryHistory)
+}
+
+// getQueryTags returns the tags of queries by their UIDs
+func getQueryTags(session *db.Session, uids ...string) (map[string][]string, error) {
+	tags := make(map[string][]string, len(uids))
+	if len(uids) == 0 {
+		return tags, nil
+	}
+	for _, uid := range uids {
+		tags[uid] = []string{}
+	}
+
+	var queryTags []QueryHistoryTag
+	if err := session.In("query_uid", uids).OrderBy("id ASC").Find(&queryTags); err != nil {
+		return nil, err
+	}
+	for _, tag := range queryTags {
+		tags[tag.QueryUID] = append(tags[tag.QueryUID], tag.Term)
+	}
+	return tags, nil
+}
+
+func insertQueryTags(session *db.Session, orgID int64, UID string, tags []string, replace bool) error {
+	if replace {
+		if _, err := session.Table("query_history_tag").Where("query_uid = ?", UID).Delete(QueryHistoryTag{}); err != nil {
+			return err
+		}
+	}
+	for _, tag := range tags {
+		if _, err := session.Insert(&QueryHistoryTag{OrgID: orgID, QueryUID: UID, Term: tag}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeTags trims tags and removes duplicates
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || len(tag) > 50 {
+			return nil, ErrInvalidTag
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
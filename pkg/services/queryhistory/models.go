@@ -2,6 +2,7 @@ package queryhistory
 
 import (
 	"errors"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
@@ -10,6 +11,30 @@ var (
 	ErrQueryNotFound        = errors.New("query in query history not found")
 	ErrStarredQueryNotFound = errors.New("starred query not found")
 	ErrQueryAlreadyStarred  = errors.New("query was already starred")
+	ErrInvalidShare         = errors.New("query can only be shared with a team or the organization")
+	ErrNotTeamMember        = errors.New("query can only be shared with teams the user is a member of")
+	ErrInvalidTag           = errors.New("tags must not be empty or longer than 50 characters")
+	ErrInvalidImport        = errors.New("imported queries must have a queries model")
+)
+
+// Who a query in query history is shared with, queries are private by default.
+const (
+	SharedWithNone = ""
+	SharedWithTeam = "team"
+	SharedWithOrg  = "org"
+)
+
+// sharedQueriesMaxAge is the minimum age of the shared queries deleted by the cleanup unless they are starred.
+const sharedQueriesMaxAge = 90 * 24 * time.Hour
+
+// Scopes of a query history search.
+const (
+	// SearchScopeOwn searches the queries of the user, this is the default.
+	SearchScopeOwn = "own"
+	// SearchScopeShared searches the queries shared with the teams of the user or the organization.
+	SearchScopeShared = "shared"
+	// SearchScopeAll searches both the queries of the user and the shared queries.
+	SearchScopeAll = "all"
 )
 
 // QueryHistory is the model for query history definitions
@@ -22,6 +47,9 @@ type QueryHistory struct {
 	CreatedAt     int64
 	Comment       string
 	Queries       *simplejson.Json
+	SharedWith    string
+	SharedTeamID  int64 `xorm:"shared_team_id"`
+	UseCount      int64
 }
 
 // QueryHistory is the model for query history star definitions
@@ -31,10 +59,20 @@ type QueryHistoryStar struct {
 	UserID   int64  `xorm:"user_id"`
 }
 
+// QueryHistoryTag is the model for query history tag definitions
+type QueryHistoryTag struct {
+	ID       int64  `xorm:"pk autoincr 'id'"`
+	OrgID    int64  `xorm:"org_id"`
+	QueryUID string `xorm:"query_uid"`
+	Term     string
+}
+
 type SearchInQueryHistoryQuery struct {
 	DatasourceUIDs []string `json:"datasourceUids"`
 	SearchString   string   `json:"searchString"`
 	OnlyStarred    bool     `json:"onlyStarred"`
+	Tags           []string `json:"tags"`
+	Scope          string   `json:"scope"`
 	Sort           string   `json:"sort"`
 	Page           int      `json:"page"`
 	Limit          int      `json:"limit"`
@@ -50,6 +88,10 @@ type QueryHistoryDTO struct {
 	Comment       string           `json:"comment"`
 	Queries       *simplejson.Json `json:"queries"`
 	Starred       bool             `json:"starred"`
+	Tags          []string         `json:"tags" xorm:"-"`
+	SharedWith    string           `json:"sharedWith"`
+	SharedTeamID  int64            `json:"sharedTeamId" xorm:"shared_team_id"`
+	UseCount      int64            `json:"useCount"`
 }
 
 // QueryHistoryResponse is a response struct for QueryHistoryDTO
@@ -91,3 +133,32 @@ type PatchQueryCommentInQueryHistoryCommand struct {
 	// Updated comment
 	Comment string `json:"comment"`
 }
+
+// UpdateQueryTagsInQueryHistoryCommand is the command for replacing the tags of a query in query history
+// swagger:model
+type UpdateQueryTagsInQueryHistoryCommand struct {
+	// Tags of the query, existing tags are replaced
+	Tags []string `json:"tags"`
+}
+
+// ShareQueryInQueryHistoryCommand is the command for sharing a query in query history
+// swagger:model
+type ShareQueryInQueryHistoryCommand struct {
+	// Who the query is shared with, empty makes the query private again
+	// Enum: ,team,org
+	SharedWith string `json:"sharedWith"`
+	// ID of the team the query is shared with, required when the query is shared with a team
+	TeamID int64 `json:"teamId"`
+}
+
+// QueryHistoryExport is the format query history is exported and imported in
+// swagger:model
+type QueryHistoryExport struct {
+	Queries []QueryHistoryDTO `json:"queries"`
+}
+
+// QueryHistoryImportResponse is the response struct for importing query history
+type QueryHistoryImportResponse struct {
+	Imported int    `json:"imported"`
+	Message  string `json:"message"`
+}
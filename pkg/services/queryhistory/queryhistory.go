@@ -12,7 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.RouteRegister, accessControl ac.AccessControl) *QueryHistoryService {
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.RouteRegister, accessControl ac.AccessControl, accessControlService ac.Service) (*QueryHistoryService, error) {
 	s := &QueryHistoryService{
 		store:         sqlStore,
 		Cfg:           cfg,
@@ -24,10 +24,13 @@ func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.Rout
 
 	// Register routes only when query history is enabled
 	if s.Cfg.QueryHistoryEnabled {
+		if err := declareFixedRoles(accessControlService); err != nil {
+			return nil, err
+		}
 		s.registerAPIEndpoints()
 	}
 
-	return s
+	return s, nil
 }
 
 type Service interface {
@@ -39,6 +42,11 @@ type Service interface {
 	UnstarQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error)
 	DeleteStaleQueriesInQueryHistory(ctx context.Context, olderThan int64) (int, error)
 	EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error)
+	UpdateQueryTagsInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string, cmd UpdateQueryTagsInQueryHistoryCommand) (QueryHistoryDTO, error)
+	ShareQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string, cmd ShareQueryInQueryHistoryCommand) (QueryHistoryDTO, error)
+	UseQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error)
+	ExportQueryHistory(ctx context.Context, user *user.SignedInUser) (QueryHistoryExport, error)
+	ImportQueryHistory(ctx context.Context, user *user.SignedInUser, export QueryHistoryExport) (int, error)
 }
 
 type QueryHistoryService struct {
@@ -81,3 +89,23 @@ func (s QueryHistoryService) DeleteStaleQueriesInQueryHistory(ctx context.Contex
 func (s QueryHistoryService) EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error) {
 	return s.enforceQueryHistoryRowLimit(ctx, limit, starredQueries)
 }
+
+func (s QueryHistoryService) UpdateQueryTagsInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string, cmd UpdateQueryTagsInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	return s.updateQueryTags(ctx, user, UID, cmd)
+}
+
+func (s QueryHistoryService) ShareQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string, cmd ShareQueryInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	return s.shareQuery(ctx, user, UID, cmd)
+}
+
+func (s QueryHistoryService) UseQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	return s.useQuery(ctx, user, UID)
+}
+
+func (s QueryHistoryService) ExportQueryHistory(ctx context.Context, user *user.SignedInUser) (QueryHistoryExport, error) {
+	return s.exportQueries(ctx, user)
+}
+
+func (s QueryHistoryService) ImportQueryHistory(ctx context.Context, user *user.SignedInUser, export QueryHistoryExport) (int, error) {
+	return s.importQueries(ctx, user, export)
+}
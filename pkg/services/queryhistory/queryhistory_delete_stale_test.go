@@ -3,6 +3,7 @@ package queryhistory
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -34,6 +35,25 @@ func TestIntegrationDeleteStaleQueryFromQueryHistory(t *testing.T) {
 			require.Equal(t, 0, rowsDeleted)
 		})
 
+	testScenarioWithQueryInQueryHistory(t, "Stale shared query history is kept longer",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithOrg})
+			validateAndUnMarshalResponse(t, sc.service.shareHandler(sc.reqContext))
+
+			olderThan := sc.service.now().Unix() + 60
+			rowsDeleted, err := sc.service.DeleteStaleQueriesInQueryHistory(context.Background(), olderThan)
+			require.NoError(t, err)
+			require.Equal(t, 0, rowsDeleted)
+
+			later := sc.service.now().Add(sharedQueriesMaxAge + time.Hour)
+			sc.service.now = func() time.Time { return later }
+			rowsDeleted, err = sc.service.DeleteStaleQueriesInQueryHistory(context.Background(), later.Unix())
+			require.NoError(t, err)
+			require.Equal(t, 1, rowsDeleted)
+		})
+
 	testScenarioWithQueryInQueryHistory(t, "Not stale query history is not deleted",
 		func(t *testing.T, sc scenarioContext) {
 			olderThan := sc.service.now().Unix() - 60
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func TestIntegrationEnforceRowLimitInQueryHistory(t *testing.T) {
@@ -28,6 +31,30 @@ func TestIntegrationEnforceRowLimitInQueryHistory(t *testing.T) {
 			require.Equal(t, 1, rowsDeleted)
 		})
 
+	testScenarioWithQueryInQueryHistory(t, "Enforce limit for shared queries in query_history after the other queries",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithOrg})
+			validateAndUnMarshalResponse(t, sc.service.shareHandler(sc.reqContext))
+
+			sc.reqContext.Req.Body = mockRequestBody(CreateQueryInQueryHistoryCommand{
+				DatasourceUID: testDsUID1,
+				Queries:       simplejson.NewFromAny([]any{map[string]any{"expr": "test2"}}),
+			})
+			validateAndUnMarshalResponse(t, sc.service.createHandler(sc.reqContext))
+
+			rowsDeleted, err := sc.service.EnforceRowLimitInQueryHistory(context.Background(), 1, false)
+			require.NoError(t, err)
+			require.Equal(t, 1, rowsDeleted)
+			sc.reqContext.SignedInUser.Permissions = queryPermissions(testDsUID1)
+			require.Equal(t, 1, searchSharedQueries(t, sc).TotalCount)
+
+			rowsDeleted, err = sc.service.EnforceRowLimitInQueryHistory(context.Background(), 0, false)
+			require.NoError(t, err)
+			require.Equal(t, 1, rowsDeleted)
+		})
+
 	// In this scenario we have 2 starred queries and 1 not starred query
 	testScenarioWithMultipleQueriesInQueryHistory(t, "Enforce limit for stars in query_history_star",
 		func(t *testing.T, sc scenarioContext) {
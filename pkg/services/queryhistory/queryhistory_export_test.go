@@ -0,0 +1,51 @@
+package queryhistory
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestIntegrationExportImportQueryHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	testScenarioWithMultipleQueriesInQueryHistory(t, "When user exports query history and another user imports it, queries should be copied",
+		func(t *testing.T, sc scenarioContext) {
+			resp := sc.service.exportHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var export QueryHistoryExport
+			err := json.Unmarshal(resp.Body(), &export)
+			require.NoError(t, err)
+			require.Len(t, export.Queries, 3)
+			require.Equal(t, "test comment 2", export.Queries[0].Comment)
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 2, OrgID: testOrgID}
+			sc.reqContext.Req.Body = mockRequestBody(export)
+			resp = sc.service.importHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var imported QueryHistoryImportResponse
+			err = json.Unmarshal(resp.Body(), &imported)
+			require.NoError(t, err)
+			require.Equal(t, 3, imported.Imported)
+
+			sc.reqContext.Req.Form.Add("onlyStarred", "true")
+			resp = sc.service.searchHandler(sc.reqContext)
+			var response QueryHistorySearchResponse
+			err = json.Unmarshal(resp.Body(), &response)
+			require.NoError(t, err)
+			require.Equal(t, 2, response.Result.TotalCount)
+			require.NotEqual(t, export.Queries[1].UID, response.Result.QueryHistory[0].UID)
+			require.Equal(t, export.Queries[2].CreatedAt, response.Result.QueryHistory[0].CreatedAt)
+		})
+
+	testScenario(t, "When user imports queries without a queries model, it should fail", false, false,
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.Req.Body = mockRequestBody(QueryHistoryExport{Queries: []QueryHistoryDTO{{Comment: "invalid"}}})
+			resp := sc.service.importHandler(sc.reqContext)
+			require.Equal(t, 400, resp.Status())
+		})
+}
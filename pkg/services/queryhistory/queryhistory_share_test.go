@@ -0,0 +1,120 @@
+package queryhistory
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestIntegrationShareQueryInQueryHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	testScenarioWithQueryInQueryHistory(t, "When user without permission tries to share query in query history, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithOrg})
+			resp := sc.service.shareHandler(sc.reqContext)
+			require.Equal(t, 403, resp.Status())
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user tries to share query with a team they are not a member of, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithTeam, TeamID: 2})
+			resp := sc.service.shareHandler(sc.reqContext)
+			require.Equal(t, 403, resp.Status())
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user shares query with a team, it should be visible to members of the team",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithTeam, TeamID: 1})
+			resp := sc.service.shareHandler(sc.reqContext)
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, SharedWithTeam, result.Result.SharedWith)
+			require.Equal(t, int64(1), result.Result.SharedTeamID)
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 2, OrgID: testOrgID, Teams: []int64{1}, Permissions: queryPermissions(testDsUID1)}
+			require.Equal(t, 1, searchSharedQueries(t, sc).TotalCount)
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 3, OrgID: testOrgID, Teams: []int64{2}, Permissions: queryPermissions(testDsUID1)}
+			require.Equal(t, 0, searchSharedQueries(t, sc).TotalCount)
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user shares query, it should only be visible to users who can query its data sources",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithOrg})
+			validateAndUnMarshalResponse(t, sc.service.shareHandler(sc.reqContext))
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 2, OrgID: testOrgID, Permissions: queryPermissions(testDsUID2)}
+			require.Equal(t, 0, searchSharedQueries(t, sc).TotalCount)
+			resp := sc.service.useHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 2, OrgID: testOrgID, Permissions: map[int64]map[string][]string{
+				testOrgID: {datasources.ActionQuery: {datasources.ScopeAll}},
+			}}
+			require.Equal(t, 1, searchSharedQueries(t, sc).TotalCount)
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user shares query with the organization, other users can star and use it",
+		func(t *testing.T, sc scenarioContext) {
+			allowSharing(sc)
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{SharedWith: SharedWithOrg})
+			validateAndUnMarshalResponse(t, sc.service.shareHandler(sc.reqContext))
+
+			sc.reqContext.SignedInUser = &user.SignedInUser{UserID: 2, OrgID: testOrgID, Permissions: queryPermissions(testDsUID1)}
+			result := searchSharedQueries(t, sc)
+			require.Equal(t, 1, result.TotalCount)
+			require.False(t, result.QueryHistory[0].Starred)
+
+			validateAndUnMarshalResponse(t, sc.service.starHandler(sc.reqContext))
+			used := validateAndUnMarshalResponse(t, sc.service.useHandler(sc.reqContext))
+			require.Equal(t, int64(1), used.Result.UseCount)
+			require.True(t, used.Result.Starred)
+
+			// Only the user who created the query can change it.
+			sc.reqContext.Req.Body = mockRequestBody(ShareQueryInQueryHistoryCommand{})
+			resp := sc.service.shareHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+}
+
+func allowSharing(sc scenarioContext) {
+	sc.reqContext.SignedInUser.Teams = []int64{1}
+	sc.reqContext.SignedInUser.Permissions = map[int64]map[string][]string{
+		testOrgID: {ActionShare: {}},
+	}
+}
+
+// queryPermissions returns the permissions of a user who can query the data sources.
+func queryPermissions(uids ...string) map[int64]map[string][]string {
+	scopes := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		scopes = append(scopes, datasources.ScopePrefix+uid)
+	}
+	return map[int64]map[string][]string{testOrgID: {datasources.ActionQuery: scopes}}
+}
+
+func searchSharedQueries(t *testing.T, sc scenarioContext) QueryHistorySearchResult {
+	t.Helper()
+
+	sc.reqContext.Req.Form.Set("scope", SearchScopeShared)
+	resp := sc.service.searchHandler(sc.reqContext)
+	require.Equal(t, 200, resp.Status())
+	var response QueryHistorySearchResponse
+	err := json.Unmarshal(resp.Body(), &response)
+	require.NoError(t, err)
+	return response.Result
+}
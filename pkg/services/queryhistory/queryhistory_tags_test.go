@@ -0,0 +1,79 @@
+package queryhistory
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestIntegrationUpdateQueryTagsInQueryHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	testScenarioWithQueryInQueryHistory(t, "When user tries to update tags of query in query history that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": "non-existent"})
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{"cpu"}})
+			resp := sc.service.updateTagsHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user tries to update tags of query in query history that exists, it should succeed",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{" cpu ", "node", "cpu"}})
+			resp := sc.service.updateTagsHandler(sc.reqContext)
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, []string{"cpu", "node"}, result.Result.Tags)
+
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{"memory"}})
+			resp = sc.service.updateTagsHandler(sc.reqContext)
+			result = validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, []string{"memory"}, result.Result.Tags)
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When user tries to add an empty tag, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{" "}})
+			resp := sc.service.updateTagsHandler(sc.reqContext)
+			require.Equal(t, 400, resp.Status())
+		})
+
+	testScenarioWithMultipleQueriesInQueryHistory(t, "When users tries to get queries with tags, it should return queries with all tags",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{"cpu", "node"}})
+			validateAndUnMarshalResponse(t, sc.service.updateTagsHandler(sc.reqContext))
+
+			sc.reqContext.Req.Form.Add("tag", "cpu")
+			sc.reqContext.Req.Form.Add("tag", "node")
+			resp := sc.service.searchHandler(sc.reqContext)
+			var response QueryHistorySearchResponse
+			err := json.Unmarshal(resp.Body(), &response)
+			require.NoError(t, err)
+			require.Equal(t, 200, resp.Status())
+			require.Equal(t, 1, response.Result.TotalCount)
+			require.Equal(t, sc.initialResult.Result.UID, response.Result.QueryHistory[0].UID)
+			require.Equal(t, []string{"cpu", "node"}, response.Result.QueryHistory[0].Tags)
+		})
+
+	testScenarioWithMultipleQueriesInQueryHistory(t, "When users tries to get queries with search string, it should match every word in queries, comments and tags",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.Req.Body = mockRequestBody(UpdateQueryTagsInQueryHistoryCommand{Tags: []string{"latency"}})
+			validateAndUnMarshalResponse(t, sc.service.updateTagsHandler(sc.reqContext))
+
+			sc.reqContext.Req.Form.Add("searchString", "latency comment")
+			resp := sc.service.searchHandler(sc.reqContext)
+			var response QueryHistorySearchResponse
+			err := json.Unmarshal(resp.Body(), &response)
+			require.NoError(t, err)
+			require.Equal(t, 200, resp.Status())
+			require.Equal(t, 1, response.Result.TotalCount)
+			require.Equal(t, sc.initialResult.Result.UID, response.Result.QueryHistory[0].UID)
+		})
+}
@@ -2,13 +2,19 @@ package queryhistory
 
 import (
 	"bytes"
+	"sort"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
-func writeStarredSQL(query SearchInQueryHistoryQuery, sqlStore db.DB, builder *db.SQLBuilder, isCount bool) {
+// mixedDatasourceUID is the data source of the queries using several data sources, which are in query_history_details.
+const mixedDatasourceUID = "-- Mixed --"
+
+func writeStarredSQL(query SearchInQueryHistoryQuery, user *user.SignedInUser, sqlStore db.DB, builder *db.SQLBuilder, isCount bool) {
 	var sql bytes.Buffer
 	if isCount {
 		sql.WriteString(`COUNT(`)
@@ -29,14 +35,29 @@ func writeStarredSQL(query SearchInQueryHistoryQuery, sqlStore db.DB, builder *d
 		sql.WriteString(`LEFT`)
 	}
 
-	sql.WriteString(` JOIN query_history_star ON query_history_star.query_uid = query_history.uid `)
-	builder.Write(sql.String())
+	// Shared queries can be starred by other users as well.
+	sql.WriteString(` JOIN query_history_star ON query_history_star.query_uid = query_history.uid AND query_history_star.user_id = ? `)
+	builder.Write(sql.String(), user.UserID)
 }
 
 func writeFiltersSQL(query SearchInQueryHistoryQuery, user *user.SignedInUser, sqlStore db.DB, builder *db.SQLBuilder) {
-	params := []any{user.OrgID, user.UserID, query.From, query.To, "%" + query.SearchString + "%", "%" + query.SearchString + "%"}
+	visibleSQL, params := visibleQueriesSQL(query.Scope, user)
+	params = append([]any{user.OrgID}, params...)
+	params = append(params, query.From, query.To)
 	var sql bytes.Buffer
-	sql.WriteString(" WHERE query_history.org_id = ? AND query_history.created_by = ? AND query_history.created_at >= ? AND query_history.created_at <= ? AND (query_history.queries " + sqlStore.GetDialect().LikeStr() + " ? OR query_history.comment " + sqlStore.GetDialect().LikeStr() + " ?) ")
+	sql.WriteString(" WHERE query_history.org_id = ? AND " + visibleSQL + " AND query_history.created_at >= ? AND query_history.created_at <= ? ")
+
+	// Every word of the search string has to be found in the queries, the comment or the tags.
+	for _, term := range strings.Fields(query.SearchString) {
+		sql.WriteString(" AND (query_history.queries " + sqlStore.GetDialect().LikeStr() + " ? OR query_history.comment " + sqlStore.GetDialect().LikeStr() + " ?")
+		sql.WriteString(" OR query_history.uid IN (SELECT t.query_uid FROM query_history_tag t WHERE t.term " + sqlStore.GetDialect().LikeStr() + " ?)) ")
+		params = append(params, "%"+term+"%", "%"+term+"%", "%"+term+"%")
+	}
+
+	for _, tag := range query.Tags {
+		sql.WriteString(" AND query_history.uid IN (SELECT t.query_uid FROM query_history_tag t WHERE t.org_id = ? AND t.term = ?) ")
+		params = append(params, user.OrgID, tag)
+	}
 
 	if len(query.DatasourceUIDs) > 0 {
 		q := "?" + strings.Repeat(",?", len(query.DatasourceUIDs)-1)
@@ -55,10 +76,69 @@ func writeFiltersSQL(query SearchInQueryHistoryQuery, user *user.SignedInUser, s
 	builder.Write(sql.String(), params...)
 }
 
+// visibleQueriesSQL returns the condition for the queries of a search scope the user can see.
+func visibleQueriesSQL(scope string, user *user.SignedInUser) (string, []any) {
+	own := "query_history.created_by = ?"
+	shared := "query_history.shared_with = '" + SharedWithOrg + "'"
+	var params []any
+	if len(user.Teams) > 0 {
+		shared = "(" + shared + " OR (query_history.shared_with = '" + SharedWithTeam + "' AND query_history.shared_team_id IN (?" + strings.Repeat(",?", len(user.Teams)-1) + ")))"
+		for _, teamID := range user.Teams {
+			params = append(params, teamID)
+		}
+	}
+	if datasourcesSQL, datasourcesParams := queryableDatasourcesSQL(user); datasourcesSQL != "" {
+		shared = "(" + shared + " AND " + datasourcesSQL + ")"
+		params = append(params, datasourcesParams...)
+	}
+
+	switch scope {
+	case SearchScopeShared:
+		return shared, params
+	case SearchScopeAll:
+		return "(" + own + " OR " + shared + ")", append([]any{user.UserID}, params...)
+	default:
+		return own, []any{user.UserID}
+	}
+}
+
+// queryableDatasourcesSQL returns the condition for the queries whose data sources can all be queried by the user, or
+// an empty string if the user can query every data source.
+func queryableDatasourcesSQL(user *user.SignedInUser) (string, []any) {
+	uids, hasWildcard := ac.ParseScopes(datasources.ScopePrefix, user.GetPermissions()[datasources.ActionQuery])
+	if hasWildcard {
+		return "", nil
+	}
+	if len(uids) == 0 {
+		return "1 = 0", nil
+	}
+
+	sorted := make([]string, 0, len(uids))
+	for uid := range uids {
+		sorted = append(sorted, uid.(string))
+	}
+	sort.Strings(sorted)
+	var params []any
+	for _, uid := range sorted {
+		params = append(params, uid)
+	}
+	for _, uid := range sorted {
+		params = append(params, uid)
+	}
+
+	in := "(?" + strings.Repeat(",?", len(sorted)-1) + ")"
+	return "(query_history.datasource_uid IN " + in + " OR query_history.datasource_uid = '" + mixedDatasourceUID + "')" +
+		" AND NOT EXISTS (SELECT 1 FROM query_history_details d WHERE d.query_history_item_uid = query_history.uid" +
+		" AND d.datasource_uid <> '' AND d.datasource_uid NOT IN " + in + ")", params
+}
+
 func writeSortSQL(query SearchInQueryHistoryQuery, sqlStore db.DB, builder *db.SQLBuilder) {
-	if query.Sort == "time-asc" {
+	switch query.Sort {
+	case "time-asc":
 		builder.Write(" ORDER BY created_at ASC ")
-	} else {
+	case "use-desc":
+		builder.Write(" ORDER BY use_count DESC, created_at DESC ")
+	default:
 		builder.Write(" ORDER BY created_at DESC ")
 	}
 }
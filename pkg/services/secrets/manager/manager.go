@@ -457,6 +457,11 @@ func (s *SecretsService) GetProviders() map[secrets.ProviderID]secrets.Provider
 	return s.providers
 }
 
+// CurrentProviderID returns the identifier of the provider new data keys are encrypted with.
+func (s *SecretsService) CurrentProviderID() secrets.ProviderID {
+	return s.currentProviderID
+}
+
 func (s *SecretsService) RotateDataKeys(ctx context.Context) error {
 	s.log.Info("Data keys rotation triggered, acquiring lock...")
 
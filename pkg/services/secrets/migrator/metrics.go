@@ -0,0 +1,44 @@
+package migrator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/metrics/metricutil"
+)
+
+var (
+	rotationInProgress = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "encryption_rotation_in_progress",
+			Help:      "Whether a scheduled data keys rotation is running on this instance",
+		},
+	)
+	rotationLastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "encryption_rotation_last_success_timestamp_seconds",
+			Help:      "Timestamp of the last scheduled data keys rotation which re-encrypted all the secrets",
+		},
+	)
+	rotationReEncryptions = metricutil.NewCounterVecStartingAtZero(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "encryption_rotation_reencryptions_total",
+			Help:      "A counter for secret types re-encrypted by the scheduled data keys rotation",
+		},
+		[]string{"success"},
+		map[string][]string{
+			"success": {"true", "false"},
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		rotationInProgress,
+		rotationLastSuccess,
+		rotationReEncryptions,
+	)
+}
@@ -6,9 +6,13 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/kmsproviders"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -24,6 +28,8 @@ type SecretsMigrator struct {
 	sqlStore      db.DB
 	settings      setting.Provider
 	features      featuremgmt.FeatureToggles
+	serverLock    *serverlock.ServerLockService
+	rotationStore *kvstore.NamespacedKVStore
 	rotators      []SecretsRotator
 }
 
@@ -33,6 +39,8 @@ func ProvideSecretsMigrator(
 	sqlStore db.DB,
 	settings setting.Provider,
 	features featuremgmt.FeatureToggles,
+	serverLock *serverlock.ServerLockService,
+	kvStore kvstore.KVStore,
 ) *SecretsMigrator {
 	rotators := []SecretsRotator{
 		simpleSecret{tableName: "dashboard_snapshot", columnName: "dashboard_encrypted"},
@@ -59,6 +67,8 @@ func ProvideSecretsMigrator(
 		sqlStore:      sqlStore,
 		settings:      settings,
 		features:      features,
+		serverLock:    serverLock,
+		rotationStore: kvstore.WithNamespace(kvStore, 0, rotationNamespace),
 		rotators:      rotators,
 	}
 }
@@ -119,6 +129,43 @@ func (m *SecretsMigrator) RollBackSecrets(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// ReEncryptDataKeys re-encrypts the data keys with the current encryption provider. To move to a new provider
+// without downtime, both providers are configured with the new one as the encryption_provider: new data keys
+// are encrypted with the new provider while the existing ones are still decrypted with the previous one until
+// they are re-encrypted. The returned status lists the data keys still encrypted with another provider, in which
+// case the previous provider must stay configured.
+func (m *SecretsMigrator) ReEncryptDataKeys(ctx context.Context) (secrets.DataKeysReEncryptionStatus, error) {
+	status := secrets.DataKeysReEncryptionStatus{Provider: m.secretsSrv.CurrentProviderID(), Remaining: []secrets.RemainingDataKey{}}
+	if err := m.secretsSrv.ReEncryptDataKeys(ctx); err != nil {
+		return status, err
+	}
+
+	var keys []struct {
+		Name     string
+		Provider string
+	}
+	if err := m.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("data_keys").Cols("name", "provider").Find(&keys)
+	}); err != nil {
+		return status, err
+	}
+
+	for _, k := range keys {
+		if kmsproviders.NormalizeProviderID(secrets.ProviderID(k.Provider)) != status.Provider {
+			logger.Warn("Data key is still encrypted with a previous provider", "id", k.Name, "provider", k.Provider)
+			status.Remaining = append(status.Remaining, secrets.RemainingDataKey{Name: k.Name, Provider: k.Provider})
+		}
+	}
+
+	if len(status.Remaining) > 0 {
+		logger.Warn("Some data keys could not be re-encrypted, the previous providers must stay configured", "count", len(status.Remaining))
+		return status, nil
+	}
+
+	logger.Info("Data keys have been re-encrypted with the current provider", "provider", status.Provider)
+	return status, nil
+}
+
 func (m *SecretsMigrator) initProvidersIfNeeded() error {
 	if m.features.IsEnabledGlobally(featuremgmt.FlagDisableEnvelopeEncryption) {
 		logger.Info("Envelope encryption is not enabled but trying to init providers anyway...")
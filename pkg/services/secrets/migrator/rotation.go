@@ -0,0 +1,256 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+)
+
+const (
+	rotationNamespace  = "secrets.rotation"
+	rotationStatusKey  = "status"
+	rotationActionName = "secrets data keys rotation"
+	// rotationCheckInterval is how often the age of the active data keys is checked.
+	rotationCheckInterval = time.Hour
+	// rotationLockTimeout is the time after which the lock of an instance which stopped during a rotation
+	// is released, so that another instance resumes it. The lock is not refreshed during the rotation, so
+	// the timeout is much longer than a rotation can take, to never run two rotations at the same time.
+	rotationLockTimeout = 24 * time.Hour
+)
+
+// rotationInterval returns the maximum age of the active data keys, or zero if the scheduled rotation is disabled.
+func (m *SecretsMigrator) rotationInterval() time.Duration {
+	value := m.settings.KeyValue("security.encryption", "data_keys_rotation_interval").MustString("0")
+	interval, err := gtime.ParseDuration(value)
+	if err != nil {
+		logger.Warn("Invalid data keys rotation interval, disabling the scheduled rotation", "value", value, "error", err)
+		return 0
+	}
+	return interval
+}
+
+// IsDisabled returns true if the scheduled rotation of data keys is not configured.
+func (m *SecretsMigrator) IsDisabled() bool {
+	return m.features.IsEnabledGlobally(featuremgmt.FlagDisableEnvelopeEncryption) || m.rotationInterval() <= 0
+}
+
+// Run rotates the data keys once the active ones are older than the rotation interval, and re-encrypts
+// the secrets with the new data keys. A rotation interrupted by a restart is resumed on startup.
+func (m *SecretsMigrator) Run(ctx context.Context) error {
+	m.rotateIfDue(ctx)
+
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.rotateIfDue(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *SecretsMigrator) rotateIfDue(ctx context.Context) {
+	// the lock is also released when stopping during a rotation, so that the rotation is resumed on the next start
+	// instead of after the lock timeout
+	err := m.serverLock.LockExecuteAndRelease(context.WithoutCancel(ctx), rotationActionName, rotationLockTimeout, func(context.Context) {
+		status, err := m.loadRotationStatus(ctx)
+		if err != nil {
+			logger.Error("Failed to load the data keys rotation status", "error", err)
+			return
+		}
+
+		if status.State == secrets.RotationStateRunning {
+			logger.Info("Resuming interrupted data keys rotation", "started", status.StartedAt, "completed", len(status.Completed))
+		} else {
+			oldest, err := m.oldestActiveDataKey(ctx)
+			if err != nil {
+				logger.Error("Failed to find the age of the active data keys", "error", err)
+				return
+			}
+
+			switch {
+			case oldest != nil && time.Since(*oldest) >= m.rotationInterval():
+				now := time.Now()
+				status = secrets.RotationStatus{State: secrets.RotationStateRunning, StartedAt: &now, Completed: []string{}, Failed: []string{}}
+			case status.State == secrets.RotationStateFailed && status.DataKeysRotated && len(status.Failed) > 0:
+				// the data keys are already rotated, only the secrets which could not be re-encrypted are retried
+				logger.Info("Retrying the re-encryption of the failed data keys rotation", "started", status.StartedAt, "failed", status.Failed)
+				status.State = secrets.RotationStateRunning
+				status.Failed = []string{}
+				status.FinishedAt = nil
+				status.Error = ""
+			default:
+				return
+			}
+		}
+
+		m.rotate(ctx, status)
+	})
+
+	var lockExists *serverlock.ServerLockExistsError
+	if errors.As(err, &lockExists) {
+		logger.Debug("Data keys rotation is running on another instance")
+	} else if err != nil {
+		logger.Error("Failed to acquire the data keys rotation lock", "error", err)
+	}
+}
+
+func (m *SecretsMigrator) rotate(ctx context.Context, status secrets.RotationStatus) {
+	rotationInProgress.Set(1)
+	defer rotationInProgress.Set(0)
+
+	status.Total = len(m.rotators)
+	if err := m.saveRotationStatus(ctx, status); err != nil {
+		logger.Error("Failed to save the data keys rotation status", "error", err)
+		return
+	}
+
+	if !status.DataKeysRotated {
+		if err := m.secretsSrv.RotateDataKeys(ctx); err != nil {
+			m.finishRotation(ctx, status, err)
+			return
+		}
+		status.DataKeysRotated = true
+		if err := m.saveRotationStatus(ctx, status); err != nil {
+			logger.Error("Failed to save the data keys rotation status", "error", err)
+			return
+		}
+	}
+
+	// Secrets which are not re-encrypted in batch are re-encrypted with the new data keys the next time they are updated,
+	// the disabled data keys are still used to decrypt them in the meantime.
+	if m.settings.KeyValue("security.encryption", "data_keys_rotation_reencrypt_secrets").MustBool(true) {
+		for _, r := range m.rotators {
+			name := rotatorName(r)
+			if slices.Contains(status.Completed, name) || slices.Contains(status.Failed, name) {
+				continue
+			}
+			// stopping, the rotation is resumed on the next start
+			if ctx.Err() != nil {
+				return
+			}
+
+			if r.ReEncrypt(ctx, m.secretsSrv, m.sqlStore) {
+				status.Completed = append(status.Completed, name)
+				rotationReEncryptions.WithLabelValues("true").Inc()
+			} else {
+				status.Failed = append(status.Failed, name)
+				rotationReEncryptions.WithLabelValues("false").Inc()
+			}
+
+			if err := m.saveRotationStatus(ctx, status); err != nil {
+				logger.Error("Failed to save the data keys rotation status", "error", err)
+				return
+			}
+		}
+	}
+
+	m.finishRotation(ctx, status, nil)
+}
+
+func (m *SecretsMigrator) finishRotation(ctx context.Context, status secrets.RotationStatus, err error) {
+	now := time.Now()
+	status.FinishedAt = &now
+	status.State = secrets.RotationStateCompleted
+
+	switch {
+	case err != nil:
+		logger.Error("Data keys rotation failed", "error", err)
+		status.State = secrets.RotationStateFailed
+		status.Error = err.Error()
+	case len(status.Failed) > 0:
+		logger.Warn("Data keys rotated, but some secrets could not be re-encrypted", "failed", status.Failed)
+		status.State = secrets.RotationStateFailed
+	default:
+		logger.Info("Data keys rotated and secrets re-encrypted successfully", "duration", now.Sub(*status.StartedAt))
+		rotationLastSuccess.SetToCurrentTime()
+	}
+
+	if err := m.saveRotationStatus(ctx, status); err != nil {
+		logger.Error("Failed to save the data keys rotation status", "error", err)
+	}
+}
+
+// RotationStatus returns the progress of the last scheduled rotation, with the time of the next one.
+func (m *SecretsMigrator) RotationStatus(ctx context.Context) (secrets.RotationStatus, error) {
+	status, err := m.loadRotationStatus(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	if interval := m.rotationInterval(); interval > 0 && status.State != secrets.RotationStateRunning {
+		oldest, err := m.oldestActiveDataKey(ctx)
+		if err != nil {
+			return status, err
+		}
+		if oldest != nil {
+			next := oldest.Add(interval)
+			status.NextRotation = &next
+		}
+	}
+
+	return status, nil
+}
+
+func (m *SecretsMigrator) loadRotationStatus(ctx context.Context) (secrets.RotationStatus, error) {
+	status := secrets.RotationStatus{State: secrets.RotationStateIdle, Completed: []string{}, Failed: []string{}}
+
+	value, found, err := m.rotationStore.Get(ctx, rotationStatusKey)
+	if err != nil || !found {
+		return status, err
+	}
+
+	err = json.Unmarshal([]byte(value), &status)
+	return status, err
+}
+
+func (m *SecretsMigrator) saveRotationStatus(ctx context.Context, status secrets.RotationStatus) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return m.rotationStore.Set(ctx, rotationStatusKey, string(value))
+}
+
+// oldestActiveDataKey returns the creation time of the oldest data key used for encryption, if any.
+func (m *SecretsMigrator) oldestActiveDataKey(ctx context.Context) (*time.Time, error) {
+	var keys []secrets.DataKey
+	err := m.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("data_keys").Where("active = ?", m.sqlStore.GetDialect().BooleanStr(true)).Asc("created").Limit(1).Find(&keys)
+	})
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return &keys[0].Created, nil
+}
+
+// rotatorName identifies the secrets re-encrypted by a rotator in the rotation status.
+func rotatorName(r SecretsRotator) string {
+	switch r := r.(type) {
+	case simpleSecret:
+		return r.tableName + "." + r.columnName
+	case b64Secret:
+		return r.tableName + "." + r.columnName
+	case jsonSecret:
+		return r.tableName + ".secure_json_data"
+	case alertingSecret:
+		return "alert_configuration"
+	case ssoSettingsSecret:
+		return "sso_setting"
+	default:
+		return reflect.TypeOf(r).String()
+	}
+}
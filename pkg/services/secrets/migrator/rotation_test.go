@@ -0,0 +1,175 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+type fakeRotator struct {
+	success bool
+	calls   *int
+}
+
+func (r fakeRotator) ReEncrypt(context.Context, *manager.SecretsService, db.DB) bool {
+	*r.calls++
+	return r.success
+}
+
+func (r fakeRotator) Rollback(context.Context, *manager.SecretsService, encryption.Internal, db.DB, string) bool {
+	return false
+}
+
+func setupRotation(t *testing.T, success bool) (*SecretsMigrator, *int) {
+	t.Helper()
+
+	sqlStore := db.InitTestDB(t)
+	raw, err := ini.Load([]byte(`
+		[security.encryption]
+		data_keys_rotation_interval = 1h`))
+	require.NoError(t, err)
+
+	m := ProvideSecretsMigrator(
+		nil,
+		manager.SetupTestService(t, database.ProvideSecretsStore(sqlStore)),
+		sqlStore,
+		setting.ProvideProvider(&setting.Cfg{Raw: raw}),
+		featuremgmt.WithFeatures(),
+		serverlock.ProvideService(sqlStore, tracing.InitializeTracerForTest()),
+		kvstore.ProvideService(sqlStore),
+	)
+
+	calls := 0
+	m.rotators = []SecretsRotator{
+		simpleSecret{tableName: "dashboard_snapshot", columnName: "dashboard_encrypted"},
+		fakeRotator{success: success, calls: &calls},
+	}
+
+	// creates the active data key
+	_, err = m.secretsSrv.Encrypt(context.Background(), []byte("grafana"), secrets.WithoutScope())
+	require.NoError(t, err)
+
+	return m, &calls
+}
+
+func ageDataKeys(t *testing.T, m *SecretsMigrator, age time.Duration) {
+	t.Helper()
+	err := m.sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE data_keys SET created = ?", time.Now().Add(-age))
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestIntegrationDataKeysRotation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+
+	t.Run("does not rotate data keys younger than the interval", func(t *testing.T) {
+		m, calls := setupRotation(t, true)
+		require.False(t, m.IsDisabled())
+
+		m.rotateIfDue(ctx)
+
+		status, err := m.RotationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secrets.RotationStateIdle, status.State)
+		require.NotNil(t, status.NextRotation)
+		require.WithinDuration(t, time.Now().Add(time.Hour), *status.NextRotation, time.Minute)
+		require.Zero(t, *calls)
+	})
+
+	t.Run("rotates data keys older than the interval and re-encrypts the secrets", func(t *testing.T) {
+		m, calls := setupRotation(t, true)
+		ageDataKeys(t, m, 2*time.Hour)
+
+		m.rotateIfDue(ctx)
+
+		status, err := m.RotationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secrets.RotationStateCompleted, status.State)
+		require.True(t, status.DataKeysRotated)
+		require.Equal(t, 2, status.Total)
+		require.Equal(t, []string{"dashboard_snapshot.dashboard_encrypted", "migrator.fakeRotator"}, status.Completed)
+		require.NotNil(t, status.FinishedAt)
+		require.Equal(t, 1, *calls)
+
+		// the rotated data key is not used anymore
+		oldest, err := m.oldestActiveDataKey(ctx)
+		require.NoError(t, err)
+		require.Nil(t, oldest)
+	})
+
+	t.Run("resumes an interrupted rotation", func(t *testing.T) {
+		m, calls := setupRotation(t, true)
+		startedAt := time.Now().Add(-time.Minute)
+		require.NoError(t, m.saveRotationStatus(ctx, secrets.RotationStatus{
+			State:           secrets.RotationStateRunning,
+			StartedAt:       &startedAt,
+			DataKeysRotated: true,
+			Completed:       []string{"dashboard_snapshot.dashboard_encrypted"},
+		}))
+
+		m.rotateIfDue(ctx)
+
+		status, err := m.RotationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secrets.RotationStateCompleted, status.State)
+		require.Equal(t, []string{"dashboard_snapshot.dashboard_encrypted", "migrator.fakeRotator"}, status.Completed)
+		require.Equal(t, 1, *calls)
+
+		// the data keys were already rotated before the interruption
+		oldest, err := m.oldestActiveDataKey(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, oldest)
+	})
+
+	t.Run("reports the secrets which could not be re-encrypted", func(t *testing.T) {
+		m, _ := setupRotation(t, false)
+		ageDataKeys(t, m, 2*time.Hour)
+
+		m.rotateIfDue(ctx)
+
+		status, err := m.RotationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secrets.RotationStateFailed, status.State)
+		require.Equal(t, []string{"migrator.fakeRotator"}, status.Failed)
+	})
+
+	t.Run("retries the secrets which could not be re-encrypted", func(t *testing.T) {
+		m, calls := setupRotation(t, false)
+		ageDataKeys(t, m, 2*time.Hour)
+
+		m.rotateIfDue(ctx)
+		m.rotators[1] = fakeRotator{success: true, calls: calls}
+		m.rotateIfDue(ctx)
+
+		status, err := m.RotationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secrets.RotationStateCompleted, status.State)
+		require.Equal(t, []string{"dashboard_snapshot.dashboard_encrypted", "migrator.fakeRotator"}, status.Completed)
+		require.Empty(t, status.Failed)
+		require.Equal(t, 2, *calls)
+	})
+}
@@ -85,4 +85,11 @@ type Migrator interface {
 	// does not stop, but returns false as the first return (success or not)
 	// at the end of the process.
 	RollBackSecrets(ctx context.Context) (bool, error)
+	// ReEncryptDataKeys decrypts and re-encrypts the data keys with the current
+	// encryption provider. The returned status lists the data keys still
+	// encrypted with another provider at the end of the process.
+	ReEncryptDataKeys(ctx context.Context) (DataKeysReEncryptionStatus, error)
+	// RotationStatus returns the progress of the last scheduled data keys
+	// rotation, or the idle state if none has run yet.
+	RotationStatus(ctx context.Context) (RotationStatus, error)
 }
@@ -35,3 +35,41 @@ func WithScope(scope string) EncryptionOptions {
 		return scope
 	}
 }
+
+type RotationState string
+
+const (
+	RotationStateIdle      RotationState = "idle"
+	RotationStateRunning   RotationState = "running"
+	RotationStateCompleted RotationState = "completed"
+	RotationStateFailed    RotationState = "failed"
+)
+
+// RotationStatus is the progress of the scheduled rotation of the data keys and re-encryption of the secrets.
+// It is persisted after each step so that an interrupted rotation is resumed where it stopped.
+type RotationStatus struct {
+	State           RotationState `json:"state"`
+	StartedAt       *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time    `json:"finishedAt,omitempty"`
+	NextRotation    *time.Time    `json:"nextRotation,omitempty"`
+	DataKeysRotated bool          `json:"dataKeysRotated"`
+	// Total is the number of secret types to re-encrypt, Completed and Failed list the ones already processed.
+	Total     int      `json:"total"`
+	Completed []string `json:"completed"`
+	Failed    []string `json:"failed"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// DataKeysReEncryptionStatus is the result of the re-encryption of the data keys with the current encryption
+// provider. Remaining lists the data keys still encrypted with a previous provider, which must stay configured
+// until they are re-encrypted.
+type DataKeysReEncryptionStatus struct {
+	Provider  ProviderID         `json:"provider"`
+	Remaining []RemainingDataKey `json:"remaining"`
+}
+
+// RemainingDataKey is a data key still encrypted with a previous encryption provider.
+type RemainingDataKey struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+}
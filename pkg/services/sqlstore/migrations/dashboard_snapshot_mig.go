@@ -71,4 +71,23 @@ func addDashboardSnapshotMigrations(mg *Migrator) {
 
 	mg.AddMigration("Change dashboard_encrypted column to MEDIUMBLOB", NewRawSQLMigration("").
 		Mysql("ALTER TABLE dashboard_snapshot MODIFY dashboard_encrypted MEDIUMBLOB;"))
+
+	mg.AddMigration("Add storage column to dashboard_snapshot table", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "storage", Type: DB_NVarchar, Length: 20, Nullable: false, Default: "''",
+	}))
+
+	mg.AddMigration("Add size column to dashboard_snapshot table", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "size", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("Set size of existing dashboard snapshots", NewRawSQLMigration(
+		"UPDATE dashboard_snapshot SET size = LENGTH(dashboard_encrypted) WHERE dashboard_encrypted IS NOT NULL"))
+
+	mg.AddMigration("Add version column to dashboard_snapshot table", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "version", Type: DB_BigInt, Nullable: false, Default: "1",
+	}))
+
+	mg.AddMigration("Add index for org_id in dashboard_snapshot table", NewAddIndexMigration(snapshotV5, &Index{
+		Cols: []string{"org_id"},
+	}))
 }
@@ -133,6 +133,10 @@ func (oss *OSSMigrations) AddMigration(mg *Migrator) {
 
 	ualert.AddRuleMetadata(mg)
 
+	ualert.AddStateHistoryTables(mg)
+
+	ualert.AddRuleKeepFiringForAndQueryOffsetColumns(mg)
+
 	accesscontrol.AddOrphanedMigrations(mg)
 
 	accesscontrol.AddActionSetPermissionsMigrator(mg)
@@ -39,4 +39,33 @@ func addQueryHistoryMigrations(mg *Migrator) {
 		},
 	}
 	mg.AddMigration("create query_history_details table v1", NewAddTableMigration(queryHistoryDetailsV1))
+
+	mg.AddMigration("add column shared_with in query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "shared_with", Type: DB_NVarchar, Length: 10, Nullable: false, Default: "''",
+	}))
+	mg.AddMigration("add column shared_team_id in query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "shared_team_id", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column use_count in query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "use_count", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add index query_history.org_id-shared_with", NewAddIndexMigration(queryHistoryV1, &Index{
+		Cols: []string{"org_id", "shared_with"},
+	}))
+
+	queryHistoryTagV1 := Table{
+		Name: "query_history_tag",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "query_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "term", Type: DB_NVarchar, Length: 50, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"query_uid", "term"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "term"}},
+		},
+	}
+	mg.AddMigration("create query_history_tag table v1", NewAddTableMigration(queryHistoryTagV1))
+	addTableIndicesMigrations(mg, "v1", queryHistoryTagV1)
 }
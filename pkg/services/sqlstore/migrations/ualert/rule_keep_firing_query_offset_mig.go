@@ -0,0 +1,22 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddRuleKeepFiringForAndQueryOffsetColumns adds the keep_firing_for and query_offset columns to the alert_rule and alert_rule_version tables.
+func AddRuleKeepFiringForAndQueryOffsetColumns(mg *migrator.Migrator) {
+	for _, table := range []string{"alert_rule", "alert_rule_version"} {
+		mg.AddMigration("add keep_firing_for column to "+table+" table", migrator.NewAddColumnMigration(migrator.Table{Name: table}, &migrator.Column{
+			Name:     "keep_firing_for",
+			Type:     migrator.DB_BigInt, // BigInt, to match the for column.
+			Nullable: false,
+			Default:  "0",
+		}))
+
+		mg.AddMigration("add query_offset column to "+table+" table", migrator.NewAddColumnMigration(migrator.Table{Name: table}, &migrator.Column{
+			Name:     "query_offset",
+			Type:     migrator.DB_BigInt,
+			Nullable: false,
+			Default:  "0",
+		}))
+	}
+}
@@ -261,6 +261,9 @@ type Cfg struct {
 	// Auth proxy settings
 	AuthProxy AuthProxySettings
 
+	// Client certificate auth settings
+	AuthMTLS AuthMTLSSettings
+
 	// OAuth
 	OAuthAutoLogin                       bool
 	OAuthLoginErrorMessage               string
@@ -350,6 +353,8 @@ type Cfg struct {
 	ExternalSnapshotUrl  string
 	ExternalSnapshotName string
 	ExternalEnabled      bool
+	// CDK bucket URL the snapshot payloads are stored in, payloads are stored in the database if empty
+	SnapshotStorageURL string
 
 	// Only used in https://snapshots.raintank.io/
 	SnapshotPublicMode bool
@@ -433,6 +438,16 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveHistoryMaxPoints is a maximum number of rows kept in the history of a managed
+	// stream channel. 0 disables the history.
+	LiveHistoryMaxPoints int
+	// LiveHistoryMaxAge is a maximum age of rows kept in the history of a managed stream channel.
+	LiveHistoryMaxAge time.Duration
+	// LivePushMaxBodySize is a maximum size in bytes of the body of a request to the Live push
+	// endpoints, after it is decompressed.
+	LivePushMaxBodySize int64
+	// LiveMQTT configures the MQTT input of Grafana Live.
+	LiveMQTT LiveMQTTSettings
 
 	// Grafana.com URL, used for OAuth redirect.
 	GrafanaComURL string
@@ -458,6 +473,8 @@ type Cfg struct {
 
 	Search SearchSettings
 
+	Caching CachingSettings
+
 	SecureSocksDSProxy SecureSocksDSProxySettings
 
 	// SAML Auth
@@ -1242,6 +1259,7 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.readAuthJWTSettings()
 	cfg.readAuthExtJWTSettings()
 	cfg.readAuthProxySettings()
+	cfg.readAuthMTLSSettings()
 	cfg.readSessionConfig()
 	if err := cfg.readSmtpSettings(); err != nil {
 		return err
@@ -1272,6 +1290,11 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 		cfg.Logger.Error("secure_socks_datasource_proxy unable to start up", "err", err.Error())
 	}
 
+	cfg.Caching, err = readCachingSettings(iniFile)
+	if err != nil {
+		return err
+	}
+
 	if cfg.VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		cfg.Logger.Warn("require_email_validation is enabled but smtp is disabled")
 	}
@@ -1886,6 +1909,7 @@ func readSnapshotsSettings(cfg *Cfg, iniFile *ini.File) error {
 
 	cfg.ExternalEnabled = snapshots.Key("external_enabled").MustBool(true)
 	cfg.SnapshotPublicMode = snapshots.Key("public_mode").MustBool(false)
+	cfg.SnapshotStorageURL = valueAsString(snapshots, "storage_url", "")
 
 	return nil
 }
@@ -2058,7 +2082,22 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 	}
 
 	cfg.LiveAllowedOrigins = originPatterns
-	return nil
+
+	cfg.LiveHistoryMaxPoints = section.Key("history_max_points").MustInt(1000)
+	if cfg.LiveHistoryMaxPoints < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_points", cfg.LiveHistoryMaxPoints)
+	}
+	cfg.LiveHistoryMaxAge, err = gtime.ParseDuration(valueAsString(section, "history_max_age", "1h"))
+	if err != nil {
+		return fmt.Errorf("invalid value for [live] history_max_age: %w", err)
+	}
+	cfg.LivePushMaxBodySize = section.Key("push_max_body_size_bytes").MustInt64(10 * 1024 * 1024)
+	if cfg.LivePushMaxBodySize <= 0 {
+		return fmt.Errorf("unexpected value %d for [live] push_max_body_size_bytes", cfg.LivePushMaxBodySize)
+	}
+
+	cfg.LiveMQTT, err = readLiveMQTTSettings(iniFile)
+	return err
 }
 
 func (cfg *Cfg) readPublicDashboardsSettings() {
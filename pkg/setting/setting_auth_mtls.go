@@ -0,0 +1,36 @@
+package setting
+
+type AuthMTLSSettings struct {
+	// Client certificate auth
+	Enabled                 bool
+	CACertFile              string
+	CRLFile                 string
+	RequireOCSPStaple       bool
+	LoginTemplate           string
+	EmailTemplate           string
+	NameTemplate            string
+	RoleTemplate            string
+	RoleAttributeStrict     bool
+	AllowAssignGrafanaAdmin bool
+	SkipOrgRoleSync         bool
+	AutoSignUp              bool
+}
+
+func (cfg *Cfg) readAuthMTLSSettings() {
+	mtlsSettings := AuthMTLSSettings{}
+	authMTLS := cfg.Raw.Section("auth.mtls")
+	mtlsSettings.Enabled = authMTLS.Key("enabled").MustBool(false)
+	mtlsSettings.CACertFile = valueAsString(authMTLS, "ca_cert", "")
+	mtlsSettings.CRLFile = valueAsString(authMTLS, "crl_file", "")
+	mtlsSettings.RequireOCSPStaple = authMTLS.Key("require_ocsp_staple").MustBool(false)
+	mtlsSettings.LoginTemplate = valueAsString(authMTLS, "login_template", "{{ .Subject.CommonName }}")
+	mtlsSettings.EmailTemplate = valueAsString(authMTLS, "email_template", "{{ first .EmailAddresses }}")
+	mtlsSettings.NameTemplate = valueAsString(authMTLS, "name_template", "{{ .Subject.CommonName }}")
+	mtlsSettings.RoleTemplate = valueAsString(authMTLS, "role_template", "")
+	mtlsSettings.RoleAttributeStrict = authMTLS.Key("role_attribute_strict").MustBool(false)
+	mtlsSettings.AllowAssignGrafanaAdmin = authMTLS.Key("allow_assign_grafana_admin").MustBool(false)
+	mtlsSettings.SkipOrgRoleSync = authMTLS.Key("skip_org_role_sync").MustBool(false)
+	mtlsSettings.AutoSignUp = authMTLS.Key("auto_sign_up").MustBool(false)
+
+	cfg.AuthMTLS = mtlsSettings
+}
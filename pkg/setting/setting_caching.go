@@ -0,0 +1,45 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"gopkg.in/ini.v1"
+)
+
+type CachingSettings struct {
+	Enabled bool
+	// TTL of cached query results, unless the data source has its own TTL in DataSourceTTLs.
+	TTL time.Duration
+	// TTL of cached resource responses.
+	ResourceTTL time.Duration
+	// The time ranges of queries are truncated to a multiple of TimeRangeAlignment before they are used in cache keys,
+	// so that queries issued a few seconds apart share the same cached results.
+	TimeRangeAlignment time.Duration
+	// Do not cache queries whose time range ends now, as their results change with every new sample.
+	SkipRelativeTimeRanges bool
+	// TTLs of cached query results by data source UID. A TTL of 0 disables caching for the data source.
+	DataSourceTTLs map[string]time.Duration
+}
+
+func readCachingSettings(iniFile *ini.File) (CachingSettings, error) {
+	s := CachingSettings{DataSourceTTLs: map[string]time.Duration{}}
+
+	cachingSection := iniFile.Section("caching")
+	s.Enabled = cachingSection.Key("enabled").MustBool(false)
+	s.TTL = cachingSection.Key("ttl").MustDuration(5 * time.Minute)
+	s.ResourceTTL = cachingSection.Key("resource_ttl").MustDuration(5 * time.Minute)
+	s.TimeRangeAlignment = cachingSection.Key("time_range_alignment").MustDuration(time.Minute)
+	s.SkipRelativeTimeRanges = cachingSection.Key("skip_relative_time_ranges").MustBool(false)
+
+	for _, key := range iniFile.Section("caching.datasources").Keys() {
+		ttl, err := gtime.ParseDuration(strings.TrimSpace(key.Value()))
+		if err != nil {
+			return s, fmt.Errorf("invalid caching TTL for data source %q: %w", key.Name(), err)
+		}
+		s.DataSourceTTLs[key.Name()] = ttl
+	}
+	return s, nil
+}
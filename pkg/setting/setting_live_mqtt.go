@@ -0,0 +1,63 @@
+package setting
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// LiveMQTTSettings configures the MQTT input of Grafana Live. Messages of the subscribed
+// topics are processed by the channel rules of Channel + "/" + <topic>.
+type LiveMQTTSettings struct {
+	Enabled  bool
+	URL      string
+	ClientID string
+	Username string
+	Password string
+	// Topics are the topic filters to subscribe to, e.g. "sensors/#".
+	Topics []string
+	// Channel is the prefix of the channels that topics are mapped to, in "scope/namespace" format.
+	Channel string
+	// OrgID is the organization whose channel rules process the messages.
+	OrgID int64
+}
+
+func readLiveMQTTSettings(iniFile *ini.File) (LiveMQTTSettings, error) {
+	section := iniFile.Section("live.mqtt")
+	s := LiveMQTTSettings{
+		Enabled:  section.Key("enabled").MustBool(false),
+		URL:      section.Key("url").MustString("mqtt://127.0.0.1:1883"),
+		ClientID: section.Key("client_id").MustString("grafana"),
+		Username: section.Key("username").MustString(""),
+		Password: section.Key("password").MustString(""),
+		Channel:  strings.Trim(section.Key("channel").MustString("stream/mqtt"), "/"),
+		OrgID:    section.Key("org_id").MustInt64(1),
+	}
+	for _, topic := range strings.Split(section.Key("topics").MustString("#"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			s.Topics = append(s.Topics, topic)
+		}
+	}
+	if !s.Enabled {
+		return s, nil
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return s, fmt.Errorf("invalid [live.mqtt] url: %w", err)
+	}
+	switch u.Scheme {
+	case "mqtt", "mqtts", "ws", "wss":
+	default:
+		return s, fmt.Errorf("unsupported [live.mqtt] url scheme: %s", u.Scheme)
+	}
+	if len(s.Topics) == 0 {
+		return s, fmt.Errorf("[live.mqtt] topics must not be empty")
+	}
+	if strings.Count(s.Channel, "/") != 1 {
+		return s, fmt.Errorf("[live.mqtt] channel must be in scope/namespace format, got %q", s.Channel)
+	}
+	return s, nil
+}
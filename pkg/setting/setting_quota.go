@@ -1,11 +1,13 @@
 package setting
 
 type OrgQuota struct {
-	User       int64 `target:"org_user"`
-	DataSource int64 `target:"data_source"`
-	Dashboard  int64 `target:"dashboard"`
-	ApiKey     int64 `target:"api_key"`
-	AlertRule  int64 `target:"alert_rule"`
+	User         int64 `target:"org_user"`
+	DataSource   int64 `target:"data_source"`
+	Dashboard    int64 `target:"dashboard"`
+	ApiKey       int64 `target:"api_key"`
+	AlertRule    int64 `target:"alert_rule"`
+	Snapshot     int64 `target:"dashboard_snapshot"`
+	SnapshotSize int64 `target:"dashboard_snapshot_size"`
 }
 
 type UserQuota struct {
@@ -22,6 +24,8 @@ type GlobalQuota struct {
 	AlertRule    int64 `target:"alert_rule"`
 	File         int64 `target:"file"`
 	Correlations int64 `target:"correlations"`
+	Snapshot     int64 `target:"dashboard_snapshot"`
+	SnapshotSize int64 `target:"dashboard_snapshot_size"`
 }
 
 type QuotaSettings struct {
@@ -38,11 +42,13 @@ func (cfg *Cfg) readQuotaSettings() {
 
 	// per ORG Limits
 	cfg.Quota.Org = OrgQuota{
-		User:       quota.Key("org_user").MustInt64(10),
-		DataSource: quota.Key("org_data_source").MustInt64(10),
-		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
-		ApiKey:     quota.Key("org_api_key").MustInt64(10),
-		AlertRule:  quota.Key("org_alert_rule").MustInt64(100),
+		User:         quota.Key("org_user").MustInt64(10),
+		DataSource:   quota.Key("org_data_source").MustInt64(10),
+		Dashboard:    quota.Key("org_dashboard").MustInt64(10),
+		ApiKey:       quota.Key("org_api_key").MustInt64(10),
+		AlertRule:    quota.Key("org_alert_rule").MustInt64(100),
+		Snapshot:     quota.Key("org_dashboard_snapshot").MustInt64(-1),
+		SnapshotSize: quota.Key("org_dashboard_snapshot_size").MustInt64(-1),
 	}
 
 	// per User limits
@@ -61,5 +67,7 @@ func (cfg *Cfg) readQuotaSettings() {
 		File:         quota.Key("global_file").MustInt64(-1),
 		AlertRule:    quota.Key("global_alert_rule").MustInt64(-1),
 		Correlations: quota.Key("global_correlations").MustInt64(-1),
+		Snapshot:     quota.Key("global_dashboard_snapshot").MustInt64(-1),
+		SnapshotSize: quota.Key("global_dashboard_snapshot_size").MustInt64(-1),
 	}
 }
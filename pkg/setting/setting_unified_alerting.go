@@ -2,6 +2,7 @@ package setting
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -62,8 +63,10 @@ const (
 	// DefaultRuleEvaluationInterval indicates a default interval of for how long a rule should be evaluated to change state from Pending to Alerting
 	DefaultRuleEvaluationInterval  = SchedulerBaseInterval * 6 // == 60 seconds
 	stateHistoryDefaultEnabled     = true
+	defaultStateHistoryRetention   = 30 * 24 * time.Hour
 	lokiDefaultMaxQueryLength      = 721 * time.Hour // 30d1h, matches the default value in Loki
 	defaultRecordingRequestTimeout = 10 * time.Second
+	defaultRecordingTSDBRetention  = 15 * 24 * time.Hour
 	lokiDefaultMaxQuerySize        = 65536 // 64kb
 )
 
@@ -130,11 +133,18 @@ type UnifiedAlertingSettings struct {
 
 type RecordingRuleSettings struct {
 	Enabled           bool
+	Backend           string
 	URL               string
 	BasicAuthUsername string
 	BasicAuthPassword string
 	CustomHeaders     map[string]string
 	Timeout           time.Duration
+
+	// The local TSDB used by the tsdb backend
+	TSDBPath      string
+	TSDBRetention time.Duration
+	// TSDBGrafanaURL is the URL of Grafana the data sources of the local TSDB query it with.
+	TSDBGrafanaURL string
 }
 
 // RemoteAlertmanagerSettings contains the configuration needed
@@ -174,6 +184,9 @@ type UnifiedAlertingStateHistorySettings struct {
 	MultiPrimary          string
 	MultiSecondaries      []string
 	ExternalLabels        map[string]string
+
+	// How long the sql backend keeps state history, 0 keeps it forever.
+	SQLRetention time.Duration
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -423,15 +436,26 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		MultiSecondaries:      splitTrim(stateHistory.Key("secondaries").MustString(""), ","),
 		ExternalLabels:        stateHistoryLabels.KeysHash(),
 	}
+	uaCfgStateHistory.SQLRetention, err = gtime.ParseDuration(valueAsString(stateHistory, "sql_retention", defaultStateHistoryRetention.String()))
+	if err != nil {
+		return err
+	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
 	rr := iniFile.Section("recording_rules")
 	uaCfgRecordingRules := RecordingRuleSettings{
 		Enabled:           rr.Key("enabled").MustBool(false),
+		Backend:           rr.Key("backend").MustString("prometheus"),
 		URL:               rr.Key("url").MustString(""),
 		BasicAuthUsername: rr.Key("basic_auth_username").MustString(""),
 		BasicAuthPassword: rr.Key("basic_auth_password").MustString(""),
 		Timeout:           rr.Key("timeout").MustDuration(defaultRecordingRequestTimeout),
+		TSDBPath:          rr.Key("tsdb_path").MustString(filepath.Join(cfg.DataPath, "recording_rules")),
+		TSDBGrafanaURL:    rr.Key("tsdb_grafana_url").MustString(cfg.AppURL),
+	}
+	uaCfgRecordingRules.TSDBRetention, err = gtime.ParseDuration(valueAsString(rr, "tsdb_retention", defaultRecordingTSDBRetention.String()))
+	if err != nil {
+		return err
 	}
 
 	rrHeaders := iniFile.Section("recording_rules.custom_headers")
@@ -1,6 +1,7 @@
 package setting
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/apiserver/rest"
@@ -37,6 +38,13 @@ func (cfg *Cfg) setUnifiedStorageConfig() {
 	cfg.UnifiedStorage = storageConfig
 }
 
+// setIndexPath reads the directory of the unified storage search index. The index removes
+// the shards it can not reopen, so the path is a dedicated directory inside the data path.
 func (cfg *Cfg) setIndexPath() {
-	cfg.IndexPath = cfg.Raw.Section("unified_storage").Key("index_path").String()
+	// cleaning the path as an absolute path removes the ".." elements leading out of the data path
+	path := filepath.Clean(string(filepath.Separator) + cfg.Raw.Section("unified_storage").Key("index_path").String())
+	if path == string(filepath.Separator) {
+		path = "unified-search"
+	}
+	cfg.IndexPath = filepath.Join(cfg.DataPath, path)
 }
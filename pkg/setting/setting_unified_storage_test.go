@@ -1,6 +1,7 @@
 package setting
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,3 +33,21 @@ func TestCfg_setUnifiedStorageConfig(t *testing.T) {
 		})
 	})
 }
+
+func TestCfg_setIndexPath(t *testing.T) {
+	cfg := NewCfg()
+	err := cfg.Load(CommandLineArgs{HomePath: "../../", Config: "../../conf/defaults.ini"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(cfg.DataPath, "unified-search"), cfg.IndexPath)
+
+	for path, expected := range map[string]string{
+		"search":       filepath.Join(cfg.DataPath, "search"),
+		"/tmp":         filepath.Join(cfg.DataPath, "tmp"),
+		"../../search": filepath.Join(cfg.DataPath, "search"),
+		"..":           filepath.Join(cfg.DataPath, "unified-search"),
+	} {
+		cfg.Raw.Section("unified_storage").Key("index_path").SetValue(path)
+		cfg.setIndexPath()
+		assert.Equal(t, expected, cfg.IndexPath, path)
+	}
+}
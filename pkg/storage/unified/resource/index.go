@@ -2,33 +2,54 @@ package resource
 
 import (
 	"context"
-	golog "log"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
-	"github.com/google/uuid"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/grafana/grafana/pkg/infra/log"
 )
 
 type Shard struct {
 	index bleve.Index
 	path  string
-	batch *bleve.Batch
 }
 
 type Index struct {
-	shards map[string]Shard
-	opts   Opts
-	s      *server
-	log    log.Logger
-	path   string
+	shardsMu sync.RWMutex
+	shards   map[string]Shard
+	opts     Opts
+	s        *server
+	log      log.Logger
+	path     string
+
+	// the latest indexed resource version by group and resource
+	rvMu sync.Mutex
+	rv   map[string]map[string]int64
 }
 
-func NewIndex(s *server, opts Opts, path string) *Index {
+const (
+	// the file in the index path with the latest indexed resource versions
+	resourceVersionsFile = "resource_versions.json"
+	// the prefix of the directory of each tenant shard in the index path
+	shardDirPrefix = "tenant-"
+)
+
+// NewIndex creates an index with its shards in path. The index removes the shards it can not
+// reopen, so path must be a directory dedicated to the index.
+func NewIndex(s *server, opts Opts, path string) (*Index, error) {
 	if path == "" {
-		path = os.TempDir()
+		return nil, errors.New("the index requires a dedicated directory")
 	}
 
 	idx := &Index{
@@ -37,82 +58,291 @@ func NewIndex(s *server, opts Opts, path string) *Index {
 		shards: make(map[string]Shard),
 		log:    log.New("unifiedstorage.search.index"),
 		path:   path,
+		rv:     make(map[string]map[string]int64),
 	}
 
-	return idx
+	return idx, nil
 }
 
 func (i *Index) IndexBatch(list *ListResponse, kind string) error {
+	i.log.Debug("initial indexing resources batch", "count", len(list.Items), "kind", kind)
+	batches := make(map[string]*bleve.Batch)
 	for _, obj := range list.Items {
+		// Transform the raw resource into a more generic indexable resource
 		res, err := NewIndexedResource(obj.Value)
 		if err != nil {
 			return err
 		}
 
-		shard, err := i.getShard(res.Namespace)
+		batch, err := i.tenantBatch(batches, res.Namespace)
 		if err != nil {
 			return err
 		}
-		i.log.Debug("initial indexing resources batch", "count", len(list.Items), "kind", kind, "tenant", res.Namespace)
-
-		// Transform the raw resource into a more generic indexable resource
-		indexableResource, err := NewIndexedResource(obj.Value)
+		err = batch.Index(res.Uid, res)
 		if err != nil {
 			return err
 		}
+	}
+
+	return i.commitBatches(batches)
+}
+
+// tenantBatch returns the batch of the changes to the shard of the tenant. The batches are
+// owned by the caller, so concurrent callers never share a batch.
+func (i *Index) tenantBatch(batches map[string]*bleve.Batch, tenant string) (*bleve.Batch, error) {
+	if batch, ok := batches[tenant]; ok {
+		return batch, nil
+	}
+	shard, err := i.getShard(tenant)
+	if err != nil {
+		return nil, err
+	}
+	batch := shard.index.NewBatch()
+	batches[tenant] = batch
+	return batch, nil
+}
 
-		err = shard.batch.Index(res.Uid, indexableResource)
+// commitBatches commits the batches to the shards of their tenant
+func (i *Index) commitBatches(batches map[string]*bleve.Batch) error {
+	for tenant, batch := range batches {
+		shard, err := i.getShard(tenant)
 		if err != nil {
 			return err
 		}
+		err = shard.index.Batch(batch)
+		if err != nil {
+			return fmt.Errorf("commit batch to index shard for tenant %s: %w", tenant, err)
+		}
 	}
+	return nil
+}
 
-	for _, shard := range i.shards {
-		err := shard.index.Batch(shard.batch)
+func (i *Index) Init(ctx context.Context) error {
+	start := time.Now().Unix()
+
+	err := os.MkdirAll(i.path, 0750)
+	if err != nil {
+		return err
+	}
+
+	resourceTypes := fetchResourceTypes()
+
+	reopened, err := i.reopen(resourceTypes)
+	if err != nil {
+		return err
+	}
+
+	if reopened {
+		i.log.Info("reopened index, it will catch up from the latest indexed resource versions", "shards", len(i.allShards()))
+	} else {
+		for _, rt := range resourceTypes {
+			i.log.Info("indexing resource", "kind", rt.Key.Resource)
+			r := &ListRequest{Options: rt, Limit: 100}
+
+			// Paginate through the list of resources and index each page
+			for {
+				list, err := i.s.List(ctx, r)
+				if err != nil {
+					return err
+				}
+
+				// Index current page
+				err = i.IndexBatch(list, rt.Key.Resource)
+				if err != nil {
+					return err
+				}
+
+				// the index is up to date with the version of the list
+				i.setResourceVersion(rt.Key.Group, rt.Key.Resource, list.ResourceVersion)
+
+				if list.NextPageToken == "" {
+					break
+				}
+
+				r.NextPageToken = list.NextPageToken
+			}
+		}
+
+		err = i.saveResourceVersions()
 		if err != nil {
 			return err
 		}
-		shard.batch.Reset()
+	}
+
+	end := time.Now().Unix()
+	if IndexServerMetrics != nil {
+		IndexServerMetrics.IndexCreationTime.WithLabelValues().Observe(float64(end - start))
 	}
 
 	return nil
 }
 
-func (i *Index) Init(ctx context.Context) error {
-	start := time.Now().Unix()
+// reopen opens the shards left in the index path by a previous run when the storage backend
+// can replay the events written since they were last updated. Otherwise the shards are removed
+// so the index is built again.
+func (i *Index) reopen(resourceTypes []*ListOptions) (bool, error) {
+	entries, err := os.ReadDir(i.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
 
-	resourceTypes := fetchResourceTypes()
+	rv, err := i.loadResourceVersions()
+	if err != nil {
+		i.log.Warn("failed to read the indexed resource versions, the index will be rebuilt", "error", err)
+	}
+
+	canCatchUp := false
+	if i.s != nil {
+		_, canCatchUp = i.s.backend.(WatchSinceBackend)
+	}
+	// a resource type that was not indexed before needs a full index
 	for _, rt := range resourceTypes {
-		i.log.Info("indexing resource", "kind", rt.Key.Resource)
-		r := &ListRequest{Options: rt, Limit: 100}
+		if _, ok := rv[rt.Key.Group][rt.Key.Resource]; !ok {
+			canCatchUp = false
+		}
+	}
 
-		// Paginate through the list of resources and index each page
-		for {
-			list, err := i.s.List(ctx, r)
-			if err != nil {
-				return err
+	i.shardsMu.Lock()
+	defer i.shardsMu.Unlock()
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), shardDirPrefix) {
+			continue
+		}
+		path := filepath.Join(i.path, entry.Name())
+		if !canCatchUp {
+			if err := os.RemoveAll(path); err != nil {
+				return false, err
 			}
+			continue
+		}
 
-			// Index current page
-			err = i.IndexBatch(list, rt.Key.Resource)
+		tenant, err := url.PathUnescape(strings.TrimPrefix(entry.Name(), shardDirPrefix))
+		if err != nil {
+			return false, err
+		}
+		index, err := bleve.Open(path)
+		if err != nil {
+			return false, fmt.Errorf("open index shard for tenant %s: %w", tenant, err)
+		}
+		i.shards[tenant] = Shard{
+			index: index,
+			path:  path,
+		}
+	}
+
+	if !canCatchUp {
+		// the versions of the removed shards must not be used if building the index is interrupted
+		err = os.Remove(filepath.Join(i.path, resourceVersionsFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+		return false, nil
+	}
+	i.rvMu.Lock()
+	i.rv = rv
+	i.rvMu.Unlock()
+	return true, nil
+}
+
+// Apply updates the index with events written to storage and records their resource versions.
+// The resource versions are only saved once the events are committed to the shards, so the
+// index never skips an event when it catches up after a restart.
+func (i *Index) Apply(ctx context.Context, events ...*WrittenEvent) error {
+	batches := make(map[string]*bleve.Batch)
+	for _, event := range events {
+		res, err := NewIndexedResource(event.Value)
+		if err != nil {
+			// the event can never be indexed, retrying it would block the following events
+			i.log.Error("failed to read the indexed resource of an event", "error", err, "group", event.Key.Group, "resource", event.Key.Resource, "name", event.Key.Name)
+			continue
+		}
+		batch, err := i.tenantBatch(batches, res.Namespace)
+		if err != nil {
+			return err
+		}
+		if event.Type == WatchEvent_DELETED {
+			batch.Delete(res.Uid)
+		} else {
+			err = batch.Index(res.Uid, res)
 			if err != nil {
 				return err
 			}
+		}
+	}
 
-			if list.NextPageToken == "" {
-				break
-			}
+	err := i.commitBatches(batches)
+	if err != nil {
+		return err
+	}
 
-			r.NextPageToken = list.NextPageToken
+	now := time.Now().UnixMicro()
+	for _, event := range events {
+		// record latency - resource version is a unix timestamp in microseconds so we convert to seconds
+		latencySeconds := float64(now-event.ResourceVersion) / 1e6
+		if IndexServerMetrics != nil {
+			IndexServerMetrics.IndexLatency.WithLabelValues(event.Key.Resource).Observe(latencySeconds)
 		}
+		i.setResourceVersion(event.Key.Group, event.Key.Resource, event.ResourceVersion)
 	}
+	return i.saveResourceVersions()
+}
 
-	end := time.Now().Unix()
-	if IndexServerMetrics != nil {
-		IndexServerMetrics.IndexCreationTime.WithLabelValues().Observe(float64(end - start))
+// ResourceVersions returns the latest indexed resource version by group and resource.
+func (i *Index) ResourceVersions() map[string]map[string]int64 {
+	i.rvMu.Lock()
+	defer i.rvMu.Unlock()
+	rv := make(map[string]map[string]int64, len(i.rv))
+	for group, items := range i.rv {
+		rv[group] = make(map[string]int64, len(items))
+		for resource, v := range items {
+			rv[group][resource] = v
+		}
 	}
+	return rv
+}
 
-	return nil
+func (i *Index) setResourceVersion(group, resource string, rv int64) {
+	i.rvMu.Lock()
+	defer i.rvMu.Unlock()
+	if i.rv[group] == nil {
+		i.rv[group] = make(map[string]int64)
+	}
+	if rv > i.rv[group][resource] {
+		i.rv[group][resource] = rv
+	}
+}
+
+func (i *Index) loadResourceVersions() (map[string]map[string]int64, error) {
+	b, err := os.ReadFile(filepath.Join(i.path, resourceVersionsFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rv := make(map[string]map[string]int64)
+	err = json.Unmarshal(b, &rv)
+	return rv, err
+}
+
+// saveResourceVersions writes the latest indexed resource versions next to the shards.
+// The file is replaced at once so a crash never leaves it half written.
+func (i *Index) saveResourceVersions() error {
+	i.rvMu.Lock()
+	defer i.rvMu.Unlock()
+	b, err := json.Marshal(i.rv)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(i.path, resourceVersionsFile)
+	err = os.WriteFile(path+".tmp", b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
 }
 
 func (i *Index) Index(ctx context.Context, data *Data) error {
@@ -153,7 +383,16 @@ func (i *Index) Delete(ctx context.Context, uid string, key *ResourceKey) error
 	return nil
 }
 
-func (i *Index) Search(ctx context.Context, tenant string, query string, limit int, offset int) ([]IndexedResource, error) {
+// IndexResults are the results of a search on the index
+type IndexResults struct {
+	Values        []IndexedResource
+	TotalHits     int64
+	Facet         map[string]*SearchResponse_Facet
+	NextPageToken string
+}
+
+func (i *Index) Search(ctx context.Context, request *SearchRequest) (*IndexResults, error) {
+	tenant := request.Tenant
 	if tenant == "" {
 		tenant = "default"
 	}
@@ -170,19 +409,13 @@ func (i *Index) Search(ctx context.Context, tenant string, query string, limit i
 	fields, _ := shard.index.Fields()
 	i.log.Debug("indexed fields", "fields", fields)
 
-	// use 10 as a default limit for now
-	if limit <= 0 {
-		limit = 10
+	req, err := newSearchRequest(request)
+	if err != nil {
+		return nil, err
 	}
 
-	req := bleve.NewSearchRequest(bleve.NewQueryStringQuery(query))
-	req.From = offset
-	req.Size = limit
-
-	req.Fields = []string{"*"} // return all indexed fields in search results
-
-	i.log.Info("searching index", "query", query, "tenant", tenant)
-	res, err := shard.index.Search(req)
+	i.log.Info("searching index", "query", request.Query, "tenant", tenant)
+	res, err := shard.index.SearchInContext(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -190,36 +423,214 @@ func (i *Index) Search(ctx context.Context, tenant string, query string, limit i
 
 	i.log.Info("got search results", "hits", hits)
 
-	results := make([]IndexedResource, len(hits))
+	// one more result than the limit is requested to know if there is a next page
+	var nextPageToken string
+	if len(hits) >= req.Size {
+		hits = hits[:req.Size-1]
+		nextPageToken = searchContinueToken{After: searchAfter(req.Sort, hits[len(hits)-1])}.String()
+	}
+
+	results := &IndexResults{
+		Values:        make([]IndexedResource, len(hits)),
+		TotalHits:     int64(res.Total),
+		NextPageToken: nextPageToken,
+	}
 	for resKey, hit := range hits {
 		ir := IndexedResource{}.FromSearchHit(hit)
-		results[resKey] = ir
+		results.Values[resKey] = ir
+	}
+
+	if len(res.Facets) > 0 {
+		results.Facet = make(map[string]*SearchResponse_Facet, len(res.Facets))
+		for name, f := range res.Facets {
+			facet := &SearchResponse_Facet{
+				Field:   name,
+				Total:   int64(f.Total),
+				Missing: int64(f.Missing),
+				Other:   int64(f.Other),
+			}
+			for _, t := range f.Terms.Terms() {
+				facet.Terms = append(facet.Terms, &SearchResponse_TermFacet{Term: t.Term, Count: int64(t.Count)})
+			}
+			results.Facet[name] = facet
+		}
 	}
 
 	return results, nil
 }
 
+// the fields that can be used in the sort and facets of a search request
+var (
+	searchSortFields = map[string]string{
+		"title":   "TitleSort",
+		"updated": "UpdatedAt",
+	}
+	searchFacetFields = map[string]string{
+		"tags":   "Tags",
+		"folder": "FolderId",
+	}
+)
+
+// newSearchRequest creates a bleve search request for the query, filters, sort and facets of the request
+func newSearchRequest(request *SearchRequest) (*bleve.SearchRequest, error) {
+	var q query.Query = bleve.NewMatchAllQuery()
+	if request.Query != "" {
+		q = bleve.NewQueryStringQuery(request.Query)
+	}
+
+	filters := []query.Query{q}
+	if request.Kind != "" {
+		filters = append(filters, termQuery("Kind", request.Kind))
+	}
+	if request.CreatedBy != "" {
+		filters = append(filters, termQuery("CreatedBy", request.CreatedBy))
+	}
+	if len(request.Folder) > 0 {
+		folders := make([]query.Query, len(request.Folder))
+		for idx, folder := range request.Folder {
+			folders[idx] = termQuery("FolderId", folder)
+		}
+		filters = append(filters, bleve.NewDisjunctionQuery(folders...))
+	}
+	for _, tag := range request.Tags {
+		filters = append(filters, termQuery("Tags", tag))
+	}
+	if len(filters) > 1 {
+		q = bleve.NewConjunctionQuery(filters...)
+	}
+
+	// use 10 as a default limit for now
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = 10
+	}
+
+	req := bleve.NewSearchRequest(q)
+	req.Size = limit + 1
+	req.Fields = []string{"*"} // return all indexed fields in search results
+
+	// sort by relevance unless asked otherwise, and by id last so every result has a distinct position for the next page
+	sort := make([]string, 0, len(request.Sort)+1)
+	for _, s := range request.Sort {
+		field, ok := searchSortFields[s.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", s.Field)
+		}
+		if s.Desc {
+			field = "-" + field
+		}
+		sort = append(sort, field)
+	}
+	if len(sort) == 0 {
+		sort = append(sort, "-_score")
+	}
+	req.SortBy(append(sort, "_id"))
+
+	for _, name := range request.Facet {
+		field, ok := searchFacetFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported facet %q", name)
+		}
+		req.AddFacet(name, bleve.NewFacetRequest(field, 50))
+	}
+
+	if request.NextPageToken != "" {
+		if request.Offset > 0 {
+			return nil, fmt.Errorf("next page token can not be used with an offset")
+		}
+		token, err := getSearchContinueToken(request.NextPageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(token.After) != len(req.Sort) {
+			return nil, fmt.Errorf("next page token does not match the sort of the request")
+		}
+		req.SetSearchAfter(token.After)
+	} else {
+		req.From = int(request.Offset)
+	}
+
+	return req, nil
+}
+
+func termQuery(field string, term string) query.Query {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+// searchAfter returns the sort values of the hit to continue the search after it
+func searchAfter(sort search.SortOrder, hit *search.DocumentMatch) []string {
+	after := make([]string, len(hit.Sort))
+	copy(after, hit.Sort)
+	for idx, s := range sort {
+		// the sort value of the score is a placeholder
+		if _, ok := s.(*search.SortScore); ok && idx < len(after) {
+			after[idx] = strconv.FormatFloat(hit.Score, 'g', -1, 64)
+		}
+	}
+	return after
+}
+
+type searchContinueToken struct {
+	After []string `json:"a"`
+}
+
+func (c searchContinueToken) String() string {
+	b, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func getSearchContinueToken(token string) (*searchContinueToken, error) {
+	continueVal, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding next page token")
+	}
+
+	t := &searchContinueToken{}
+	err = json.Unmarshal(continueVal, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
 type Opts struct {
 	Workers    int // This controls how many goroutines are used to index objects
 	BatchSize  int // This is the batch size for how many objects to add to the index at once
 	Concurrent bool
 }
 
-func createFileIndex(path string) (bleve.Index, string, error) {
-	indexPath := filepath.Join(path, uuid.New().String())
-	index, err := bleve.New(indexPath, createIndexMappings())
+func createFileIndex(path string, tenant string) (bleve.Index, string, error) {
+	indexPath := filepath.Join(path, shardDirPrefix+url.PathEscape(tenant))
+	indexMapping, err := createIndexMappings()
 	if err != nil {
-		golog.Fatalf("Failed to create index: %v", err)
+		return nil, "", err
 	}
-	return index, indexPath, err
+	index, err := bleve.New(indexPath, indexMapping)
+	if err != nil {
+		return nil, "", fmt.Errorf("create index shard for tenant %s: %w", tenant, err)
+	}
+	return index, indexPath, nil
 }
 
 func (i *Index) getShard(tenant string) (Shard, error) {
+	i.shardsMu.RLock()
 	shard, ok := i.shards[tenant]
+	i.shardsMu.RUnlock()
+	if ok {
+		return shard, nil
+	}
+
+	i.shardsMu.Lock()
+	defer i.shardsMu.Unlock()
+	// another request may have created the shard while waiting for the lock
+	shard, ok = i.shards[tenant]
 	if ok {
 		return shard, nil
 	}
-	index, path, err := createFileIndex(i.path)
+	index, path, err := createFileIndex(i.path, tenant)
 	if err != nil {
 		return Shard{}, err
 	}
@@ -227,26 +638,32 @@ func (i *Index) getShard(tenant string) (Shard, error) {
 	shard = Shard{
 		index: index,
 		path:  path,
-		batch: index.NewBatch(),
 	}
-	// TODO: do we need to lock this?
 	i.shards[tenant] = shard
 	return shard, nil
 }
 
-// TODO - fetch from api
+// allShards returns the shards of all tenants
+func (i *Index) allShards() []Shard {
+	i.shardsMu.RLock()
+	defer i.shardsMu.RUnlock()
+	shards := make([]Shard, 0, len(i.shards))
+	for _, shard := range i.shards {
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+// fetchResourceTypes returns the list options of the registered indexable resources
 func fetchResourceTypes() []*ListOptions {
 	items := []*ListOptions{}
-	items = append(items, &ListOptions{
-		Key: &ResourceKey{
-			Group:    "playlist.grafana.app",
-			Resource: "playlists",
-		},
-	}, &ListOptions{
-		Key: &ResourceKey{
-			Group:    "folder.grafana.app",
-			Resource: "folders",
-		},
-	})
+	for _, r := range getIndexableResources() {
+		items = append(items, &ListOptions{
+			Key: &ResourceKey{
+				Group:    r.Group,
+				Resource: r.Resource,
+			},
+		})
+	}
 	return items
 }
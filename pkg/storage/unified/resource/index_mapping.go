@@ -1,13 +1,19 @@
 package resource
 
 import (
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type IndexedResource struct {
@@ -22,6 +28,7 @@ type IndexedResource struct {
 	UpdatedAt string
 	UpdatedBy string
 	FolderId  string
+	Tags      []string
 	Spec      any
 }
 
@@ -36,6 +43,19 @@ func (ir IndexedResource) FromSearchHit(hit *search.DocumentMatch) IndexedResour
 	ir.UpdatedAt = hit.Fields["UpdatedAt"].(string)
 	ir.UpdatedBy = hit.Fields["UpdatedBy"].(string)
 	ir.Title = hit.Fields["Title"].(string)
+	ir.FolderId, _ = hit.Fields["FolderId"].(string)
+
+	// a single tag is returned as a string and several as a list
+	switch tags := hit.Fields["Tags"].(type) {
+	case string:
+		ir.Tags = []string{tags}
+	case []interface{}:
+		for _, tag := range tags {
+			if t, ok := tag.(string); ok {
+				ir.Tags = append(ir.Tags, t)
+			}
+		}
+	}
 
 	// add indexed spec fields to search results
 	specResult := map[string]interface{}{}
@@ -84,44 +104,84 @@ func NewIndexedResource(rawResource []byte) (*IndexedResource, error) {
 		ir.UpdatedAt = ir.CreatedAt
 	}
 	ir.UpdatedBy = meta.GetUpdatedBy()
+	ir.FolderId = meta.GetFolder()
 	spec, err := meta.GetSpec()
 	if err != nil {
 		return nil, err
 	}
 	ir.Spec = spec
+	ir.Tags = specTags(spec)
+	if ir.Title == "" {
+		// the title of unstructured objects is only found in the spec
+		if s, ok := spec.(map[string]interface{}); ok {
+			ir.Title, _ = s["title"].(string)
+		}
+	}
 
 	return ir, nil
 }
 
-func createIndexMappings() *mapping.IndexMappingImpl {
+// specTags returns the string values of the tags field of the spec
+func specTags(spec any) []string {
+	s, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, ok := s["tags"].([]interface{})
+	if !ok {
+		return nil
+	}
+	tags := make([]string, 0, len(list))
+	for _, v := range list {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+const titleSortAnalyzer = "title_sort"
+
+func createIndexMappings() (*mapping.IndexMappingImpl, error) {
 	// Create the index mapping
 	indexMapping := bleve.NewIndexMapping()
 	// Create an individual index mapping for each kind
 	indexMapping.TypeField = "Kind"
 
+	err := indexMapping.AddCustomAnalyzer(titleSortAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// for all kinds, create their index mappings
 	for k := range getSpecObjectMappings() {
 		objMapping := createIndexMappingForKind(k)
 		indexMapping.AddDocumentMapping(k, objMapping)
 	}
 
-	return indexMapping
+	return indexMapping, nil
 }
 
 func createIndexMappingForKind(resourceKind string) *mapping.DocumentMapping {
 	// create mappings for top level fields
-	baseFields := map[string]*mapping.FieldMapping{
-		"Uid":       bleve.NewTextFieldMapping(),
-		"Group":     bleve.NewTextFieldMapping(),
-		"Namespace": bleve.NewTextFieldMapping(),
-		"Kind":      bleve.NewTextFieldMapping(),
-		"Name":      bleve.NewTextFieldMapping(),
-		"Title":     bleve.NewTextFieldMapping(),
-		"CreatedAt": bleve.NewDateTimeFieldMapping(),
-		"CreatedBy": bleve.NewTextFieldMapping(),
-		"UpdatedAt": bleve.NewDateTimeFieldMapping(),
-		"UpdatedBy": bleve.NewTextFieldMapping(),
-		"FolderId":  bleve.NewTextFieldMapping(),
+	// fields used in filters and facets are keywords so they match exactly
+	baseFields := map[string][]*mapping.FieldMapping{
+		"Uid":       {bleve.NewTextFieldMapping()},
+		"Group":     {bleve.NewTextFieldMapping()},
+		"Namespace": {bleve.NewTextFieldMapping()},
+		"Kind":      {bleve.NewKeywordFieldMapping()},
+		"Name":      {bleve.NewTextFieldMapping()},
+		"Title":     {bleve.NewTextFieldMapping(), titleSortFieldMapping()},
+		"CreatedAt": {bleve.NewDateTimeFieldMapping()},
+		"CreatedBy": {bleve.NewKeywordFieldMapping()},
+		"UpdatedAt": {bleve.NewDateTimeFieldMapping()},
+		"UpdatedBy": {bleve.NewTextFieldMapping()},
+		"FolderId":  {bleve.NewKeywordFieldMapping()},
+		"Tags":      {bleve.NewKeywordFieldMapping()},
 	}
 
 	// Spec is different for all resources, so we need to generate the spec mapping based on the kind
@@ -135,43 +195,79 @@ func createIndexMappingForKind(resourceKind string) *mapping.DocumentMapping {
 
 	// map top level fields
 	for k, v := range baseFields {
-		objectMapping.AddFieldMappingsAt(k, v)
+		objectMapping.AddFieldMappingsAt(k, v...)
 	}
 
 	return objectMapping
 }
 
+// titleSortFieldMapping indexes the whole lowercase title as a single term so results can be sorted by it
+func titleSortFieldMapping() *mapping.FieldMapping {
+	m := bleve.NewTextFieldMapping()
+	m.Name = "TitleSort"
+	m.Analyzer = titleSortAnalyzer
+	m.Store = false
+	m.IncludeInAll = false
+	return m
+}
+
 type SpecFieldMapping struct {
 	Field string
 	Type  string
 }
 
-// Right now we are hardcoding which spec fields to index for each kind
-// In the future, which fields to index will be defined on the resources themselves by their owners.
-func getSpecObjectMappings() map[string][]SpecFieldMapping {
-	mappings := map[string][]SpecFieldMapping{
-		"Playlist": {
-			{
-				Field: "interval",
-				Type:  "string",
-			},
-			{
-				Field: "title",
-				Type:  "string",
-			},
-		},
-		"Folder": {
-			{
-				Field: "title",
-				Type:  "string",
-			},
-			{
-				Field: "description",
-				Type:  "string",
-			},
-		},
+// IndexableResource is a resource type of an API group that is added to the search index
+type IndexableResource struct {
+	Group    string
+	Resource string
+	Kind     string
+	// The spec fields to index
+	Fields []SpecFieldMapping
+}
+
+var (
+	indexableResourcesMu sync.RWMutex
+	indexableResources   = map[schema.GroupResource]IndexableResource{}
+)
+
+// RegisterIndexableResource adds a resource type to the search index.
+// The API groups register the resource types they own when their package is initialized,
+// so they are known before the index is loaded, including by a standalone storage server.
+func RegisterIndexableResource(r IndexableResource) {
+	indexableResourcesMu.Lock()
+	defer indexableResourcesMu.Unlock()
+	indexableResources[schema.GroupResource{Group: r.Group, Resource: r.Resource}] = r
+}
+
+// getIndexableResources returns the registered resource types sorted by group and resource
+func getIndexableResources() []IndexableResource {
+	indexableResourcesMu.RLock()
+	defer indexableResourcesMu.RUnlock()
+	list := make([]IndexableResource, 0, len(indexableResources))
+	for _, r := range indexableResources {
+		list = append(list, r)
 	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Group != list[j].Group {
+			return list[i].Group < list[j].Group
+		}
+		return list[i].Resource < list[j].Resource
+	})
+	return list
+}
 
+func isIndexableResource(key *ResourceKey) bool {
+	indexableResourcesMu.RLock()
+	defer indexableResourcesMu.RUnlock()
+	_, ok := indexableResources[schema.GroupResource{Group: key.Group, Resource: key.Resource}]
+	return ok
+}
+
+func getSpecObjectMappings() map[string][]SpecFieldMapping {
+	mappings := map[string][]SpecFieldMapping{}
+	for _, r := range getIndexableResources() {
+		mappings[r.Kind] = r.Fields
+	}
 	return mappings
 }
 
@@ -93,7 +93,7 @@ func getTotalDocCount(index *Index) float64 {
 	if index == nil {
 		return totalCount
 	}
-	for _, shard := range index.shards {
+	for _, shard := range index.allShards() {
 		docCount, err := shard.index.DocCount()
 		if err != nil {
 			continue
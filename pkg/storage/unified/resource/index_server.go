@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,12 +22,16 @@ type IndexServer struct {
 }
 
 func (is *IndexServer) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
-	results, err := is.index.Search(ctx, req.Tenant, req.Query, int(req.Limit), int(req.Offset))
+	results, err := is.index.Search(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	res := &SearchResponse{}
-	for _, r := range results {
+	res := &SearchResponse{
+		TotalHits:     results.TotalHits,
+		Facet:         results.Facet,
+		NextPageToken: results.NextPageToken,
+	}
+	for _, r := range results.Values {
 		resJsonBytes, err := json.Marshal(r)
 		if err != nil {
 			return nil, err
@@ -46,16 +51,22 @@ func (is *IndexServer) Origin(ctx context.Context, req *OriginRequest) (*OriginR
 
 // Load the index
 func (is *IndexServer) Load(ctx context.Context) error {
-	is.index = NewIndex(is.s, Opts{}, is.cfg.IndexPath)
-	err := is.index.Init(ctx)
+	index, err := NewIndex(is.s, Opts{}, is.cfg.IndexPath)
 	if err != nil {
 		return err
 	}
-	return nil
+	is.index = index
+	return is.index.Init(ctx)
 }
 
 // Watch resources for changes and update the index
 func (is *IndexServer) Watch(ctx context.Context) error {
+	// when the backend can replay events, the index catches up from the latest indexed resource versions
+	if backend, ok := is.s.backend.(WatchSinceBackend); ok {
+		go is.watchSince(ctx, backend)
+		return nil
+	}
+
 	rtList := fetchResourceTypes()
 	for _, rt := range rtList {
 		wr := &WatchRequest{
@@ -76,6 +87,61 @@ func (is *IndexServer) Watch(ctx context.Context) error {
 	return nil
 }
 
+// watchSince updates the index with the events written after the latest indexed resource versions
+func (is *IndexServer) watchSince(ctx context.Context, backend WatchSinceBackend) {
+	for {
+		events, err := backend.WatchWriteEventsSince(ctx, is.index.ResourceVersions())
+		if err != nil {
+			is.log.Error("Error watching resources", "error", err)
+		} else {
+			for event := range events {
+				batch := nextIndexableEvents(event, events)
+				if len(batch) == 0 {
+					continue
+				}
+				err := is.index.Apply(ctx, batch...)
+				if err != nil {
+					is.log.Error("Error indexing resources", "error", err, "count", len(batch))
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		is.log.Debug("Resource watch ended. Restarting watch")
+	}
+}
+
+// the maximum number of events committed to the index in a single batch
+const watchBatchSize = 100
+
+// nextIndexableEvents returns the indexable events among the event and the events already
+// waiting in the channel, so they are committed to the index in a single batch
+func nextIndexableEvents(event *WrittenEvent, events <-chan *WrittenEvent) []*WrittenEvent {
+	batch := make([]*WrittenEvent, 0, 1)
+	for {
+		if isIndexableResource(event.Key) {
+			batch = append(batch, event)
+		}
+		if len(batch) >= watchBatchSize {
+			return batch
+		}
+
+		var ok bool
+		select {
+		case event, ok = <-events:
+			if !ok {
+				return batch
+			}
+		default:
+			return batch
+		}
+	}
+}
+
 // Init sets the resource server on the index server
 // so we can call the resource server from the index server
 // TODO: a chicken and egg problem - index server needs the resource server but the resource server is created with the index server
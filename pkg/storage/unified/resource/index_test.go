@@ -0,0 +1,249 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// the API groups register their resources outside of this package
+	RegisterIndexableResource(IndexableResource{
+		Group:    "playlist.grafana.app",
+		Resource: "playlists",
+		Kind:     "Playlist",
+		Fields:   []SpecFieldMapping{{Field: "interval", Type: "string"}, {Field: "title", Type: "string"}},
+	})
+	RegisterIndexableResource(IndexableResource{
+		Group:    "folder.grafana.app",
+		Resource: "folders",
+		Kind:     "Folder",
+		Fields:   []SpecFieldMapping{{Field: "title", Type: "string"}, {Field: "description", Type: "string"}},
+	})
+}
+
+func TestNewIndex(t *testing.T) {
+	_, err := NewIndex(nil, Opts{}, "")
+	require.Error(t, err)
+}
+
+func TestIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index, err := NewIndex(nil, Opts{}, t.TempDir())
+	require.NoError(t, err)
+
+	add := func(name, kind, title, folder, createdBy, updated string, tags ...string) {
+		tagsJSON, err := json.Marshal(tags)
+		require.NoError(t, err)
+		raw := fmt.Sprintf(`{
+			"apiVersion": "playlist.grafana.app/v0alpha1",
+			"kind": %q,
+			"metadata": {
+				"name": %q,
+				"namespace": "default",
+				"uid": %q,
+				"creationTimestamp": "2024-01-01T00:00:00Z",
+				"annotations": {
+					"grafana.app/folder": %q,
+					"grafana.app/createdBy": %q,
+					"grafana.app/updatedTimestamp": %q
+				}
+			},
+			"spec": {
+				"title": %q,
+				"tags": %s
+			}
+		}`, kind, name, "uid-"+name, folder, createdBy, updated, title, tagsJSON)
+		err = index.Index(ctx, &Data{
+			Key:   &ResourceKey{Namespace: "default", Resource: "playlists", Name: name},
+			Value: &ResourceWrapper{Value: []byte(raw)},
+		})
+		require.NoError(t, err)
+	}
+	add("a", "Playlist", "Bravo", "f1", "user:1", "2024-01-03T00:00:00Z", "prod", "web")
+	add("b", "Playlist", "alpha", "f1", "user:2", "2024-01-05T00:00:00Z", "prod")
+	add("c", "Playlist", "Charlie", "f2", "user:1", "2024-01-02T00:00:00Z", "dev")
+	add("d", "Folder", "Delta", "", "user:1", "2024-01-04T00:00:00Z")
+
+	names := func(res *IndexResults) []string {
+		names := make([]string, len(res.Values))
+		for i, v := range res.Values {
+			names[i] = v.Name
+		}
+		return names
+	}
+
+	t.Run("filters by kind, folder, tags and creator", func(t *testing.T) {
+		res, err := index.Search(ctx, &SearchRequest{Kind: "Playlist", Folder: []string{"f1", "f2"}, Tags: []string{"prod"}, CreatedBy: "user:1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, names(res))
+		require.Equal(t, int64(1), res.TotalHits)
+		require.Equal(t, []string{"prod", "web"}, res.Values[0].Tags)
+		require.Equal(t, "f1", res.Values[0].FolderId)
+	})
+
+	t.Run("sorts by title and updated", func(t *testing.T) {
+		res, err := index.Search(ctx, &SearchRequest{Sort: []*SearchRequest_Sort{{Field: "title"}}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "a", "c", "d"}, names(res))
+
+		res, err = index.Search(ctx, &SearchRequest{Sort: []*SearchRequest_Sort{{Field: "updated", Desc: true}}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "d", "a", "c"}, names(res))
+
+		_, err = index.Search(ctx, &SearchRequest{Sort: []*SearchRequest_Sort{{Field: "name"}}})
+		require.Error(t, err)
+	})
+
+	t.Run("counts tags and folders", func(t *testing.T) {
+		res, err := index.Search(ctx, &SearchRequest{Kind: "Playlist", Facet: []string{"tags", "folder"}})
+		require.NoError(t, err)
+
+		tags := res.Facet["tags"]
+		require.NotNil(t, tags)
+		require.Equal(t, "prod", tags.Terms[0].Term)
+		require.Equal(t, int64(2), tags.Terms[0].Count)
+		require.Len(t, tags.Terms, 3)
+
+		folders := res.Facet["folder"]
+		require.NotNil(t, folders)
+		require.Equal(t, "f1", folders.Terms[0].Term)
+		require.Equal(t, int64(2), folders.Terms[0].Count)
+		require.Equal(t, "f2", folders.Terms[1].Term)
+		require.Equal(t, int64(1), folders.Terms[1].Count)
+	})
+
+	t.Run("pages with the next page token", func(t *testing.T) {
+		req := &SearchRequest{Limit: 3, Sort: []*SearchRequest_Sort{{Field: "title", Desc: true}}}
+		res, err := index.Search(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"d", "c", "a"}, names(res))
+		require.Equal(t, int64(4), res.TotalHits)
+		require.NotEmpty(t, res.NextPageToken)
+
+		req.NextPageToken = res.NextPageToken
+		res, err = index.Search(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, names(res))
+		require.Empty(t, res.NextPageToken)
+
+		req.Offset = 1
+		_, err = index.Search(ctx, req)
+		require.Error(t, err)
+	})
+
+	t.Run("pages by relevance", func(t *testing.T) {
+		req := &SearchRequest{Query: "Tags:prod", Limit: 1}
+		res, err := index.Search(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		first := res.Values[0].Name
+
+		req.NextPageToken = res.NextPageToken
+		res, err = index.Search(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		require.NotEqual(t, first, res.Values[0].Name)
+		require.Empty(t, res.NextPageToken)
+	})
+}
+
+type watchSinceBackend struct {
+	StorageBackend
+}
+
+func (b *watchSinceBackend) WatchWriteEventsSince(ctx context.Context, since map[string]map[string]int64) (<-chan *WrittenEvent, error) {
+	return nil, nil
+}
+
+func TestIndexReopen(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+	s := &server{backend: &watchSinceBackend{}}
+
+	event := func(rv int64, group, resource, kind, name string) *WrittenEvent {
+		return &WrittenEvent{
+			WriteEvent: WriteEvent{
+				Type: WatchEvent_ADDED,
+				Key:  &ResourceKey{Group: group, Resource: resource, Namespace: "default", Name: name},
+				Value: []byte(fmt.Sprintf(`{
+					"apiVersion": "%s/v0alpha1",
+					"kind": %q,
+					"metadata": {
+						"name": %q,
+						"namespace": "default",
+						"uid": %q,
+						"creationTimestamp": "2024-01-01T00:00:00Z"
+					},
+					"spec": {
+						"title": %q
+					}
+				}`, group, kind, name, "uid-"+name, name)),
+			},
+			ResourceVersion: rv,
+		}
+	}
+
+	index, err := NewIndex(s, Opts{}, path)
+	require.NoError(t, err)
+	reopened, err := index.reopen(fetchResourceTypes())
+	require.NoError(t, err)
+	require.False(t, reopened)
+	require.NoError(t, index.Apply(ctx, event(10, "playlist.grafana.app", "playlists", "Playlist", "a")))
+	require.NoError(t, index.Apply(ctx,
+		event(12, "folder.grafana.app", "folders", "Folder", "b"),
+		event(11, "playlist.grafana.app", "playlists", "Playlist", "c"),
+	))
+	for _, shard := range index.allShards() {
+		require.NoError(t, shard.index.Close())
+	}
+
+	t.Run("reopens the shards and the resource versions when the backend can catch up", func(t *testing.T) {
+		index, err := NewIndex(s, Opts{}, path)
+		require.NoError(t, err)
+		reopened, err := index.reopen(fetchResourceTypes())
+		require.NoError(t, err)
+		require.True(t, reopened)
+		require.Equal(t, map[string]map[string]int64{
+			"playlist.grafana.app": {"playlists": 11},
+			"folder.grafana.app":   {"folders": 12},
+		}, index.ResourceVersions())
+
+		res, err := index.Search(ctx, &SearchRequest{Tenant: "default"})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), res.TotalHits)
+		for _, shard := range index.allShards() {
+			require.NoError(t, shard.index.Close())
+		}
+	})
+
+	t.Run("removes the shards when the backend can not catch up", func(t *testing.T) {
+		index, err := NewIndex(&server{}, Opts{}, path)
+		require.NoError(t, err)
+		reopened, err := index.reopen(fetchResourceTypes())
+		require.NoError(t, err)
+		require.False(t, reopened)
+		require.Empty(t, index.allShards())
+		require.Empty(t, index.ResourceVersions())
+		require.NoDirExists(t, filepath.Join(path, shardDirPrefix+"default"))
+		require.NoFileExists(t, filepath.Join(path, resourceVersionsFile))
+	})
+}
+
+func TestNextIndexableEvents(t *testing.T) {
+	playlist := &WrittenEvent{WriteEvent: WriteEvent{Key: &ResourceKey{Group: "playlist.grafana.app", Resource: "playlists"}}}
+	other := &WrittenEvent{WriteEvent: WriteEvent{Key: &ResourceKey{Group: "other.grafana.app", Resource: "others"}}}
+
+	events := make(chan *WrittenEvent, 3)
+	events <- other
+	events <- playlist
+	require.Equal(t, []*WrittenEvent{playlist, playlist}, nextIndexableEvents(playlist, events))
+	require.Empty(t, nextIndexableEvents(other, events))
+
+	close(events)
+	require.Equal(t, []*WrittenEvent{playlist}, nextIndexableEvents(playlist, events))
+}
@@ -77,6 +77,15 @@ type StorageBackend interface {
 	WatchWriteEvents(ctx context.Context) (<-chan *WrittenEvent, error)
 }
 
+// WatchSinceBackend is implemented by storage backends that can replay the events written
+// after a resource version before streaming new events. It lets consumers that keep their
+// own copy of the data, like the search index, catch up after a restart.
+type WatchSinceBackend interface {
+	// Get all events written after the resource version of each group and resource.
+	// The groups and resources that are not included start from the latest resource version.
+	WatchWriteEventsSince(ctx context.Context, since map[string]map[string]int64) (<-chan *WrittenEvent, error)
+}
+
 // This interface is not exposed to end users directly
 // Access to this interface is already gated by access control
 type BlobSupport interface {
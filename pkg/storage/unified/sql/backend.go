@@ -29,6 +29,7 @@ const defaultPollingInterval = 100 * time.Millisecond
 
 type Backend interface {
 	resource.StorageBackend
+	resource.WatchSinceBackend
 	resource.DiagnosticsServer
 	resource.LifecycleHooks
 }
@@ -521,6 +522,27 @@ func (b *backend) WatchWriteEvents(ctx context.Context) (<-chan *resource.Writte
 	return stream, nil
 }
 
+// WatchWriteEventsSince implements resource.WatchSinceBackend.
+func (b *backend) WatchWriteEventsSince(ctx context.Context, since map[string]map[string]int64) (<-chan *resource.WrittenEvent, error) {
+	// Get the latest RV, which is used for the resources without a version to start from
+	latest, err := b.listLatestRVs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get the latest resource version: %w", err)
+	}
+	for group, items := range since {
+		if latest[group] == nil {
+			latest[group] = map[string]int64{}
+		}
+		for resource, rv := range items {
+			latest[group][resource] = rv
+		}
+	}
+	// Start the poller
+	stream := make(chan *resource.WrittenEvent)
+	go b.poller(ctx, latest, stream)
+	return stream, nil
+}
+
 func (b *backend) poller(ctx context.Context, since groupResourceRV, stream chan<- *resource.WrittenEvent) {
 	t := time.NewTicker(b.pollingInterval)
 	defer close(stream)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/services/unifiedSearch"
@@ -53,17 +55,18 @@ var (
 	)
 )
 
-func ProvideService(search searchV2.SearchService, searchNext unifiedSearch.SearchService, store store.StorageService, features featuremgmt.FeatureToggles) *Service {
-	return newService(search, searchNext, store, features)
+func ProvideService(search searchV2.SearchService, searchNext unifiedSearch.SearchService, store store.StorageService, features featuremgmt.FeatureToggles, liveHistory *managedstream.FrameHistory) *Service {
+	return newService(search, searchNext, store, features, liveHistory)
 }
 
-func newService(search searchV2.SearchService, searchNext unifiedSearch.SearchService, store store.StorageService, features featuremgmt.FeatureToggles) *Service {
+func newService(search searchV2.SearchService, searchNext unifiedSearch.SearchService, store store.StorageService, features featuremgmt.FeatureToggles, liveHistory *managedstream.FrameHistory) *Service {
 	s := &Service{
-		search:     search,
-		searchNext: searchNext,
-		store:      store,
-		log:        log.New("grafanads"),
-		features:   features,
+		search:      search,
+		searchNext:  searchNext,
+		store:       store,
+		log:         log.New("grafanads"),
+		features:    features,
+		liveHistory: liveHistory,
 	}
 
 	return s
@@ -71,11 +74,12 @@ func newService(search searchV2.SearchService, searchNext unifiedSearch.SearchSe
 
 // Service exists regardless of user settings
 type Service struct {
-	search     searchV2.SearchService
-	searchNext unifiedSearch.SearchService
-	store      store.StorageService
-	log        log.Logger
-	features   featuremgmt.FeatureToggles
+	search      searchV2.SearchService
+	searchNext  unifiedSearch.SearchService
+	store       store.StorageService
+	log         log.Logger
+	features    featuremgmt.FeatureToggles
+	liveHistory *managedstream.FrameHistory
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -103,6 +107,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch, queryTypeSearchNext:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeLiveHistory:
+			response.Responses[q.RefID] = s.doLiveHistoryQuery(req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -169,6 +175,37 @@ func (s *Service) doReadQuery(ctx context.Context, query backend.DataQuery) back
 	return response
 }
 
+func (s *Service) doLiveHistoryQuery(req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	q := &liveHistoryQueryModel{}
+	response := backend.DataResponse{}
+	err := json.Unmarshal(query.JSON, &q)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+
+	if s.liveHistory == nil {
+		response.Error = fmt.Errorf("live history is disabled")
+		return response
+	}
+	channel, err := live.ParseChannel(q.Channel)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	// Other scopes are managed by plugins and data sources, which check permissions on subscribe.
+	if channel.Scope != live.ScopeStream {
+		response.Error = fmt.Errorf("history is only available for %s channels", live.ScopeStream)
+		return response
+	}
+
+	frame, ok := s.liveHistory.Get(req.PluginContext.OrgID, channel.String(), query.TimeRange.From, query.TimeRange.To)
+	if ok {
+		response.Frames = data.Frames{frame}
+	}
+	return response
+}
+
 func (s *Service) doRandomWalk(query backend.DataQuery) backend.DataResponse {
 	response := backend.DataResponse{}
 
@@ -17,6 +17,9 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// queryTypeLiveHistory returns the history of a managed stream channel in the query time range
+	queryTypeLiveHistory = "liveHistory"
 )
 
 type listQueryModel struct {
@@ -25,3 +28,7 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type liveHistoryQueryModel struct {
+	Channel string `json:"channel"`
+}